@@ -0,0 +1,166 @@
+package mysql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditEntry describes a single write statement that was executed
+type AuditEntry struct {
+	// Query is the executed query with all literal values redacted
+	Query        string
+	User         string
+	TxID         uint64
+	RowsAffected int64
+	Time         time.Time
+	Duration     time.Duration
+	Error        error
+}
+
+// AuditFunc is called after every write statement executes,
+// successfully or not. Set it on the database to record an audit trail
+// without having to remember to call anything from application code.
+type AuditFunc func(ctx context.Context, entry AuditEntry)
+
+var auditUserKey = key(2)
+
+// NewContextWithAuditUser returns a new context.Context with the given acting user,
+// which is recorded with every audited write made with the context
+func NewContextWithAuditUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, auditUserKey, user)
+}
+
+// AuditUserFromContext returns the acting user from a context.Context
+// or an empty string if none is present.
+func AuditUserFromContext(ctx context.Context) string {
+	user, _ := ctx.Value(auditUserKey).(string)
+	return user
+}
+
+var skipAuditKey = key(3)
+
+func (db *Database) callAudit(ctx context.Context, entry AuditEntry) {
+	if db.Audit == nil {
+		return
+	}
+
+	if skip, _ := ctx.Value(skipAuditKey).(bool); skip {
+		return
+	}
+
+	entry.User = AuditUserFromContext(ctx)
+	db.Audit(ctx, entry)
+}
+
+// redactQuery replaces all the literal values in a query with `?`,
+// leaving keywords and identifiers in place
+func redactQuery(query string) string {
+	queryTokens := parseQuery(query)
+
+	parts := make([]string, 0, len(queryTokens))
+	redacted := false
+	for _, t := range queryTokens {
+		switch {
+		case t.kind == queryTokenKindString && t.string[0] != '`',
+			t.kind == queryTokenKindWord && '0' <= t.string[0] && t.string[0] <= '9':
+			// numbers like `1.5E+00` are split into multiple tokens,
+			// so fold them back into the previous placeholder
+			if l := len(parts); redacted && l >= 2 && (parts[l-1] == "." || parts[l-1] == "+" || parts[l-1] == "-") {
+				parts = parts[:l-1]
+				continue
+			}
+
+			parts = append(parts, "?")
+			redacted = true
+		default:
+			parts = append(parts, t.string)
+			if t.kind != queryTokenKindMisc || (t.string != "." && t.string != "+" && t.string != "-") {
+				redacted = false
+			}
+		}
+	}
+
+	return strings.Join(parts, "")
+}
+
+// AuditToWriter returns an AuditFunc that writes each entry to w as a line of JSON.
+// Writes are serialized, so w doesn't need to be safe for concurrent use.
+func AuditToWriter(w io.Writer) AuditFunc {
+	mx := new(sync.Mutex)
+
+	return func(ctx context.Context, entry AuditEntry) {
+		var errString string
+		if entry.Error != nil {
+			errString = entry.Error.Error()
+		}
+
+		j, err := json.Marshal(struct {
+			Query        string        `json:"query"`
+			User         string        `json:"user,omitempty"`
+			TxID         uint64        `json:"txID,omitempty"`
+			RowsAffected int64         `json:"rowsAffected"`
+			Time         time.Time     `json:"time"`
+			Duration     time.Duration `json:"duration"`
+			Error        string        `json:"error,omitempty"`
+		}{
+			Query:        entry.Query,
+			User:         entry.User,
+			TxID:         entry.TxID,
+			RowsAffected: entry.RowsAffected,
+			Time:         entry.Time,
+			Duration:     entry.Duration,
+			Error:        errString,
+		})
+		if err != nil {
+			return
+		}
+
+		mx.Lock()
+		defer mx.Unlock()
+
+		w.Write(append(j, '\n'))
+	}
+}
+
+// AuditToTable returns an AuditFunc that inserts each entry into the given table
+// using db. The table needs the columns `Query`, `User`, `TxID`, `RowsAffected`,
+// `Time`, `Duration` (in nanoseconds), and `Error`.
+// The inserts made by this func are not audited themselves, and aren't part of the context's transaction,
+// so the entries of writes that were rolled back are kept.
+func AuditToTable(db *Database, table string) AuditFunc {
+	type auditRow struct {
+		Query        string
+		User         string
+		TxID         uint64
+		RowsAffected int64
+		Time         time.Time
+		Duration     int64
+		Error        *string
+	}
+
+	return func(ctx context.Context, entry AuditEntry) {
+		row := auditRow{
+			Query:        entry.Query,
+			User:         entry.User,
+			TxID:         entry.TxID,
+			RowsAffected: entry.RowsAffected,
+			Time:         entry.Time,
+			Duration:     int64(entry.Duration),
+		}
+		if entry.Error != nil {
+			row.Error = p(entry.Error.Error())
+		}
+
+		// the entry is written outside of the context's transaction,
+		// so it's kept even if the transaction is rolled back
+		ctx = NewContextWithTx(context.WithValue(ctx, skipAuditKey, true), nil)
+		if err := db.InsertContext(ctx, table, row); err != nil {
+			db.Logger.Warn(fmt.Sprintf("failed to write audit entry: %v", err))
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package mysql
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/StirlingMarketingGroup/cool-mysql/internal/testdriver"
+)
+
+func Test_redactQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "no values",
+			query: "delete from`users`where`ID`is null",
+			want:  "delete from`users`where`ID`is null",
+		},
+		{
+			name:  "string and int",
+			query: "update`users`set`Name`=_utf8mb4 0x4a6f686e collate utf8mb4_unicode_ci where`ID`=5",
+			want:  "update`users`set`Name`=_utf8mb4 ? collate utf8mb4_unicode_ci where`ID`=?",
+		},
+		{
+			name:  "quoted strings",
+			query: "insert into`t`(`a`,`b`)values('it''s',\"x\")",
+			want:  "insert into`t`(`a`,`b`)values(?,?)",
+		},
+		{
+			name:  "float",
+			query: "insert into`t`values(1.5E+00,-2)",
+			want:  "insert into`t`values(?,-?)",
+		},
+		{
+			name:  "time",
+			query: "insert into`t`values(convert_tz('2020-01-01 00:00:00.000000','UTC',@@session.time_zone))",
+			want:  "insert into`t`values(convert_tz(?,?,@@session.time_zone))",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactQuery(tt.query); got != tt.want {
+				t.Errorf("redactQuery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditToTable_rolledBackTx(t *testing.T) {
+	db := testDatabase(t, &testdriver.Driver{Tx: true})
+	db.Audit = AuditToTable(db, "audit")
+
+	inTx := make(map[string]bool)
+	db.Log = func(detail LogDetail) {
+		if strings.HasPrefix(detail.Query, "update") || strings.HasPrefix(detail.Query, "insert") {
+			inTx[detail.Query[:6]] = detail.Tx != nil
+		}
+	}
+
+	tx, cancel, err := db.BeginTxContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := NewContextWithTx(context.Background(), tx)
+	if err := tx.ExecContext(ctx, "update`users`set`Name`='a'"); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	// the update is rolled back with the transaction, but its audit entry isn't
+	if !inTx["update"] {
+		t.Error("the update wasn't run in the transaction")
+	}
+	if inserted, ok := inTx["insert"]; !ok || inserted {
+		t.Errorf("the audit entry was inserted in the transaction = %v, written = %v, want it written outside it", inserted, ok)
+	}
+}
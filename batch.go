@@ -0,0 +1,197 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Batch is a queue of selects and execs that are sent to the database together,
+// in as few round trips as possible, see Database.Batch
+type Batch struct {
+	db    *Database
+	items []batchItem
+}
+
+type batchItem struct {
+	dest   any
+	query  string
+	params []any
+}
+
+// Batch returns an empty batch of queries.
+//
+// If the DSN allows multiple statements, with multiStatements=true, each run of consecutive
+// selects in the batch is sent as one multi-statement query, and each of their result sets is
+// scanned into its select's dest. Each run of consecutive execs is sent as one statement too,
+// and the rows affected of each exec can't be told apart. Otherwise, the queries are run one
+// at a time, just like they would be without a batch.
+//
+// Selects are never cached, and they use the writes connection if there are any execs in the batch,
+// so they see the changes made by the execs before them.
+func (db *Database) Batch() *Batch {
+	return &Batch{db: db}
+}
+
+// Select queues a select into dest, which is set when the batch is run
+func (b *Batch) Select(dest any, q string, params ...any) *Batch {
+	b.items = append(b.items, batchItem{dest: dest, query: q, params: params})
+	return b
+}
+
+// Exec queues an exec
+func (b *Batch) Exec(q string, params ...any) *Batch {
+	b.items = append(b.items, batchItem{query: q, params: params})
+	return b
+}
+
+// Len returns the number of queued queries
+func (b *Batch) Len() int {
+	return len(b.items)
+}
+
+// Run runs the queued queries in order, stopping at the first error, and empties the batch
+func (b *Batch) Run(ctx context.Context) error {
+	items := b.items
+	b.items = nil
+
+	multiStatements := b.db.multiStatements()
+
+	conn := b.db.Reads
+	for _, item := range items {
+		if item.dest == nil {
+			conn = b.db.Writes
+			break
+		}
+	}
+
+	for len(items) != 0 {
+		// the next run of consecutive queries of the same kind
+		n := 1
+		for n < len(items) && (items[n].dest == nil) == (items[0].dest == nil) {
+			n++
+		}
+		run := items[:n]
+		items = items[n:]
+
+		if !multiStatements || len(run) == 1 {
+			for _, item := range run {
+				var err error
+				if item.dest == nil {
+					_, err = b.db.exec(b.db.Writes, ctx, nil, true, item.query, item.params...)
+				} else {
+					err = b.db.query(conn, ctx, item.dest, item.query, 0, item.params...)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if run[0].dest == nil {
+			if err := b.db.execBatch(ctx, run); err != nil {
+				return err
+			}
+		} else {
+			if err := b.db.queryBatch(conn, ctx, run); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// multiStatements returns true if the DSNs of both connections allow multiple statements in one query
+func (db *Database) multiStatements() bool {
+	for _, dsn := range []string{db.WritesDSN, db.ReadsDSN} {
+		cfg, err := mysql.ParseDSN(dsn)
+		if err != nil || !cfg.MultiStatements {
+			return false
+		}
+	}
+
+	return true
+}
+
+// joinBatch interpolates the params of each query and joins them into one multi-statement query
+func (db *Database) joinBatch(ctx context.Context, items []batchItem) (string, error) {
+	s := new(strings.Builder)
+	for i, item := range items {
+		replacedQuery, _, err := db.interpolateParams(ctx, item.query, tenantParams(ctx, item.params)...)
+		if err != nil {
+			return "", fmt.Errorf("failed to interpolate params of batch query %d: %w", i, err)
+		}
+
+		if i != 0 {
+			s.WriteString(";\n")
+		}
+		s.WriteString(strings.TrimRight(strings.TrimSpace(replacedQuery), ";"))
+	}
+
+	return s.String(), nil
+}
+
+func (db *Database) execBatch(ctx context.Context, items []batchItem) error {
+	q, err := db.joinBatch(ctx, items)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.execInterpolated(db.Writes, ctx, nil, true, q, q, nil)
+	return err
+}
+
+// batchResultSet is the conn of a select in a batch, whose rows are the batch's current result set
+type batchResultSet struct {
+	rows *sql.Rows
+}
+
+func (rs batchResultSet) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return rs.rows, nil
+}
+
+func (rs batchResultSet) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return nil, fmt.Errorf("cool-mysql: can't exec in a batch's result set")
+}
+
+func (db *Database) queryBatch(conn handlerWithContext, ctx context.Context, items []batchItem) error {
+	q, err := db.joinBatch(ctx, items)
+	if err != nil {
+		return err
+	}
+
+	rows, err := conn.QueryContext(ctx, db.commented(ctx, q))
+	if err != nil {
+		return Error{
+			Err:           err,
+			OriginalQuery: q,
+			ReplacedQuery: q,
+		}
+	}
+	defer rows.Close()
+
+	for i, item := range items {
+		if i != 0 && !rows.NextResultSet() {
+			err := rows.Err()
+			if err == nil {
+				err = fmt.Errorf("cool-mysql: batch returned %d result sets for %d selects", i, len(items))
+			}
+			return Error{
+				Err:           err,
+				OriginalQuery: item.query,
+			}
+		}
+
+		// the query is only interpolated again for logs and errors, since its rows were already selected
+		if err := db.query(batchResultSet{rows: rows}, ctx, item.dest, item.query, 0, item.params...); err != nil {
+			return err
+		}
+	}
+
+	return rows.Close()
+}
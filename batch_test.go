@@ -0,0 +1,44 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDatabase_joinBatch(t *testing.T) {
+	db := new(Database)
+
+	q, err := db.joinBatch(context.Background(), []batchItem{
+		{query: "select*from`Users`where`ID`=@@ID;", params: []any{Params{"ID": 1}}},
+		{query: " select 1 "},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "select*from`Users`where`ID`=1;\nselect 1"
+	if q != want {
+		t.Errorf("joinBatch() = %q, want %q", q, want)
+	}
+}
+
+func TestDatabase_multiStatements(t *testing.T) {
+	tests := []struct {
+		name          string
+		writes, reads string
+		want          bool
+	}{
+		{"both", "root@/test?multiStatements=true", "root@/test?multiStatements=true", true},
+		{"writes only", "root@/test?multiStatements=true", "root@/test", false},
+		{"neither", "root@/test", "root@/test", false},
+		{"no dsn", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &Database{WritesDSN: tt.writes, ReadsDSN: tt.reads}
+			if got := db.multiStatements(); got != tt.want {
+				t.Errorf("multiStatements() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Package bench has the helpers of cool-mysql's benchmarks, so the work it does around queries,
+// like interpolating params, scanning rows, chunking inserts, and encoding cached results,
+// can be measured, and held to allocation budgets by tests, without a MySQL or redis server:
+//
+//	func BenchmarkSelectUsers(b *testing.B) {
+//		db := bench.Database(b, bench.WideDriver(8, 64, 1000))
+//		for i := 0; i < b.N; i++ {
+//			var users []user
+//			db.Select(&users, "select*from`users`", 0)
+//		}
+//	}
+//
+//	func TestSelectUsersAllocs(t *testing.T) {
+//		db := bench.Database(t, bench.WideDriver(8, 64, 1000))
+//		bench.AllocBudget(t, 2100, func() {
+//			var users []user
+//			db.Select(&users, "select*from`users`", 0)
+//		})
+//	}
+package bench
+
+import (
+	"database/sql"
+	"testing"
+
+	mysql "github.com/StirlingMarketingGroup/cool-mysql"
+	"github.com/StirlingMarketingGroup/cool-mysql/internal/testdriver"
+)
+
+// Driver is a driver whose every query returns the same rows, reusing its buffers between rows
+// like the mysql driver, and whose every exec affects one row. It's the driver of cool-mysql's own tests.
+type Driver = testdriver.Driver
+
+// WideDriver returns a driver whose rows have columns Text0, Text1, and so on, of width bytes each
+func WideDriver(columns, width, rows int) *Driver {
+	return testdriver.Wide(columns, width, rows)
+}
+
+// Database returns a database whose reads and writes are both the driver, closed when the test ends
+func Database(tb testing.TB, d *Driver) *mysql.Database {
+	tb.Helper()
+
+	conn := sql.OpenDB(d)
+	tb.Cleanup(func() {
+		conn.Close()
+	})
+
+	return mysql.NewFromConn(conn, nil)
+}
+
+// AllocBudget fails the test if fn allocates more than budget times a run on average,
+// so changes that add allocations to hot paths are caught by tests instead of profiles.
+// The race detector allocates too, so budgets are only checked without it.
+func AllocBudget(tb testing.TB, budget float64, fn func()) {
+	tb.Helper()
+
+	if raceEnabled {
+		tb.Skip("allocation budgets aren't checked with the race detector")
+	}
+
+	if allocs := testing.AllocsPerRun(10, fn); allocs > budget {
+		tb.Errorf("allocated %v times a run, over the budget of %v", allocs, budget)
+	}
+}
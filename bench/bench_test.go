@@ -0,0 +1,180 @@
+package bench_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	mysql "github.com/StirlingMarketingGroup/cool-mysql"
+	"github.com/StirlingMarketingGroup/cool-mysql/bench"
+)
+
+type wideRow struct {
+	Text0, Text1, Text2, Text3, Text4, Text5, Text6, Text7 string
+}
+
+type insertRow struct {
+	ID      int
+	Name    string
+	Score   float64
+	Created time.Time
+}
+
+var (
+	interpolateQuery  = "select*from`Rows`where`ID`=@@ID and`Name`=@@Name and`Created`<@@Created and`ID`in(@@IDs)"
+	interpolateParams = mysql.Params{"ID": 5, "Name": "name", "Created": time.Now(), "IDs": []int{1, 2, 3, 4, 5}}
+)
+
+func insertRows(n int) []insertRow {
+	rows := make([]insertRow, n)
+	for i := range rows {
+		rows[i] = insertRow{ID: i, Name: "name " + strconv.Itoa(i), Score: float64(i) / 3, Created: time.Now()}
+	}
+
+	return rows
+}
+
+// benchmarks are named by what they measure, and each has an allocation budget checked by TestAllocBudgets,
+// about a tenth over what it allocates now, so a change that makes one of them allocate more fails a test
+var benchmarks = []struct {
+	name   string
+	budget float64
+	setup  func(tb testing.TB) func(tb testing.TB)
+}{
+	{
+		name:   "InterpolateParams",
+		budget: 32,
+		setup: func(tb testing.TB) func(tb testing.TB) {
+			db := bench.Database(tb, bench.WideDriver(8, 64, 100))
+			return func(tb testing.TB) {
+				if _, _, err := db.InterpolateParams(interpolateQuery, interpolateParams); err != nil {
+					tb.Fatal(err)
+				}
+			}
+		},
+	},
+	{
+		name:   "ScanStructs",
+		budget: 1400,
+		setup: func(tb testing.TB) func(tb testing.TB) {
+			db := bench.Database(tb, bench.WideDriver(8, 64, 100))
+			return func(tb testing.TB) {
+				var rows []wideRow
+				if err := db.Select(&rows, "select*from`Wide`", 0); err != nil {
+					tb.Fatal(err)
+				}
+			}
+		},
+	},
+	{
+		name:   "ScanMapRows",
+		budget: 7000,
+		setup: func(tb testing.TB) func(tb testing.TB) {
+			db := bench.Database(tb, bench.WideDriver(8, 64, 100))
+			return func(tb testing.TB) {
+				var rows []mysql.MapRow
+				if err := db.Select(&rows, "select*from`Wide`", 0); err != nil {
+					tb.Fatal(err)
+				}
+			}
+		},
+	},
+	{
+		name:   "ScanFunc",
+		budget: 420,
+		setup: func(tb testing.TB) func(tb testing.TB) {
+			db := bench.Database(tb, bench.WideDriver(8, 64, 100))
+			db.ZeroCopyStrings = true
+			return func(tb testing.TB) {
+				var n int
+				err := db.Select(func(r wideRow) {
+					n += len(r.Text0)
+				}, "select*from`Wide`", 0)
+				if err != nil {
+					tb.Fatal(err)
+				}
+			}
+		},
+	},
+	{
+		name:   "InsertChunks",
+		budget: 9000,
+		setup: func(tb testing.TB) func(tb testing.TB) {
+			d := bench.WideDriver(0, 0, 0)
+			db := bench.Database(tb, d)
+			rows := insertRows(1000)
+			return func(tb testing.TB) {
+				start := d.Execs.Load()
+				if err := db.I().SetMaxChunkBytes(16<<10).Insert("Rows", rows); err != nil {
+					tb.Fatal(err)
+				}
+				if d.Execs.Load()-start < 2 {
+					tb.Fatal("the insert wasn't split into chunks")
+				}
+			}
+		},
+	},
+	{
+		name:   "CacheHit",
+		budget: 1200,
+		setup: func(tb testing.TB) func(tb testing.TB) {
+			db := bench.Database(tb, bench.WideDriver(8, 64, 100))
+			db.EnableRedis(bench.Redis(tb))
+
+			var rows []wideRow
+			if err := db.Select(&rows, "select*from`Wide`", time.Minute); err != nil {
+				tb.Fatal(err)
+			}
+
+			return func(tb testing.TB) {
+				var rows []wideRow
+				if err := db.Select(&rows, "select*from`Wide`", time.Minute); err != nil {
+					tb.Fatal(err)
+				}
+			}
+		},
+	},
+	{
+		name:   "CacheFill",
+		budget: 1650,
+		setup: func(tb testing.TB) func(tb testing.TB) {
+			db := bench.Database(tb, bench.WideDriver(8, 64, 100))
+			db.EnableRedis(bench.Redis(tb))
+
+			// every select has its own key, so each one misses and fills the cache
+			i := 0
+			return func(tb testing.TB) {
+				i++
+				var rows []wideRow
+				if err := db.Select(&rows, "select*from`Wide`where`ID`!=@@ID", time.Minute, i); err != nil {
+					tb.Fatal(err)
+				}
+			}
+		},
+	},
+}
+
+func Benchmark(b *testing.B) {
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			run := bm.setup(b)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				run(b)
+			}
+		})
+	}
+}
+
+func TestAllocBudgets(t *testing.T) {
+	for _, bm := range benchmarks {
+		t.Run(bm.name, func(t *testing.T) {
+			run := bm.setup(t)
+			bench.AllocBudget(t, bm.budget, func() {
+				run(t)
+			})
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package bench
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis returns a client of an in-memory server that answers the few commands
+// cool-mysql's cache uses, so cached selects can be measured without a redis server.
+// Keys never expire, and the server is stopped when the test ends.
+func Redis(tb testing.TB) *redis.Client {
+	tb.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	s := &redisServer{data: make(map[string]string)}
+	go s.serve(l)
+
+	client := redis.NewClient(&redis.Options{Addr: l.Addr().String()})
+	tb.Cleanup(func() {
+		client.Close()
+		l.Close()
+	})
+
+	return client
+}
+
+type redisServer struct {
+	mx   sync.Mutex
+	data map[string]string
+}
+
+func (s *redisServer) serve(l net.Listener) {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer c.Close()
+
+			r := bufio.NewReader(c)
+			w := bufio.NewWriter(c)
+			for {
+				args, err := readRedisCommand(r)
+				if err != nil {
+					return
+				}
+
+				s.do(w, args)
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}()
+	}
+}
+
+// do writes the reply of the command, where scripts are assumed to be redsync's,
+// which only change the lock's key if it still has the value it was locked with
+func (s *redisServer) do(w *bufio.Writer, args []string) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	switch cmd := strings.ToUpper(args[0]); {
+	case cmd == "PING":
+		w.WriteString("+PONG\r\n")
+	case cmd == "GET" && len(args) == 2:
+		v, ok := s.data[args[1]]
+		if !ok {
+			w.WriteString("$-1\r\n")
+			return
+		}
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(v), v)
+	case cmd == "SET" && len(args) >= 3:
+		for _, a := range args[3:] {
+			if strings.EqualFold(a, "nx") {
+				if _, ok := s.data[args[1]]; ok {
+					w.WriteString("$-1\r\n")
+					return
+				}
+			}
+		}
+		s.data[args[1]] = args[2]
+		w.WriteString("+OK\r\n")
+	case cmd == "DEL":
+		n := 0
+		for _, k := range args[1:] {
+			if _, ok := s.data[k]; ok {
+				delete(s.data, k)
+				n++
+			}
+		}
+		fmt.Fprintf(w, ":%d\r\n", n)
+	case cmd == "EVALSHA":
+		w.WriteString("-NOSCRIPT No matching script. Please use EVAL.\r\n")
+	case cmd == "EVAL" && len(args) >= 5:
+		if s.data[args[3]] != args[4] {
+			w.WriteString(":0\r\n")
+			return
+		}
+		if strings.Contains(args[1], `"DEL"`) {
+			delete(s.data, args[3])
+		}
+		w.WriteString(":1\r\n")
+	default:
+		fmt.Fprintf(w, "-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+// readRedisCommand reads a command, which clients send as an array of bulk strings
+func readRedisCommand(r *bufio.Reader) ([]string, error) {
+	n, err := readRedisLength(r, '*')
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 {
+		return nil, fmt.Errorf("empty command")
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		l, err := readRedisLength(r, '$')
+		if err != nil {
+			return nil, err
+		}
+
+		b := make([]byte, l+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		args[i] = string(b[:l])
+	}
+
+	return args, nil
+}
+
+func readRedisLength(r *bufio.Reader, prefix byte) (int, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return 0, err
+	}
+	if len(line) < 3 || line[0] != prefix {
+		return 0, fmt.Errorf("unexpected line %q", line)
+	}
+
+	return strconv.Atoi(strings.TrimRight(line[1:], "\r\n"))
+}
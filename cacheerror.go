@@ -0,0 +1,96 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+)
+
+// CacheGetErrorAction is what a cached query does when it can't get its result from the cache
+type CacheGetErrorAction int
+
+const (
+	// CacheGetServeDB selects the result from the database, like it wasn't cached
+	CacheGetServeDB CacheGetErrorAction = iota
+
+	// CacheGetFail returns the cache's error
+	CacheGetFail
+)
+
+// CacheSetErrorAction is what a cached query does when it can't cache its result
+type CacheSetErrorAction int
+
+const (
+	// CacheSetWarn logs the cache's error as a warning and returns the result
+	CacheSetWarn CacheSetErrorAction = iota
+
+	// CacheSetIgnore returns the result without logging the cache's error
+	CacheSetIgnore
+
+	// CacheSetFail returns the cache's error, after the rows were already sent to the destination
+	CacheSetFail
+)
+
+// CacheErrorPolicy is what cached queries do when the cache fails, see SetCacheErrorPolicy and WithCacheErrorPolicy.
+// The zero policy never fails a query because of the cache, and selects from the database instead.
+type CacheErrorPolicy struct {
+	OnGet CacheGetErrorAction
+	OnSet CacheSetErrorAction
+}
+
+// SetCacheErrorPolicy sets what cached queries do when the cache fails, unless their context has its own policy.
+// If HandleRedisError is set, it handles the errors instead.
+func (db *Database) SetCacheErrorPolicy(policy CacheErrorPolicy) *Database {
+	db.CacheErrorPolicy = policy
+	return db
+}
+
+var cacheErrorPolicyKey = key(12)
+
+// WithCacheErrorPolicy returns a new context.Context whose cached queries use the policy instead of the database's,
+// like a read that would rather fail than put its load on the database when the cache is down
+func WithCacheErrorPolicy(ctx context.Context, policy CacheErrorPolicy) context.Context {
+	return context.WithValue(ctx, cacheErrorPolicyKey, policy)
+}
+
+// cacheErrorPolicy returns the cache error policy of the context's queries
+func (db *Database) cacheErrorPolicy(ctx context.Context) CacheErrorPolicy {
+	if policy, ok := ctx.Value(cacheErrorPolicyKey).(CacheErrorPolicy); ok {
+		return policy
+	}
+
+	return db.CacheErrorPolicy
+}
+
+// handleCacheGetError returns the error of a query that couldn't get its result from the cache,
+// or nil if it should select from the database instead
+func (db *Database) handleCacheGetError(ctx context.Context, err error) error {
+	err = fmt.Errorf("failed to get data from redis: %w", err)
+	if db.HandleRedisError != nil {
+		return db.HandleRedisError(err)
+	}
+
+	if db.cacheErrorPolicy(ctx).OnGet == CacheGetFail {
+		return err
+	}
+
+	db.Logger.Warn(err.Error())
+	return nil
+}
+
+// handleCacheSetError returns the error of a query that couldn't cache its result, or nil if it should return the result anyway
+func (db *Database) handleCacheSetError(ctx context.Context, err error) error {
+	err = fmt.Errorf("failed to set redis cache: %w", err)
+	if db.HandleRedisError != nil {
+		return db.HandleRedisError(err)
+	}
+
+	switch db.cacheErrorPolicy(ctx).OnSet {
+	case CacheSetFail:
+		return err
+	case CacheSetIgnore:
+		return nil
+	default:
+		db.Logger.Warn(err.Error())
+		return nil
+	}
+}
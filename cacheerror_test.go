@@ -0,0 +1,146 @@
+package mysql_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	mysql "github.com/StirlingMarketingGroup/cool-mysql"
+	"github.com/StirlingMarketingGroup/cool-mysql/bench"
+	"github.com/redis/go-redis/v9"
+)
+
+// failingHook fails the cache's redis commands of the name, like redis is down for them,
+// but not the locks' `set nx`, so queries still get the locks of their cache keys
+type failingHook string
+
+func (failingHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h failingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if args := cmd.Args(); cmd.Name() == string(h) && fmt.Sprint(args[len(args)-1]) != "nx" {
+			cmd.SetErr(errors.New("redis is down"))
+			return cmd.Err()
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (failingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func TestCacheErrorPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		fail      string
+		policy    mysql.CacheErrorPolicy
+		ctxPolicy *mysql.CacheErrorPolicy
+		handle    mysql.HandleRedisError
+		wantErr   bool
+	}{
+		{
+			name: "get error serves db",
+			fail: "get",
+		},
+		{
+			name:    "get error fails",
+			fail:    "get",
+			policy:  mysql.CacheErrorPolicy{OnGet: mysql.CacheGetFail},
+			wantErr: true,
+		},
+		{
+			name:      "get error fails by context",
+			fail:      "get",
+			ctxPolicy: &mysql.CacheErrorPolicy{OnGet: mysql.CacheGetFail},
+			wantErr:   true,
+		},
+		{
+			name:      "get error serves db by context",
+			fail:      "get",
+			policy:    mysql.CacheErrorPolicy{OnGet: mysql.CacheGetFail},
+			ctxPolicy: &mysql.CacheErrorPolicy{},
+		},
+		{
+			name: "set error warns",
+			fail: "set",
+		},
+		{
+			name:   "set error ignored",
+			fail:   "set",
+			policy: mysql.CacheErrorPolicy{OnSet: mysql.CacheSetIgnore},
+		},
+		{
+			name:    "set error fails",
+			fail:    "set",
+			policy:  mysql.CacheErrorPolicy{OnSet: mysql.CacheSetFail},
+			wantErr: true,
+		},
+		{
+			name:    "handler overrides policy",
+			fail:    "get",
+			handle:  func(err error) error { return err },
+			wantErr: true,
+		},
+		{
+			name: "no errors",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := bench.Redis(t)
+			client.AddHook(failingHook(tt.fail))
+
+			db := bench.Database(t, bench.WideDriver(1, 8, 1))
+			db.EnableRedis(client)
+			db.SetCacheErrorPolicy(tt.policy)
+			db.HandleRedisError = tt.handle
+
+			ctx := context.Background()
+			if tt.ctxPolicy != nil {
+				ctx = mysql.WithCacheErrorPolicy(ctx, *tt.ctxPolicy)
+			}
+
+			for _, exists := range []bool{false, true} {
+				var err error
+				if exists {
+					_, err = db.ExistsContext(ctx, "select`Text0`from`Wide`", time.Minute)
+				} else {
+					var rows []string
+					err = db.SelectContext(ctx, &rows, "select`Text0`from`Wide`", time.Minute)
+				}
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("exists %v error = %v, wantErr %v", exists, err, tt.wantErr)
+				}
+			}
+
+		})
+	}
+}
+
+func TestHandleRedisErrorExistsSet(t *testing.T) {
+	client := bench.Redis(t)
+	client.AddHook(failingHook("set"))
+
+	db := bench.Database(t, bench.WideDriver(1, 8, 1))
+	db.EnableRedis(client)
+
+	var handled int
+	db.HandleRedisError = func(err error) error {
+		handled++
+		return nil
+	}
+
+	var rows []string
+	if err := db.Select(&rows, "select`Text0`from`Wide`", time.Minute); err != nil {
+		t.Fatalf("select error = %v, want nil", err)
+	}
+	if _, err := db.Exists("select`Text0`from`Wide`", time.Minute); err == nil {
+		t.Fatal("exists error = nil, want the set error")
+	}
+	if handled != 2 {
+		t.Fatalf("handled = %d, want 2", handled)
+	}
+}
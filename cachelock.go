@@ -0,0 +1,147 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrCacheLockTimeout is matched by the errors of cached queries that stopped waiting for the lock
+// of their cache key, or for another query to fill it, see CacheLock
+var ErrCacheLockTimeout = errors.New("cool-mysql: timed out waiting for the cache lock")
+
+// CacheLockTimeoutError is the error of a cached query that stopped waiting for its cache key's lock,
+// see ErrCacheLockTimeout. It wraps the context's error if the context ended the wait.
+type CacheLockTimeoutError struct {
+	Err error
+
+	// Waited is how long the query waited, and Tries how many times it tried to get the lock
+	Waited time.Duration
+	Tries  int
+}
+
+func (e CacheLockTimeoutError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s after %s and %d tries: %s", ErrCacheLockTimeout, e.Waited, e.Tries, e.Err)
+	}
+
+	return fmt.Sprintf("%s after %s and %d tries", ErrCacheLockTimeout, e.Waited, e.Tries)
+}
+
+func (e CacheLockTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+func (e CacheLockTimeoutError) Is(target error) bool {
+	return target == ErrCacheLockTimeout
+}
+
+// CacheLock is how cached queries that miss the cache wait for the lock that lets one of them fill it,
+// while the others check the cache again, waiting longer between each try, starting at RedisLockRetryDelay
+// and up to RedisLockMaxRetryDelay. Waiting always stops at the context's deadline, or when the next wait
+// would end after it, with a CacheLockTimeoutError.
+type CacheLock struct {
+	// MaxWait is the longest a query waits, after which it returns a CacheLockTimeoutError.
+	// 0 means MaxExecutionTime.
+	MaxWait time.Duration
+
+	// ProceedAfter is how many times a query can fail to get the lock before it stops waiting and
+	// selects from the database without it, filling the cache like the lock's holder will,
+	// so a stuck or slow holder only delays the others. 0 means never.
+	ProceedAfter int
+
+	// TTL is how long a lock is held at most, in case its holder never unlocks it. 0 means 8 seconds.
+	TTL time.Duration
+}
+
+// SetCacheLock sets how cached queries wait for the locks of their cache keys
+func (db *Database) SetCacheLock(lock CacheLock) *Database {
+	db.CacheLock = lock
+	return db
+}
+
+// cacheLockBackOff returns the backoff between the tries of a query to get its cache key's lock
+func (db *Database) cacheLockBackOff() backoff.BackOff {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = RedisLockRetryDelay
+	b.MaxInterval = RedisLockMaxRetryDelay
+	if b.MaxInterval < b.InitialInterval {
+		b.MaxInterval = b.InitialInterval
+	}
+
+	b.MaxElapsedTime = db.CacheLock.MaxWait
+	if b.MaxElapsedTime <= 0 {
+		b.MaxElapsedTime = db.maxExecutionTime()
+	}
+	b.Reset()
+
+	return b
+}
+
+// cacheLockTTL returns how long the lock of a cache key is held at most
+func (db *Database) cacheLockTTL() time.Duration {
+	if db.CacheLock.TTL > 0 {
+		return db.CacheLock.TTL
+	}
+
+	return 8 * time.Second
+}
+
+// cacheGetOrLock returns the cached value of the key, or if it isn't cached, locks the key so the query
+// can fill the cache, and returns the func that unlocks it. The unlock func is nil if the value was cached,
+// or if the query goes ahead without the lock, see CacheLock.ProceedAfter. Errors from redis itself
+// are returned as they are, so they can be handled by the cache error policy.
+func (db *Database) cacheGetOrLock(ctx context.Context, cacheKey string) (b []byte, hit bool, unlock func(), err error) {
+	var bo backoff.BackOff
+	start := time.Now()
+
+	for tries := 1; ; tries++ {
+		b, err = db.redis.Get(ctx, cacheKey).Bytes()
+		if err == nil {
+			return b, true, nil, nil
+		}
+		if !errors.Is(err, redis.Nil) {
+			return nil, false, nil, err
+		}
+
+		// cache miss! grab a lock so we can update the cache
+		if unlock, err := db.cacheLocker().TryLock(ctx, cacheKey+":mutex", db.cacheLockTTL()); err == nil {
+			return nil, false, func() {
+				if err := unlock(); err != nil {
+					db.Logger.Warn(fmt.Sprintf("failed to unlock cache lock: %v", err))
+				}
+			}, nil
+		} else if !errors.Is(err, ErrLockTaken) {
+			db.Logger.Warn(fmt.Sprintf("failed to lock cache lock: %v", err))
+		}
+
+		if db.CacheLock.ProceedAfter > 0 && tries >= db.CacheLock.ProceedAfter {
+			db.Logger.Warn(fmt.Sprintf("querying without the cache lock after %d tries", tries))
+			return nil, false, nil, nil
+		}
+
+		// if we couldn't get the lock, then wait and check the cache again
+		if bo == nil {
+			bo = db.cacheLockBackOff()
+		}
+		next := bo.NextBackOff()
+		if deadline, ok := ctx.Deadline(); next != backoff.Stop && ok && time.Now().Add(next).After(deadline) {
+			next = backoff.Stop
+		}
+		if next == backoff.Stop {
+			return nil, false, nil, CacheLockTimeoutError{Err: ctx.Err(), Waited: time.Since(start), Tries: tries}
+		}
+
+		t := time.NewTimer(next)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return nil, false, nil, CacheLockTimeoutError{Err: ctx.Err(), Waited: time.Since(start), Tries: tries}
+		}
+	}
+}
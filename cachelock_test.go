@@ -0,0 +1,131 @@
+package mysql_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	mysql "github.com/StirlingMarketingGroup/cool-mysql"
+	"github.com/StirlingMarketingGroup/cool-mysql/bench"
+	"github.com/redis/go-redis/v9"
+)
+
+// lockedHook fails every redsync lock, like another process is holding them all
+type lockedHook struct{}
+
+func (lockedHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (lockedHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if args := cmd.Args(); len(args) > 1 && cmd.Name() == "set" && strings.HasSuffix(args[1].(string), ":mutex") {
+			cmd.SetErr(errors.New("locked"))
+			return nil
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (lockedHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+// takenLocker is a locker whose every key is locked by someone else
+type takenLocker struct{}
+
+func (takenLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (func() error, error) {
+	return nil, mysql.ErrLockTaken
+}
+
+func TestCacheLock(t *testing.T) {
+	tests := []struct {
+		name    string
+		lock    mysql.CacheLock
+		locker  mysql.Locker
+		timeout time.Duration
+		wantErr bool
+	}{
+		{
+			name:    "max wait",
+			lock:    mysql.CacheLock{MaxWait: 50 * time.Millisecond},
+			wantErr: true,
+		},
+		{
+			name:    "deadline",
+			timeout: 50 * time.Millisecond,
+			wantErr: true,
+		},
+		{
+			name:    "locker",
+			lock:    mysql.CacheLock{MaxWait: 50 * time.Millisecond},
+			locker:  takenLocker{},
+			wantErr: true,
+		},
+		{
+			name:   "locker proceed after",
+			lock:   mysql.CacheLock{ProceedAfter: 3},
+			locker: takenLocker{},
+		},
+		{
+			name: "proceed after",
+			lock: mysql.CacheLock{ProceedAfter: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := bench.Redis(t)
+			client.AddHook(lockedHook{})
+
+			db := bench.Database(t, bench.WideDriver(1, 8, 1))
+			db.EnableRedis(client)
+			db.SetCacheLock(tt.lock)
+			if tt.locker != nil {
+				db.UseLocker(tt.locker)
+			}
+
+			var hits int
+			db.Log = func(detail mysql.LogDetail) {
+				if detail.CacheHit {
+					hits++
+				}
+			}
+
+			ctx := context.Background()
+			if tt.timeout != 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			start := time.Now()
+			var rows []string
+			err := db.SelectContext(ctx, &rows, "select`Text0`from`Wide`", time.Minute)
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("SelectContext() waited %s", elapsed)
+			}
+
+			if tt.wantErr {
+				var lockErr mysql.CacheLockTimeoutError
+				if !errors.Is(err, mysql.ErrCacheLockTimeout) || !errors.As(err, &lockErr) || lockErr.Tries < 2 {
+					t.Fatalf("SelectContext() error = %v, want a cache lock timeout after a few tries", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != 1 {
+				t.Fatalf("SelectContext() = %q, want a row", rows)
+			}
+
+			// the query that went ahead without the lock filled the cache
+			if err := db.SelectContext(ctx, &rows, "select`Text0`from`Wide`", time.Minute); err != nil {
+				t.Fatal(err)
+			}
+			if hits != 1 {
+				t.Errorf("SelectContext() hit the cache %d times, want 1", hits)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package mysql
+
+import (
+	"sync"
+)
+
+// ChangeKind is the kind of write a ChangeEvent describes
+type ChangeKind int
+
+const (
+	ChangeInsert ChangeKind = iota + 1
+	ChangeUpdate
+	ChangeDelete
+
+	// ChangeUpsert is reported for rows written with an `on duplicate key update`
+	// clause, where we can't know if the row was inserted or updated
+	ChangeUpsert
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeInsert:
+		return "insert"
+	case ChangeUpdate:
+		return "update"
+	case ChangeDelete:
+		return "delete"
+	case ChangeUpsert:
+		return "upsert"
+	default:
+		return "unknown"
+	}
+}
+
+// ChangeEvent describes a single row changed by a write
+type ChangeEvent struct {
+	Table string
+	Kind  ChangeKind
+
+	// Keys are the values of the unique columns used to find the row, if known
+	Keys Params
+
+	// Before and After are the row before and after the change, if known
+	Before any
+	After  any
+}
+
+// ChangeFunc is called with the rows changed by the Insert and Upsert helpers, the Update and Delete
+// of repositories, and DeleteReturning, or reported with RecordChanges. Changes made in a transaction
+// are only reported after the transaction is committed.
+//
+// Before is only known for the rows of repositories' Update and Delete, which select the row first,
+// and of DeleteReturning with a slice or single row dest. Other updates and deletes, like from
+// Exec, aren't seen and have to be reported with RecordChanges.
+//
+// A single write never calls it concurrently, but concurrent writes do,
+// so it has to be safe for concurrent use.
+type ChangeFunc func(events []ChangeEvent)
+
+type txChanges struct {
+	sync.Mutex
+	events []ChangeEvent
+}
+
+// RecordChanges reports changes made outside of the helpers that report their own,
+// like from a manual update or delete, to the database's HandleChanges func
+func (db *Database) RecordChanges(events ...ChangeEvent) {
+	db.reportChanges(nil, events)
+}
+
+// RecordChanges queues changes made outside of the helpers that report their own
+// to be reported once the transaction is committed
+func (tx *Tx) RecordChanges(events ...ChangeEvent) {
+	tx.db.reportChanges(tx, events)
+}
+
+func (db *Database) reportChanges(tx *Tx, events []ChangeEvent) {
+	if db.HandleChanges == nil || len(events) == 0 {
+		return
+	}
+
+	if tx != nil {
+		tx.changes.Lock()
+		defer tx.changes.Unlock()
+
+		tx.changes.events = append(tx.changes.events, events...)
+		return
+	}
+
+	db.HandleChanges(events)
+}
+
+// flushChanges reports all the changes queued in the transaction
+func (tx *Tx) flushChanges() {
+	tx.changes.Lock()
+	events := tx.changes.events
+	tx.changes.events = nil
+	tx.changes.Unlock()
+
+	if tx.db.HandleChanges != nil && len(events) != 0 {
+		tx.db.HandleChanges(events)
+	}
+}
@@ -0,0 +1,159 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/StirlingMarketingGroup/cool-mysql/internal/testdriver"
+)
+
+type changeUser struct {
+	ID   int
+	Name string
+}
+
+func TestDatabase_HandleChanges(t *testing.T) {
+	db := testDatabase(t, &testdriver.Driver{Tx: true})
+
+	var events []ChangeEvent
+	db.HandleChanges = func(e []ChangeEvent) {
+		events = append(events, e...)
+	}
+
+	ctx := context.Background()
+	if err := db.InsertContext(ctx, "users", []changeUser{{1, "Ann"}, {2, "Bob"}}); err != nil {
+		t.Fatal(err)
+	}
+	want := []ChangeEvent{
+		{Table: "users", Kind: ChangeInsert, After: changeUser{1, "Ann"}},
+		{Table: "users", Kind: ChangeInsert, After: changeUser{2, "Bob"}},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("InsertContext() reported %+v, want %+v", events, want)
+	}
+
+	// changes in a transaction are only reported once it's committed
+	events = nil
+	tx, cancel, err := db.BeginTxContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+
+	if err := tx.InsertContext(ctx, "users", changeUser{3, "Cal"}); err != nil {
+		t.Fatal(err)
+	}
+	tx.RecordChanges(ChangeEvent{Table: "users", Kind: ChangeDelete, Keys: Params{"ID": 1}})
+	if len(events) != 0 {
+		t.Errorf("Tx reported %+v before it was committed", events)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	want = []ChangeEvent{
+		{Table: "users", Kind: ChangeInsert, After: changeUser{3, "Cal"}},
+		{Table: "users", Kind: ChangeDelete, Keys: Params{"ID": 1}},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("Commit() reported %+v, want %+v", events, want)
+	}
+}
+
+func TestDatabase_HandleChangesUpsert(t *testing.T) {
+	// every update matches its row, so nothing is inserted
+	db := testDatabase(t, &testdriver.Driver{})
+
+	var events []ChangeEvent
+	db.HandleChanges = func(e []ChangeEvent) {
+		events = append(events, e...)
+	}
+
+	err := db.UpsertContext(context.Background(), "insert into`users`", []string{"ID"}, []string{"Name"}, "", nil, []changeUser{{1, "Ann"}, {2, "Bob"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ChangeEvent{
+		{Table: "users", Kind: ChangeUpdate, Keys: Params{"ID": 1}, After: changeUser{1, "Ann"}},
+		{Table: "users", Kind: ChangeUpdate, Keys: Params{"ID": 2}, After: changeUser{2, "Bob"}},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("UpsertContext() reported %+v, want %+v", events, want)
+	}
+}
+
+func TestRepository_HandleChanges(t *testing.T) {
+	var rows [][]driver.Value
+	db := testDatabase(t, &testdriver.Driver{
+		QueryFunc: func(ctx context.Context, query string) (driver.Rows, error) {
+			return testdriver.NewRows([]string{"ID", "Name"}, rows...), nil
+		},
+	})
+
+	var events []ChangeEvent
+	db.HandleChanges = func(e []ChangeEvent) {
+		events = append(events, e...)
+	}
+
+	ctx := context.Background()
+	users := Repo[changeUser](db, "users")
+
+	rows = [][]driver.Value{{int64(1), []byte("Ann")}}
+	if err := users.Update(ctx, changeUser{1, "Amy"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.Delete(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	want := []ChangeEvent{
+		{Table: "users", Kind: ChangeUpdate, Keys: Params{"ID": 1}, Before: changeUser{1, "Ann"}, After: changeUser{1, "Amy"}},
+		{Table: "users", Kind: ChangeDelete, Keys: Params{"ID": 1}, Before: changeUser{1, "Ann"}},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("Update() and Delete() reported %+v, want %+v", events, want)
+	}
+
+	// rows that weren't there weren't changed
+	events = nil
+	rows = nil
+	if err := users.Update(ctx, changeUser{2, "Bob"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.Delete(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 0 {
+		t.Errorf("Update() and Delete() of missing rows reported %+v", events)
+	}
+}
+
+func TestDatabase_DeleteReturningChanges(t *testing.T) {
+	db := testDatabase(t, &testdriver.Driver{
+		QueryFunc: func(ctx context.Context, query string) (driver.Rows, error) {
+			if !strings.HasSuffix(query, "returning`ID`,`Name`") {
+				t.Errorf("DeleteReturning() ran %q", query)
+			}
+			return testdriver.NewRows([]string{"ID", "Name"}, []driver.Value{int64(1), []byte("Ann")}), nil
+		},
+	})
+	db.SetServerInfo(ParseServerVersion("10.11.2-MariaDB"))
+
+	var events []ChangeEvent
+	db.HandleChanges = func(e []ChangeEvent) {
+		events = append(events, e...)
+	}
+
+	var deleted []changeUser
+	if err := db.DeleteReturning(context.Background(), &deleted, "delete from`app`.`users`where`Name`='Ann'"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ChangeEvent{{Table: "app.users", Kind: ChangeDelete, Before: changeUser{1, "Ann"}}}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("DeleteReturning() reported %+v, want %+v", events, want)
+	}
+}
@@ -0,0 +1,139 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ClaimColumns are the names of the columns used to claim rows from a queue table,
+// passed with the params of ClaimRows for tables whose columns aren't `ID` and `LeasedUntil`
+type ClaimColumns struct {
+	// Key uniquely identifies each row. Empty means `ID`.
+	Key string
+
+	// LeasedUntil is when the claim on the row expires, and
+	// rows with a null or past lease can be claimed. Empty means `LeasedUntil`.
+	LeasedUntil string
+}
+
+// claimColumnsFromParams returns the claim columns passed with the params, with the defaults
+// for the ones that weren't, and the params without them
+func claimColumnsFromParams(params []any) (ClaimColumns, []any, error) {
+	var cols ClaimColumns
+	var found bool
+	rest := make([]any, 0, len(params))
+	for _, p := range params {
+		c, ok := p.(ClaimColumns)
+		if !ok {
+			rest = append(rest, p)
+			continue
+		}
+
+		if found {
+			return ClaimColumns{}, nil, errors.New("cool-mysql: a claim can only have one set of claim columns")
+		}
+		cols, found = c, true
+	}
+
+	if len(cols.Key) == 0 {
+		cols.Key = "ID"
+	}
+	if len(cols.LeasedUntil) == 0 {
+		cols.LeasedUntil = "LeasedUntil"
+	}
+
+	return cols, rest, nil
+}
+
+// ClaimRows claims up to limit rows of the queue table that aren't leased and match the
+// optional where clause, leasing them for the given duration so no other caller claims them
+// until the lease expires. Rows locked by concurrent claims are skipped instead of waited for.
+// The rows are returned as they were before their leases were set. The columns of the rows'
+// keys and leases are `ID` and `LeasedUntil`, unless ClaimColumns are passed with the params.
+func ClaimRows[T any](ctx context.Context, db *Database, table string, where string, limit int, lease time.Duration, params ...any) ([]T, error) {
+	tx, cancel, err := db.BeginTxContext(ctx)
+	defer cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin tx: %w", err)
+	}
+
+	rows, err := ClaimRowsTx[T](ctx, tx, table, where, limit, lease, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit claim: %w", err)
+	}
+
+	return rows, nil
+}
+
+// ClaimRowsTx claims rows like ClaimRows, but in the given transaction,
+// so the claims are only visible to other callers once it commits
+func ClaimRowsTx[T any](ctx context.Context, tx *Tx, table string, where string, limit int, lease time.Duration, params ...any) ([]T, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	cols, params, err := claimColumnsFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	quotedTable := tx.db.quoteTable(table)
+	key := quoteIdentifier(cols.Key)
+	leasedUntil := quoteIdentifier(cols.LeasedUntil)
+
+	q := "select*from" + quotedTable + "where(" + leasedUntil + "is null or" + leasedUntil + "<now(6))"
+	if len(strings.TrimSpace(where)) != 0 {
+		q += "and(" + where + ")"
+	}
+	q += "order by" + key + "limit " + strconv.Itoa(limit)
+
+	var rows []T
+	err = tx.SelectContext(ctx, &rows, q, 0, append(append(make([]any, 0, len(params)+1), params...), ForUpdate().SkipLocked())...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select rows to claim: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]any, 0, len(rows))
+	err = forEachRow(rows, false, func(row map[string]any) error {
+		k, ok := row[cols.Key]
+		if !ok {
+			// the key column can be named in a different case than the rows' columns, like MySQL's names
+			for c, v := range row {
+				if strings.EqualFold(c, cols.Key) {
+					k, ok = v, true
+					break
+				}
+			}
+		}
+		if !ok {
+			return fmt.Errorf("cool-mysql: claimed row is missing key column %q", cols.Key)
+		}
+		keys = append(keys, k)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the rows were already limited to the tenant by the select, if they had to be
+	err = tx.ExecContext(AllowAllTenants(ctx), "update"+quotedTable+"set"+leasedUntil+"=now(6)+interval @@Lease microsecond where"+key+"in(@@Keys)", Params{
+		"Lease": lease.Microseconds(),
+		"Keys":  keys,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to lease claimed rows: %w", err)
+	}
+
+	return rows, nil
+}
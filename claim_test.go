@@ -0,0 +1,119 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StirlingMarketingGroup/cool-mysql/internal/testdriver"
+)
+
+func Test_claimColumnsFromParams(t *testing.T) {
+	cols, rest, err := claimColumnsFromParams([]any{Params{"A": 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (ClaimColumns{Key: "ID", LeasedUntil: "LeasedUntil"}); cols != want || len(rest) != 1 {
+		t.Errorf("claimColumnsFromParams() = %+v, %v, want the defaults and the params", cols, rest)
+	}
+
+	cols, rest, err = claimColumnsFromParams([]any{ClaimColumns{Key: "JobID"}, Params{"A": 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (ClaimColumns{Key: "JobID", LeasedUntil: "LeasedUntil"}); cols != want || len(rest) != 1 {
+		t.Errorf("claimColumnsFromParams() = %+v, %v, want the passed key and the params without it", cols, rest)
+	}
+
+	if _, _, err := claimColumnsFromParams([]any{ClaimColumns{}, ClaimColumns{}}); err == nil {
+		t.Error("claimColumnsFromParams() of two sets of claim columns didn't fail")
+	}
+}
+
+func TestClaimRows(t *testing.T) {
+	db := testDatabase(t, &testdriver.Driver{
+		Columns: []string{"jobid", "Payload"},
+		Row:     []driver.Value{int64(7), []byte("email")},
+		Rows:    1,
+		Tx:      true,
+	})
+	db.SetServerInfo(ParseServerVersion("8.0.33"))
+
+	var queries []string
+	db.Log = func(detail LogDetail) {
+		// the transaction's own statements aren't the claim's
+		if strings.HasPrefix(detail.Query, "select") || strings.HasPrefix(detail.Query, "update") {
+			queries = append(queries, detail.Query)
+		}
+	}
+
+	type job struct {
+		JobID   int
+		Payload string
+	}
+
+	ctx := context.Background()
+	tests := []struct {
+		name  string
+		claim func() (any, error)
+		want  any
+	}{
+		{
+			name: "struct",
+			claim: func() (any, error) {
+				return ClaimRows[job](ctx, db, "jobs", "`Payload`=@@Payload", 10, time.Minute,
+					ClaimColumns{Key: "JobID", LeasedUntil: "LockedUntil"}, Params{"Payload": "email"})
+			},
+			want: []job{{JobID: 7, Payload: "email"}},
+		},
+		{
+			// the key is looked up in the map's columns whatever its case
+			name: "map",
+			claim: func() (any, error) {
+				return ClaimRows[MapRow](ctx, db, "jobs", "", 10, time.Minute, ClaimColumns{Key: "JobID", LeasedUntil: "LockedUntil"})
+			},
+			want: []MapRow{{"jobid": int64(7), "Payload": []byte("email")}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries = nil
+			rows, err := tt.claim()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(rows, tt.want) {
+				t.Errorf("ClaimRows() = %#v, want %#v", rows, tt.want)
+			}
+
+			if len(queries) != 2 {
+				t.Fatalf("ClaimRows() ran %q, want a select and an update", queries)
+			}
+			if !strings.HasPrefix(queries[0], "select*from`jobs`where(`LockedUntil`is null or`LockedUntil`<now(6))") ||
+				!strings.HasSuffix(queries[0], "order by`JobID`limit 10\nfor update skip locked") {
+				t.Errorf("ClaimRows() selected with %q", queries[0])
+			}
+			if want := "update`jobs`set`LockedUntil`=now(6)+interval 60000000 microsecond where`JobID`in(7)"; queries[1] != want {
+				t.Errorf("ClaimRows() updated with %q, want %q", queries[1], want)
+			}
+		})
+	}
+}
+
+func TestClaimRows_missingKey(t *testing.T) {
+	db := testDatabase(t, &testdriver.Driver{
+		Columns: []string{"Payload"},
+		Row:     []driver.Value{[]byte("email")},
+		Rows:    1,
+		Tx:      true,
+	})
+	db.SetServerInfo(ParseServerVersion("8.0.33"))
+
+	_, err := ClaimRows[MapRow](context.Background(), db, "jobs", "", 10, time.Minute)
+	if err == nil || !strings.Contains(err.Error(), `"ID"`) {
+		t.Errorf("ClaimRows() of rows without their key error = %v, want one naming it", err)
+	}
+}
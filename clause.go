@@ -0,0 +1,67 @@
+package mysql
+
+import "strings"
+
+// When returns the query fragment if ok, otherwise nothing, so optional filters can be composed in Go
+// instead of `{{ if }}` templates, like
+//
+//	q := "select*from`events`where`Active`" +
+//		mysql.When(from != nil, "and`CreatedAt`>=@@From") +
+//		mysql.When(len(types) != 0, "and`Type`in(@@Types)")
+//
+// The fragment is padded with spaces, so it's safe to put between any other parts of a query.
+func When(ok bool, fragment string) string {
+	if !ok {
+		return ""
+	}
+
+	return " " + fragment + " "
+}
+
+// WhenElse returns the then fragment if ok, otherwise the other fragment, padded with spaces like When
+func WhenElse(ok bool, then, otherwise string) string {
+	if ok {
+		return " " + then + " "
+	}
+
+	return " " + otherwise + " "
+}
+
+// Where returns a where clause of the conditions that aren't empty, joined with and,
+// or nothing if they're all empty, for conditions that are only sometimes used, like
+//
+//	q := "select*from`events`" + mysql.Where(
+//		mysql.When(from != nil, "`CreatedAt`>=@@From"),
+//		mysql.When(len(types) != 0, "`Type`in(@@Types)"),
+//	) + "order by`CreatedAt`"
+func Where(conds ...string) string {
+	b := new(strings.Builder)
+	for _, c := range conds {
+		c = strings.TrimSpace(c)
+		if len(c) == 0 {
+			continue
+		}
+
+		if b.Len() == 0 {
+			b.WriteString(" where(")
+		} else {
+			b.WriteString(")and(")
+		}
+		b.WriteString(c)
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	b.WriteString(") ")
+
+	return b.String()
+}
+
+// If returns the condition if ok, otherwise nil, which And and Or skip
+func If(ok bool, cond Cond) Cond {
+	if !ok {
+		return nil
+	}
+
+	return cond
+}
@@ -0,0 +1,38 @@
+package mysql
+
+import "testing"
+
+func TestWhere(t *testing.T) {
+	tests := []struct {
+		name  string
+		conds []string
+		want  string
+	}{
+		{"none", nil, ""},
+		{"all empty", []string{When(false, "`A`=1"), ""}, ""},
+		{"one", []string{When(false, "`A`=1"), When(true, "`B`=@@B")}, " where(`B`=@@B) "},
+		{"many", []string{When(true, "`A`=1"), "`B`=2 or`C`=3"}, " where(`A`=1)and(`B`=2 or`C`=3) "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Where(tt.conds...); got != tt.want {
+				t.Errorf("Where() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	q := "select*from`events`where`Active`" + When(true, "and`Type`in(@@Types)") + When(false, "and`CreatedAt`>=@@From") +
+		WhenElse(false, "order by`ID`", "order by`CreatedAt`")
+	got, _, err := InterpolateParams(q, nil, nil, Params{"Types": []int{1, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "select*from`events`where`Active` and`Type`in(1,2)  order by`CreatedAt` "; got != want {
+		t.Errorf("InterpolateParams() = %q, want %q", got, want)
+	}
+
+	where, _ := And(If(false, Eq("A", 1)), If(true, Eq("B", 2))).Build()
+	if want := "(`B`=@@__Cond0)"; where != want {
+		t.Errorf("If() built %q, want %q", where, want)
+	}
+}
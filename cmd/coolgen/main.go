@@ -0,0 +1,50 @@
+// Command coolgen generates Go structs from MySQL tables
+// with the struct tags cool-mysql uses for selects and inserts.
+//
+// Usage:
+//
+//	coolgen -dsn 'user:pass@tcp(localhost:3306)/schema' -pkg models -out models/tables.go users orders
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	mysql "github.com/StirlingMarketingGroup/cool-mysql"
+)
+
+func main() {
+	dsn := flag.String("dsn", os.Getenv("COOLGEN_DSN"), "MySQL DSN to read the tables from")
+	pkg := flag.String("pkg", "models", "package name of the generated file")
+	out := flag.String("out", "", "file to write to, defaults to stdout")
+	flag.Parse()
+
+	if len(*dsn) == 0 || flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: coolgen -dsn <dsn> [-pkg <package>] [-out <file>] <table>...")
+		os.Exit(2)
+	}
+
+	db, err := mysql.NewFromDSN(*dsn, *dsn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "coolgen: failed to connect: %v\n", err)
+		os.Exit(1)
+	}
+
+	src, err := mysql.GenerateStructs(context.Background(), db, *pkg, flag.Args()...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "coolgen: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(*out) == 0 {
+		os.Stdout.Write(src)
+		return
+	}
+
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "coolgen: failed to write %q: %v\n", *out, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,175 @@
+package mysql
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Collated is a param whose strings are converted to the charset of the collation, and use it, instead of
+// utf8mb4_unicode_ci, so they can be compared to columns of other charsets, like legacy latin1 tables,
+// without an "illegal mix of collations" error. Its other values are marshaled like they would be otherwise.
+type Collated struct {
+	Value     any
+	Collation string
+}
+
+var collatedType = reflect.TypeOf(Collated{})
+
+// Collate returns the param with its strings in the collation, see Collated
+func Collate(v any, collation string) Collated {
+	return Collated{Value: v, Collation: collation}
+}
+
+// Collation is a query param that puts every string param of the query in the collation,
+// like each were wrapped with Collate
+type Collation string
+
+// WithCollation puts every string param of the view's queries in the collation,
+// unless the query has its own Collation. The values of inserted rows aren't params,
+// and are stored in their columns' own collations, so they aren't converted,
+// unless they're Collated themselves.
+func WithCollation(collation string) Option {
+	return func(db *Database) {
+		db.collation = collation
+	}
+}
+
+// collationCharset returns the charset of the collation, which is always the first part of its name
+func collationCharset(collation string) string {
+	charset, _, _ := strings.Cut(collation, "_")
+	return charset
+}
+
+// appendCollated appends the collated param to dst, like appendMarshal
+func appendCollated(dst []byte, v Collated, opts marshalOpt, fieldName string, valuerFuncs *valuers) ([]byte, error) {
+	rv := reflectUnwrap(reflect.ValueOf(v.Value))
+
+	switch rv.Kind() {
+	case reflect.String:
+		s := rv.String()
+
+		// the string is still sent as utf8mb4, and converted by the server, so it's never misread
+		dst = append(dst, "convert("...)
+		if len(s) == 0 {
+			dst = append(dst, "''"...)
+		} else {
+			dst = appendHex(append(dst, "_utf8mb4 0x"...), s)
+		}
+		dst = append(append(append(dst, " using "...), collationCharset(v.Collation)...), ')')
+		return append(append(dst, "collate "...), v.Collation...), nil
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 || opts&marshalOptJSONSlice != 0 {
+			break
+		}
+
+		if opts&marshalOptWrapSliceWithParens != 0 {
+			dst = append(dst, '(')
+		}
+		if rv.Len() == 0 {
+			dst = append(dst, "null"...)
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if i != 0 {
+				dst = append(dst, ',')
+			}
+
+			var err error
+			dst, err = appendCollated(dst, Collated{Value: rv.Index(i).Interface(), Collation: v.Collation}, opts|marshalOptWrapSliceWithParens, fieldName, valuerFuncs)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if opts&marshalOptWrapSliceWithParens != 0 {
+			dst = append(dst, ')')
+		}
+		return dst, nil
+	}
+
+	return appendMarshal(dst, v.Value, opts, fieldName, valuerFuncs)
+}
+
+// collationFromParams returns the last Collation of the params, and the params without any of them
+func collationFromParams(params []any) (string, []any) {
+	var collation string
+	var rest []any
+	for i, p := range params {
+		c, ok := p.(Collation)
+		if !ok {
+			if rest != nil {
+				rest = append(rest, p)
+			}
+			continue
+		}
+
+		collation = string(c)
+		if rest == nil {
+			rest = append(make([]any, 0, len(params)-1), params[:i]...)
+		}
+	}
+
+	if rest == nil {
+		return collation, params
+	}
+
+	return collation, rest
+}
+
+// collatedParams puts the string params in the collation, with the collated fields
+// of map and struct params in params right after them, like zonedParams
+func collatedParams(params []any, collation string) []any {
+	if len(collation) == 0 {
+		return params
+	}
+
+	collated := make([]any, 0, len(params))
+	for _, p := range params {
+		t := reflect.TypeOf(p)
+		if t != nil && t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t == nil || isSingleParam(t) || isNil(p) {
+			if c, ok := collateString(p, collation); ok {
+				p = c
+			}
+			collated = append(collated, p)
+			continue
+		}
+
+		cp, _ := convertToParams("", p)
+		overrides := make(Params)
+		for k, v := range cp {
+			if c, ok := collateString(v, collation); ok {
+				overrides[k] = c
+			}
+		}
+		collated = append(collated, p, overrides)
+	}
+
+	return collated
+}
+
+// collateString returns the value in the collation, and true, if it's a string or slice of strings
+func collateString(v any, collation string) (Collated, bool) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return Collated{}, false
+	}
+	t = reflectUnwrapType(t)
+
+	switch t.Kind() {
+	case reflect.String:
+	case reflect.Slice, reflect.Array:
+		if reflectUnwrapType(t.Elem()).Kind() != reflect.String {
+			return Collated{}, false
+		}
+	default:
+		return Collated{}, false
+	}
+
+	// only plain strings, since other types could have their own way of being marshaled
+	if t.Implements(valuerType) || reflect.PointerTo(t).Implements(valuerType) {
+		return Collated{}, false
+	}
+
+	return Collated{Value: v, Collation: collation}, true
+}
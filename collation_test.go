@@ -0,0 +1,62 @@
+package mysql
+
+import "testing"
+
+func TestDatabase_collation(t *testing.T) {
+	type user struct {
+		Name string
+		Age  int
+	}
+
+	db := benchDatabase(t)
+	latin1 := db.With(WithCollation("latin1_swedish_ci"))
+
+	tests := []struct {
+		name   string
+		db     *Database
+		query  string
+		params []any
+		want   string
+	}{
+		{
+			name:   "param",
+			db:     db,
+			query:  "`Name`=@@Name and`Names`in(@@Names)",
+			params: []any{Params{"Name": Collate("é", "latin1_swedish_ci"), "Names": Collate([]string{"a", ""}, "latin1_bin")}},
+			want: "`Name`=convert(_utf8mb4 0xc3a9 using latin1)collate latin1_swedish_ci and" +
+				"`Names`in(convert(_utf8mb4 0x61 using latin1)collate latin1_bin,convert('' using latin1)collate latin1_bin)",
+		},
+		{
+			name:   "query",
+			db:     db,
+			query:  "`Name`=@@Name and`Age`=@@Age",
+			params: []any{user{Name: "a", Age: 2}, Collation("latin1_german1_ci")},
+			want:   "`Name`=convert(_utf8mb4 0x61 using latin1)collate latin1_german1_ci and`Age`=2",
+		},
+		{
+			name:   "view",
+			db:     latin1,
+			query:  "`Name`=@@Name",
+			params: []any{"a"},
+			want:   "`Name`=convert(_utf8mb4 0x61 using latin1)collate latin1_swedish_ci",
+		},
+		{
+			name:   "query over view",
+			db:     latin1,
+			query:  "`Name`=@@Name",
+			params: []any{Collation("utf8mb3_general_ci"), Params{"Name": "a"}},
+			want:   "`Name`=convert(_utf8mb4 0x61 using utf8mb3)collate utf8mb3_general_ci",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := tt.db.InterpolateParams(tt.query, tt.params...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("InterpolateParams() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package mysql
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Collection is a table of json documents, laid out like the collections of the
+// MySQL document store, with the document in the `doc` column and its `_id`
+// as a generated primary key
+type Collection struct {
+	db   *Database
+	Name string
+}
+
+// Collection returns the collection of documents stored in the given table
+func (db *Database) Collection(name string) *Collection {
+	return &Collection{
+		db:   db,
+		Name: name,
+	}
+}
+
+// Create creates the collection's table if it doesn't exist
+func (c *Collection) Create(ctx context.Context) error {
+	return c.db.ExecContext(ctx, "create table if not exists"+c.db.quoteTable(c.Name)+"("+
+		"`doc`json,"+
+		"`_id`varbinary(32)generated always as(json_unquote(json_extract(`doc`,'$._id')))stored not null primary key)")
+}
+
+// CreateIndex indexes the value at the json path of every document,
+// like `$.email`, through a generated column with the same name as the index
+// and the given sql type, like `varchar(255)`
+func (c *Collection) CreateIndex(ctx context.Context, name, path, sqlType string) error {
+	return c.db.ExecContext(ctx, "alter table"+c.db.quoteTable(c.Name)+
+		"add column"+quoteIdentifier(name)+sqlType+"generated always as(json_unquote(json_extract(`doc`,@@Path)))virtual,"+
+		"add index"+quoteIdentifier(name)+"("+quoteIdentifier(name)+")", Params{
+		"Path": path,
+	})
+}
+
+// Add adds the documents to the collection, returning their ids.
+// Documents are marshaled to json, and the ones without an `_id` get a random one.
+func (c *Collection) Add(ctx context.Context, docs ...any) ([]string, error) {
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	type row struct {
+		Doc json.RawMessage `mysql:"doc"`
+	}
+
+	rows := make([]row, len(docs))
+	ids := make([]string, len(docs))
+	for i, d := range docs {
+		j, err := json.Marshal(d)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal document: %w", err)
+		}
+
+		var m map[string]any
+		if err := json.Unmarshal(j, &m); err != nil || m == nil {
+			return nil, fmt.Errorf("cool-mysql: documents must be json objects, got %s", j)
+		}
+
+		id, ok := m["_id"].(string)
+		if !ok {
+			if id, err = newDocumentID(); err != nil {
+				return nil, err
+			}
+			m["_id"] = id
+
+			if j, err = json.Marshal(m); err != nil {
+				return nil, fmt.Errorf("failed to marshal document: %w", err)
+			}
+		}
+
+		rows[i].Doc = j
+		ids[i] = id
+	}
+
+	if err := c.db.InsertContext(ctx, c.Name, rows); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// Find selects the documents that contain the filter into dest, which can be a pointer
+// to a slice or a single value. The filter is marshaled to json and matched with
+// `json_contains`, so `map[string]any{"address": map[string]any{"city": "Tulsa"}}`
+// matches the documents with that city in their address, and a nil filter matches every document.
+func (c *Collection) Find(ctx context.Context, dest any, filter any, cache time.Duration) error {
+	where, params, err := documentFilter(filter)
+	if err != nil {
+		return err
+	}
+
+	return c.FindWhere(ctx, dest, where, cache, params)
+}
+
+// FindWhere selects the documents that match the where clause into dest,
+// which can be a pointer to a slice or a single value
+func (c *Collection) FindWhere(ctx context.Context, dest any, where string, cache time.Duration, params ...any) error {
+	destRef := reflect.ValueOf(dest)
+	if destRef.Kind() != reflect.Pointer || destRef.IsNil() {
+		return ErrDestType
+	}
+
+	q := "select`doc`from" + c.db.quoteTable(c.Name)
+	if len(strings.TrimSpace(where)) != 0 {
+		q += "where " + where
+	}
+
+	var docs []json.RawMessage
+	if err := c.db.SelectContext(ctx, &docs, q, cache, params...); err != nil {
+		return err
+	}
+
+	el := destRef.Elem()
+	if el.Kind() != reflect.Slice || el.Type().Elem().Kind() == reflect.Uint8 {
+		if len(docs) == 0 {
+			return sql.ErrNoRows
+		}
+
+		if err := json.Unmarshal(docs[0], dest); err != nil {
+			return fmt.Errorf("failed to unmarshal document: %w", err)
+		}
+
+		return nil
+	}
+
+	out := reflect.MakeSlice(el.Type(), len(docs), len(docs))
+	for i, d := range docs {
+		if err := json.Unmarshal(d, out.Index(i).Addr().Interface()); err != nil {
+			return fmt.Errorf("failed to unmarshal document: %w", err)
+		}
+	}
+	el.Set(out)
+
+	return nil
+}
+
+// Remove removes the documents that contain the filter, like Find
+func (c *Collection) Remove(ctx context.Context, filter any) error {
+	where, params, err := documentFilter(filter)
+	if err != nil {
+		return err
+	}
+
+	q := "delete from" + c.db.quoteTable(c.Name)
+	if len(where) != 0 {
+		q += "where " + where
+	}
+
+	return c.db.ExecContext(AllowAllTenants(ctx), q, params)
+}
+
+// Replace replaces the document with the given id, keeping its id
+func (c *Collection) Replace(ctx context.Context, id string, doc any) error {
+	j, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal document: %w", err)
+	}
+
+	return c.db.ExecContext(AllowAllTenants(ctx), "update"+c.db.quoteTable(c.Name)+
+		"set`doc`=json_set(@@Doc,'$._id',@@ID)where`_id`=@@ID", Params{
+		"Doc": json.RawMessage(j),
+		"ID":  id,
+	})
+}
+
+func documentFilter(filter any) (string, Params, error) {
+	if filter == nil {
+		return "", nil, nil
+	}
+
+	j, err := json.Marshal(filter)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to marshal document filter: %w", err)
+	}
+
+	if string(j) == "null" || string(j) == "{}" {
+		return "", nil, nil
+	}
+
+	return "json_contains(`doc`,@@Filter)", Params{"Filter": json.RawMessage(j)}, nil
+}
+
+func newDocumentID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate document id: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
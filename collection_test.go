@@ -0,0 +1,138 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/StirlingMarketingGroup/cool-mysql/internal/testdriver"
+)
+
+func Test_documentFilter(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    any
+		where     string
+		filterDoc string
+	}{
+		{"nil", nil, "", ""},
+		{"empty", map[string]any{}, "", ""},
+		{"nil map", map[string]any(nil), "", ""},
+		{"nested", map[string]any{"address": map[string]any{"city": "Tulsa"}}, "json_contains(`doc`,@@Filter)", `{"address":{"city":"Tulsa"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, params, err := documentFilter(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if where != tt.where {
+				t.Errorf("documentFilter() where = %q, want %q", where, tt.where)
+			}
+
+			var filterDoc string
+			if params != nil {
+				filterDoc = string(params["Filter"].(json.RawMessage))
+			}
+			if filterDoc != tt.filterDoc {
+				t.Errorf("documentFilter() filter = %s, want %s", filterDoc, tt.filterDoc)
+			}
+		})
+	}
+
+	if _, _, err := documentFilter(make(chan int)); err == nil {
+		t.Error("documentFilter() of a filter that can't be marshaled didn't fail")
+	}
+}
+
+func Test_newDocumentID(t *testing.T) {
+	a, err := newDocumentID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := newDocumentID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := hex.DecodeString(a); err != nil || len(a) != 32 {
+		t.Errorf("newDocumentID() = %q, want 32 hex digits", a)
+	}
+	if a == b {
+		t.Errorf("newDocumentID() returned %q twice", a)
+	}
+}
+
+func TestCollection(t *testing.T) {
+	db := testDatabase(t, &testdriver.Driver{
+		Columns: []string{"doc"},
+		Row:     []driver.Value{[]byte(`{"_id":"a","name":"Ann"}`)},
+		Rows:    1,
+	})
+
+	var queries []string
+	db.Log = func(detail LogDetail) {
+		queries = append(queries, detail.Query)
+	}
+
+	ctx := context.Background()
+	people := db.Collection("people")
+
+	queries = nil
+	ids, err := people.Add(ctx, map[string]any{"_id": "a", "name": "Ann"}, struct {
+		Name string `json:"name"`
+	}{"Bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] != "a" || len(ids[1]) != 32 {
+		t.Errorf("Add() = %q, want the first document's id and a new one", ids)
+	}
+	if len(queries) != 1 || !strings.HasPrefix(queries[0], "insert into`people`(`doc`)values(") ||
+		!strings.Contains(queries[0], hex.EncodeToString([]byte(`{"_id":"`+ids[1]+`","name":"Bob"}`))) {
+		t.Errorf("Add() ran %q, want an insert of the documents with their ids", queries)
+	}
+
+	if _, err := people.Add(ctx, []int{1}); err == nil {
+		t.Error("Add() of a document that isn't an object didn't fail")
+	}
+
+	type person struct {
+		ID   string `json:"_id"`
+		Name string `json:"name"`
+	}
+
+	queries = nil
+	var found []person
+	if err := people.Find(ctx, &found, map[string]any{"name": "Ann"}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if want := []person{{"a", "Ann"}}; !reflect.DeepEqual(found, want) {
+		t.Errorf("Find() = %v, want %v", found, want)
+	}
+	if len(queries) != 1 || !strings.HasPrefix(queries[0], "select`doc`from`people`where json_contains(`doc`,") {
+		t.Errorf("Find() ran %q, want a select of the documents containing the filter", queries)
+	}
+
+	queries = nil
+	var one person
+	if err := people.Find(ctx, &one, nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	if one.ID != "a" || len(queries) != 1 || queries[0] != "select`doc`from`people`" {
+		t.Errorf("Find() of every document = %v and ran %q", one, queries)
+	}
+
+	queries = nil
+	if err := people.Replace(ctx, "a", person{Name: "Amy"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 1 || !strings.HasPrefix(queries[0], "update`people`set`doc`=json_set(") ||
+		!strings.Contains(queries[0], ",'$._id',") || !strings.Contains(queries[0], "where`_id`=") {
+		t.Errorf("Replace() ran %q, want an update of the document keeping its id", queries)
+	}
+}
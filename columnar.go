@@ -0,0 +1,301 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ColumnField describes a column of a columnar result, from the result set's metadata
+type ColumnField struct {
+	Name string
+
+	// DatabaseType is the type of the column as reported by the driver, like "DECIMAL" or "UNSIGNED BIGINT"
+	DatabaseType string
+
+	Nullable bool
+
+	// Precision and Scale are set for decimal columns
+	Precision, Scale int64
+}
+
+// ColumnBatch is a batch of rows of a result set stored by column, like an Arrow record batch,
+// so it can be handed to analytics pipelines without going through structs or maps.
+//
+// Each of Columns is a slice with a value per row, where the type of the slice depends on the column:
+//   - []int64 for signed integers and YEAR
+//   - []uint64 for unsigned integers and BIT
+//   - []float64 for FLOAT and DOUBLE
+//   - []decimal.Decimal for DECIMAL, keeping its exact value
+//   - []time.Time for DATE, DATETIME, and TIMESTAMP, in the database's time zone
+//   - [][]byte for binary strings and blobs
+//   - []string for everything else, including TIME and JSON
+//
+// Valid has a slice per column too, where false means the row's value is null
+// and its value in Columns is the zero value.
+type ColumnBatch struct {
+	Fields  []ColumnField
+	Columns []any
+	Valid   [][]bool
+	Len     int
+}
+
+// SelectColumnar runs the query and calls fn with its rows in batches of at most batchSize rows
+// stored by column, streaming the result so only one batch is held in memory at a time.
+// The batch's slices are only valid until fn returns.
+func (db *Database) SelectColumnar(ctx context.Context, batchSize int, fn func(ColumnBatch) error, q string, params ...any) error {
+	return db.selectColumnar(db.Reads, ctx, batchSize, fn, q, params...)
+}
+
+// SelectColumnar runs the query and calls fn with its rows in batches stored by column
+func (tx *Tx) SelectColumnar(ctx context.Context, batchSize int, fn func(ColumnBatch) error, q string, params ...any) error {
+	return tx.db.selectColumnar(tx.Tx, ctx, batchSize, fn, q, params...)
+}
+
+func (db *Database) selectColumnar(conn handlerWithContext, ctx context.Context, batchSize int, fn func(ColumnBatch) error, query string, params ...any) error {
+	if batchSize <= 0 {
+		return fmt.Errorf("cool-mysql: invalid batch size %d", batchSize)
+	}
+
+	params = tenantParams(ctx, params)
+
+	replacedQuery, normalizedParams, err := db.interpolateParams(ctx, query, params...)
+	if err != nil {
+		return fmt.Errorf("failed to interpolate params: %w", err)
+	}
+
+	if db.die {
+		fmt.Println(replacedQuery)
+		os.Exit(0)
+	}
+
+	if err := db.checkReadOnly(replacedQuery); err != nil {
+		return err
+	}
+
+	if err := db.checkStatementPolicy(replacedQuery); err != nil {
+		return err
+	}
+
+	wrapErr := func(err error) error {
+		return Error{
+			Err:           err,
+			OriginalQuery: query,
+			ReplacedQuery: replacedQuery,
+			Params:        normalizedParams,
+		}
+	}
+
+	start := time.Now()
+	rows, err := conn.QueryContext(ctx, db.commented(ctx, replacedQuery))
+	db.callLog(LogDetail{
+		Query:    replacedQuery,
+		Params:   normalizedParams,
+		Duration: time.Since(start),
+		Attempt:  1,
+		Error:    err,
+	})
+	if err != nil {
+		return wrapErr(err)
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return wrapErr(err)
+	}
+
+	fields := make([]ColumnField, len(columnTypes))
+	builders := make([]columnBuilder, len(columnTypes))
+	dests := make([]any, len(columnTypes))
+	for i, ct := range columnTypes {
+		fields[i] = ColumnField{
+			Name:         ct.Name(),
+			DatabaseType: ct.DatabaseTypeName(),
+		}
+		fields[i].Nullable, _ = ct.Nullable()
+		fields[i].Precision, fields[i].Scale, _ = ct.DecimalSize()
+
+		builders[i] = newColumnBuilder(fields[i].DatabaseType, batchSize)
+		dests[i] = builders[i]
+	}
+
+	n := 0
+	flush := func() error {
+		batch := ColumnBatch{
+			Fields:  fields,
+			Columns: make([]any, len(builders)),
+			Valid:   make([][]bool, len(builders)),
+			Len:     n,
+		}
+		for i, b := range builders {
+			batch.Columns[i], batch.Valid[i] = b.values()
+		}
+
+		err := fn(batch)
+
+		for _, b := range builders {
+			b.reset()
+		}
+		n = 0
+
+		return err
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(dests...); err != nil {
+			return wrapErr(err)
+		}
+		n++
+
+		if n == batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return wrapErr(err)
+	}
+
+	if n != 0 {
+		return flush()
+	}
+
+	return nil
+}
+
+// columnBuilder is scanned into for every row, appending the value to its column
+type columnBuilder interface {
+	sql.Scanner
+	values() (any, []bool)
+	reset()
+}
+
+func newColumnBuilder(databaseType string, batchSize int) columnBuilder {
+	switch databaseType {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT", "YEAR":
+		return newTypedColumnBuilder(batchSize, func(src any) (int64, error) {
+			var v sql.NullInt64
+			err := v.Scan(src)
+			return v.Int64, err
+		})
+	case "UNSIGNED TINYINT", "UNSIGNED SMALLINT", "UNSIGNED INT", "UNSIGNED BIGINT":
+		return newTypedColumnBuilder(batchSize, scanUint64)
+	case "BIT":
+		return newTypedColumnBuilder(batchSize, scanBits)
+	case "FLOAT", "DOUBLE":
+		return newTypedColumnBuilder(batchSize, func(src any) (float64, error) {
+			var v sql.NullFloat64
+			err := v.Scan(src)
+			return v.Float64, err
+		})
+	case "DECIMAL":
+		return newTypedColumnBuilder(batchSize, func(src any) (decimal.Decimal, error) {
+			var v decimal.NullDecimal
+			err := v.Scan(src)
+			return v.Decimal, err
+		})
+	case "DATE", "DATETIME", "TIMESTAMP":
+		return newTypedColumnBuilder(batchSize, func(src any) (time.Time, error) {
+			var v sql.NullTime
+			err := v.Scan(src)
+			return v.Time, err
+		})
+	case "BINARY", "VARBINARY", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB", "GEOMETRY":
+		return newTypedColumnBuilder(batchSize, func(src any) ([]byte, error) {
+			switch src := src.(type) {
+			case []byte:
+				return append([]byte(nil), src...), nil
+			case string:
+				return []byte(src), nil
+			}
+			return nil, fmt.Errorf("cool-mysql: can't scan %T into bytes", src)
+		})
+	default:
+		return newTypedColumnBuilder(batchSize, func(src any) (string, error) {
+			var v sql.NullString
+			err := v.Scan(src)
+			return v.String, err
+		})
+	}
+}
+
+type typedColumnBuilder[T any] struct {
+	convert func(src any) (T, error)
+	col     []T
+	valid   []bool
+}
+
+func newTypedColumnBuilder[T any](batchSize int, convert func(src any) (T, error)) *typedColumnBuilder[T] {
+	return &typedColumnBuilder[T]{
+		convert: convert,
+		col:     make([]T, 0, batchSize),
+		valid:   make([]bool, 0, batchSize),
+	}
+}
+
+func (b *typedColumnBuilder[T]) Scan(src any) error {
+	var v T
+	if src != nil {
+		var err error
+		if v, err = b.convert(src); err != nil {
+			return err
+		}
+	}
+
+	b.col = append(b.col, v)
+	b.valid = append(b.valid, src != nil)
+
+	return nil
+}
+
+func (b *typedColumnBuilder[T]) values() (any, []bool) {
+	return b.col, b.valid
+}
+
+func (b *typedColumnBuilder[T]) reset() {
+	b.col = b.col[:0]
+	b.valid = b.valid[:0]
+}
+
+// scanUint64 converts unsigned integers, which can overflow int64
+func scanUint64(src any) (uint64, error) {
+	switch src := src.(type) {
+	case int64:
+		return uint64(src), nil
+	case uint64:
+		return src, nil
+	case []byte:
+		return strconv.ParseUint(string(src), 10, 64)
+	case string:
+		return strconv.ParseUint(src, 10, 64)
+	}
+
+	return 0, fmt.Errorf("cool-mysql: can't scan %T into uint64", src)
+}
+
+// scanBits converts BIT values, which are sent as big-endian bytes
+func scanBits(src any) (uint64, error) {
+	switch src := src.(type) {
+	case int64:
+		return uint64(src), nil
+	case []byte:
+		if len(src) > 8 {
+			return 0, fmt.Errorf("cool-mysql: can't scan %d bytes into uint64", len(src))
+		}
+
+		var v uint64
+		for _, b := range src {
+			v = v<<8 | uint64(b)
+		}
+		return v, nil
+	}
+
+	return 0, fmt.Errorf("cool-mysql: can't scan %T into uint64", src)
+}
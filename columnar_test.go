@@ -0,0 +1,49 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestColumnBuilder(t *testing.T) {
+	tests := []struct {
+		databaseType string
+		src          []any
+		want         any
+	}{
+		{"BIGINT", []any{[]byte("-5"), nil}, []int64{-5, 0}},
+		{"UNSIGNED BIGINT", []any{[]byte("18446744073709551615"), nil}, []uint64{18446744073709551615, 0}},
+		{"BIT", []any{[]byte{1, 2}, nil}, []uint64{258, 0}},
+		{"DOUBLE", []any{[]byte("1.5"), nil}, []float64{1.5, 0}},
+		{"DECIMAL", []any{[]byte("1.10"), nil}, []decimal.Decimal{decimal.RequireFromString("1.10"), {}}},
+		{"DATETIME", []any{time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), nil}, []time.Time{time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), {}}},
+		{"VARBINARY", []any{[]byte{0, 1}, nil}, [][]byte{{0, 1}, nil}},
+		{"JSON", []any{[]byte(`{"a":1}`), nil}, []string{`{"a":1}`, ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.databaseType, func(t *testing.T) {
+			b := newColumnBuilder(tt.databaseType, 2)
+			for _, src := range tt.src {
+				if err := b.Scan(src); err != nil {
+					t.Fatalf("Scan() error = %v", err)
+				}
+			}
+
+			got, valid := b.values()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("values() = %#v, want %#v", got, tt.want)
+			}
+			if !reflect.DeepEqual(valid, []bool{true, false}) {
+				t.Errorf("valid = %v, want [true false]", valid)
+			}
+
+			b.reset()
+			if _, valid := b.values(); len(valid) != 0 {
+				t.Errorf("reset() left %d values", len(valid))
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TableColumn is a column's definition from information_schema
+type TableColumn struct {
+	Name                 string  `mysql:"COLUMN_NAME"`
+	Position             int     `mysql:"ORDINAL_POSITION"`
+	DataType             string  `mysql:"DATA_TYPE"`
+	ColumnType           string  `mysql:"COLUMN_TYPE"`
+	Nullable             bool    `mysql:"IS_NULLABLE"`
+	Default              *string `mysql:"COLUMN_DEFAULT"`
+	CharacterMaxLength   *int64  `mysql:"CHARACTER_MAXIMUM_LENGTH"`
+	NumericPrecision     *int64  `mysql:"NUMERIC_PRECISION"`
+	NumericScale         *int64  `mysql:"NUMERIC_SCALE"`
+	Key                  string  `mysql:"COLUMN_KEY"`
+	Extra                string  `mysql:"EXTRA"`
+	GenerationExpression string  `mysql:"GENERATION_EXPRESSION"`
+	Comment              string  `mysql:"COLUMN_COMMENT"`
+}
+
+// Unsigned returns true if the column is an unsigned numeric type
+func (c TableColumn) Unsigned() bool {
+	return strings.Contains(strings.ToLower(c.ColumnType), "unsigned")
+}
+
+// Generated returns true if the column is a virtual or stored generated column
+func (c TableColumn) Generated() bool {
+	return len(c.GenerationExpression) != 0 || strings.Contains(strings.ToLower(c.Extra), "generated")
+}
+
+// AutoIncrement returns true if the column is an auto_increment column
+func (c TableColumn) AutoIncrement() bool {
+	return strings.Contains(strings.ToLower(c.Extra), "auto_increment")
+}
+
+// splitTableName splits an optionally schema qualified table name
+func splitTableName(table string) (schema, name string) {
+	parts := parseQuery(table)
+	names := make([]string, 0, 2)
+	for _, t := range parts {
+		if t.kind == queryTokenKindWord || t.kind == queryTokenKindString {
+			names = append(names, parseName(t.string))
+		}
+	}
+
+	switch len(names) {
+	case 0:
+		return "", table
+	case 1:
+		return "", names[0]
+	default:
+		return names[len(names)-2], names[len(names)-1]
+	}
+}
+
+// TableColumns returns the column definitions of the table, in order,
+// from information_schema. The table can be schema qualified, otherwise
+// the connection's current schema is used.
+func (db *Database) TableColumns(ctx context.Context, table string, cache time.Duration) ([]TableColumn, error) {
+	schema, name := splitTableName(table)
+
+	if len(schema) == 0 {
+		schema = db.schema
+	}
+	schema, name = db.normalizeTableName(schema), db.normalizeTableName(name)
+
+	var schemaParam any
+	if len(schema) != 0 {
+		schemaParam = schema
+	}
+
+	var columns []TableColumn
+	err := db.query(db.Reads, ctx, &columns, "select`COLUMN_NAME`,`ORDINAL_POSITION`,`DATA_TYPE`,`COLUMN_TYPE`,"+
+		"`IS_NULLABLE`='YES'`IS_NULLABLE`,`COLUMN_DEFAULT`,`CHARACTER_MAXIMUM_LENGTH`,`NUMERIC_PRECISION`,`NUMERIC_SCALE`,"+
+		"`COLUMN_KEY`,`EXTRA`,coalesce(`GENERATION_EXPRESSION`,'')`GENERATION_EXPRESSION`,`COLUMN_COMMENT`"+
+		"from`information_schema`.`COLUMNS`"+
+		"where`TABLE_SCHEMA`=coalesce(@@Schema,database())and`TABLE_NAME`=@@Table "+
+		"order by`ORDINAL_POSITION`", cache, Params{
+		"Schema": schemaParam,
+		"Table":  name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns for table %q: %w", table, err)
+	}
+
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table %q not found or has no columns", table)
+	}
+
+	return columns, nil
+}
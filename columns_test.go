@@ -0,0 +1,111 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/StirlingMarketingGroup/cool-mysql/internal/testdriver"
+)
+
+func Test_splitTableName(t *testing.T) {
+	tests := []struct {
+		table, schema, name string
+	}{
+		{"users", "", "users"},
+		{"`users`", "", "users"},
+		{"app.users", "app", "users"},
+		{"`app`.`users`", "app", "users"},
+		{"`my.app`.`user``s`", "my.app", "user`s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.table, func(t *testing.T) {
+			schema, name := splitTableName(tt.table)
+			if schema != tt.schema || name != tt.name {
+				t.Errorf("splitTableName() = %q, %q, want %q, %q", schema, name, tt.schema, tt.name)
+			}
+		})
+	}
+}
+
+func TestTableColumn(t *testing.T) {
+	tests := []struct {
+		name                               string
+		column                             TableColumn
+		unsigned, generated, autoIncrement bool
+	}{
+		{"plain", TableColumn{ColumnType: "varchar(255)"}, false, false, false},
+		{"unsigned", TableColumn{ColumnType: "int(10) UNSIGNED"}, true, false, false},
+		{"auto increment", TableColumn{ColumnType: "bigint unsigned", Extra: "AUTO_INCREMENT"}, true, false, true},
+		{"virtual", TableColumn{Extra: "VIRTUAL GENERATED"}, false, true, false},
+		{"expression", TableColumn{GenerationExpression: "`a`+1"}, false, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.column.Unsigned(); got != tt.unsigned {
+				t.Errorf("Unsigned() = %v, want %v", got, tt.unsigned)
+			}
+			if got := tt.column.Generated(); got != tt.generated {
+				t.Errorf("Generated() = %v, want %v", got, tt.generated)
+			}
+			if got := tt.column.AutoIncrement(); got != tt.autoIncrement {
+				t.Errorf("AutoIncrement() = %v, want %v", got, tt.autoIncrement)
+			}
+		})
+	}
+}
+
+func TestDatabase_TableColumns(t *testing.T) {
+	var rows [][]driver.Value
+	db := testDatabase(t, &testdriver.Driver{
+		QueryFunc: func(ctx context.Context, query string) (driver.Rows, error) {
+			return testdriver.NewRows([]string{"COLUMN_NAME", "ORDINAL_POSITION", "DATA_TYPE", "COLUMN_TYPE", "IS_NULLABLE",
+				"COLUMN_DEFAULT", "CHARACTER_MAXIMUM_LENGTH", "NUMERIC_PRECISION", "NUMERIC_SCALE",
+				"COLUMN_KEY", "EXTRA", "GENERATION_EXPRESSION", "COLUMN_COMMENT"}, rows...), nil
+		},
+	})
+
+	var params []Params
+	db.Log = func(detail LogDetail) {
+		params = append(params, detail.Params)
+	}
+
+	rows = [][]driver.Value{
+		{[]byte("ID"), int64(1), []byte("int"), []byte("int unsigned"), int64(0), nil, nil, int64(10), int64(0), []byte("PRI"), []byte("auto_increment"), []byte(""), []byte("")},
+		{[]byte("Name"), int64(2), []byte("varchar"), []byte("varchar(255)"), int64(1), []byte("''"), int64(255), nil, nil, []byte(""), []byte(""), []byte(""), []byte("their name")},
+	}
+
+	columns, err := db.TableColumns(context.Background(), "`app`.`users`", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	def, length := "''", int64(255)
+	precision, scale := int64(10), int64(0)
+	want := []TableColumn{
+		{Name: "ID", Position: 1, DataType: "int", ColumnType: "int unsigned", NumericPrecision: &precision, NumericScale: &scale, Key: "PRI", Extra: "auto_increment"},
+		{Name: "Name", Position: 2, DataType: "varchar", ColumnType: "varchar(255)", Nullable: true, Default: &def, CharacterMaxLength: &length, Comment: "their name"},
+	}
+	if !reflect.DeepEqual(columns, want) {
+		t.Errorf("TableColumns() = %+v, want %+v", columns, want)
+	}
+	if len(params) != 1 || params[0]["schema"] != "app" || params[0]["table"] != "users" {
+		t.Errorf("TableColumns() params = %v, want the schema and table", params)
+	}
+
+	// without a schema, it's the connection's
+	params = nil
+	if _, err := db.TableColumns(context.Background(), "users", 0); err != nil {
+		t.Fatal(err)
+	}
+	if len(params) != 1 || params[0]["schema"] != nil {
+		t.Errorf("TableColumns() params = %v, want a null schema", params)
+	}
+
+	rows = nil
+	if _, err := db.TableColumns(context.Background(), "missing", 0); err == nil || !strings.Contains(err.Error(), `"missing"`) {
+		t.Errorf("TableColumns() of a missing table error = %v, want one naming it", err)
+	}
+}
@@ -0,0 +1,76 @@
+package mysql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ColumnValuer marshals the values of a column, see Database.AddColumnValuer
+type ColumnValuer func(v any) ([]byte, error)
+
+// AddColumnValuer overrides how the values of a table's column are written by inserts and upserts,
+// where the column is like "blogposts.Tags", which is the table's name, with or without its schema,
+// and the column's name as it is in the table and struct tags. The func's bytes are written as a string,
+// or null if they're nil, so a column can always be written as a JSON array, for example,
+// without changing its Go type or the valuer funcs of the type that other tables depend on.
+// The func is called with the value as it is in the row, before it's encrypted or converted
+// to its column's time zone, since the func replaces how the column's values are marshaled entirely.
+func (db *Database) AddColumnValuer(column string, fn func(v any) ([]byte, error)) {
+	i := strings.LastIndexByte(column, '.')
+	if i <= 0 || i == len(column)-1 {
+		panic(fmt.Errorf("invalid column valuer column: %q isn't like \"table.column\"", column))
+	}
+
+	if db.columnValuers == nil {
+		db.columnValuers = make(map[string]ColumnValuer)
+	}
+
+	db.columnValuers[strings.ToLower(column)] = fn
+}
+
+// columnValuersOf returns the column valuers of the columns of the table, which is unquoted,
+// by the columns' names, or nil if none of them have one
+func (db *Database) columnValuersOf(table string, columns []string) map[string]ColumnValuer {
+	if len(db.columnValuers) == 0 || len(table) == 0 {
+		return nil
+	}
+
+	table = strings.ToLower(table)
+	_, name := splitTableName(table)
+
+	var valuers map[string]ColumnValuer
+	for _, c := range columns {
+		lc := strings.ToLower(c)
+		fn, ok := db.columnValuers[table+"."+lc]
+		if !ok && name != table {
+			fn, ok = db.columnValuers[name+"."+lc]
+		}
+		if !ok {
+			continue
+		}
+
+		if valuers == nil {
+			valuers = make(map[string]ColumnValuer)
+		}
+		valuers[c] = fn
+	}
+
+	return valuers
+}
+
+// appendColumnValue appends the value of a column marshaled by its column valuer
+func appendColumnValue(dst []byte, fn ColumnValuer, column string, v any) ([]byte, error) {
+	b, err := fn(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal column %q: %w", column, err)
+	}
+
+	switch {
+	case b == nil:
+		return append(dst, "null"...), nil
+	case len(b) == 0:
+		return append(dst, "''"...), nil
+	default:
+		return appendHexString(dst, b), nil
+	}
+}
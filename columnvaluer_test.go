@@ -0,0 +1,86 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDatabase_AddColumnValuer(t *testing.T) {
+	db := benchDatabase(t)
+
+	var queries []string
+	db.Log = func(detail LogDetail) {
+		queries = append(queries, detail.Query)
+	}
+
+	// slices are written as JSON arrays by default, so the column's written as a list instead
+	db.AddColumnValuer("blogposts.Tags", func(v any) ([]byte, error) {
+		return []byte(strings.Join(v.([]string), ",")), nil
+	})
+
+	type post struct {
+		ID   int
+		Tags []string
+	}
+
+	tagsList := fmt.Sprintf("0x%x", "a,b")
+	tagsJSON := fmt.Sprintf("0x%x", `["a","b"]`)
+
+	tests := []struct {
+		name    string
+		run     func() error
+		want    []string
+		notWant string
+	}{
+		{
+			name: "insert",
+			run: func() error {
+				return db.Insert("blogposts", post{ID: 1, Tags: []string{"a", "b"}})
+			},
+			want: []string{tagsList},
+		},
+		{
+			name: "schema qualified map row",
+			run: func() error {
+				return db.Insert("`blog`.`BlogPosts`", map[string]any{"ID": 1, "tags": []string{"a", "b"}})
+			},
+			want: []string{tagsList},
+		},
+		{
+			name: "other table",
+			run: func() error {
+				return db.Insert("pages", post{ID: 1, Tags: []string{"a", "b"}})
+			},
+			want:    []string{tagsJSON},
+			notWant: tagsList,
+		},
+		{
+			name: "upsert",
+			run: func() error {
+				return db.UpsertContext(context.Background(), "insert into`blogposts`", []string{"ID"}, []string{"Tags"},
+					"", nil, post{ID: 1, Tags: []string{"a", "b"}})
+			},
+			want: []string{"`Tags`=_utf8mb4 " + tagsList},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries = nil
+			if err := tt.run(); err != nil {
+				t.Fatal(err)
+			}
+
+			q := strings.Join(queries, ";")
+			for _, w := range tt.want {
+				if !strings.Contains(q, w) {
+					t.Errorf("ran %q, want it to contain %q", q, w)
+				}
+			}
+			if len(tt.notWant) != 0 && strings.Contains(q, tt.notWant) {
+				t.Errorf("ran %q, want it not to contain %q", q, tt.notWant)
+			}
+		})
+	}
+}
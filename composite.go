@@ -0,0 +1,201 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+
+	"github.com/fatih/structtag"
+)
+
+// compositeTable is a field of a composite struct whose rows are inserted into their own table
+type compositeTable struct {
+	index []int
+	table string
+
+	// fk is the column of the rows that's set to the parent's generated id
+	fk string
+
+	// id is the column of the parent that's set to its generated id
+	id string
+}
+
+// compositeTables returns the fields of the struct tagged `table`, the first of which is the parent
+func compositeTables(t reflect.Type) ([]compositeTable, error) {
+	var tables []compositeTable
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		tags, _ := structtag.Parse(string(f.Tag))
+		if tags == nil {
+			continue
+		}
+		tag, _ := tags.Get("mysql")
+		if _, ok := tagOption(tag, "table"); !ok {
+			continue
+		}
+
+		ct := compositeTable{index: f.Index, table: tag.Name}
+		if len(ct.table) == 0 {
+			ct.table = f.Name
+		}
+		ct.fk, _ = tagOption(tag, "fk")
+		ct.id, _ = tagOption(tag, "id")
+
+		if len(tables) == 0 {
+			if len(ct.fk) != 0 {
+				return nil, fmt.Errorf("cool-mysql: parent table %q of composite %s can't have a foreign key", ct.table, t)
+			}
+			if reflectUnwrapType(f.Type).Kind() != reflect.Struct {
+				return nil, fmt.Errorf("cool-mysql: parent table %q of composite %s must be a single struct, got %s", ct.table, t, f.Type)
+			}
+		}
+
+		tables = append(tables, ct)
+	}
+
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("cool-mysql: composite %s has no fields tagged `table`", t)
+	}
+
+	return tables, nil
+}
+
+// InsertComposite inserts the fields of the struct tagged `table` into their tables, in one transaction, like
+//
+//	type OrderWithLines struct {
+//		Order Order       `mysql:"orders,table,id=ID"`
+//		Lines []OrderLine `mysql:"order_lines,table,fk=OrderID"`
+//	}
+//
+// The first table is the parent, and is inserted first. Its generated id is set to its `id` column, and to
+// the `fk` column of the rows of the other tables before they're inserted. If source is a pointer, the ids are
+// set in its rows too.
+func (db *Database) InsertComposite(ctx context.Context, source any) error {
+	tx, cancel, err := db.BeginTxContext(ctx)
+	defer cancel()
+	if err != nil {
+		return fmt.Errorf("failed to begin tx: %w", err)
+	}
+
+	if err := tx.InsertComposite(ctx, source); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// InsertComposite inserts the fields of the struct tagged `table` into their tables, see Database.InsertComposite
+func (tx *Tx) InsertComposite(ctx context.Context, source any) error {
+	v := reflectUnwrap(reflect.ValueOf(source))
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("cool-mysql: composite must be a struct, got %T", source)
+	}
+
+	tables, err := compositeTables(v.Type())
+	if err != nil {
+		return err
+	}
+
+	// the ids are set in a copy if they can't be set in the source itself
+	if !v.CanAddr() {
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		v = c
+	}
+
+	var parentID int64
+	for i, ct := range tables {
+		rows := v.FieldByIndex(ct.index)
+		if i != 0 && len(ct.fk) != 0 && parentID != 0 {
+			if err := setGeneratedID(rows, ct.fk, parentID); err != nil {
+				return fmt.Errorf("failed to set foreign key of table %q: %w", ct.table, err)
+			}
+		}
+
+		// nil and empty tables are skipped
+		switch r := reflectUnwrap(rows); r.Kind() {
+		case reflect.Invalid, reflect.Pointer, reflect.Interface:
+			continue
+		case reflect.Slice, reflect.Array:
+			if r.Len() == 0 {
+				continue
+			}
+		}
+
+		var res sql.Result
+		err := tx.I().SetResultHandler(func(r sql.Result) {
+			res = r
+		}).InsertContext(ctx, ct.table, rows.Interface())
+		if err != nil {
+			return fmt.Errorf("failed to insert into table %q: %w", ct.table, err)
+		}
+
+		if i == 0 && res != nil {
+			if parentID, err = res.LastInsertId(); err != nil {
+				return fmt.Errorf("failed to get generated id of table %q: %w", ct.table, err)
+			}
+
+			if len(ct.id) != 0 && parentID != 0 {
+				if err := setGeneratedID(rows, ct.id, parentID); err != nil {
+					return fmt.Errorf("failed to set id of table %q: %w", ct.table, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// setGeneratedID sets the column of the struct, or of every struct in the slice, to the id
+func setGeneratedID(v reflect.Value, column string, id int64) error {
+	v = reflectUnwrap(v)
+
+	switch v.Kind() {
+	case reflect.Invalid, reflect.Pointer, reflect.Interface:
+		// nil rows don't have any columns to set
+		return nil
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := setGeneratedID(v.Index(i), column, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+	default:
+		return fmt.Errorf("cool-mysql: rows must be structs, got %s", v.Type())
+	}
+
+	_, colOpts, _, err := colNamesFromStruct(v.Type())
+	if err != nil {
+		return err
+	}
+	opts, ok := colOpts[column]
+	if !ok {
+		return fmt.Errorf("cool-mysql: %s has no column %q", v.Type(), column)
+	}
+
+	f := v.FieldByIndex(opts.index)
+	if f.Kind() == reflect.Pointer {
+		if f.IsNil() {
+			f.Set(reflect.New(f.Type().Elem()))
+		}
+		f = f.Elem()
+	}
+
+	switch f.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f.SetInt(id)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f.SetUint(uint64(id))
+	default:
+		return fmt.Errorf("cool-mysql: column %q of %s must be an integer for a generated id, got %s", column, v.Type(), f.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_compositeTables(t *testing.T) {
+	type order struct {
+		ID    int `mysql:"ID,readonly"`
+		Total int
+	}
+	type orderLine struct {
+		OrderID *uint64
+		SKU     string
+	}
+
+	type orderWithLines struct {
+		Order order        `mysql:"orders,table,id=ID"`
+		Lines []*orderLine `mysql:"order_lines,table,fk=OrderID"`
+		Note  string
+	}
+
+	tables, err := compositeTables(reflect.TypeOf(orderWithLines{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []compositeTable{
+		{index: []int{0}, table: "orders", id: "ID"},
+		{index: []int{1}, table: "order_lines", fk: "OrderID"},
+	}
+	if !reflect.DeepEqual(tables, want) {
+		t.Errorf("compositeTables() = %+v, want %+v", tables, want)
+	}
+
+	c := orderWithLines{Lines: []*orderLine{{SKU: "a"}, nil, {SKU: "b"}}}
+	v := reflect.ValueOf(&c).Elem()
+	if err := setGeneratedID(v.Field(0), "ID", 7); err != nil {
+		t.Fatal(err)
+	}
+	if err := setGeneratedID(v.Field(1), "OrderID", 7); err != nil {
+		t.Fatal(err)
+	}
+	if c.Order.ID != 7 || *c.Lines[0].OrderID != 7 || *c.Lines[2].OrderID != 7 {
+		t.Errorf("setGeneratedID() didn't set the ids of %+v", c)
+	}
+
+	if err := setGeneratedID(v.Field(1), "SKU", 7); err == nil {
+		t.Error("setGeneratedID() of a string column should fail")
+	}
+
+	type childFirst struct {
+		Lines []orderLine `mysql:"order_lines,table,fk=OrderID"`
+	}
+	if _, err := compositeTables(reflect.TypeOf(childFirst{})); err == nil {
+		t.Error("compositeTables() with a foreign key on the parent should fail")
+	}
+}
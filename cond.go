@@ -0,0 +1,208 @@
+package mysql
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// Cond is a condition of a where clause, composed with And, Or, and Not from conditions
+// like Eq and In, instead of templates, like
+//
+//	where, params := mysql.And(mysql.Eq("Status", s), mysql.In("ID", ids), mysql.Like("Name", q)).Build()
+//	err := db.Select(&rows, "select*from`users`where "+where, 0, params)
+//
+// Nil conditions are skipped by And and Or, so optional filters can be left nil.
+type Cond interface {
+	// Build returns the condition's query, with its values as `@@` params, and the params
+	Build() (string, Params)
+
+	appendCond(b *condBuilder)
+}
+
+// condBuilder builds a condition's query, naming each value's param by its position,
+// so the params of composed conditions never collide
+type condBuilder struct {
+	buf    []byte
+	params Params
+}
+
+func (b *condBuilder) column(column string) {
+	b.buf = append(b.buf, quoteIdentifier(column)...)
+}
+
+func (b *condBuilder) param(v any) {
+	name := "__Cond" + strconv.Itoa(len(b.params))
+	b.params[name] = v
+	b.buf = append(append(b.buf, "@@"...), name...)
+}
+
+func buildCond(c Cond) (string, Params) {
+	b := &condBuilder{params: make(Params)}
+	c.appendCond(b)
+	return string(b.buf), b.params
+}
+
+type compareCond struct {
+	column string
+	op     string
+	v      any
+}
+
+func (c compareCond) Build() (string, Params) { return buildCond(c) }
+
+func (c compareCond) appendCond(b *condBuilder) {
+	b.column(c.column)
+
+	// nothing is ever equal to null, so these are null checks instead
+	if isNil(c.v) {
+		switch c.op {
+		case "=":
+			b.buf = append(b.buf, "is null"...)
+			return
+		case "!=":
+			b.buf = append(b.buf, "is not null"...)
+			return
+		}
+	}
+
+	b.buf = append(b.buf, c.op...)
+	b.param(c.v)
+}
+
+// Eq is the condition that the column equals the value, or is null if the value is nil
+func Eq(column string, v any) Cond { return compareCond{column, "=", v} }
+
+// Ne is the condition that the column doesn't equal the value, or isn't null if the value is nil
+func Ne(column string, v any) Cond { return compareCond{column, "!=", v} }
+
+// Gt is the condition that the column is greater than the value
+func Gt(column string, v any) Cond { return compareCond{column, ">", v} }
+
+// Gte is the condition that the column is greater than or equal to the value
+func Gte(column string, v any) Cond { return compareCond{column, ">=", v} }
+
+// Lt is the condition that the column is less than the value
+func Lt(column string, v any) Cond { return compareCond{column, "<", v} }
+
+// Lte is the condition that the column is less than or equal to the value
+func Lte(column string, v any) Cond { return compareCond{column, "<=", v} }
+
+// Like is the condition that the column matches the like pattern
+func Like(column string, pattern string) Cond { return compareCond{column, " like ", pattern} }
+
+type inCond struct {
+	column string
+	values any
+	not    bool
+}
+
+func (c inCond) Build() (string, Params) { return buildCond(c) }
+
+func (c inCond) appendCond(b *condBuilder) {
+	// `in()` isn't valid, so no values are just true or false
+	if v := reflect.ValueOf(c.values); isNil(c.values) || (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Len() == 0 {
+		if c.not {
+			b.buf = append(b.buf, "true"...)
+		} else {
+			b.buf = append(b.buf, "false"...)
+		}
+		return
+	}
+
+	b.column(c.column)
+	if c.not {
+		b.buf = append(b.buf, "not in("...)
+	} else {
+		b.buf = append(b.buf, "in("...)
+	}
+	b.param(c.values)
+	b.buf = append(b.buf, ')')
+}
+
+// In is the condition that the column is one of the values, a slice.
+// It's false if there aren't any values.
+func In(column string, values any) Cond { return inCond{column, values, false} }
+
+// NotIn is the condition that the column isn't any of the values, a slice.
+// It's true if there aren't any values.
+func NotIn(column string, values any) Cond { return inCond{column, values, true} }
+
+type nullCond struct {
+	column string
+	not    bool
+}
+
+func (c nullCond) Build() (string, Params) { return buildCond(c) }
+
+func (c nullCond) appendCond(b *condBuilder) {
+	b.column(c.column)
+	if c.not {
+		b.buf = append(b.buf, "is not null"...)
+	} else {
+		b.buf = append(b.buf, "is null"...)
+	}
+}
+
+// IsNull is the condition that the column is null
+func IsNull(column string) Cond { return nullCond{column, false} }
+
+// IsNotNull is the condition that the column isn't null
+func IsNotNull(column string) Cond { return nullCond{column, true} }
+
+type logicCond struct {
+	op    string
+	conds []Cond
+}
+
+func (c logicCond) Build() (string, Params) { return buildCond(c) }
+
+func (c logicCond) appendCond(b *condBuilder) {
+	n := 0
+	for _, cond := range c.conds {
+		if cond == nil {
+			continue
+		}
+
+		if n != 0 {
+			b.buf = append(b.buf, c.op...)
+		}
+		b.buf = append(b.buf, '(')
+		cond.appendCond(b)
+		b.buf = append(b.buf, ')')
+		n++
+	}
+
+	// no conditions are the identity of the operator, so they can still be composed
+	if n == 0 {
+		if c.op == "and" {
+			b.buf = append(b.buf, "true"...)
+		} else {
+			b.buf = append(b.buf, "false"...)
+		}
+	}
+}
+
+// And is the condition that all of the conditions are true, which is true if there aren't any
+func And(conds ...Cond) Cond { return logicCond{"and", conds} }
+
+// Or is the condition that any of the conditions are true, which is false if there aren't any
+func Or(conds ...Cond) Cond { return logicCond{"or", conds} }
+
+type notCond struct {
+	cond Cond
+}
+
+func (c notCond) Build() (string, Params) { return buildCond(c) }
+
+func (c notCond) appendCond(b *condBuilder) {
+	b.buf = append(b.buf, "not("...)
+	if c.cond != nil {
+		c.cond.appendCond(b)
+	} else {
+		b.buf = append(b.buf, "true"...)
+	}
+	b.buf = append(b.buf, ')')
+}
+
+// Not is the condition that the condition is false
+func Not(cond Cond) Cond { return notCond{cond} }
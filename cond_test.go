@@ -0,0 +1,62 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCond_Build(t *testing.T) {
+	var nilStatus *int
+
+	tests := []struct {
+		name       string
+		cond       Cond
+		want       string
+		wantParams Params
+	}{
+		{
+			name:       "and",
+			cond:       And(Eq("Status", 1), In("u.ID", []int{1, 2}), nil, Like("Name", "a%")),
+			want:       "(`Status`=@@__Cond0)and(`u`.`ID`in(@@__Cond1))and(`Name` like @@__Cond2)",
+			wantParams: Params{"__Cond0": 1, "__Cond1": []int{1, 2}, "__Cond2": "a%"},
+		},
+		{
+			name:       "nested",
+			cond:       Or(Not(Gte("Age", 18)), And(IsNull("DeletedAt"), Ne("Status", nilStatus))),
+			want:       "(not(`Age`>=@@__Cond0))or((`DeletedAt`is null)and(`Status`is not null))",
+			wantParams: Params{"__Cond0": 18},
+		},
+		{
+			name:       "empty in",
+			cond:       And(In("ID", []int{}), NotIn("ID", nil)),
+			want:       "(false)and(true)",
+			wantParams: Params{},
+		},
+		{
+			name:       "no conditions",
+			cond:       Or(And(), nil),
+			want:       "(true)",
+			wantParams: Params{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, params := tt.cond.Build()
+			if got != tt.want {
+				t.Errorf("Build() = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(params, tt.wantParams) {
+				t.Errorf("Build() params = %v, want %v", params, tt.wantParams)
+			}
+		})
+	}
+
+	where, params := And(Eq("ID", 1), In("Status", []int{2, 3})).Build()
+	got, _, err := InterpolateParams("select*from`users`where "+where, nil, nil, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "select*from`users`where (`ID`=1)and(`Status`in(2,3))"; got != want {
+		t.Errorf("InterpolateParams() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,81 @@
+package mysql
+
+import (
+	"fmt"
+	"time"
+)
+
+// Config are the policies of a database that can be changed while it's in use,
+// like to take pressure off of the database during an incident without redeploying
+type Config struct {
+	// CacheTTLMultiplier scales how long the results of selects and exists checks are cached, like 4 to cache
+	// them 4 times as long. Cache keys don't change with it, so results that are already
+	// cached are still used. 0 means 1.
+	CacheTTLMultiplier float64
+
+	// MaxExecutionTime is how long failed queries keep being retried,
+	// where 0 means the package's MaxExecutionTime
+	MaxExecutionTime time.Duration
+
+	// SlowQueryThreshold logs the queries that take at least this long as warnings,
+	// where 0 means they aren't
+	SlowQueryThreshold time.Duration
+}
+
+// ApplyConfig replaces the database's config, which is safe to do while queries are running,
+// and applies to every clone of the database
+func (db *Database) ApplyConfig(config Config) error {
+	if config.CacheTTLMultiplier < 0 {
+		return fmt.Errorf("cool-mysql: cache ttl multiplier can't be negative, got %v", config.CacheTTLMultiplier)
+	}
+	if config.MaxExecutionTime < 0 {
+		return fmt.Errorf("cool-mysql: max execution time can't be negative, got %v", config.MaxExecutionTime)
+	}
+
+	if db.config == nil {
+		db.config = new(synct[Config])
+	}
+	db.config.Set(config)
+
+	return nil
+}
+
+// Config returns the database's current config
+func (db *Database) Config() Config {
+	if db.config == nil {
+		return Config{}
+	}
+
+	return db.config.Get()
+}
+
+// maxExecutionTime returns how long failed queries keep being retried
+func (db *Database) maxExecutionTime() time.Duration {
+	if d := db.Config().MaxExecutionTime; d > 0 {
+		return d
+	}
+
+	return MaxExecutionTime
+}
+
+// cacheTTL returns how long a result selected with the cache duration is cached
+func (db *Database) cacheTTL(cacheDuration time.Duration) time.Duration {
+	if m := db.Config().CacheTTLMultiplier; m > 0 {
+		cacheDuration = time.Duration(float64(cacheDuration) * m)
+	}
+	if db.cacheTTLMultiplier > 0 {
+		cacheDuration = time.Duration(float64(cacheDuration) * db.cacheTTLMultiplier)
+	}
+
+	return cacheDuration
+}
+
+// logSlowQuery logs the query as a warning if it's over the slow query threshold
+func (db *Database) logSlowQuery(detail LogDetail) {
+	threshold := db.Config().SlowQueryThreshold
+	if threshold <= 0 || detail.CacheHit || detail.Duration < threshold || db.Logger == nil {
+		return
+	}
+
+	db.Logger.Warn(fmt.Sprintf("slow query took %s: %s", detail.Duration, detail.Query))
+}
@@ -0,0 +1,34 @@
+package mysql
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDatabase_ApplyConfig(t *testing.T) {
+	db := new(Database)
+
+	if got := db.cacheTTL(time.Minute); got != time.Minute {
+		t.Errorf("cacheTTL() without a config = %s, want 1m0s", got)
+	}
+	if got := db.maxExecutionTime(); got != MaxExecutionTime {
+		t.Errorf("maxExecutionTime() without a config = %s, want %s", got, MaxExecutionTime)
+	}
+
+	if err := db.ApplyConfig(Config{CacheTTLMultiplier: 2.5, MaxExecutionTime: 5 * time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	if got := db.cacheTTL(time.Minute); got != 150*time.Second {
+		t.Errorf("cacheTTL() = %s, want 2m30s", got)
+	}
+	if got := db.Clone().maxExecutionTime(); got != 5*time.Second {
+		t.Errorf("maxExecutionTime() of a clone = %s, want 5s", got)
+	}
+
+	if err := db.ApplyConfig(Config{CacheTTLMultiplier: -1}); err == nil {
+		t.Error("ApplyConfig() with a negative multiplier should fail")
+	}
+	if got := db.Config().CacheTTLMultiplier; got != 2.5 {
+		t.Errorf("failed ApplyConfig() changed the multiplier to %v", got)
+	}
+}
@@ -0,0 +1,136 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Conn is a single connection checked out of the writes pool, for work that needs the same
+// session across queries, like temporary tables, user variables, get_lock, load data,
+// and last_insert_id, which could each run on a different connection of the pool otherwise.
+// It has to be closed to return the connection to the pool.
+type Conn struct {
+	db *Database
+
+	Conn *sql.Conn
+}
+
+// Conn checks out a connection from the writes pool, see Conn
+func (db *Database) Conn(ctx context.Context) (*Conn, error) {
+	conn, err := db.Writes.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	return &Conn{db: db, Conn: conn}, nil
+}
+
+// Close returns the connection to the pool, and the session state goes with it,
+// unless it's cleared first
+func (c *Conn) Close() error {
+	return c.Conn.Close()
+}
+
+func (c *Conn) DefaultInsertOptions() *Inserter {
+	return &Inserter{
+		db:   c.db,
+		conn: c.Conn,
+	}
+}
+
+func (c *Conn) I() *Inserter {
+	return c.DefaultInsertOptions()
+}
+
+func (c *Conn) Insert(insert string, source any) error {
+	return c.I().Insert(insert, source)
+}
+
+func (c *Conn) InsertContext(ctx context.Context, insert string, source any) error {
+	return c.I().InsertContext(ctx, insert, source)
+}
+
+func (c *Conn) Upsert(insert string, uniqueColumns, updateColumns []string, where string, whereParams Params, source any) error {
+	return c.I().Upsert(insert, uniqueColumns, updateColumns, where, whereParams, source)
+}
+
+func (c *Conn) UpsertContext(ctx context.Context, insert string, uniqueColumns, updateColumns []string, where string, whereParams Params, source any) error {
+	return c.I().UpsertContext(ctx, insert, uniqueColumns, updateColumns, where, whereParams, source)
+}
+
+// ExecContextResult executes a query and nothing more
+func (c *Conn) ExecContextResult(ctx context.Context, query string, params ...any) (sql.Result, error) {
+	return c.db.exec(c.Conn, ctx, nil, true, query, params...)
+}
+
+// ExecContext executes a query and nothing more
+func (c *Conn) ExecContext(ctx context.Context, query string, params ...any) error {
+	_, err := c.ExecContextResult(ctx, query, params...)
+	return err
+}
+
+// ExecResult executes a query and nothing more
+func (c *Conn) ExecResult(query string, params ...any) (sql.Result, error) {
+	return c.ExecContextResult(context.Background(), query, params...)
+}
+
+// Exec executes a query and nothing more
+func (c *Conn) Exec(query string, params ...any) error {
+	_, err := c.ExecContextResult(context.Background(), query, params...)
+	return err
+}
+
+func (c *Conn) Select(dest any, q string, cache time.Duration, params ...any) error {
+	return c.db.query(c.Conn, context.Background(), dest, q, cache, params...)
+}
+
+func (c *Conn) SelectRows(q string, cache time.Duration, params ...any) (Rows, error) {
+	var rows Rows
+	err := c.db.query(c.Conn, context.Background(), &rows, q, cache, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+func (c *Conn) SelectContext(ctx context.Context, dest any, q string, cache time.Duration, params ...any) error {
+	return c.db.query(c.Conn, ctx, dest, q, cache, params...)
+}
+
+func (c *Conn) SelectJSON(dest any, query string, cache time.Duration, params ...any) error {
+	return c.SelectJSONContext(context.Background(), dest, query, cache, params...)
+}
+
+func (c *Conn) SelectJSONContext(ctx context.Context, dest any, query string, cache time.Duration, params ...any) error {
+	var j []byte
+	err := c.SelectContext(ctx, &j, query, cache, params...)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(j, dest)
+}
+
+// Exists efficiently checks if there are any rows in the given query on the connection
+func (c *Conn) Exists(query string, cache time.Duration, params ...any) (bool, error) {
+	return c.db.exists(c.Conn, context.Background(), query, cache, params...)
+}
+
+// ExistsContext efficiently checks if there are any rows in the given query on the connection
+func (c *Conn) ExistsContext(ctx context.Context, query string, cache time.Duration, params ...any) (bool, error) {
+	return c.db.exists(c.Conn, ctx, query, cache, params...)
+}
+
+// Count efficiently checks the number of rows a query returns on the connection
+func (c *Conn) Count(query string, cache time.Duration, params ...any) (int, error) {
+	return c.db.count(c.Conn, context.Background(), query, cache, params...)
+}
+
+// CountContext efficiently checks the number of rows a query returns on the connection
+func (c *Conn) CountContext(ctx context.Context, query string, cache time.Duration, params ...any) (int, error) {
+	return c.db.count(c.Conn, ctx, query, cache, params...)
+}
@@ -0,0 +1,40 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDatabase_Conn(t *testing.T) {
+	db := benchDatabase(t)
+
+	// with one connection, anything that isn't run on the checked out
+	// connection would wait for it until the context times out
+	db.Writes.SetMaxOpenConns(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.ExecContext(ctx, "create temporary table`ids`(`ID`int)"); err != nil {
+		t.Errorf("ExecContext() failed: %v", err)
+	}
+	if err := c.InsertContext(ctx, "ids", []struct{ ID int }{{1}, {2}}); err != nil {
+		t.Errorf("InsertContext() failed: %v", err)
+	}
+	var rows []benchWideRow
+	if err := c.SelectContext(ctx, &rows, "select*from`ids`", 0); err != nil || len(rows) != 1000 {
+		t.Errorf("SelectContext() = %d rows, %v, want 1000 rows", len(rows), err)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.ExecContext(ctx, "select 1"); err != nil {
+		t.Errorf("ExecContext() after the connection was returned failed: %v", err)
+	}
+}
@@ -92,6 +92,8 @@ func GetOrCreateTxFromContext(ctx context.Context) (tx *Tx, commit, cancel func(
 	return tx, func() error { return nil }, func() error { return nil }, nil
 }
 
+// TxOrDatabaseFromContext returns the context's *Tx, or its *Database if it has no transaction,
+// for code that should run its queries in the ambient transaction whenever there is one
 func TxOrDatabaseFromContext(ctx context.Context) Handler {
 	if tx := TxFromContext(ctx); tx != nil {
 		return tx
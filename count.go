@@ -1,47 +1,57 @@
 package mysql
 
 import (
-	"fmt"
-	"os"
+	"context"
 	"time"
 )
 
+// Integer is any integer type, for Count
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
 // Count efficiently checks the number of rows a query returns
 func (db *Database) Count(query string, cache time.Duration, params ...any) (int, error) {
-	replacedQuery, normalizedParams, err := db.InterpolateParams(query, params...)
-	if err != nil {
-		return 0, fmt.Errorf("failed to interpolate params: %w", err)
-	}
+	return db.count(db.Reads, context.Background(), query, cache, params...)
+}
 
-	if db.die {
-		fmt.Println(replacedQuery)
-		os.Exit(0)
-	}
+// CountContext efficiently checks the number of rows a query returns
+func (db *Database) CountContext(ctx context.Context, query string, cache time.Duration, params ...any) (int, error) {
+	return db.count(db.Reads, ctx, query, cache, params...)
+}
 
-	start := time.Now()
-	rows, err := db.Reads.Query(replacedQuery)
-	db.callLog(LogDetail{
-		Query:    replacedQuery,
-		Params:   normalizedParams,
-		Duration: time.Since(start),
-		Attempt:  1,
-		Error:    err,
-	})
-
-	if err != nil {
-		return 0, Error{
-			Err:           err,
-			OriginalQuery: query,
-			ReplacedQuery: replacedQuery,
-			Params:        normalizedParams,
-		}
-	}
+// CountWrites efficiently checks the number of rows a query returns using the `Writes` connection
+func (db *Database) CountWrites(query string, cache time.Duration, params ...any) (int, error) {
+	return db.count(db.Writes, context.Background(), query, cache, params...)
+}
 
-	defer rows.Close()
+// CountWritesContext efficiently checks the number of rows a query returns using the `Writes` connection
+func (db *Database) CountWritesContext(ctx context.Context, query string, cache time.Duration, params ...any) (int, error) {
+	return db.count(db.Writes, ctx, query, cache, params...)
+}
+
+// Count efficiently checks the number of rows a query returns in the transaction
+func (tx *Tx) Count(query string, cache time.Duration, params ...any) (int, error) {
+	return tx.db.count(tx.Tx, context.Background(), query, cache, params...)
+}
+
+// CountContext efficiently checks the number of rows a query returns in the transaction
+func (tx *Tx) CountContext(ctx context.Context, query string, cache time.Duration, params ...any) (int, error) {
+	return tx.db.count(tx.Tx, ctx, query, cache, params...)
+}
+
+// Count checks the number of rows a query returns with a *Database or *Tx, as T
+func Count[T Integer](ctx context.Context, q Querier, query string, cache time.Duration, params ...any) (T, error) {
+	count, err := q.CountContext(ctx, query, cache, params...)
+	return T(count), err
+}
 
+// count checks the number of rows a query returns on the connection. Its rows are selected like any
+// other select's, through the middleware, with retries and the context's tenant, and cached for cache.
+func (db *Database) count(conn handlerWithContext, ctx context.Context, query string, cache time.Duration, params ...any) (int, error) {
 	count := 0
-	for rows.Next() {
-		count++
+	if err := db.query(conn, ctx, func(struct{}) { count++ }, query, cache, params...); err != nil {
+		return 0, err
 	}
 
 	return count, nil
@@ -0,0 +1,168 @@
+package mysql
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// CSVOptions configure how rows are written by SelectCSV
+type CSVOptions struct {
+	// Comma is the field delimiter, like '\t' for TSV
+	Comma rune
+
+	// Null is written for null values
+	Null string
+
+	// TimeFormat is the layout of times
+	TimeFormat string
+
+	// NoHeader leaves out the header row of column names
+	NoHeader bool
+}
+
+// DefaultCSVOptions are the options used by SelectCSV
+var DefaultCSVOptions = CSVOptions{
+	Comma:      ',',
+	Null:       "",
+	TimeFormat: "2006-01-02 15:04:05.999999",
+}
+
+// SelectCSV streams the rows of the query to w as CSV, with a header row of the column names
+func (db *Database) SelectCSV(w io.Writer, q string, params ...any) error {
+	return db.SelectCSVContext(context.Background(), w, DefaultCSVOptions, q, params...)
+}
+
+// SelectCSVContext streams the rows of the query to w as CSV with the given options,
+// one row at a time, so the whole result is never held in memory
+func (db *Database) SelectCSVContext(ctx context.Context, w io.Writer, opts CSVOptions, q string, params ...any) error {
+	return db.selectCSV(db.Reads, ctx, w, opts, q, params...)
+}
+
+// SelectCSVContext streams the rows of the query to w as CSV with the given options
+func (tx *Tx) SelectCSVContext(ctx context.Context, w io.Writer, opts CSVOptions, q string, params ...any) error {
+	return tx.db.selectCSV(tx.Tx, ctx, w, opts, q, params...)
+}
+
+func (db *Database) selectCSV(conn handlerWithContext, ctx context.Context, w io.Writer, opts CSVOptions, query string, params ...any) error {
+	params = tenantParams(ctx, params)
+
+	replacedQuery, normalizedParams, err := db.interpolateParams(ctx, query, params...)
+	if err != nil {
+		return fmt.Errorf("failed to interpolate params: %w", err)
+	}
+
+	if db.die {
+		fmt.Println(replacedQuery)
+		os.Exit(0)
+	}
+
+	if err := db.checkReadOnly(replacedQuery); err != nil {
+		return err
+	}
+
+	if err := db.checkStatementPolicy(replacedQuery); err != nil {
+		return err
+	}
+
+	wrapErr := func(err error) error {
+		return Error{
+			Err:           err,
+			OriginalQuery: query,
+			ReplacedQuery: replacedQuery,
+			Params:        normalizedParams,
+		}
+	}
+
+	start := time.Now()
+	rows, err := conn.QueryContext(ctx, db.commented(ctx, replacedQuery))
+	db.callLog(LogDetail{
+		Query:    replacedQuery,
+		Params:   normalizedParams,
+		Duration: time.Since(start),
+		Attempt:  1,
+		Error:    err,
+	})
+	if err != nil {
+		return wrapErr(err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return wrapErr(err)
+	}
+
+	cw := csv.NewWriter(w)
+	if opts.Comma != 0 {
+		cw.Comma = opts.Comma
+	}
+
+	if !opts.NoHeader {
+		if err := cw.Write(columns); err != nil {
+			return fmt.Errorf("failed to write csv header: %w", err)
+		}
+	}
+
+	row := make(SliceRow, len(columns))
+	ptrs := make([]any, len(columns))
+	for i := range row {
+		ptrs[i] = &row[i]
+	}
+	record := make([]string, len(columns))
+
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return wrapErr(err)
+		}
+
+		for i, v := range row {
+			record[i] = csvValue(v, opts)
+		}
+
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("failed to write csv row: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return wrapErr(err)
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write csv: %w", err)
+	}
+
+	return nil
+}
+
+// csvValue formats a value scanned from a dynamic row
+func csvValue(v any, opts CSVOptions) string {
+	switch v := v.(type) {
+	case nil:
+		return opts.Null
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case time.Time:
+		return v.Format(opts.TimeFormat)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	default:
+		return fmt.Sprint(v)
+	}
+}
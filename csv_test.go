@@ -0,0 +1,79 @@
+package mysql
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/StirlingMarketingGroup/cool-mysql/internal/testdriver"
+)
+
+func Test_csvValue(t *testing.T) {
+	opts := CSVOptions{Null: `\N`, TimeFormat: DefaultCSVOptions.TimeFormat}
+
+	tests := []struct {
+		name string
+		v    any
+		want string
+	}{
+		{"null", nil, `\N`},
+		{"bytes", []byte("a,b"), "a,b"},
+		{"string", "a", "a"},
+		{"time", time.Date(2024, 2, 3, 4, 5, 6, 7000, time.UTC), "2024-02-03 04:05:06.000007"},
+		{"whole second time", time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC), "2024-02-03 04:05:06"},
+		{"int", int64(-5), "-5"},
+		{"float", 1.5, "1.5"},
+		{"float32", float32(0.1), "0.1"},
+		{"true", true, "1"},
+		{"false", false, "0"},
+		{"other", uint64(7), "7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := csvValue(tt.v, opts); got != tt.want {
+				t.Errorf("csvValue() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDatabase_SelectCSVContext(t *testing.T) {
+	db := testDatabase(t, &testdriver.Driver{
+		QueryFunc: func(ctx context.Context, query string) (driver.Rows, error) {
+			return testdriver.NewRows([]string{"Name", "Note", "Deleted"},
+				[]driver.Value{[]byte("Ann"), []byte(`says "hi", twice`), nil},
+				[]driver.Value{[]byte("Bob"), []byte("two\nlines"), int64(1)},
+			), nil
+		},
+	})
+
+	tests := []struct {
+		name string
+		opts CSVOptions
+		want string
+	}{
+		{
+			name: "default",
+			opts: DefaultCSVOptions,
+			want: "Name,Note,Deleted\nAnn,\"says \"\"hi\"\", twice\",\nBob,\"two\nlines\",1\n",
+		},
+		{
+			name: "tsv without header",
+			opts: CSVOptions{Comma: '\t', Null: "NULL", NoHeader: true},
+			want: "Ann\t\"says \"\"hi\"\", twice\"\tNULL\nBob\t\"two\nlines\"\t1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := db.SelectCSVContext(context.Background(), &b, tt.opts, "select*from`notes`"); err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("SelectCSVContext() wrote %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
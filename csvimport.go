@@ -0,0 +1,179 @@
+package mysql
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"golang.org/x/sync/errgroup"
+)
+
+// RecordReader reads records of string fields, where the first record is the header.
+// csv.Reader is a RecordReader, and readers of other formats, like Parquet,
+// can be imported by adapting them to it.
+type RecordReader interface {
+	Read() (record []string, err error)
+}
+
+// InsertFromCSV streams the CSV rows from r into the table through the chunked insert,
+// where the first row is the header. Mapping maps header names to column names,
+// and if it's nil the header names are used as the column names.
+// Values are converted to the types of the table's columns, see InsertFromRecords.
+func (in *Inserter) InsertFromCSV(ctx context.Context, table string, r io.Reader, mapping map[string]string) error {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+
+	return in.InsertFromRecords(ctx, table, cr, mapping)
+}
+
+// InsertFromRecords streams the records into the table through the chunked insert, like InsertFromCSV.
+// Values are checked against the types of the table's columns, introspected from information_schema,
+// so a bad value fails with its record number instead of being truncated by MySQL. Empty values
+// are null in nullable columns, or the column's default in columns with one.
+func (in *Inserter) InsertFromRecords(ctx context.Context, table string, rr RecordReader, mapping map[string]string) error {
+	header, err := rr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read header: %w", err)
+	}
+
+	tableColumns, err := in.db.TableColumns(ctx, table, 0)
+	if err != nil {
+		return err
+	}
+	if len(tableColumns) == 0 {
+		return fmt.Errorf("cool-mysql: table %q doesn't exist", table)
+	}
+
+	columnsByName := make(map[string]TableColumn, len(tableColumns))
+	for _, c := range tableColumns {
+		columnsByName[strings.ToLower(c.Name)] = c
+	}
+
+	// the table column of each field of the records, or nil to skip the field
+	fieldColumns := make([]*TableColumn, len(header))
+	for i, h := range header {
+		name := h
+		if mapping != nil {
+			var ok bool
+			if name, ok = mapping[h]; !ok {
+				continue
+			}
+		}
+
+		c, ok := columnsByName[strings.ToLower(name)]
+		if !ok {
+			return fmt.Errorf("cool-mysql: table %q has no column %q", table, name)
+		}
+		fieldColumns[i] = &c
+	}
+
+	ch := make(chan map[string]any)
+	grp, ctx := errgroup.WithContext(ctx)
+
+	grp.Go(func() error {
+		defer close(ch)
+
+		for n := 2; ; n++ {
+			record, err := rr.Read()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read record %d: %w", n, err)
+			}
+
+			row := make(map[string]any, len(fieldColumns))
+			for i, c := range fieldColumns {
+				if c == nil {
+					continue
+				}
+
+				var field string
+				if i < len(record) {
+					field = record[i]
+				}
+
+				row[c.Name], err = recordValue(field, *c)
+				if err != nil {
+					return fmt.Errorf("record %d column %q: %w", n, c.Name, err)
+				}
+			}
+
+			select {
+			case ch <- row:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	})
+
+	grp.Go(func() error {
+		err := in.insert(ctx, table, ch)
+
+		// keep reading so the reader isn't blocked forever if the insert failed
+		for range ch {
+		}
+
+		return err
+	})
+
+	return grp.Wait()
+}
+
+// recordValue converts a field of a record to a value of the column's type
+func recordValue(field string, c TableColumn) (any, error) {
+	if len(field) == 0 {
+		switch {
+		case c.Nullable:
+			return nil, nil
+		case c.Default != nil:
+			return Raw("default(" + quoteIdentifier(c.Name) + ")"), nil
+		case isStringDataType(strings.ToLower(c.DataType)) || isBinaryDataType(strings.ToLower(c.DataType)):
+			return "", nil
+		default:
+			return nil, fmt.Errorf("empty value for non-null %s column", c.ColumnType)
+		}
+	}
+
+	dataType := strings.ToLower(c.DataType)
+	if bits, ok := intBits[dataType]; ok {
+		if c.Unsigned() {
+			return strconv.ParseUint(field, 10, bits)
+		}
+		return strconv.ParseInt(field, 10, bits)
+	}
+
+	switch {
+	case dataType == "decimal":
+		return decimal.NewFromString(field)
+	case dataType == "float", dataType == "double":
+		return strconv.ParseFloat(field, 64)
+	case dataType == "bit":
+		switch strings.ToLower(field) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return strconv.ParseUint(field, 10, 64)
+	case dataType == "json":
+		if !json.Valid([]byte(field)) {
+			return nil, fmt.Errorf("invalid json %q", field)
+		}
+		return json.RawMessage(field), nil
+	case isBinaryDataType(dataType):
+		return []byte(field), nil
+	default:
+		// strings, and temporal values which MySQL parses in the session's time zone
+		return field, nil
+	}
+}
@@ -0,0 +1,43 @@
+package mysql
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRecordValue(t *testing.T) {
+	def := "0"
+	tests := []struct {
+		name    string
+		field   string
+		column  TableColumn
+		want    any
+		wantErr bool
+	}{
+		{"int", "-12", TableColumn{Name: "a", DataType: "int", ColumnType: "int"}, int64(-12), false},
+		{"unsigned", "200", TableColumn{Name: "a", DataType: "tinyint", ColumnType: "tinyint unsigned"}, uint64(200), false},
+		{"int out of range", "200", TableColumn{Name: "a", DataType: "tinyint", ColumnType: "tinyint"}, nil, true},
+		{"bad int", "1.5", TableColumn{Name: "a", DataType: "int", ColumnType: "int"}, nil, true},
+		{"double", "1.5", TableColumn{Name: "a", DataType: "double", ColumnType: "double"}, 1.5, false},
+		{"bit", "true", TableColumn{Name: "a", DataType: "bit", ColumnType: "bit(1)"}, true, false},
+		{"json", `{"a":1}`, TableColumn{Name: "a", DataType: "json", ColumnType: "json"}, json.RawMessage(`{"a":1}`), false},
+		{"bad json", `{"a":`, TableColumn{Name: "a", DataType: "json", ColumnType: "json"}, nil, true},
+		{"string", "hi", TableColumn{Name: "a", DataType: "varchar", ColumnType: "varchar(10)"}, "hi", false},
+		{"empty nullable", "", TableColumn{Name: "a", DataType: "int", ColumnType: "int", Nullable: true}, nil, false},
+		{"empty default", "", TableColumn{Name: "a", DataType: "int", ColumnType: "int", Default: &def}, Raw("default(`a`)"), false},
+		{"empty string", "", TableColumn{Name: "a", DataType: "varchar", ColumnType: "varchar(10)"}, "", false},
+		{"empty not null", "", TableColumn{Name: "a", DataType: "int", ColumnType: "int"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := recordValue(tt.field, tt.column)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("recordValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("recordValue() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,17 +3,16 @@ package mysql
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
+	"database/sql/driver"
 	"fmt"
 	"net"
 	"reflect"
 	"strconv"
+	"strings"
 	"sync"
 	"text/template"
 	"time"
 
-	"github.com/go-redsync/redsync/v4"
-	"github.com/go-redsync/redsync/v4/redis/goredis/v9"
 	"github.com/go-sql-driver/mysql"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
@@ -28,27 +27,164 @@ type Database struct {
 	WritesDSN string
 	ReadsDSN  string
 
-	Log              LogFunc
-	Finished         FinishedFunc
+	Log      LogFunc
+	Finished FinishedFunc
+
+	// HandleRedisError, if set, handles the cache's errors instead of CacheErrorPolicy.
+	//
+	// Deprecated: use SetCacheErrorPolicy, or WithCacheErrorPolicy for single queries.
 	HandleRedisError HandleRedisError
 
+	// CacheErrorPolicy is what cached queries do when the cache fails, see SetCacheErrorPolicy
+	CacheErrorPolicy CacheErrorPolicy
+
+	// Audit, if set, is called after every write statement
+	Audit AuditFunc
+
+	// HandleChanges, if set, is called with the rows changed by writes
+	HandleChanges ChangeFunc
+
+	// Encrypt and Decrypt are used for struct fields tagged `encrypted`,
+	// see SetEncryption
+	Encrypt EncryptFunc
+	Decrypt DecryptFunc
+
+	// MaskPolicy, if set, masks selected struct fields tagged with a mask,
+	// see SetMaskPolicy
+	MaskPolicy *MaskPolicy
+
 	die bool
 
 	MaxInsertSize *synct[int]
 
+	serverInfo *synct[ServerInfo]
+
+	// detectServerInfo and refreshLimits detect the server info and refresh MaxInsertSize
+	// the first time they're needed after connecting, for databases connected with a DSN
+	detectServerInfo *lazyOnce
+	refreshLimits    *lazyOnce
+
+	// config are the policies that can be changed while the database is in use, see ApplyConfig
+	config *synct[Config]
+
+	// nowFunc is the current time of the database, see SetNowFunc
+	nowFunc func() time.Time
+
+	// shadow mirrors writes, see SetShadow
+	shadow *shadow
+
+	// enums are the enum types registered with RegisterEnum
+	enums *sync.Map
+
 	redis redis.UniversalClient
-	rs    *redsync.Redsync
+
+	// redisLocker locks cache keys in the redis of EnableRedis, unless there's a locker of UseLocker
+	redisLocker Locker
+	locker      Locker
 
 	// DisableForeignKeyChecks only affects foreign keys for transactions
 	DisableForeignKeyChecks bool
 
+	// Timeouts limit how long queries can run, see SetTimeouts
+	Timeouts Timeouts
+
+	// CacheLock is how cache misses wait for the locks of their cache keys, see SetCacheLock
+	CacheLock CacheLock
+
+	// LoadShedding holds back low priority queries while the pool is saturated, see SetLoadShedding
+	LoadShedding LoadShedding
+
+	// ResultLimit limits the results of selects into slices, see SetResultLimit
+	ResultLimit ResultLimit
+
+	// FullTableWriteGuard rejects updates and deletes that could write whole tables, see SetFullTableWriteGuard
+	FullTableWriteGuard FullTableWriteGuard
+
+	// NoRetryAfterSend stops writes from being retried after errors that could have happened
+	// after they reached the server, like a lost connection, since retrying a write that was
+	// applied, like an insert, could apply it twice. Those writes return an AmbiguousWriteError
+	// instead, unless their context is from NewContextWithIdempotentWrites, or they're
+	// inserts with an idempotency key.
+	NoRetryAfterSend bool
+
+	// queryLog keeps the last queries logged, see SetQueryLogSize
+	queryLog *queryLog
+
+	// fullScanAnalyzer explains a sample of selects, see SetFullScanAnalyzer
+	fullScanAnalyzer *FullScanAnalyzer
+
+	// cacheTTLMultiplier, comment, defaultParams, and collation are the defaults of a view, see With
+	cacheTTLMultiplier float64
+	comment            string
+	defaultParams      Params
+	collation          string
+
+	// applicationName is added to the comment of every query, see SetApplicationName
+	applicationName string
+
+	// readOnly views can't write, see ReadOnly
+	readOnly bool
+
+	// schema qualifies the table names passed to the view's helpers, see OnSchema
+	schema string
+
+	// statementPolicy limits the statements that can run, see SetStatementPolicy
+	statementPolicy *StatementPolicy
+
+	// middleware wraps every select and exec, see Use
+	middleware []Middleware
+
+	// namedQueries are the queries registered with RegisterQuery
+	namedQueries *sync.Map
+
 	testMx *sync.Mutex
 
 	Logger                      *zap.Logger
 	DisableUnusedColumnWarnings bool
 
-	tmplFuncs   template.FuncMap
-	valuerFuncs map[reflect.Type]reflect.Value
+	// StrictUint64 keeps the full precision of unsigned bigints, even past the 2^53 that fits
+	// in a float64, by scanning them as uint64s in MapRows and SliceRows, including their
+	// cached results, and by keeping numbers in json as json.Number in interfaces
+	StrictUint64 bool
+
+	// NormalizeCacheKeys keys cached results by the fingerprints of their queries instead of the queries as they are,
+	// so queries that only differ by their whitespace, or the order of the values of their `in()` lists, share results
+	NormalizeCacheKeys bool
+
+	// DecimalMode is how decimals are scanned into MapRows and SliceRows, see SetDecimalMode
+	DecimalMode DecimalMode
+
+	// IdentifierCase is how the names of tables and the keys of MapRows are normalized, see SetIdentifierCase
+	IdentifierCase IdentifierCase
+
+	// LocalTimeZone is the zone of time columns tagged `tz` without a zone name,
+	// for legacy tables that store local times instead of UTC, see SetLocalTimeZone
+	LocalTimeZone *time.Location
+
+	// ZeroCopyStrings scans string columns for func dests without copying them,
+	// so the strings share the driver's buffer and are only valid until the func returns.
+	// Funcs that keep the strings, or any part of them, have to copy them first.
+	// Strings sent to channels, appended to slices, or cached are always copies,
+	// whether this is set or not, so they're owned by the receiver.
+	ZeroCopyStrings bool
+
+	// PositionalColumns binds the columns of struct rows to the struct's fields in order, instead of by name,
+	// so rows with the same column more than once, like `select*` of joins, can be scanned into structs
+	// with a field for each, in the order of the tables' columns. Embedded structs' fields are bound in
+	// their places, and columns past the last field are unused. See ErrDuplicateColumn.
+	PositionalColumns bool
+
+	tmplFuncs    template.FuncMap
+	ctxTmplFuncs []func(ctx context.Context) template.FuncMap
+	valuerFuncs  map[reflect.Type]reflect.Value
+
+	// genericValuerFuncs are the valuer funcs of generic types in the order they were added
+	genericValuerFuncs []genericValuerFunc
+
+	// valuerCache is the valuer func found for each type, see valuerFunc
+	valuerCache *valuerCache
+
+	columnValuers map[string]ColumnValuer
 }
 
 // Clone returns a copy of the db with the same connections
@@ -62,7 +198,7 @@ func (db *Database) Clone() *Database {
 // with the given connection information
 func (db *Database) EnableRedis(redisClient redis.UniversalClient) *Database {
 	db.redis = redisClient
-	db.rs = redsync.New(goredis.NewPool(db.redis))
+	db.redisLocker = NewRedisLocker(redisClient)
 
 	return db
 }
@@ -76,6 +212,10 @@ type LogDetail struct {
 	RowsAffected int64
 	Attempt      int
 	Error        error
+
+	// QueryName and Tags are the name and policy tags of the query, if it was run with Run
+	QueryName string
+	Tags      []string
 }
 
 // LogFunc is called after the query executes
@@ -86,10 +226,19 @@ type LogFunc func(detail LogDetail)
 type FinishedFunc func(cached bool, replacedQuery string, params Params, execDuration time.Duration, fetchDuration time.Duration)
 
 // HandleRedisError is executed on a redis error, so it can be handled by the user
-// return false to let the function return the error, or return to let the function continue executing despite the redis error
+// return the error to let the function return it, or nil to let the function continue executing despite the redis error.
+// Exists ignores what it returns for errors caching its result, and returns them either way.
+//
+// Deprecated: use CacheErrorPolicy.
 type HandleRedisError func(err error) error
 
 func (db *Database) callLog(detail LogDetail) {
+	db.logSlowQuery(detail)
+
+	if db.queryLog != nil {
+		db.queryLog.add(detail)
+	}
+
 	if db.Log != nil {
 		db.Log(detail)
 	}
@@ -110,7 +259,7 @@ func New(wUser, wPass, wSchema, wHost string, wPort int,
 	}
 	writes.ParseTime = true
 	writes.InterpolateParams = true
-	writes.ClientFoundRows = true
+	writes.ClientFoundRows = ClientFoundRows
 	if len(collation) != 0 {
 		writes.Collation = collation
 	}
@@ -126,7 +275,7 @@ func New(wUser, wPass, wSchema, wHost string, wPort int,
 	}
 	reads.ParseTime = true
 	reads.InterpolateParams = true
-	reads.ClientFoundRows = true
+	reads.ClientFoundRows = ClientFoundRows
 	if len(collation) != 0 {
 		reads.Collation = collation
 	}
@@ -139,6 +288,8 @@ func New(wUser, wPass, wSchema, wHost string, wPort int,
 func NewFromDSN(writes, reads string) (db *Database, err error) {
 	db = new(Database)
 	db.testMx = new(sync.Mutex)
+	db.namedQueries = new(sync.Map)
+	db.config = new(synct[Config])
 
 	db.WritesDSN = writes
 	db.Writes, err = sql.Open("mysql", writes)
@@ -183,9 +334,67 @@ func NewFromDSN(writes, reads string) (db *Database, err error) {
 	}
 	db.Logger = l.Named("cool-mysql")
 
+	// the server is only asked about itself once it's needed, so connecting doesn't wait on it,
+	// and proxies that can't answer don't get asked unless the answer's used
+	db.serverInfo = new(synct[ServerInfo])
+	db.detectServerInfo = new(lazyOnce)
+	db.refreshLimits = new(lazyOnce)
+
 	return
 }
 
+// NewFromConn creates a new Database from connections that are already open, like ones of a fake driver
+// for tests and benchmarks, without querying the server, so its ServerInfo is empty, and its MaxInsertSize is
+// the driver's default until RefreshLimits is called. The reads connection is the writes one if it's nil.
+func NewFromConn(writes, reads *sql.DB) *Database {
+	if reads == nil {
+		reads = writes
+	}
+
+	db := &Database{
+		Writes:        writes,
+		Reads:         reads,
+		testMx:        new(sync.Mutex),
+		namedQueries:  new(sync.Map),
+		config:        new(synct[Config]),
+		serverInfo:    new(synct[ServerInfo]),
+		MaxInsertSize: new(synct[int]),
+		Logger:        zap.NewNop(),
+	}
+	db.MaxInsertSize.Set(mysql.NewConfig().MaxAllowedPacket)
+
+	return db
+}
+
+// clientFoundRows returns whether the writes connection counts the rows that updates match as affected,
+// instead of the rows they change, which is false for connections it can't tell about
+func (db *Database) clientFoundRows() bool {
+	cfg, err := mysql.ParseDSN(db.WritesDSN)
+	return err == nil && cfg.ClientFoundRows
+}
+
+// RefreshLimits queries the writes server for its max_allowed_packet and updates MaxInsertSize,
+// for when it was raised since connecting, or the DSN's value doesn't match the server's.
+// The DSN's maxAllowedPacket is still used if it's smaller, since the driver won't send more than that.
+func (db *Database) RefreshLimits(ctx context.Context) error {
+	var maxAllowedPacket int
+	err := db.query(db.Writes, ctx, &maxAllowedPacket, "select@@max_allowed_packet", 0)
+	if err != nil {
+		return fmt.Errorf("failed to get max_allowed_packet: %w", err)
+	}
+
+	if cfg, err := mysql.ParseDSN(db.WritesDSN); err == nil && cfg.MaxAllowedPacket > 0 && cfg.MaxAllowedPacket < maxAllowedPacket {
+		maxAllowedPacket = cfg.MaxAllowedPacket
+	}
+
+	if db.MaxInsertSize == nil {
+		db.MaxInsertSize = new(synct[int])
+	}
+	db.MaxInsertSize.Set(maxAllowedPacket)
+
+	return nil
+}
+
 // AddTemplateFuncs adds template functions to the database
 func (db *Database) AddTemplateFuncs(funcs template.FuncMap) {
 	if db.tmplFuncs == nil {
@@ -197,6 +406,37 @@ func (db *Database) AddTemplateFuncs(funcs template.FuncMap) {
 	}
 }
 
+// AddCtxTemplateFuncs adds template functions made from each query's context,
+// so templates can use per request values, like the locale or feature flags.
+// They're used over the funcs of AddTemplateFuncs with the same names.
+func (db *Database) AddCtxTemplateFuncs(fn func(ctx context.Context) template.FuncMap) {
+	// copied so clones of the database don't share added funcs
+	db.ctxTmplFuncs = append(db.ctxTmplFuncs[:len(db.ctxTmplFuncs):len(db.ctxTmplFuncs)], fn)
+}
+
+// templateFuncs returns the template funcs of the query with the context
+func (db *Database) templateFuncs(ctx context.Context, query string) template.FuncMap {
+	if len(db.ctxTmplFuncs) == 0 || !strings.Contains(query, "{{") {
+		return db.tmplFuncs
+	}
+
+	funcs := make(template.FuncMap, len(db.tmplFuncs))
+	for k, v := range db.tmplFuncs {
+		funcs[k] = v
+	}
+	for _, fn := range db.ctxTmplFuncs {
+		for k, v := range fn(ctx) {
+			funcs[k] = v
+		}
+	}
+
+	return funcs
+}
+
+// AddValuerFuncs adds funcs that marshal values of the types of their params, like
+// `func(v Money) (driver.Value, error)`, for types that can't implement driver.Valuer themselves.
+// Funcs whose params are interfaces, like `func(v Valueser) (driver.Value, error)`, marshal every type
+// that implements them and doesn't have a func of its own. See AddGenericValuerFunc for generic types.
 func (db *Database) AddValuerFuncs(funcs ...any) {
 	for _, f := range funcs {
 		r := reflect.ValueOf(f)
@@ -211,10 +451,50 @@ func (db *Database) AddValuerFuncs(funcs ...any) {
 
 		db.valuerFuncs[rt.In(0)] = r
 	}
+	db.resetValuerCache()
+}
+
+// AddGenericValuerFunc adds a func that marshals every instantiation of the generic type of the example,
+// like `set.Set[int]{}` for set.Set[string] and set.Set[int64] too, which is called with the value as an any.
+// The funcs of instantiations added by AddValuerFuncs come first, and interface funcs come after.
+// Adding another func for the same generic type replaces the earlier one.
+func (db *Database) AddGenericValuerFunc(example any, fn func(v any) (driver.Value, error)) {
+	t := reflectUnwrapType(reflect.TypeOf(example))
+	if len(genericTypeName(t)) == 0 {
+		panic(fmt.Errorf("invalid generic valuer func example: %T isn't a generic type", example))
+	}
+
+	g := genericValuerFunc{name: genericTypeName(t), fn: reflect.ValueOf(fn)}
+	replaced := false
+	for i := range db.genericValuerFuncs {
+		if db.genericValuerFuncs[i].name == g.name {
+			db.genericValuerFuncs[i], replaced = g, true
+		}
+	}
+	if !replaced {
+		db.genericValuerFuncs = append(db.genericValuerFuncs, g)
+	}
+	db.resetValuerCache()
+}
+
+// resetValuerCache forgets the valuer funcs found for each type, since added funcs can come before them
+func (db *Database) resetValuerCache() {
+	db.valuerCache = new(valuerCache)
+}
+
+// valuers returns the valuer funcs of the database, or nil if it doesn't have any
+func (db *Database) valuers() *valuers {
+	if len(db.valuerFuncs) == 0 && len(db.genericValuerFuncs) == 0 {
+		return nil
+	}
+
+	return &valuers{funcs: db.valuerFuncs, generics: db.genericValuerFuncs, cache: db.valuerCache}
 }
 
 // Reconnect creates new connection(s) for writes and reads
-// and replaces the existing connections with the new ones
+// and replaces the existing connections with the new ones,
+// refreshing MaxInsertSize and the server info from the server too,
+// since it could be a different one after a failover
 func (db *Database) Reconnect() error {
 	new, err := NewFromDSN(db.WritesDSN, db.ReadsDSN)
 	if err != nil {
@@ -223,10 +503,57 @@ func (db *Database) Reconnect() error {
 
 	db.Writes = new.Writes
 	db.Reads = new.Reads
+	if db.MaxInsertSize == nil {
+		db.MaxInsertSize = new.MaxInsertSize
+	} else {
+		db.MaxInsertSize.Set(new.MaxInsertSize.Get())
+	}
+
+	db.resetDetection(new)
 
 	return nil
 }
 
+// resetDetection makes the server info and limits be detected again the next time they're needed,
+// or takes the detection of the new connection if the database didn't have its own
+func (db *Database) resetDetection(new *Database) {
+	if db.serverInfo == nil {
+		db.serverInfo = new.serverInfo
+	}
+
+	if db.detectServerInfo == nil {
+		db.detectServerInfo = new.detectServerInfo
+	} else {
+		db.detectServerInfo.reset()
+	}
+
+	if db.refreshLimits == nil {
+		db.refreshLimits = new.refreshLimits
+	} else {
+		db.refreshLimits.reset()
+	}
+}
+
+// maxInsertSize returns MaxInsertSize, refreshed from the server with ctx the first time it's needed
+// after connecting, unless it was changed from the DSN's value since
+func (db *Database) maxInsertSize(ctx context.Context) int {
+	if db.refreshLimits != nil {
+		db.refreshLimits.do(ctx, func(ctx context.Context) error {
+			if cfg, err := mysql.ParseDSN(db.WritesDSN); err != nil || cfg.MaxAllowedPacket != db.MaxInsertSize.Get() {
+				return nil
+			}
+
+			err := db.RefreshLimits(ctx)
+			if err != nil {
+				db.Logger.Warn(err.Error())
+			}
+			return err
+		})
+	}
+
+	return db.MaxInsertSize.Get()
+}
+
 // Test pings both writes and reads connection and if either fail
 // reconnects both connections
 func (db *Database) Test() error {
@@ -255,6 +582,7 @@ func (db *Database) Insert(insert string, source any) error {
 	return db.I().Insert(insert, source)
 }
 
+// InsertContext inserts the source, as part of the context's transaction if it has one of this database
 func (db *Database) InsertContext(ctx context.Context, insert string, source any) error {
 	return db.I().InsertContext(ctx, insert, source)
 }
@@ -326,7 +654,7 @@ func (db *Database) SelectJSONContext(ctx context.Context, dest any, query strin
 		return err
 	}
 
-	err = json.Unmarshal(j, dest)
+	err = db.unmarshalJSON(j, dest)
 	if err != nil {
 		return err
 	}
@@ -354,18 +682,40 @@ func (db *Database) ExistsWritesContext(ctx context.Context, query string, cache
 	return db.exists(db.Writes, ctx, query, cache, params...)
 }
 
-func (db *Database) Upsert(insert string, uniqueColumns, updateColumns []string, where string, source any) error {
-	return db.I().Upsert(insert, uniqueColumns, updateColumns, where, source)
+func (db *Database) Upsert(insert string, uniqueColumns, updateColumns []string, where string, whereParams Params, source any) error {
+	return db.I().Upsert(insert, uniqueColumns, updateColumns, where, whereParams, source)
 }
 
-func (db *Database) UpsertContext(ctx context.Context, insert string, uniqueColumns, updateColumns []string, where string, source any) error {
-	return db.I().UpsertContext(ctx, insert, uniqueColumns, updateColumns, where, source)
+// UpsertContext upserts the source, as part of the context's transaction if it has one of this database
+func (db *Database) UpsertContext(ctx context.Context, insert string, uniqueColumns, updateColumns []string, where string, whereParams Params, source any) error {
+	return db.I().UpsertContext(ctx, insert, uniqueColumns, updateColumns, where, whereParams, source)
 }
 
+// InterpolateParams replaces the query's params and templates, like the database does for every query
 func (db *Database) InterpolateParams(query string, params ...any) (replacedQuery string, normalizedParams Params, err error) {
-	return InterpolateParams(query, db.tmplFuncs, db.valuerFuncs, params...)
+	return db.interpolateParams(context.Background(), query, params...)
 }
 
-func (db *Database) interpolateParams(query string, params ...any) (replacedQuery string, normalizedParams Params, err error) {
-	return interpolateParams(query, db.tmplFuncs, db.valuerFuncs, params...)
+// InterpolateParamsContext is like InterpolateParams, with the context passed to the context template funcs
+func (db *Database) InterpolateParamsContext(ctx context.Context, query string, params ...any) (replacedQuery string, normalizedParams Params, err error) {
+	return db.interpolateParams(ctx, query, params...)
+}
+
+func (db *Database) interpolateParams(ctx context.Context, query string, params ...any) (replacedQuery string, normalizedParams Params, err error) {
+	collation, params := collationFromParams(params)
+	if len(collation) == 0 {
+		collation = db.collation
+	}
+
+	params, err = db.encryptParams(params)
+	if err != nil {
+		return "", nil, err
+	}
+
+	params, err = db.zonedParams(params)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return interpolateParams(query, db.templateFuncs(ctx, query), db.valuers(), db.nowParams(collatedParams(db.withDefaultParams(params), collation))...)
 }
@@ -0,0 +1,113 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrDuplicateColumn is matched by the errors of selects whose rows have a column more than once, like `select*`
+// of a join of tables that both have an `ID`, into rows whose columns are bound by name, which could only keep one
+// of them, see DuplicateColumnError. SliceRows are bound by position, and so are structs with PositionalColumns.
+var ErrDuplicateColumn = errors.New("cool-mysql: query selects the same column more than once")
+
+// DuplicateColumnError is the error of a select whose rows have a column more than once, see ErrDuplicateColumn
+type DuplicateColumnError struct {
+	Column string
+
+	// Positions are the positions of the column in the rows, starting at 0
+	Positions []int
+}
+
+func (e DuplicateColumnError) Error() string {
+	return fmt.Sprintf("%s: %q is at positions %v, so give the columns their own aliases, or select into SliceRows or positional structs", ErrDuplicateColumn, e.Column, e.Positions)
+}
+
+func (e DuplicateColumnError) Is(target error) bool {
+	return target == ErrDuplicateColumn
+}
+
+// SetPositionalColumns sets whether the columns of struct rows are bound by position, see PositionalColumns
+func (db *Database) SetPositionalColumns(positional bool) *Database {
+	db.PositionalColumns = positional
+	return db
+}
+
+// duplicateColumnError returns the DuplicateColumnError of the first column that's in the columns more than once,
+// or nil if there isn't one. Rows only have a few columns, so they're compared to each other instead of mapped.
+func duplicateColumnError(columns []string) error {
+	for i, c := range columns {
+		for _, prev := range columns[:i] {
+			if prev == c {
+				return newDuplicateColumnError(columns, c)
+			}
+		}
+	}
+
+	return nil
+}
+
+func newDuplicateColumnError(columns []string, column string) DuplicateColumnError {
+	e := DuplicateColumnError{Column: column}
+	for i, c := range columns {
+		if c == column {
+			e.Positions = append(e.Positions, i)
+		}
+	}
+
+	return e
+}
+
+// columnFieldIndexes returns the indexes of the struct's fields of the columns at the same positions,
+// and the column names of those fields, which are nil and empty for columns that don't have fields.
+// Columns are bound to the fields with their names, or if positional, to the fields in the struct's order,
+// where embedded structs are skipped, since their fields are bound instead.
+func columnFieldIndexes(t reflect.Type, columns []string, positional bool) (fieldIndexes [][]int, fieldColumns []string, err error) {
+	fields, err := structColumnFields(t)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fieldIndexes = make([][]int, len(columns))
+	fieldColumns = make([]string, len(columns))
+
+	if positional {
+		i := 0
+		for _, f := range fields {
+			if i == len(columns) {
+				break
+			}
+			if f.embedded {
+				continue
+			}
+
+			fieldIndexes[i], fieldColumns[i] = f.index, f.name
+			i++
+		}
+
+		return fieldIndexes, fieldColumns, nil
+	}
+
+	fieldsMap := make(map[string][]int, len(fields))
+	for _, f := range fields {
+		fieldsMap[f.name] = f.index
+	}
+
+	for i, c := range columns {
+		index, ok := fieldsMap[c]
+		if !ok {
+			continue
+		}
+
+		// columns that aren't bound to fields are only warned about, so their duplicates are too
+		for _, prev := range columns[:i] {
+			if prev == c {
+				return nil, nil, newDuplicateColumnError(columns, c)
+			}
+		}
+
+		fieldIndexes[i], fieldColumns[i] = index, c
+	}
+
+	return fieldIndexes, fieldColumns, nil
+}
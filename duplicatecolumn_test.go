@@ -0,0 +1,104 @@
+package mysql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/StirlingMarketingGroup/cool-mysql/internal/testdriver"
+)
+
+func TestSelectDuplicateColumns(t *testing.T) {
+	// like `select*` of a join of two tables with an `ID`
+	conn := sql.OpenDB(&testdriver.Driver{
+		Columns: []string{"ID", "Name", "ID"},
+		Row:     []driver.Value{int64(1), []byte("Ann"), int64(2)},
+		Rows:    1,
+	})
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	type named struct {
+		ID   int
+		Name string
+	}
+	type unbound struct {
+		Name string
+	}
+	type positional struct {
+		AID  int
+		Name string
+		BID  int
+	}
+
+	tests := []struct {
+		name       string
+		positional bool
+		dest       any
+		want       any
+		wantErr    bool
+	}{
+		{
+			name:    "struct",
+			dest:    new([]named),
+			wantErr: true,
+		},
+		{
+			name:    "map row",
+			dest:    new([]MapRow),
+			wantErr: true,
+		},
+		{
+			name:       "map row positional",
+			positional: true,
+			dest:       new([]MapRow),
+			wantErr:    true,
+		},
+		{
+			name: "slice row",
+			dest: new([]SliceRow),
+			want: &[]SliceRow{{int64(1), []byte("Ann"), int64(2)}},
+		},
+		{
+			name: "struct without the duplicate's field",
+			dest: new([]unbound),
+			want: &[]unbound{{"Ann"}},
+		},
+		{
+			name:       "struct positional",
+			positional: true,
+			dest:       new([]positional),
+			want:       &[]positional{{1, "Ann", 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := benchDatabase(t)
+			db.Reads = conn
+			db.DisableUnusedColumnWarnings = true
+			db.SetPositionalColumns(tt.positional)
+
+			err := db.Select(tt.dest, "select*from`a`join`b`using(`ID`)", 0)
+			if tt.wantErr {
+				var dupErr DuplicateColumnError
+				if !errors.Is(err, ErrDuplicateColumn) || !errors.As(err, &dupErr) {
+					t.Fatalf("Select() error = %v, want %v", err, ErrDuplicateColumn)
+				}
+				if !strings.EqualFold(dupErr.Column, "id") || !reflect.DeepEqual(dupErr.Positions, []int{0, 2}) {
+					t.Errorf("Select() error = %+v, want id at 0 and 2", dupErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tt.dest, tt.want) {
+				t.Errorf("Select() = %+v, want %+v", tt.dest, tt.want)
+			}
+		})
+	}
+}
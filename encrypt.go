@@ -0,0 +1,390 @@
+package mysql
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/fatih/structtag"
+)
+
+// EncryptFunc encrypts the plaintext of a field tagged `encrypted`
+type EncryptFunc func(plaintext []byte) ([]byte, error)
+
+// DecryptFunc decrypts the ciphertext of a field tagged `encrypted`
+type DecryptFunc func(ciphertext []byte) ([]byte, error)
+
+// ErrNoEncryption is returned when a struct with encrypted fields is used,
+// but the database has no Encrypt or Decrypt func
+var ErrNoEncryption = errors.New("cool-mysql: struct has encrypted fields but no encrypt/decrypt funcs are set")
+
+// SetEncryption sets the funcs used to encrypt and decrypt fields tagged
+// like `mysql:"SSN,encrypted"`. Encrypted columns hold the raw ciphertext,
+// so they should be binary columns. Strings and byte slices are encrypted as is,
+// and any other type is encrypted as json.
+// Fields tagged `encrypted` in structs that are stored as json are encrypted too,
+// as long as they're strings (stored as base64) or byte slices.
+func (db *Database) SetEncryption(encrypt EncryptFunc, decrypt DecryptFunc) *Database {
+	db.Encrypt = encrypt
+	db.Decrypt = decrypt
+
+	return db
+}
+
+var encryptedTypes sync.Map
+
+// typeHasEncryptedFields returns true if the type, or any type it contains,
+// has a field tagged `encrypted`
+func typeHasEncryptedFields(t reflect.Type) bool {
+	if v, ok := encryptedTypes.Load(t); ok {
+		return v.(bool)
+	}
+
+	// store false first so recursive types don't loop forever
+	encryptedTypes.Store(t, false)
+	has := typeHasEncryptedFieldsUncached(t)
+	encryptedTypes.Store(t, has)
+
+	return has
+}
+
+func typeHasEncryptedFieldsUncached(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return typeHasEncryptedFields(t.Elem())
+	case reflect.Map:
+		return typeHasEncryptedFields(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+
+			if fieldIsEncrypted(f) || typeHasEncryptedFields(f.Type) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func fieldIsEncrypted(f reflect.StructField) bool {
+	tags, err := structtag.Parse(string(f.Tag))
+	if err != nil {
+		return false
+	}
+
+	t, _ := tags.Get("mysql")
+	return t != nil && t.HasOption("encrypted")
+}
+
+// encryptedColumns returns the lowercased column names of the fields tagged `encrypted`
+func encryptedColumns(t reflect.Type) (map[string]struct{}, error) {
+	columns, colOpts, _, err := colNamesFromStruct(t)
+	if err != nil {
+		return nil, err
+	}
+
+	var encrypted map[string]struct{}
+	for _, c := range columns {
+		if colOpts[c].encrypted {
+			if encrypted == nil {
+				encrypted = make(map[string]struct{})
+			}
+			encrypted[strings.ToLower(c)] = struct{}{}
+		}
+	}
+
+	return encrypted, nil
+}
+
+// encryptValue returns the ciphertext of a column value
+func (db *Database) encryptValue(v reflect.Value) (any, error) {
+	if db.Encrypt == nil {
+		return nil, ErrNoEncryption
+	}
+
+	v = reflectUnwrap(v)
+	if !v.IsValid() || (v.Kind() == reflect.Pointer && v.IsNil()) {
+		return nil, nil
+	}
+
+	var plaintext []byte
+	switch {
+	case v.Kind() == reflect.String:
+		plaintext = []byte(v.String())
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		if v.IsNil() {
+			return nil, nil
+		}
+		plaintext = v.Bytes()
+	default:
+		var err error
+		plaintext, err = json.Marshal(v.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal value to encrypt: %w", err)
+		}
+	}
+
+	ciphertext, err := db.Encrypt(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt value: %w", err)
+	}
+
+	return ciphertext, nil
+}
+
+// decryptInto decrypts a column's ciphertext into dest
+func (db *Database) decryptInto(dest reflect.Value, ciphertext []byte) error {
+	if db.Decrypt == nil {
+		return ErrNoEncryption
+	}
+
+	plaintext, err := db.Decrypt(ciphertext)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt value: %w", err)
+	}
+
+	if dest.Kind() == reflect.Pointer {
+		dest.Set(reflect.New(dest.Type().Elem()))
+		dest = dest.Elem()
+	}
+
+	switch {
+	case dest.Kind() == reflect.String:
+		dest.SetString(string(plaintext))
+	case dest.Kind() == reflect.Slice && dest.Type().Elem().Kind() == reflect.Uint8:
+		dest.SetBytes(plaintext)
+	default:
+		if err := json.Unmarshal(plaintext, dest.Addr().Interface()); err != nil {
+			return fmt.Errorf("failed to unmarshal decrypted value: %w", err)
+		}
+	}
+
+	return db.decryptNested(dest)
+}
+
+// encryptNested returns a copy of v with every nested string and byte slice field
+// tagged `encrypted` replaced with its ciphertext, leaving v untouched
+func (db *Database) encryptNested(v reflect.Value) (reflect.Value, error) {
+	if !v.IsValid() || !typeHasEncryptedFields(v.Type()) {
+		return v, nil
+	}
+
+	if db.Encrypt == nil {
+		return v, ErrNoEncryption
+	}
+
+	return db.transformNested(v, true)
+}
+
+// decryptNested decrypts every nested string and byte slice field tagged `encrypted` in place
+func (db *Database) decryptNested(v reflect.Value) error {
+	if !v.IsValid() || !typeHasEncryptedFields(v.Type()) {
+		return nil
+	}
+
+	if db.Decrypt == nil {
+		return ErrNoEncryption
+	}
+
+	out, err := db.transformNested(v, false)
+	if err != nil {
+		return err
+	}
+
+	if v.CanSet() {
+		v.Set(out)
+	}
+
+	return nil
+}
+
+func (db *Database) transformNested(v reflect.Value, encrypt bool) (reflect.Value, error) {
+	if !typeHasEncryptedFields(v.Type()) {
+		return v, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v, nil
+		}
+
+		elem, err := db.transformNested(v.Elem(), encrypt)
+		if err != nil {
+			return v, err
+		}
+
+		out := reflect.New(v.Type().Elem())
+		out.Elem().Set(elem)
+		return out, nil
+	case reflect.Slice, reflect.Array:
+		var out reflect.Value
+		if v.Kind() == reflect.Slice {
+			if v.IsNil() {
+				return v, nil
+			}
+			out = reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		} else {
+			out = reflect.New(v.Type()).Elem()
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			elem, err := db.transformNested(v.Index(i), encrypt)
+			if err != nil {
+				return v, err
+			}
+			out.Index(i).Set(elem)
+		}
+		return out, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return v, nil
+		}
+
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			elem, err := db.transformNested(iter.Value(), encrypt)
+			if err != nil {
+				return v, err
+			}
+			out.SetMapIndex(iter.Key(), elem)
+		}
+		return out, nil
+	case reflect.Struct:
+		out := reflect.New(v.Type()).Elem()
+		out.Set(v)
+
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+
+			fv := out.Field(i)
+			if fieldIsEncrypted(f) {
+				if err := db.transformField(fv, encrypt); err != nil {
+					return v, fmt.Errorf("field %q: %w", f.Name, err)
+				}
+				continue
+			}
+
+			elem, err := db.transformNested(fv, encrypt)
+			if err != nil {
+				return v, err
+			}
+			fv.Set(elem)
+		}
+		return out, nil
+	}
+
+	return v, nil
+}
+
+// transformField encrypts or decrypts a nested string or byte slice field in place
+func (db *Database) transformField(fv reflect.Value, encrypt bool) error {
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return nil
+		}
+
+		elem := reflect.New(fv.Type().Elem())
+		elem.Elem().Set(fv.Elem())
+		fv.Set(elem)
+		fv = elem.Elem()
+	}
+
+	switch {
+	case fv.Kind() == reflect.String:
+		if encrypt {
+			ciphertext, err := db.Encrypt([]byte(fv.String()))
+			if err != nil {
+				return fmt.Errorf("failed to encrypt value: %w", err)
+			}
+			fv.SetString(base64.StdEncoding.EncodeToString(ciphertext))
+		} else {
+			ciphertext, err := base64.StdEncoding.DecodeString(fv.String())
+			if err != nil {
+				return fmt.Errorf("failed to decode encrypted value: %w", err)
+			}
+			plaintext, err := db.Decrypt(ciphertext)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt value: %w", err)
+			}
+			fv.SetString(string(plaintext))
+		}
+	case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Uint8:
+		if fv.IsNil() {
+			return nil
+		}
+
+		fn := func(b []byte) ([]byte, error) { return db.Encrypt(b) }
+		if !encrypt {
+			fn = db.Decrypt
+		}
+		b, err := fn(fv.Bytes())
+		if err != nil {
+			return fmt.Errorf("failed to encrypt or decrypt value: %w", err)
+		}
+		fv.SetBytes(b)
+	default:
+		return fmt.Errorf("cool-mysql: only string and []byte fields can be encrypted inside json, got %s", fv.Type())
+	}
+
+	return nil
+}
+
+// encryptParams replaces struct params that have encrypted fields with
+// params holding the encrypted values
+func (db *Database) encryptParams(params []any) ([]any, error) {
+	var out []any
+	for i, p := range params {
+		v := reflectUnwrap(reflect.ValueOf(p))
+		if !v.IsValid() || v.Kind() != reflect.Struct || !isMultiValueElement(v.Type()) || !typeHasEncryptedFields(v.Type()) {
+			continue
+		}
+
+		if out == nil {
+			out = append(make([]any, 0, len(params)), params...)
+		}
+
+		cp, _ := convertToParams("", v.Interface())
+		t := v.Type()
+		for _, index := range StructFieldIndexes(t) {
+			f := t.FieldByIndex(index)
+			if !f.IsExported() {
+				continue
+			}
+
+			fv := v.FieldByIndex(index)
+			var err error
+			if fieldIsEncrypted(f) {
+				cp[f.Name], err = db.encryptValue(fv)
+			} else if typeHasEncryptedFields(f.Type) {
+				var ev reflect.Value
+				ev, err = db.encryptNested(fv)
+				cp[f.Name] = ev.Interface()
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt param %q: %w", f.Name, err)
+			}
+		}
+
+		out[i] = cp
+	}
+
+	if out == nil {
+		return params, nil
+	}
+
+	return out, nil
+}
@@ -0,0 +1,61 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testXOR(b []byte) ([]byte, error) {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[i] = b[i] ^ 0x5a
+	}
+	return out, nil
+}
+
+func TestEncryptNested(t *testing.T) {
+	type contact struct {
+		Email string  `mysql:",encrypted"`
+		Phone *string `mysql:",encrypted"`
+		Name  string
+	}
+	type profile struct {
+		Contacts []contact
+		Primary  *contact
+		Raw      []byte `mysql:",encrypted"`
+	}
+
+	db := new(Database).SetEncryption(testXOR, testXOR)
+
+	original := profile{
+		Contacts: []contact{{Email: "a@example.com", Phone: p("555-0100"), Name: "A"}},
+		Primary:  &contact{Email: "b@example.com", Name: "B"},
+		Raw:      []byte("raw"),
+	}
+
+	encrypted, err := db.encryptNested(reflect.ValueOf(original))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ep := encrypted.Interface().(profile)
+	if ep.Contacts[0].Email == "a@example.com" || *ep.Contacts[0].Phone == "555-0100" || ep.Primary.Email == "b@example.com" || string(ep.Raw) == "raw" {
+		t.Errorf("encryptNested() didn't encrypt every tagged field: %+v", ep)
+	}
+	if ep.Contacts[0].Name != "A" || ep.Primary.Name != "B" {
+		t.Errorf("encryptNested() changed untagged fields: %+v", ep)
+	}
+	if original.Contacts[0].Email != "a@example.com" || *original.Contacts[0].Phone != "555-0100" || original.Primary.Email != "b@example.com" {
+		t.Errorf("encryptNested() modified the original value: %+v", original)
+	}
+
+	decrypted := reflect.New(encrypted.Type()).Elem()
+	decrypted.Set(encrypted)
+	if err := db.decryptNested(decrypted); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(decrypted.Interface(), original) {
+		t.Errorf("decryptNested() = %+v, want %+v", decrypted.Interface(), original)
+	}
+}
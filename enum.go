@@ -0,0 +1,256 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidEnumValue is returned when a value isn't one of the values allowed by an ENUM or SET column
+var ErrInvalidEnumValue = errors.New("cool-mysql: invalid enum value")
+
+// EnumValues returns the values allowed by an ENUM or SET column,
+// or nil if the column is neither
+func (c TableColumn) EnumValues() []string {
+	t := c.ColumnType
+	lower := strings.ToLower(t)
+
+	var rest string
+	switch {
+	case strings.HasPrefix(lower, "enum("):
+		rest = t[len("enum("):]
+	case strings.HasPrefix(lower, "set("):
+		rest = t[len("set("):]
+	default:
+		return nil
+	}
+
+	var values []string
+	for _, tok := range parseQuery(rest) {
+		if tok.kind != queryTokenKindString || tok.string[0] != '\'' {
+			continue
+		}
+
+		v := tok.string[1 : len(tok.string)-1]
+		values = append(values, strings.ReplaceAll(v, "''", "'"))
+	}
+
+	return values
+}
+
+type enumDef struct {
+	table, column string
+	set           bool
+	values        map[string]struct{}
+}
+
+// check returns an error if the value isn't allowed,
+// where SET values can be any of the allowed values separated by commas
+func (e *enumDef) check(v string) error {
+	if e.set {
+		if len(v) == 0 {
+			return nil
+		}
+
+		for _, part := range strings.Split(v, ",") {
+			if _, ok := e.values[part]; !ok {
+				return fmt.Errorf("%w %q for set column %q.%q", ErrInvalidEnumValue, part, e.table, e.column)
+			}
+		}
+
+		return nil
+	}
+
+	if _, ok := e.values[v]; !ok {
+		return fmt.Errorf("%w %q for enum column %q.%q", ErrInvalidEnumValue, v, e.table, e.column)
+	}
+
+	return nil
+}
+
+// RegisterEnum introspects the values allowed by the table's ENUM or SET column,
+// making sure every one of the given Go values is allowed, and from then on every
+// value of type T selected by the database is checked against them too.
+// Enums should be registered when the database is set up, before it's used.
+func RegisterEnum[T ~string](ctx context.Context, db *Database, table, column string, values ...T) error {
+	t := reflect.TypeOf(values).Elem()
+	if t == reflect.TypeOf("") {
+		return errors.New("cool-mysql: enums must be their own string types, not string")
+	}
+
+	columns, err := db.TableColumns(ctx, table, 0)
+	if err != nil {
+		return err
+	}
+
+	var def *enumDef
+	for _, c := range columns {
+		if !strings.EqualFold(c.Name, column) {
+			continue
+		}
+
+		allowed := c.EnumValues()
+		if allowed == nil {
+			return fmt.Errorf("cool-mysql: column %q.%q is %s, not an enum or set", table, column, c.ColumnType)
+		}
+
+		def = &enumDef{
+			table:  table,
+			column: column,
+			set:    strings.EqualFold(c.DataType, "set"),
+			values: make(map[string]struct{}, len(allowed)),
+		}
+		for _, v := range allowed {
+			def.values[v] = struct{}{}
+		}
+	}
+	if def == nil {
+		return fmt.Errorf("cool-mysql: column %q.%q doesn't exist", table, column)
+	}
+
+	for _, v := range values {
+		if err := def.check(string(v)); err != nil {
+			return err
+		}
+	}
+
+	if db.enums == nil {
+		db.enums = new(sync.Map)
+	}
+	db.enums.Store(t, def)
+
+	return nil
+}
+
+func (db *Database) enumDef(t reflect.Type) *enumDef {
+	if db.enums == nil {
+		return nil
+	}
+
+	def, _ := db.enums.Load(reflectUnwrapType(t))
+	e, _ := def.(*enumDef)
+	return e
+}
+
+type enumField struct {
+	index []int
+	def   *enumDef
+}
+
+// enumFieldsFromStruct returns the fields of the struct with registered enum types
+func (db *Database) enumFieldsFromStruct(t reflect.Type) []enumField {
+	if db.enums == nil {
+		return nil
+	}
+
+	t = reflectUnwrapType(t)
+	if t.Kind() != reflect.Struct || !isMultiValueElement(t) {
+		return nil
+	}
+
+	var fields []enumField
+	for _, i := range StructFieldIndexes(t) {
+		f := t.FieldByIndex(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		if def := db.enumDef(f.Type); def != nil {
+			fields = append(fields, enumField{index: i, def: def})
+		}
+	}
+
+	return fields
+}
+
+// checkEnum returns an error if the value, or its pointer, isn't allowed
+func checkEnum(v reflect.Value, def *enumDef) error {
+	v = reflect.Indirect(v)
+	if !v.IsValid() || v.Kind() != reflect.String {
+		return nil
+	}
+
+	return def.check(v.String())
+}
+
+// Set is the value of a SET column, marshaled as its values separated by commas
+// instead of being expanded like a slice
+type Set[T ~string] map[T]struct{}
+
+// NewSet returns a set of the given values
+func NewSet[T ~string](values ...T) Set[T] {
+	s := make(Set[T], len(values))
+	for _, v := range values {
+		s[v] = struct{}{}
+	}
+
+	return s
+}
+
+// Has returns true if the value is in the set
+func (s Set[T]) Has(v T) bool {
+	_, ok := s[v]
+	return ok
+}
+
+// Values returns the values of the set, sorted
+func (s Set[T]) Values() []T {
+	values := make([]T, 0, len(s))
+	for v := range s {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i] < values[j]
+	})
+
+	return values
+}
+
+// Value marshals the set as its values separated by commas
+func (s Set[T]) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	values := s.Values()
+	parts := make([]string, len(values))
+	for i, v := range values {
+		if strings.Contains(string(v), ",") {
+			return nil, fmt.Errorf("cool-mysql: set value %q can't contain a comma", v)
+		}
+		parts[i] = string(v)
+	}
+
+	return strings.Join(parts, ","), nil
+}
+
+// Scan unmarshals the set from its values separated by commas
+func (s *Set[T]) Scan(src any) error {
+	var str string
+	switch src := src.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case []byte:
+		str = string(src)
+	case string:
+		str = src
+	default:
+		return fmt.Errorf("cool-mysql: can't scan %T into a set", src)
+	}
+
+	set := make(Set[T])
+	if len(str) != 0 {
+		for _, v := range strings.Split(str, ",") {
+			set[T(v)] = struct{}{}
+		}
+	}
+	*s = set
+
+	return nil
+}
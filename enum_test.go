@@ -0,0 +1,69 @@
+package mysql
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTableColumnEnumValues(t *testing.T) {
+	tests := []struct {
+		columnType string
+		want       []string
+	}{
+		{"enum('active','it''s paused','done')", []string{"active", "it's paused", "done"}},
+		{"set('a','b,c')", []string{"a", "b,c"}},
+		{"varchar(255)", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.columnType, func(t *testing.T) {
+			got := TableColumn{ColumnType: tt.columnType}.EnumValues()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EnumValues() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnumDefCheck(t *testing.T) {
+	enum := &enumDef{values: map[string]struct{}{"a": {}, "b": {}}}
+	set := &enumDef{set: true, values: enum.values}
+
+	if err := enum.check("a"); err != nil {
+		t.Errorf("enum check(a) = %v", err)
+	}
+	if err := enum.check("a,b"); !errors.Is(err, ErrInvalidEnumValue) {
+		t.Errorf("enum check(a,b) = %v, want ErrInvalidEnumValue", err)
+	}
+	if err := set.check("a,b"); err != nil {
+		t.Errorf("set check(a,b) = %v", err)
+	}
+	if err := set.check(""); err != nil {
+		t.Errorf("set check() = %v", err)
+	}
+	if err := set.check("a,c"); !errors.Is(err, ErrInvalidEnumValue) {
+		t.Errorf("set check(a,c) = %v, want ErrInvalidEnumValue", err)
+	}
+}
+
+func TestSet(t *testing.T) {
+	type perm string
+
+	s := NewSet[perm]("write", "read")
+	b, err := marshal(s, 0, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := marshal("read,write", 0, "", nil)
+	if string(b) != string(want) {
+		t.Errorf("marshal(Set) = %s, want %s", b, want)
+	}
+
+	var scanned Set[perm]
+	if err := scanned.Scan([]byte("read,write")); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(scanned, s) {
+		t.Errorf("Scan() = %v, want %v", scanned, s)
+	}
+}
@@ -45,3 +45,14 @@ func getenvFloat(key string, fallback float64) float64 {
 	}
 	return fallback
 }
+
+// getenvBool gets an environment variable with a default bool
+func getenvBool(key string, fallback bool) bool {
+	if value, ok := os.LookupEnv(key); ok {
+		b, err := strconv.ParseBool(value)
+		if err == nil {
+			return b
+		}
+	}
+	return fallback
+}
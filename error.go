@@ -22,12 +22,18 @@ type Error struct {
 var QueryErrorLoggingLength = getenvInt("COOL_MYSQL_MAX_QUERY_LOG_LENGTH", 1<<12) // 4kB
 
 func (v Error) Error() string {
-	if QueryErrorLoggingLength > 0 && len(v.ReplacedQuery) > QueryErrorLoggingLength {
+	// queries whose params couldn't be interpolated only have the original
+	query := v.ReplacedQuery
+	if len(query) == 0 {
+		query = v.OriginalQuery
+	}
+
+	if QueryErrorLoggingLength > 0 && len(query) > QueryErrorLoggingLength {
 		half := QueryErrorLoggingLength >> 1
-		v.ReplacedQuery = v.ReplacedQuery[:half] + fmt.Sprintf("\n/* %d characters hidden */\n", len(v.ReplacedQuery)-QueryErrorLoggingLength) + v.ReplacedQuery[len(v.ReplacedQuery)-half:]
+		query = query[:half] + fmt.Sprintf("\n/* %d characters hidden */\n", len(query)-QueryErrorLoggingLength) + query[len(query)-half:]
 	}
 	j, _ := json.MarshalIndent(v.Params, "", "  ")
-	return fmt.Sprintf("%s\n\nquery len:\n%d\n\nquery:\n%s\n\nparams:\n%s", v.Err.Error(), len(v.ReplacedQuery), v.ReplacedQuery, j)
+	return fmt.Sprintf("%s\n\nquery len:\n%d\n\nquery:\n%s\n\nparams:\n%s", v.Err.Error(), len(query), query, j)
 }
 
 func (v Error) Unwrap() error {
@@ -16,9 +16,73 @@ import (
 // exec executes a query and nothing more
 // newQuery is true if this is a new query, false if it's a replay of a query in a transaction
 func (db *Database) exec(conn handlerWithContext, ctx context.Context, tx *Tx, newQuery bool, query string, params ...any) (sql.Result, error) {
-	replacedQuery, normalizedParams, err := db.interpolateParams(query, params...)
+	if c, ok := inChunks(query, params, false, db.valuers()); ok && newQuery {
+		return db.execInChunks(conn, ctx, tx, c, query, params...)
+	}
+
+	if len(db.middleware) == 0 {
+		return db.runExec(conn, ctx, tx, newQuery, query, params...)
+	}
+
+	q := &Query{
+		Kind:   QueryKindExec,
+		Query:  query,
+		Params: params,
+	}
+	err := db.withMiddleware(func(ctx context.Context, q *Query) error {
+		var err error
+		q.Result, err = db.runExec(conn, ctx, tx, newQuery, q.Query, q.Params...)
+		return err
+	})(ctx, q)
+
+	return q.Result, err
+}
+
+// runExec executes the query, after it went through the middleware
+func (db *Database) runExec(conn handlerWithContext, ctx context.Context, tx *Tx, newQuery bool, query string, params ...any) (sql.Result, error) {
+	params = tenantParams(ctx, params)
+
+	if err := checkTenantScope(ctx, query, false); err != nil {
+		return nil, err
+	}
+
+	replacedQuery, normalizedParams, err := db.interpolateParams(ctx, query, params...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to interpolate params: %w", err)
+		return nil, interpolateError(query, err)
+	}
+
+	return db.execInterpolated(conn, ctx, tx, newQuery, query, replacedQuery, normalizedParams)
+}
+
+// execMarshaled executes a query whose values were already marshaled into it, like a chunk of an insert,
+// passing it through the middleware as an exec, and only interpolating it again if the middleware added params
+func (db *Database) execMarshaled(conn handlerWithContext, ctx context.Context, tx *Tx, query string) (sql.Result, error) {
+	if len(db.middleware) == 0 {
+		return db.execInterpolated(conn, ctx, tx, true, query, query, nil)
+	}
+
+	q := &Query{
+		Kind:  QueryKindExec,
+		Query: query,
+	}
+	err := db.withMiddleware(func(ctx context.Context, q *Query) error {
+		var err error
+		if len(q.Params) == 0 {
+			q.Result, err = db.execInterpolated(conn, ctx, tx, true, q.Query, q.Query, nil)
+		} else {
+			q.Result, err = db.runExec(conn, ctx, tx, true, q.Query, q.Params...)
+		}
+		return err
+	})(ctx, q)
+
+	return q.Result, err
+}
+
+// execInterpolated executes a query whose params were already interpolated,
+// like an insert whose rows were marshaled as they were added to it
+func (db *Database) execInterpolated(conn handlerWithContext, ctx context.Context, tx *Tx, newQuery bool, query, replacedQuery string, normalizedParams Params) (sql.Result, error) {
+	if db.readOnly {
+		return nil, ReadOnlyError{Op: "exec", Query: query}
 	}
 
 	if db.die {
@@ -28,22 +92,40 @@ func (db *Database) exec(conn handlerWithContext, ctx context.Context, tx *Tx, n
 		os.Exit(0)
 	}
 
+	if err := db.checkStatementPolicy(replacedQuery); err != nil {
+		return nil, err
+	}
+
+	if err := db.checkFullTableWrite(ctx, replacedQuery); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := db.totalContext(ctx)
+	defer cancel()
+
+	if err := db.admit(ctx, conn); err != nil {
+		return nil, err
+	}
+
 	start := time.Now()
+	startTime := db.now()
 	var res sql.Result
 
-	var b = backoff.NewExponentialBackOff()
-	b.MaxElapsedTime = MaxExecutionTime
+	b := db.retryBackOff(ctx)
 	var attempt int
 	var rowsAffected int64
 	exec := func() error {
 		attempt++
+		attemptCtx, cancelAttempt := db.attemptContext(ctx)
+		defer cancelAttempt()
+
 		var err error
-		res, err = conn.ExecContext(ctx, replacedQuery)
+		res, err = conn.ExecContext(attemptCtx, db.commented(attemptCtx, replacedQuery))
 		if res != nil {
 			rowsAffected, _ = res.RowsAffected()
 		}
 		realTx, _ := conn.(*sql.Tx)
-		db.callLog(LogDetail{
+		db.callLog(withQueryName(ctx, LogDetail{
 			Query:        replacedQuery,
 			Params:       normalizedParams,
 			Duration:     time.Since(start),
@@ -51,10 +133,9 @@ func (db *Database) exec(conn handlerWithContext, ctx context.Context, tx *Tx, n
 			Tx:           realTx,
 			Attempt:      attempt,
 			Error:        err,
-		})
+		}))
 		if err != nil {
-			var handleDeadlock func(err error) error
-			handleDeadlock = func(err error) error {
+			handleDeadlock := func(err error) error {
 				if tx == nil || !checkDeadlockError(err) {
 					return nil
 				}
@@ -66,17 +147,15 @@ func (db *Database) exec(conn handlerWithContext, ctx context.Context, tx *Tx, n
 				}
 
 				// deadlock occurred, which means *every* query in this transaction
-				// was rolled back, so we need to run them all again
+				// was rolled back, so we need to run them all again. They're replayed as they were
+				// run, already interpolated, so they aren't scoped or passed through the middleware again.
 				tx.updates.RLock()
 				defer tx.updates.RUnlock()
 
 				for _, q := range tx.updates.queries {
-					_, err := db.exec(conn, ctx, nil, false, q)
-					if err := handleDeadlock(err); err != nil {
-						return err
-					}
-					if err != nil {
-						return err
+					if _, err := db.execInterpolated(conn, ctx, tx, false, q, q, nil); err != nil {
+						// the transaction is missing a write now, so retrying this query can't fix it
+						return backoff.Permanent(fmt.Errorf("failed to replay transaction after deadlock: %w", err))
 					}
 				}
 
@@ -87,7 +166,13 @@ func (db *Database) exec(conn handlerWithContext, ctx context.Context, tx *Tx, n
 				return err
 			}
 
-			if checkRetryError(err) {
+			// a write whose attempt timed out may have been applied too
+			timedOut := retryTimedOutAttempt(conn, attemptCtx, ctx)
+			if db.NoRetryAfterSend && (isAmbiguousError(err) || timedOut) && !idempotentWrites(ctx) {
+				return backoff.Permanent(AmbiguousWriteError{Err: err})
+			}
+
+			if checkRetryError(err) || timedOut {
 				return err
 			} else if errors.Is(err, mysql.ErrInvalidConn) {
 				return db.Test()
@@ -99,7 +184,27 @@ func (db *Database) exec(conn handlerWithContext, ctx context.Context, tx *Tx, n
 		return nil
 	}
 
-	err = backoff.Retry(exec, backoff.WithContext(b, ctx))
+	err := b.err(backoff.Retry(exec, backoff.WithContext(b, ctx)))
+
+	if db.Audit != nil && newQuery {
+		var txID uint64
+		if tx != nil {
+			txID = tx.ID
+		}
+		db.callAudit(ctx, AuditEntry{
+			Query:        redactQuery(replacedQuery),
+			TxID:         txID,
+			RowsAffected: rowsAffected,
+			Time:         startTime,
+			Duration:     time.Since(start),
+			Error:        err,
+		})
+	}
+
+	if newQuery {
+		db.shadowWrite(tx, replacedQuery, rowsAffected, err)
+	}
+
 	if err != nil {
 		return nil, Error{
 			Err:           err,
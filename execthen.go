@@ -0,0 +1,57 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// ExecThen executes the query, then selects the followup into dest on the same connection,
+// for session state that's only on the connection that ran the query, like `select last_insert_id()`,
+// `select row_count()`, or a user variable the query set, which would race with other queries
+// if the followup could run on any connection of the pool. Both get the params.
+//
+// If the connection is lost while executing the query, both are retried on a new connection,
+// unless the query can't be retried after it's sent, see NoRetryAfterSend. If it's lost after,
+// the followup fails instead of running on another connection that doesn't have the state.
+func (db *Database) ExecThen(ctx context.Context, query, followup string, dest any, params ...any) (sql.Result, error) {
+	var res sql.Result
+
+	b := db.retryBackOff(ctx)
+	err := backoff.Retry(func() error {
+		conn, err := db.Writes.Conn(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get connection: %w", err)
+		}
+		defer conn.Close()
+
+		res, err = db.exec(conn, ctx, nil, true, query, params...)
+		if err != nil {
+			if isLostConn(err) && (!db.NoRetryAfterSend || idempotentWrites(ctx)) {
+				return err
+			}
+			return backoff.Permanent(err)
+		}
+
+		// the session state is gone with the connection, so the followup is never retried on another
+		if err := db.query(conn, ctx, dest, followup, 0, params...); err != nil {
+			return backoff.Permanent(fmt.Errorf("failed to run followup on the same connection: %w", err))
+		}
+
+		return nil
+	}, backoff.WithContext(b, ctx))
+	if err := b.err(err); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// isLostConn returns true if the error is from a connection that was lost, or already closed
+func isLostConn(err error) bool {
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || isAmbiguousError(err)
+}
@@ -0,0 +1,37 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDatabase_ExecThen(t *testing.T) {
+	db := benchDatabase(t)
+
+	// with one connection, a followup that isn't run on the connection the
+	// query checked out would wait for it until the context times out
+	db.Writes.SetMaxOpenConns(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var queries []string
+	db.Log = func(detail LogDetail) {
+		queries = append(queries, detail.Query)
+	}
+
+	var ids []struct{ Text0 string }
+	res, err := db.ExecThen(ctx, "insert into`users`(`Name`)values(@@Name)", "select last_insert_id()`Text0`", &ids, Params{"Name": "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		t.Errorf("ExecThen() affected %d rows, want 1", n)
+	}
+	if len(ids) == 0 {
+		t.Error("ExecThen() didn't select into dest")
+	}
+	if len(queries) != 2 {
+		t.Errorf("ExecThen() ran %q, want the query and its followup", queries)
+	}
+}
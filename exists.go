@@ -12,20 +12,56 @@ import (
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
-	"github.com/go-redsync/redsync/v4"
 	"github.com/go-sql-driver/mysql"
-	"github.com/redis/go-redis/v9"
 	"golang.org/x/crypto/sha3"
 )
 
 // exists efficiently checks if there are any rows in the given query
-func (db *Database) exists(conn handlerWithContext, ctx context.Context, query string, cacheDuration time.Duration, params ...any) (exists bool, err error) {
+func (db *Database) exists(conn handlerWithContext, ctx context.Context, query string, cacheDuration time.Duration, params ...any) (bool, error) {
+	if len(db.middleware) == 0 {
+		return db.runExists(conn, ctx, query, cacheDuration, params...)
+	}
+
+	q := &Query{
+		Kind:   QueryKindExists,
+		Query:  query,
+		Params: params,
+		Cache:  cacheDuration,
+	}
+	err := db.withMiddleware(func(ctx context.Context, q *Query) error {
+		var err error
+		q.Exists, err = db.runExists(conn, ctx, q.Query, q.Cache, q.Params...)
+		return err
+	})(ctx, q)
+
+	return q.Exists, err
+}
+
+// runExists checks if there are any rows in the query, after it went through the middleware
+func (db *Database) runExists(conn handlerWithContext, ctx context.Context, query string, cacheDuration time.Duration, params ...any) (exists bool, err error) {
+	ctx, cancelTotal := db.totalContext(ctx)
+	defer cancelTotal()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	replacedQuery, normalizedParams, err := db.interpolateParams(query, params...)
+	params = tenantParams(ctx, params)
+
+	lock, params, err := lockingClauseFromParams(conn, params)
+	if err != nil {
+		return false, err
+	}
+	if lock != nil {
+		// locked rows have to come from the database itself
+		cacheDuration = 0
+	}
+
+	replacedQuery, normalizedParams, err := db.interpolateParams(ctx, query, params...)
 	if err != nil {
-		return false, fmt.Errorf("failed to interpolate params: %w", err)
+		return false, interpolateError(query, err)
+	}
+	if lock != nil {
+		replacedQuery = appendLockingClause(replacedQuery, lock, db.serverInfoContext(ctx).Flavor)
 	}
 
 	if db.die {
@@ -33,6 +69,14 @@ func (db *Database) exists(conn handlerWithContext, ctx context.Context, query s
 		os.Exit(0)
 	}
 
+	if err := db.checkReadOnly(replacedQuery); err != nil {
+		return false, err
+	}
+
+	if err := db.checkStatementPolicy(replacedQuery); err != nil {
+		return false, err
+	}
+
 	defer func() {
 		if err != nil {
 			err = Error{
@@ -49,7 +93,7 @@ func (db *Database) exists(conn handlerWithContext, ctx context.Context, query s
 	if cacheDuration > 0 {
 		key := new(strings.Builder)
 		key.WriteString("cool-mysql:exists:")
-		key.WriteString(replacedQuery)
+		key.WriteString(db.cacheKeyQuery(replacedQuery))
 		key.WriteByte(':')
 		key.WriteString(strconv.FormatInt(int64(cacheDuration), 10))
 
@@ -58,38 +102,25 @@ func (db *Database) exists(conn handlerWithContext, ctx context.Context, query s
 
 		start := time.Now()
 
-	CHECK_CACHE:
-		exists, err = db.redis.Get(ctx, cacheKey).Bool()
-		if errors.Is(err, redis.Nil) {
-			// cache miss!
-
-			// grab a lock so we can update the cache
-			mutex := db.rs.NewMutex(cacheKey+":mutex", redsync.WithTries(1))
-
-			if err = mutex.Lock(); err != nil {
-				// if we couldn't get the lock, then just check the cache again
-				time.Sleep(RedisLockRetryDelay)
-				goto CHECK_CACHE
-			}
-
-			unlock := func() {
-				if mutex != nil && len(mutex.Value()) != 0 {
-					if _, err = mutex.Unlock(); err != nil {
-						db.Logger.Warn(fmt.Sprintf("failed to unlock redis mutex: %v", err))
-					}
-				}
-			}
-
+		// on a miss, the cache is filled under the key's lock, which is held until the result is cached
+		var b []byte
+		var hit bool
+		var unlock func()
+		b, hit, unlock, err = db.cacheGetOrLock(ctx, cacheKey)
+		if unlock != nil {
 			defer unlock()
-		} else if err != nil {
-			err = fmt.Errorf("failed to get data from redis: %w", err)
-			if db.HandleRedisError != nil {
-				err = db.HandleRedisError(err)
-			}
-			if err != nil {
+		}
+		if errors.Is(err, ErrCacheLockTimeout) {
+			return false, err
+		}
+		if err == nil && hit {
+			exists, err = strconv.ParseBool(string(b))
+		}
+		if err != nil {
+			if err = db.handleCacheGetError(ctx, err); err != nil {
 				return
 			}
-		} else {
+		} else if hit {
 			tx, _ := conn.(*sql.Tx)
 			db.callLog(LogDetail{
 				Query:    replacedQuery,
@@ -110,15 +141,28 @@ func (db *Database) exists(conn handlerWithContext, ctx context.Context, query s
 		}
 	}()
 
+	if err = db.admit(ctx, conn); err != nil {
+		return false, err
+	}
+
 	start := time.Now()
 
-	var b = backoff.NewExponentialBackOff()
-	b.MaxElapsedTime = MaxExecutionTime
+	b := db.retryBackOff(ctx)
 	var attempt int
+
+	cancelAttempt := context.CancelFunc(func() {})
+	defer func() {
+		cancelAttempt()
+	}()
+
 	err = backoff.Retry(func() error {
 		attempt++
+		cancelAttempt()
+		var attemptCtx context.Context
+		attemptCtx, cancelAttempt = db.attemptContext(ctx)
+
 		var err error
-		rows, err = conn.QueryContext(ctx, replacedQuery)
+		rows, err = conn.QueryContext(attemptCtx, db.commented(attemptCtx, replacedQuery))
 		tx, _ := conn.(*sql.Tx)
 		db.callLog(LogDetail{
 			Query:    replacedQuery,
@@ -129,7 +173,7 @@ func (db *Database) exists(conn handlerWithContext, ctx context.Context, query s
 			Error:    err,
 		})
 		if err != nil {
-			if checkRetryError(err) {
+			if checkRetryError(err) || retryTimedOutAttempt(conn, attemptCtx, ctx) {
 				return err
 			} else if errors.Is(err, mysql.ErrInvalidConn) {
 				return db.Test()
@@ -140,6 +184,7 @@ func (db *Database) exists(conn handlerWithContext, ctx context.Context, query s
 
 		return nil
 	}, backoff.WithContext(b, ctx))
+	err = b.err(err)
 	if err != nil {
 		return
 	}
@@ -150,12 +195,13 @@ func (db *Database) exists(conn handlerWithContext, ctx context.Context, query s
 	}
 
 	if len(cacheKey) != 0 {
-		err = db.redis.Set(ctx, cacheKey, exists, cacheDuration).Err()
-		if err != nil {
+		err = db.redis.Set(ctx, cacheKey, exists, db.cacheTTL(cacheDuration)).Err()
+		if err != nil && db.HandleRedisError != nil {
+			// exists has always returned its set errors, whatever the legacy handler returned
 			err = fmt.Errorf("failed to set redis cache: %w", err)
-			if db.HandleRedisError != nil {
-				db.HandleRedisError(err)
-			}
+			db.HandleRedisError(err)
+		} else if err != nil {
+			err = db.handleCacheSetError(ctx, err)
 		}
 	}
 
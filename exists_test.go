@@ -0,0 +1,57 @@
+package mysql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mysql "github.com/StirlingMarketingGroup/cool-mysql"
+	"github.com/StirlingMarketingGroup/cool-mysql/bench"
+	"github.com/redis/go-redis/v9"
+)
+
+// ttlHook records the ttls of the values cached with `set ... px`
+type ttlHook struct {
+	ttls *[]time.Duration
+}
+
+func (ttlHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h ttlHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if c, ok := cmd.(*redis.StatusCmd); ok && cmd.Name() == "set" {
+			args := c.Args()
+			for i := 3; i+1 < len(args); i++ {
+				if args[i] == "px" {
+					*h.ttls = append(*h.ttls, time.Duration(args[i+1].(int64))*time.Millisecond)
+				}
+				if args[i] == "ex" {
+					*h.ttls = append(*h.ttls, time.Duration(args[i+1].(int64))*time.Second)
+				}
+			}
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (ttlHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func TestExistsCacheTTL(t *testing.T) {
+	var ttls []time.Duration
+	client := bench.Redis(t)
+	client.AddHook(ttlHook{&ttls})
+
+	db := bench.Database(t, bench.WideDriver(1, 8, 1))
+	db.EnableRedis(client)
+
+	view := db.With(mysql.WithCacheTTLMultiplier(2))
+	if _, err := view.Exists("select`Text0`from`Wide`", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ttls) != 1 || ttls[0] != 2*time.Minute {
+		t.Errorf("Exists() cached for %v, want [2m0s]", ttls)
+	}
+}
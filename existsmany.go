@@ -0,0 +1,61 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+)
+
+// ExistsMany checks which of the keys exist with one query per chunk of keys, instead of
+// one Exists per key. The query selects the keys that exist from the chunk in `@@Keys`,
+// like "select`ID`from`users`where`ID`in(@@Keys)", so the keys have to be the only
+// selected column. Every key is in the returned map, with whether it exists.
+func ExistsMany[K comparable](ctx context.Context, db *Database, q string, keys []K, params ...any) (map[K]bool, error) {
+	exists := make(map[K]bool, len(keys))
+	for _, chunk := range uniqueChunks(keys, ExistsManyChunkSize) {
+		for _, k := range chunk {
+			exists[k] = false
+		}
+
+		var found []K
+		err := db.SelectContext(ctx, &found, q, 0, append(params[:len(params):len(params)], Params{"Keys": chunk})...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check which keys exist: %w", err)
+		}
+
+		for _, k := range found {
+			if _, ok := exists[k]; ok {
+				exists[k] = true
+			}
+		}
+	}
+
+	return exists, nil
+}
+
+// uniqueChunks splits the unique keys into chunks of at most size keys, in their original order
+func uniqueChunks[K comparable](keys []K, size int) [][]K {
+	if size < 1 {
+		size = 1
+	}
+
+	seen := make(map[K]struct{}, len(keys))
+	var chunks [][]K
+	var chunk []K
+	for _, k := range keys {
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+
+		chunk = append(chunk, k)
+		if len(chunk) == size {
+			chunks = append(chunks, chunk)
+			chunk = nil
+		}
+	}
+	if len(chunk) != 0 {
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks
+}
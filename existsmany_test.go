@@ -0,0 +1,27 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_uniqueChunks(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []int
+		size int
+		want [][]int
+	}{
+		{"empty", nil, 2, nil},
+		{"one chunk", []int{1, 2}, 2, [][]int{{1, 2}}},
+		{"remainder", []int{1, 2, 3, 4, 5}, 2, [][]int{{1, 2}, {3, 4}, {5}}},
+		{"duplicates", []int{1, 1, 2, 1, 3}, 2, [][]int{{1, 2}, {3}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := uniqueChunks(tt.keys, tt.size); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("uniqueChunks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
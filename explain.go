@@ -0,0 +1,173 @@
+package mysql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ExplainPlan is the plan MySQL chose for a query, from `EXPLAIN FORMAT=JSON`
+type ExplainPlan struct {
+	QueryBlock ExplainQueryBlock `json:"query_block"`
+
+	// JSON is the whole plan, including the parts not in the typed structs
+	JSON json.RawMessage `json:"-"`
+
+	// Analyze is the measured plan tree from `EXPLAIN ANALYZE`, only set by ExplainAnalyze
+	Analyze string `json:"-"`
+}
+
+// ExplainQueryBlock is a select of the plan
+type ExplainQueryBlock struct {
+	SelectID int          `json:"select_id"`
+	Message  string       `json:"message"`
+	CostInfo *ExplainCost `json:"cost_info"`
+
+	Table      *ExplainTable       `json:"table"`
+	NestedLoop []ExplainNestedLoop `json:"nested_loop"`
+}
+
+// ExplainNestedLoop is one of the tables joined by a nested loop
+type ExplainNestedLoop struct {
+	Table ExplainTable `json:"table"`
+}
+
+// ExplainCost is the optimizer's cost estimate of part of a plan
+type ExplainCost struct {
+	QueryCost       string `json:"query_cost"`
+	ReadCost        string `json:"read_cost"`
+	EvalCost        string `json:"eval_cost"`
+	PrefixCost      string `json:"prefix_cost"`
+	DataReadPerJoin string `json:"data_read_per_join"`
+}
+
+// ExplainTable is how a table is read in the plan
+type ExplainTable struct {
+	TableName string `json:"table_name"`
+
+	// AccessType is how rows are found, where "ALL" is a full table scan
+	AccessType   string   `json:"access_type"`
+	PossibleKeys []string `json:"possible_keys"`
+	Key          string   `json:"key"`
+	UsedKeyParts []string `json:"used_key_parts"`
+	KeyLength    string   `json:"key_length"`
+	Ref          []string `json:"ref"`
+
+	RowsExaminedPerScan int64  `json:"rows_examined_per_scan"`
+	RowsProducedPerJoin int64  `json:"rows_produced_per_join"`
+	Filtered            string `json:"filtered"`
+
+	UsingIndex               bool            `json:"using_index"`
+	UsingTemporary           bool            `json:"using_temporary_table"`
+	UsingFilesort            bool            `json:"using_filesort"`
+	CostInfo                 *ExplainCost    `json:"cost_info"`
+	UsedColumns              []string        `json:"used_columns"`
+	AttachedCondition        string          `json:"attached_condition"`
+	MaterializedFromSubquery json.RawMessage `json:"materialized_from_subquery"`
+}
+
+// FullScan returns true if every row of the table is read
+func (t ExplainTable) FullScan() bool {
+	return t.AccessType == "ALL"
+}
+
+// Tables returns every table read by the plan, including the ones in subqueries,
+// unions, and sorting and grouping operations
+func (p ExplainPlan) Tables() []ExplainTable {
+	var root any
+	if err := json.Unmarshal(p.JSON, &root); err != nil {
+		return nil
+	}
+
+	var tables []ExplainTable
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for k, child := range v {
+				if k == "table" {
+					if _, ok := child.(map[string]any); ok {
+						j, _ := json.Marshal(child)
+						var t ExplainTable
+						if json.Unmarshal(j, &t) == nil {
+							tables = append(tables, t)
+						}
+					}
+				}
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(root)
+
+	return tables
+}
+
+// Explain returns the plan MySQL would use for the query with its params
+func (db *Database) Explain(ctx context.Context, query string, params ...any) (ExplainPlan, error) {
+	var plan ExplainPlan
+
+	j, err := db.explain(ctx, "explain format=json ", query, params...)
+	if err != nil {
+		return plan, err
+	}
+
+	if err := json.Unmarshal([]byte(j), &plan); err != nil {
+		return plan, fmt.Errorf("failed to unmarshal explain plan: %w", err)
+	}
+	plan.JSON = json.RawMessage(j)
+
+	return plan, nil
+}
+
+// ExplainAnalyze runs the query with `EXPLAIN ANALYZE` and returns its plan
+// along with the measured plan tree. The query is actually executed, so it should
+// only be used for selects.
+func (db *Database) ExplainAnalyze(ctx context.Context, query string, params ...any) (ExplainPlan, error) {
+	plan, err := db.Explain(ctx, query, params...)
+	if err != nil {
+		return plan, err
+	}
+
+	plan.Analyze, err = db.explain(ctx, "explain analyze ", query, params...)
+	if err != nil {
+		return plan, err
+	}
+
+	return plan, nil
+}
+
+func (db *Database) explain(ctx context.Context, prefix string, query string, params ...any) (string, error) {
+	replacedQuery, normalizedParams, err := db.interpolateParams(ctx, query, params...)
+	if err != nil {
+		return "", fmt.Errorf("failed to interpolate params: %w", err)
+	}
+
+	explainQuery := prefix + replacedQuery
+
+	start := time.Now()
+	var out string
+	err = db.Reads.QueryRowContext(ctx, explainQuery).Scan(&out)
+	db.callLog(LogDetail{
+		Query:    explainQuery,
+		Params:   normalizedParams,
+		Duration: time.Since(start),
+		Attempt:  1,
+		Error:    err,
+	})
+	if err != nil {
+		return "", Error{
+			Err:           err,
+			OriginalQuery: query,
+			ReplacedQuery: explainQuery,
+			Params:        normalizedParams,
+		}
+	}
+
+	return out, nil
+}
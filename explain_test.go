@@ -0,0 +1,41 @@
+package mysql
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestExplainPlanTables(t *testing.T) {
+	j := `{"query_block":{"select_id":1,"cost_info":{"query_cost":"3.10"},"ordering_operation":{"using_filesort":true,
+		"nested_loop":[{"table":{"table_name":"u","access_type":"ALL","rows_examined_per_scan":10}},
+		{"table":{"table_name":"o","access_type":"ref","key":"UserID","ref":["db.u.ID"],
+		"materialized_from_subquery":{"query_block":{"table":{"table_name":"i","access_type":"index"}}}}}]}}}`
+
+	var plan ExplainPlan
+	if err := json.Unmarshal([]byte(j), &plan); err != nil {
+		t.Fatal(err)
+	}
+	plan.JSON = json.RawMessage(j)
+
+	if plan.QueryBlock.CostInfo == nil || plan.QueryBlock.CostInfo.QueryCost != "3.10" {
+		t.Errorf("QueryBlock.CostInfo = %+v, want query cost 3.10", plan.QueryBlock.CostInfo)
+	}
+
+	names := make(map[string]ExplainTable)
+	for _, table := range plan.Tables() {
+		names[table.TableName] = table
+	}
+
+	if len(names) != 3 {
+		t.Fatalf("Tables() returned %d tables, want 3: %+v", len(names), names)
+	}
+	if !names["u"].FullScan() || names["u"].RowsExaminedPerScan != 10 {
+		t.Errorf("table u = %+v, want a full scan of 10 rows", names["u"])
+	}
+	if names["o"].FullScan() || names["o"].Key != "UserID" {
+		t.Errorf("table o = %+v, want ref on UserID", names["o"])
+	}
+	if _, ok := names["i"]; !ok {
+		t.Errorf("Tables() is missing the materialized subquery's table")
+	}
+}
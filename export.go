@@ -0,0 +1,225 @@
+package mysql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// ExportCheckpoint is how far an export got, see Export
+type ExportCheckpoint struct {
+	// After is the key of the last exported row, as it's written in a query, or empty before the first page
+	After string
+
+	// Rows is the number of rows exported so far
+	Rows int64
+
+	// Done is true once every row is exported
+	Done bool
+}
+
+// ExportCheckpointStore saves the checkpoints of exports by their names, so they can resume after a crash
+type ExportCheckpointStore interface {
+	// LoadExportCheckpoint returns the export's last saved checkpoint, or false if it has none
+	LoadExportCheckpoint(ctx context.Context, name string) (ExportCheckpoint, bool, error)
+
+	// SaveExportCheckpoint saves the export's checkpoint, replacing its last one
+	SaveExportCheckpoint(ctx context.Context, name string, checkpoint ExportCheckpoint) error
+}
+
+// ExportOptions configure an Export
+type ExportOptions struct {
+	// Name identifies the export's checkpoints in the store
+	Name string
+
+	// Key is the column the rows are paged by, which has to be selected by the query,
+	// unique and never null, and ideally the primary key of the table the rows come from
+	Key string
+
+	// PageSize is the most rows selected, and passed to the export's func, at once,
+	// which is 1000 by default
+	PageSize int
+
+	// Store saves the export's checkpoints. Without one, the export can't resume.
+	Store ExportCheckpointStore
+}
+
+// Export selects every row of the query, page by page in the order of the options' key, and calls fn
+// with each page, saving a checkpoint to the store once fn returns, so an export that fails,
+// or whose process crashes, resumes after the last page fn finished instead of from the first row.
+// An export whose checkpoint is done returns right away, so its store's checkpoint has to be
+// deleted to run it again.
+//
+// The pages are selected by keyset pagination, like `where key > last key order by key limit n`,
+// so each page is as fast as the first, and all of them in one snapshot of the database, see SnapshotTx,
+// so the rows of a run are consistent with each other. Rows written after a run's snapshot are only
+// exported if the export resumes in a later run, and their keys come after its checkpoint.
+// Since the snapshot is held for the whole run, very long exports keep old versions of rows around
+// in the server's undo log until they finish, like mysqldump's --single-transaction does.
+//
+// fn can be called with the same page again if it fails, or the process crashes before its checkpoint is saved,
+// so it should write each page in a way that can be repeated, like upserting it.
+func Export[T any](ctx context.Context, db *Database, q string, opts ExportOptions, fn func(ctx context.Context, rows []T) error, params ...any) error {
+	if len(opts.Key) == 0 {
+		return fmt.Errorf("cool-mysql: export needs a key")
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = 1000
+	}
+
+	key, err := mergeColumnOf(reflect.TypeOf((*T)(nil)).Elem(), opts.Key)
+	if err != nil {
+		return err
+	}
+
+	var checkpoint ExportCheckpoint
+	if opts.Store != nil {
+		cp, ok, err := opts.Store.LoadExportCheckpoint(ctx, opts.Name)
+		if err != nil {
+			return fmt.Errorf("failed to load export checkpoint: %w", err)
+		}
+		if ok {
+			checkpoint = cp
+		}
+	}
+	if checkpoint.Done {
+		return nil
+	}
+
+	tx, err := db.SnapshotTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Close()
+
+	col := quoteIdentifier(opts.Key)
+	for {
+		pq := "select*from(" + q + ")`cool_mysql_export`"
+		if len(checkpoint.After) != 0 {
+			pq += "where" + col + ">" + checkpoint.After
+		}
+		pq += " order by" + col + " limit " + strconv.Itoa(opts.PageSize)
+
+		var page []T
+		if err := tx.SelectContext(ctx, &page, pq, 0, params...); err != nil {
+			return fmt.Errorf("failed to select export page: %w", err)
+		}
+
+		if len(page) != 0 {
+			if err := fn(ctx, page); err != nil {
+				return err
+			}
+
+			last := key.get(reflect.ValueOf(page[len(page)-1]))
+			if last == nil {
+				return fmt.Errorf("cool-mysql: export key %q can't be null", opts.Key)
+			}
+			after, err := marshal(last, 0, "", db.valuers())
+			if err != nil {
+				return fmt.Errorf("failed to marshal export key: %w", err)
+			}
+
+			checkpoint.After = string(after)
+			checkpoint.Rows += int64(len(page))
+		}
+		checkpoint.Done = len(page) < opts.PageSize
+
+		if opts.Store != nil {
+			if err := opts.Store.SaveExportCheckpoint(ctx, opts.Name, checkpoint); err != nil {
+				return fmt.Errorf("failed to save export checkpoint: %w", err)
+			}
+		}
+
+		if checkpoint.Done {
+			return nil
+		}
+	}
+}
+
+// ExportCheckpointFile is an ExportCheckpointStore that saves the checkpoints of every export
+// as JSON in a file, which is replaced atomically so a crash can't leave it half written
+type ExportCheckpointFile struct {
+	Path string
+
+	mx sync.Mutex
+}
+
+// NewExportCheckpointFile returns a store of export checkpoints in the file at path,
+// which is created when the first checkpoint is saved
+func NewExportCheckpointFile(path string) *ExportCheckpointFile {
+	return &ExportCheckpointFile{Path: path}
+}
+
+func (f *ExportCheckpointFile) LoadExportCheckpoint(ctx context.Context, name string) (ExportCheckpoint, bool, error) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	checkpoints, err := f.load()
+	if err != nil {
+		return ExportCheckpoint{}, false, err
+	}
+
+	cp, ok := checkpoints[name]
+	return cp, ok, nil
+}
+
+func (f *ExportCheckpointFile) SaveExportCheckpoint(ctx context.Context, name string, checkpoint ExportCheckpoint) error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	checkpoints, err := f.load()
+	if err != nil {
+		return err
+	}
+	checkpoints[name] = checkpoint
+
+	j, err := json.Marshal(checkpoints)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), filepath.Base(f.Path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(j); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+// load returns the checkpoints in the file, or none if it doesn't exist yet
+func (f *ExportCheckpointFile) load() (map[string]ExportCheckpoint, error) {
+	checkpoints := make(map[string]ExportCheckpoint)
+
+	j, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return checkpoints, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(j, &checkpoints); err != nil {
+		return nil, fmt.Errorf("failed to decode export checkpoints: %w", err)
+	}
+
+	return checkpoints, nil
+}
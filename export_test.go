@@ -0,0 +1,69 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	db := benchDatabase(t)
+
+	var queries []string
+	db.Log = func(detail LogDetail) {
+		queries = append(queries, detail.Query)
+	}
+
+	ctx := context.Background()
+	store := NewExportCheckpointFile(filepath.Join(t.TempDir(), "checkpoints.json"))
+	opts := ExportOptions{Name: "users", Key: "Text0", PageSize: 1000, Store: store}
+
+	// the bench driver always returns full pages, so the export is stopped on its second page
+	errStop := errors.New("stop")
+	pages := 0
+	err := Export(ctx, db, "select*from`users`", opts, func(ctx context.Context, rows []benchWideRow) error {
+		pages++
+		if pages == 2 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("Export() error = %v, want %v", err, errStop)
+	}
+
+	cp, ok, err := store.LoadExportCheckpoint(ctx, "users")
+	if err != nil || !ok {
+		t.Fatalf("LoadExportCheckpoint() = %v, %v", ok, err)
+	}
+	if cp.Rows != 1000 || len(cp.After) == 0 || cp.Done {
+		t.Errorf("LoadExportCheckpoint() = %+v, want 1000 rows after the first page", cp)
+	}
+
+	var pageQueries []string
+	for _, q := range queries {
+		if strings.HasPrefix(q, "select*from(") {
+			pageQueries = append(pageQueries, q)
+		}
+	}
+	want := []string{
+		"select*from(select*from`users`)`cool_mysql_export` order by`Text0` limit 1000",
+		"select*from(select*from`users`)`cool_mysql_export`where`Text0`>" + cp.After + " order by`Text0` limit 1000",
+	}
+	if strings.Join(pageQueries, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Export() ran %q, want %q", pageQueries, want)
+	}
+
+	cp.Done = true
+	if err := store.SaveExportCheckpoint(ctx, "users", cp); err != nil {
+		t.Fatal(err)
+	}
+	queries = nil
+	if err := Export(ctx, db, "select*from`users`", opts, func(ctx context.Context, rows []benchWideRow) error {
+		return errStop
+	}); err != nil || len(queries) != 0 {
+		t.Errorf("Export() of a done export = %v after %q, want nothing run", err, queries)
+	}
+}
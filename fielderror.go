@@ -0,0 +1,234 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	stdMysql "github.com/go-sql-driver/mysql"
+)
+
+// ConstraintKind is the kind of constraint a write violated, see FieldError
+type ConstraintKind int
+
+const (
+	// ConstraintUnique is a unique index or primary key that already has the row's values, error 1062
+	ConstraintUnique ConstraintKind = iota + 1
+
+	// ConstraintForeignKey is a foreign key whose parent row doesn't exist, error 1452
+	ConstraintForeignKey
+
+	// ConstraintCheck is a check constraint the row's values don't pass, error 3819
+	ConstraintCheck
+)
+
+func (k ConstraintKind) String() string {
+	switch k {
+	case ConstraintUnique:
+		return "unique"
+	case ConstraintForeignKey:
+		return "foreign key"
+	case ConstraintCheck:
+		return "check"
+	default:
+		return "unknown"
+	}
+}
+
+// FieldError is a column of a row whose write violated a constraint, with the field of the struct
+// it was written from, so it can be returned to whoever sent the row, like in a 400 response
+type FieldError struct {
+	// Field is the name of the struct field of the column, or the column if there's no struct field for it
+	Field string
+
+	// Column is the column of the constraint
+	Column string
+
+	// Constraint is the name of the index or constraint that was violated
+	Constraint string
+
+	Kind ConstraintKind
+
+	// Err is the error of the write
+	Err error
+}
+
+func (e FieldError) Error() string {
+	switch e.Kind {
+	case ConstraintUnique:
+		return fmt.Sprintf("%s is already taken (%s)", e.Field, e.Constraint)
+	case ConstraintForeignKey:
+		return fmt.Sprintf("%s doesn't match an existing row (%s)", e.Field, e.Constraint)
+	default:
+		return fmt.Sprintf("%s isn't valid (%s)", e.Field, e.Constraint)
+	}
+}
+
+func (e FieldError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	duplicateKeyRegexp = regexp.MustCompile(`for key '([^']*)'`)
+	foreignKeyRegexp   = regexp.MustCompile("CONSTRAINT `((?:[^`]|``)*)` FOREIGN KEY \\(((?:`(?:[^`]|``)*`(?:, ?)?)+)\\)")
+	checkRegexp        = regexp.MustCompile(`Check constraint '([^']*)' is violated`)
+	backtickedRegexp   = regexp.MustCompile("`((?:[^`]|``)*)`")
+)
+
+// FieldErrors returns the columns of the table that the write's error says violated a unique index, foreign key,
+// or check constraint, with the fields of the struct they were written from, if it's a struct or a slice of them,
+// like the source of an insert. Unique indexes and check constraints only give their names in the error,
+// so their columns are read from information_schema. It returns nothing if the error isn't one of those.
+func (db *Database) FieldErrors(ctx context.Context, table string, source any, writeErr error) ([]FieldError, error) {
+	var mysqlErr *stdMysql.MySQLError
+	if !errors.As(writeErr, &mysqlErr) {
+		return nil, nil
+	}
+
+	var kind ConstraintKind
+	var constraint string
+	var columns []string
+	switch mysqlErr.Number {
+	case 1062:
+		m := duplicateKeyRegexp.FindStringSubmatch(mysqlErr.Message)
+		if m == nil {
+			return nil, nil
+		}
+
+		// MySQL 8 prefixes the index with its table, like 'users.email'
+		kind, constraint = ConstraintUnique, m[1]
+		if i := strings.LastIndexByte(constraint, '.'); i != -1 {
+			constraint = constraint[i+1:]
+		}
+
+		indexes, err := db.TableIndexes(ctx, table, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, index := range indexes {
+			if index.Name == constraint {
+				columns = index.Columns
+				break
+			}
+		}
+	case 1452:
+		m := foreignKeyRegexp.FindStringSubmatch(mysqlErr.Message)
+		if m == nil {
+			return nil, nil
+		}
+
+		kind, constraint = ConstraintForeignKey, strings.ReplaceAll(m[1], "``", "`")
+		for _, c := range backtickedRegexp.FindAllStringSubmatch(m[2], -1) {
+			columns = append(columns, strings.ReplaceAll(c[1], "``", "`"))
+		}
+	case 3819:
+		m := checkRegexp.FindStringSubmatch(mysqlErr.Message)
+		if m == nil {
+			return nil, nil
+		}
+
+		kind, constraint = ConstraintCheck, m[1]
+		var err error
+		columns, err = db.checkConstraintColumns(ctx, table, constraint)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, nil
+	}
+
+	fields := fieldsOfColumns(source)
+
+	// the constraint is still reported if its columns couldn't be found, like for a functional index
+	if len(columns) == 0 {
+		return []FieldError{{Field: constraint, Constraint: constraint, Kind: kind, Err: writeErr}}, nil
+	}
+
+	fieldErrors := make([]FieldError, 0, len(columns))
+	for _, c := range columns {
+		field, ok := fields[strings.ToLower(c)]
+		if !ok {
+			field = c
+		}
+
+		fieldErrors = append(fieldErrors, FieldError{
+			Field:      field,
+			Column:     c,
+			Constraint: constraint,
+			Kind:       kind,
+			Err:        writeErr,
+		})
+	}
+
+	return fieldErrors, nil
+}
+
+// checkConstraintColumns returns the columns named in the clause of the check constraint of the table's schema
+func (db *Database) checkConstraintColumns(ctx context.Context, table string, constraint string) ([]string, error) {
+	schema, _ := splitTableName(table)
+
+	var schemaParam any
+	if len(schema) != 0 {
+		schemaParam = schema
+	}
+
+	var clause string
+	err := db.query(db.Reads, ctx, &clause, "select`CHECK_CLAUSE`"+
+		"from`information_schema`.`CHECK_CONSTRAINTS`"+
+		"where`CONSTRAINT_SCHEMA`=coalesce(@@Schema,database())and`CONSTRAINT_NAME`=@@Constraint", 0, Params{
+		"Schema":     schemaParam,
+		"Constraint": constraint,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get check constraint %q: %w", constraint, err)
+	}
+
+	var columns []string
+	seen := make(map[string]struct{})
+	for _, c := range backtickedRegexp.FindAllStringSubmatch(clause, -1) {
+		column := strings.ReplaceAll(c[1], "``", "`")
+		if _, ok := seen[column]; ok {
+			continue
+		}
+		seen[column] = struct{}{}
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
+
+// fieldsOfColumns returns the names of the struct fields of the lowercased columns
+// of the source, if it's a struct, or a slice, array, or channel of them
+func fieldsOfColumns(source any) map[string]string {
+	if source == nil {
+		return nil
+	}
+
+	t := reflectUnwrapType(reflect.TypeOf(source))
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Chan:
+		t = reflectUnwrapType(t.Elem())
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		return nil
+	}
+
+	_, _, colFieldMap, err := colNamesFromStruct(t)
+	if err != nil {
+		return nil
+	}
+
+	fields := make(map[string]string, len(colFieldMap))
+	for column, field := range colFieldMap {
+		fields[strings.ToLower(column)] = field
+	}
+
+	return fields
+}
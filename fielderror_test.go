@@ -0,0 +1,60 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	stdMysql "github.com/go-sql-driver/mysql"
+)
+
+func TestDatabase_FieldErrors(t *testing.T) {
+	type order struct {
+		UserID    int `mysql:"user_id"`
+		ProductID int `mysql:"product_id"`
+	}
+
+	db := benchDatabase(t)
+	tests := []struct {
+		name string
+		err  error
+		want []FieldError
+	}{
+		{"not a mysql error", errors.New("oops"), nil},
+		{"other mysql error", &stdMysql.MySQLError{Number: 1213, Message: "Deadlock found"}, nil},
+		{
+			"foreign key",
+			&stdMysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row: a foreign key constraint fails " +
+				"(`shop`.`orders`, CONSTRAINT `orders_user` FOREIGN KEY (`user_id`, `product_id`) REFERENCES `users` (`id`, `product_id`))"},
+			[]FieldError{
+				{Field: "UserID", Column: "user_id", Constraint: "orders_user", Kind: ConstraintForeignKey},
+				{Field: "ProductID", Column: "product_id", Constraint: "orders_user", Kind: ConstraintForeignKey},
+			},
+		},
+		{
+			"unique index without columns",
+			&stdMysql.MySQLError{Number: 1062, Message: "Duplicate entry '1' for key 'orders.orders_unique'"},
+			[]FieldError{{Field: "orders_unique", Constraint: "orders_unique", Kind: ConstraintUnique}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Wrap(tt.err, "", "", nil)
+			got, fieldErr := db.FieldErrors(context.Background(), "orders", []order{{1, 2}}, err)
+			if fieldErr != nil {
+				t.Fatal(fieldErr)
+			}
+
+			for i := range got {
+				if !errors.Is(got[i], tt.err) {
+					t.Errorf("FieldErrors()[%d] doesn't wrap the error", i)
+				}
+				got[i].Err = nil
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FieldErrors() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package mysql
+
+import (
+	"sort"
+	"strings"
+)
+
+// SetNormalizeCacheKeys sets whether cached results are keyed by the fingerprints of their queries,
+// see NormalizeCacheKeys. Rows of queries without an order by can be in any order anyway, so queries
+// whose `in()` lists only differ by their order can be served each other's rows in either order.
+func (db *Database) SetNormalizeCacheKeys(normalize bool) *Database {
+	db.NormalizeCacheKeys = normalize
+	return db
+}
+
+// cacheKeyQuery returns the query as it's written in its cache key
+func (db *Database) cacheKeyQuery(query string) string {
+	if !db.NormalizeCacheKeys {
+		return query
+	}
+
+	return fingerprintQuery(query)
+}
+
+// fingerprintQuery returns the query normalized so that equivalent queries are the same,
+// with its runs of whitespace collapsed, and the values of its `in()` lists sorted and deduplicated.
+// Queries with comments are returned as they are, since where their comments end depends on their whitespace.
+func fingerprintQuery(query string) string {
+	tokens := parseQuery(query)
+	for i, t := range tokens {
+		if t.kind != queryTokenKindMisc {
+			continue
+		}
+
+		var next string
+		if i+1 < len(tokens) {
+			next = tokens[i+1].string
+		}
+		if t.string == "#" || t.string == "-" && next == "-" || t.string == "/" && next == "*" {
+			return query
+		}
+	}
+
+	b := new(strings.Builder)
+	b.Grow(len(query))
+	appendFingerprint(b, tokens)
+
+	return b.String()
+}
+
+// appendFingerprint writes the tokens with a space in place of each run of whitespace that separates them,
+// or none next to parens and commas, which already separate them
+func appendFingerprint(b *strings.Builder, tokens []queryToken) {
+	var last *queryToken
+	space := false
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		if t.kind == queryTokenKindMisc && len(strings.TrimSpace(t.string)) == 0 {
+			space = true
+			continue
+		}
+
+		if space && last != nil && !isFingerprintSeparator(*last) && !isFingerprintSeparator(t) {
+			b.WriteByte(' ')
+		}
+		space = false
+
+		if t.string == "(" && last != nil && last.kind == queryTokenKindWord && strings.EqualFold(last.string, "in") {
+			if end, values, ok := inListValues(tokens, i); ok {
+				b.WriteByte('(')
+				b.WriteString(strings.Join(values, ","))
+				b.WriteByte(')')
+
+				i = end
+				last = &tokens[end]
+				continue
+			}
+		}
+
+		b.WriteString(t.string)
+		last = &tokens[i]
+	}
+}
+
+func isFingerprintSeparator(t queryToken) bool {
+	return t.kind == queryTokenKindParen || t.kind == queryTokenKindComma
+}
+
+// inListValues returns the index of the paren that closes the `in()` list opened at start,
+// and its values sorted and deduplicated, if it's a list of values without any parens,
+// so it can't have subqueries or function calls, whose order could matter
+func inListValues(tokens []queryToken, start int) (end int, values []string, ok bool) {
+	valueStart := start + 1
+	for i := start + 1; i < len(tokens); i++ {
+		t := tokens[i]
+		switch {
+		case t.string == "(":
+			return 0, nil, false
+		case t.kind == queryTokenKindComma || t.string == ")":
+			vb := new(strings.Builder)
+			appendFingerprint(vb, tokens[valueStart:i])
+			if vb.Len() == 0 {
+				return 0, nil, false
+			}
+			values = append(values, vb.String())
+			valueStart = i + 1
+
+			if t.string == ")" {
+				sort.Strings(values)
+
+				unique := values[:1]
+				for _, v := range values[1:] {
+					if v != unique[len(unique)-1] {
+						unique = append(unique, v)
+					}
+				}
+
+				return i, unique, true
+			}
+		}
+	}
+
+	return 0, nil, false
+}
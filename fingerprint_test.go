@@ -0,0 +1,29 @@
+package mysql
+
+import "testing"
+
+func Test_fingerprintQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"whitespace", "select *\n\tfrom `users`  where `ID` = 1 ", "select * from `users` where `ID` = 1"},
+		{"parens and commas", "select `ID` , `Name` from ( select 1 ) `u`", "select `ID`,`Name` from(select 1)`u`"},
+		{"in list", "select*from`users`where`ID`in( 3, 1,2 ,1)", "select*from`users`where`ID`in(1,2,3)"},
+		{"not in list", "select*from`users`where`ID`not in(3,1)", "select*from`users`where`ID`not in(1,3)"},
+		{"in strings", "where`Name`in(_utf8mb4 0x62 collate utf8mb4_unicode_ci,_utf8mb4 0x61 collate utf8mb4_unicode_ci)",
+			"where`Name`in(_utf8mb4 0x61 collate utf8mb4_unicode_ci,_utf8mb4 0x62 collate utf8mb4_unicode_ci)"},
+		{"subquery", "where`ID`in(select`ID`from`admins`where`Level`in(2,1))", "where`ID`in(select`ID`from`admins`where`Level`in(1,2))"},
+		{"function", "where`ID`in(abs(2),1)", "where`ID`in(abs(2),1)"},
+		{"strings", "select 'a  b' 'c'", "select 'a  b' 'c'"},
+		{"comment", "select 1 -- in(2,1)\n,2", "select 1 -- in(2,1)\n,2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fingerprintQuery(tt.query); got != tt.want {
+				t.Errorf("fingerprintQuery() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
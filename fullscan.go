@@ -0,0 +1,135 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// FullScanWarning is a table that's fully scanned by the plan of a select
+type FullScanWarning struct {
+	// Query is the select, with its params interpolated
+	Query string
+
+	Table string
+
+	// RowsExamined is the optimizer's estimate of the rows the scan reads
+	RowsExamined int64
+}
+
+// FullScanAnalyzer explains a sample of selects after they run, reporting the tables
+// their plans read in full, as a guardrail for queries that are fine in development
+// and slow in production. See Database.SetFullScanAnalyzer.
+type FullScanAnalyzer struct {
+	// SampleRate is the fraction of the matching selects that are explained, from 0 to 1
+	SampleRate float64
+
+	// MinRows is the fewest estimated rows of a full scan that's reported,
+	// since scanning a small table is usually faster than using an index anyway
+	MinRows int64
+
+	// Match returns true for the selects that are analyzed.
+	// By default, that's the selects without a where or limit clause.
+	Match func(query string) bool
+
+	// Warn is called with each full scan, like to count them in metrics.
+	// By default, they're logged as warnings.
+	Warn func(FullScanWarning)
+
+	// Timeout limits each explain, which is 5 seconds by default
+	Timeout time.Duration
+
+	explained int64
+	fullScans int64
+}
+
+// Counts returns the number of selects that were explained, and the number of full scans reported
+func (a *FullScanAnalyzer) Counts() (explained, fullScans int64) {
+	return atomic.LoadInt64(&a.explained), atomic.LoadInt64(&a.fullScans)
+}
+
+// SetFullScanAnalyzer sets the analyzer of the database's selects, or stops analyzing if it's nil.
+// Selects are explained in the background after they run, so they aren't slowed down.
+func (db *Database) SetFullScanAnalyzer(a *FullScanAnalyzer) *Database {
+	db.fullScanAnalyzer = a
+	return db
+}
+
+// missingWhereOrLimit returns true if the query is a select without a where or limit clause
+func missingWhereOrLimit(query string) bool {
+	isSelect := false
+	for _, t := range parseQuery(query) {
+		if t.kind != queryTokenKindWord {
+			continue
+		}
+
+		switch strings.ToLower(t.string) {
+		case "select":
+			isSelect = true
+		case "where", "limit":
+			return false
+		}
+	}
+
+	return isSelect
+}
+
+// sample returns true if the select should be explained
+func (a *FullScanAnalyzer) sample(query string) bool {
+	if a.SampleRate <= 0 || (a.SampleRate < 1 && rand.Float64() >= a.SampleRate) {
+		return false
+	}
+
+	match := a.Match
+	if match == nil {
+		match = missingWhereOrLimit
+	}
+
+	return match(query)
+}
+
+// analyzeFullScan explains the select in the background if it's sampled
+func (db *Database) analyzeFullScan(replacedQuery string) {
+	a := db.fullScanAnalyzer
+	if a == nil || !a.sample(replacedQuery) {
+		return
+	}
+
+	go func() {
+		timeout := a.Timeout
+		if timeout <= 0 {
+			timeout = 5 * time.Second
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		plan, err := db.Explain(ctx, replacedQuery)
+		if err != nil {
+			db.Logger.Warn(fmt.Sprintf("failed to explain select for full scans: %v", err))
+			return
+		}
+		atomic.AddInt64(&a.explained, 1)
+
+		for _, t := range plan.Tables() {
+			if !t.FullScan() || t.RowsExaminedPerScan < a.MinRows {
+				continue
+			}
+
+			atomic.AddInt64(&a.fullScans, 1)
+
+			w := FullScanWarning{
+				Query:        replacedQuery,
+				Table:        t.TableName,
+				RowsExamined: t.RowsExaminedPerScan,
+			}
+			if a.Warn != nil {
+				a.Warn(w)
+			} else {
+				db.Logger.Warn(fmt.Sprintf("select fully scans table %q, examining about %d rows: %s", w.Table, w.RowsExamined, w.Query))
+			}
+		}
+	}()
+}
@@ -0,0 +1,27 @@
+package mysql
+
+import "testing"
+
+func Test_missingWhereOrLimit(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"select*from`users`", true},
+		{"SELECT `ID` FROM `users` ORDER BY `ID`", true},
+		{"select*from`users`where`ID`=1", false},
+		{"select*from`users`limit 10", false},
+		{"select*from`users`where`Name`='limit'", false},
+		{"select*from`users`u join`orders`o using(`UserID`)", true},
+		{"select'where'from`users`", true},
+		{"select`where`from`users`", true},
+		{"update`users`set`Name`=''", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := missingWhereOrLimit(tt.query); got != tt.want {
+				t.Errorf("missingWhereOrLimit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
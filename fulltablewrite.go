@@ -0,0 +1,127 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrFullTableWrite is matched by the error of an update or delete rejected by the
+// database's FullTableWriteGuard
+var ErrFullTableWrite = errors.New("cool-mysql: update or delete could write the whole table")
+
+// FullTableWriteGuard rejects updates and deletes that could write every row of a table,
+// as a last line of defense against maintenance scripts with a missing or mistyped where clause.
+// Writes that are meant to touch the whole table can opt in with AllowFullTableWrite.
+// It's set with Database.SetFullTableWriteGuard.
+type FullTableWriteGuard struct {
+	// RequireWhere rejects updates and deletes without a where clause
+	RequireWhere bool
+
+	// MaxRows rejects updates and deletes whose plan is estimated to examine more rows
+	// in a table than this, which means every one of them is explained first, so it's
+	// best kept to scripts, where 0 means they aren't explained
+	MaxRows int64
+}
+
+// SetFullTableWriteGuard sets the guard of the database's updates and deletes, see FullTableWriteGuard
+func (db *Database) SetFullTableWriteGuard(guard FullTableWriteGuard) *Database {
+	db.FullTableWriteGuard = guard
+	return db
+}
+
+var fullTableWriteKey = key(10)
+
+// AllowFullTableWrite returns a new context.Context whose updates and deletes
+// aren't checked by the database's FullTableWriteGuard
+func AllowFullTableWrite(ctx context.Context) context.Context {
+	return context.WithValue(ctx, fullTableWriteKey, true)
+}
+
+// fullTableWriteAllowed returns true if the context's writes can write whole tables
+func fullTableWriteAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(fullTableWriteKey).(bool)
+	return allowed
+}
+
+// checkFullTableWrite returns an error if the guard rejects the query
+func (db *Database) checkFullTableWrite(ctx context.Context, replacedQuery string) error {
+	guard := db.FullTableWriteGuard
+	if !guard.RequireWhere && guard.MaxRows <= 0 {
+		return nil
+	}
+
+	verb, hasWhere := updateOrDeleteWhere(replacedQuery)
+	if len(verb) == 0 || fullTableWriteAllowed(ctx) {
+		return nil
+	}
+
+	if !hasWhere && guard.RequireWhere {
+		return fmt.Errorf("%w: %s without a where clause", ErrFullTableWrite, verb)
+	}
+
+	if guard.MaxRows <= 0 {
+		return nil
+	}
+
+	plan, err := db.Explain(ctx, replacedQuery)
+	if err != nil {
+		return fmt.Errorf("failed to explain %s for full table writes: %w", verb, err)
+	}
+
+	for _, t := range plan.Tables() {
+		if t.RowsExaminedPerScan > guard.MaxRows {
+			return fmt.Errorf("%w: %s examines about %d rows of %q, more than %d", ErrFullTableWrite, verb, t.RowsExaminedPerScan, t.TableName, guard.MaxRows)
+		}
+	}
+
+	return nil
+}
+
+// updateOrDeleteWhere returns "update" or "delete" if the query is one, after the common table expressions
+// of its with clause, if it has one, and whether it has a where clause of its own, instead of only in its
+// subqueries or common table expressions
+func updateOrDeleteWhere(query string) (verb string, hasWhere bool) {
+	depth := 0
+	with := false
+	afterParen := false
+	for _, t := range parseQuery(query) {
+		switch t.kind {
+		case queryTokenKindParen:
+			if t.string == "(" {
+				depth++
+			} else {
+				depth--
+			}
+			afterParen = depth == 0
+			continue
+		case queryTokenKindMisc:
+			if strings.TrimSpace(t.string) == "" {
+				continue
+			}
+		case queryTokenKindWord:
+			w := strings.ToLower(t.string)
+			if len(verb) == 0 {
+				switch {
+				case !with && w == "with":
+					with = true
+				case with && (depth != 0 || !afterParen || w == "as"):
+					// the names and queries of the common table expressions
+				case w != "update" && w != "delete":
+					return "", false
+				default:
+					verb = w
+				}
+				break
+			}
+
+			if depth == 0 && w == "where" {
+				return verb, true
+			}
+		}
+		afterParen = false
+	}
+
+	return verb, false
+}
@@ -0,0 +1,38 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDatabase_SetFullTableWriteGuard(t *testing.T) {
+	ctx := context.Background()
+	db := benchDatabase(t).SetFullTableWriteGuard(FullTableWriteGuard{RequireWhere: true})
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		query   string
+		wantErr bool
+	}{
+		{"update with where", ctx, "update`users`set`Active`=0 where`ID`=1", false},
+		{"delete with where", ctx, "DELETE FROM `users` WHERE `ID` IN (1, 2)", false},
+		{"insert", ctx, "insert into`users`(`ID`)values(1)on duplicate key update`ID`=values(`ID`)", false},
+		{"update", ctx, "update`users`set`Active`=0", true},
+		{"delete", ctx, "delete from`users`", true},
+		{"where in subquery", ctx, "update`users`set`Total`=(select sum(`Amount`)from`orders`where`Paid`)", true},
+		{"with", ctx, "with`old`as(select`ID`from`users`where`Active`=0)delete`users`from`users`join`old`using(`ID`)", true},
+		{"with and where", ctx, "with recursive`a`(`ID`)as(select 1),`b`as(select 2)update`users`set`Active`=0 where`ID`in(select`ID`from`a`)", false},
+		{"with select", ctx, "with`a`as(select 1)select*from`a`", false},
+		{"allowed", AllowFullTableWrite(ctx), "delete from`users`", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := db.ExecContext(tt.ctx, tt.query)
+			if got := errors.Is(err, ErrFullTableWrite); got != tt.wantErr {
+				t.Errorf("ExecContext() error = %v, want ErrFullTableWrite %t", err, tt.wantErr)
+			}
+		})
+	}
+}
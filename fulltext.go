@@ -0,0 +1,106 @@
+package mysql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FullTextMode is the search modifier of a full-text search
+type FullTextMode int
+
+const (
+	// NaturalLanguageMode searches for the text as natural language
+	NaturalLanguageMode FullTextMode = iota
+
+	// QueryExpansionMode searches for the text as natural language,
+	// and searches again with the words of the most relevant rows
+	QueryExpansionMode
+
+	// BooleanMode searches for any of the words of the text, where the boolean
+	// operators of the text are escaped, so it's safe to use for user input
+	BooleanMode
+
+	// BooleanModeAllWords searches for the rows with every word of the text,
+	// where the boolean operators of the text are escaped like BooleanMode
+	BooleanModeAllWords
+
+	// BooleanModeOperators searches with the text as is, using its boolean operators,
+	// so it should never be used for user input
+	BooleanModeOperators
+)
+
+// MatchExpr is the `MATCH` part of a full-text search
+type MatchExpr struct {
+	columns []string
+}
+
+// Match returns a full-text search of the columns, which need to have
+// a full-text index, to be finished with Against
+func Match(columns ...string) MatchExpr {
+	return MatchExpr{columns: columns}
+}
+
+// Against returns the full-text search for the text, as an expression that's its relevance,
+// to be used in the where clause or order by, like
+// `"select*from Posts where"+mysql.Match("Title","Body").Against(q, mysql.BooleanMode)`
+func (m MatchExpr) Against(text string, mode FullTextMode) Raw {
+	s := new(strings.Builder)
+	s.WriteString("match(")
+	for i, c := range m.columns {
+		if i != 0 {
+			s.WriteByte(',')
+		}
+		s.WriteString(quoteIdentifier(c))
+	}
+	s.WriteString(")against(")
+
+	switch mode {
+	case BooleanMode:
+		text = EscapeBooleanSearch(text, false)
+	case BooleanModeAllWords:
+		text = EscapeBooleanSearch(text, true)
+	}
+
+	// against needs a constant string, and the column's collation is used,
+	// so this is only hex encoded instead of marshaled with a collation
+	if len(text) == 0 {
+		s.WriteString("''")
+	} else {
+		fmt.Fprintf(s, "_utf8mb4 0x%x", text)
+	}
+
+	switch mode {
+	case QueryExpansionMode:
+		s.WriteString(" with query expansion")
+	case BooleanMode, BooleanModeAllWords, BooleanModeOperators:
+		s.WriteString(" in boolean mode")
+	default:
+		s.WriteString(" in natural language mode")
+	}
+	s.WriteByte(')')
+
+	return Raw(s.String())
+}
+
+// booleanOperators are the characters with special meaning in boolean mode searches
+const booleanOperators = `+-<>()~*"@`
+
+// EscapeBooleanSearch removes the boolean mode operators from the text, leaving its words,
+// so searching it in boolean mode can't fail or match in unexpected ways. If allWords is true,
+// every word is prefixed with `+` so only rows with all of the words match.
+func EscapeBooleanSearch(text string, allWords bool) string {
+	words := strings.Fields(strings.Map(func(r rune) rune {
+		if strings.ContainsRune(booleanOperators, r) {
+			return ' '
+		}
+		return r
+	}, text))
+
+	if allWords {
+		for i, w := range words {
+			words[i] = "+" + w
+		}
+	}
+
+	return strings.Join(words, " ")
+}
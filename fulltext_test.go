@@ -0,0 +1,38 @@
+package mysql
+
+import "testing"
+
+func TestEscapeBooleanSearch(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		allWords bool
+		want     string
+	}{
+		{"plain", "red shoes", false, "red shoes"},
+		{"operators", `+red -"shoes" (size~10)* @3`, false, "red shoes size 10 3"},
+		{"all words", "red  -shoes", true, "+red +shoes"},
+		{"only operators", `+-""`, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EscapeBooleanSearch(tt.text, tt.allWords); got != tt.want {
+				t.Errorf("EscapeBooleanSearch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchAgainst(t *testing.T) {
+	got := Match("Title", "Body").Against("-a", BooleanModeAllWords)
+	want := Raw("match(`Title`,`Body`)against(_utf8mb4 0x2b61 in boolean mode)")
+	if got != want {
+		t.Errorf("Against() = %q, want %q", got, want)
+	}
+
+	got = Match("Title").Against("", QueryExpansionMode)
+	want = Raw("match(`Title`)against('' with query expansion)")
+	if got != want {
+		t.Errorf("Against() = %q, want %q", got, want)
+	}
+}
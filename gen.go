@@ -0,0 +1,192 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// GenerateStructs reads the columns of the given tables from information_schema
+// and returns the formatted source of a Go file in package pkg with a struct for
+// each table, tagged to be used for selects and inserts.
+func GenerateStructs(ctx context.Context, db *Database, pkg string, tables ...string) ([]byte, error) {
+	s := new(strings.Builder)
+	imports := make(map[string]struct{})
+
+	for _, table := range tables {
+		columns, err := db.TableColumns(ctx, table, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		_, name := splitTableName(table)
+
+		s.WriteByte('\n')
+		writeStruct(s, goName(name), columns, imports)
+	}
+
+	src := new(strings.Builder)
+	src.WriteString("// Code generated by coolgen. DO NOT EDIT.\n\n")
+	src.WriteString("package ")
+	src.WriteString(pkg)
+	src.WriteString("\n")
+
+	if len(imports) != 0 {
+		paths := make([]string, 0, len(imports))
+		for p := range imports {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+
+		src.WriteString("\nimport (\n")
+		for _, p := range paths {
+			src.WriteString("\t\"")
+			src.WriteString(p)
+			src.WriteString("\"\n")
+		}
+		src.WriteString(")\n")
+	}
+
+	src.WriteString(s.String())
+
+	b, err := format.Source([]byte(src.String()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated source: %w", err)
+	}
+
+	return b, nil
+}
+
+func writeStruct(s *strings.Builder, name string, columns []TableColumn, imports map[string]struct{}) {
+	s.WriteString("type ")
+	s.WriteString(name)
+	s.WriteString(" struct {\n")
+
+	usedNames := make(map[string]int, len(columns))
+	for _, c := range columns {
+		fieldName := goName(c.Name)
+		if n := usedNames[fieldName]; n != 0 {
+			usedNames[fieldName]++
+			fieldName += fmt.Sprint(n + 1)
+		} else {
+			usedNames[fieldName] = 1
+		}
+
+		goType, importPath := goTypeForColumn(c)
+		if len(importPath) != 0 {
+			imports[importPath] = struct{}{}
+		}
+
+		tagName := strings.ReplaceAll(c.Name, ",", "0x2c")
+		tag := tagName
+		if c.Default != nil || c.AutoIncrement() {
+			tag += ",defaultzero"
+		}
+
+		if len(c.Comment) != 0 {
+			s.WriteString("\t// ")
+			s.WriteString(strings.ReplaceAll(c.Comment, "\n", " "))
+			s.WriteByte('\n')
+		}
+
+		s.WriteByte('\t')
+		s.WriteString(fieldName)
+		s.WriteByte(' ')
+		s.WriteString(goType)
+		s.WriteString(" `mysql:")
+		s.WriteString(fmt.Sprintf("%q", tag))
+		s.WriteString(" json:")
+		s.WriteString(fmt.Sprintf("%q", tagName))
+		s.WriteString("`\n")
+	}
+
+	s.WriteString("}\n")
+}
+
+// goTypeForColumn returns the Go type that best holds the column's values,
+// and the import path needed for the type, if any
+func goTypeForColumn(c TableColumn) (goType string, importPath string) {
+	columnType := strings.ToLower(c.ColumnType)
+	unsigned := c.Unsigned()
+	intType := func(bits string) string {
+		if unsigned {
+			return "uint" + bits
+		}
+		return "int" + bits
+	}
+
+	switch strings.ToLower(c.DataType) {
+	case "tinyint":
+		if strings.HasPrefix(columnType, "tinyint(1)") {
+			goType = "bool"
+		} else {
+			goType = intType("8")
+		}
+	case "smallint":
+		goType = intType("16")
+	case "mediumint", "int", "integer":
+		goType = intType("32")
+	case "bigint":
+		goType = intType("64")
+	case "year":
+		goType = "int16"
+	case "bit":
+		if columnType == "bit(1)" {
+			goType = "bool"
+		} else {
+			goType = "uint64"
+		}
+	case "float":
+		goType = "float32"
+	case "double", "real":
+		goType = "float64"
+	case "decimal", "numeric":
+		goType, importPath = "decimal.Decimal", "github.com/shopspring/decimal"
+	case "date":
+		goType, importPath = "civil.Date", "cloud.google.com/go/civil"
+	case "datetime", "timestamp":
+		goType, importPath = "time.Time", "time"
+	case "json":
+		// a nil raw message is already null
+		return "json.RawMessage", "encoding/json"
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob":
+		// a nil slice is already null
+		return "[]byte", ""
+	default:
+		goType = "string"
+	}
+
+	if c.Nullable {
+		goType = "*" + goType
+	}
+
+	return goType, importPath
+}
+
+// goName converts a table or column name to an exported Go identifier
+func goName(name string) string {
+	s := new(strings.Builder)
+	upper := true
+	for _, r := range name {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if upper {
+				r = unicode.ToUpper(r)
+				upper = false
+			}
+			s.WriteRune(r)
+		default:
+			upper = true
+		}
+	}
+
+	out := s.String()
+	if len(out) == 0 || unicode.IsDigit([]rune(out)[0]) {
+		out = "X" + out
+	}
+
+	return out
+}
@@ -0,0 +1,72 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/StirlingMarketingGroup/cool-mysql/internal/testdriver"
+)
+
+func TestGenerateStructs(t *testing.T) {
+	db := testDatabase(t, &testdriver.Driver{
+		QueryFunc: func(ctx context.Context, query string) (driver.Rows, error) {
+			column := func(name, dataType, columnType string, nullable bool, def any, extra, comment string) []driver.Value {
+				var null int64
+				if nullable {
+					null = 1
+				}
+				return []driver.Value{[]byte(name), int64(0), []byte(dataType), []byte(columnType), null,
+					def, nil, nil, nil, []byte(""), []byte(extra), []byte(""), []byte(comment)}
+			}
+
+			return testdriver.NewRows([]string{"COLUMN_NAME", "ORDINAL_POSITION", "DATA_TYPE", "COLUMN_TYPE", "IS_NULLABLE",
+				"COLUMN_DEFAULT", "CHARACTER_MAXIMUM_LENGTH", "NUMERIC_PRECISION", "NUMERIC_SCALE",
+				"COLUMN_KEY", "EXTRA", "GENERATION_EXPRESSION", "COLUMN_COMMENT"},
+				column("id", "bigint", "bigint unsigned", false, nil, "auto_increment", ""),
+				column("user_name", "varchar", "varchar(255)", false, nil, "", "their\nname"),
+				column("active", "tinyint", "tinyint(1)", false, []byte("1"), "", ""),
+				column("balance", "decimal", "decimal(10,2)", true, nil, "", ""),
+				column("birthday", "date", "date", true, nil, "", ""),
+				column("created", "datetime", "datetime", false, []byte("CURRENT_TIMESTAMP"), "", ""),
+				column("settings", "json", "json", true, nil, "", ""),
+				column("avatar", "blob", "blob", true, nil, "", ""),
+				column("user-name", "int", "int", false, nil, "", ""),
+				column("a,b", "float", "float", false, nil, "", ""),
+			), nil
+		},
+	})
+
+	got, err := GenerateStructs(context.Background(), db, "models", "app.user_accounts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "// Code generated by coolgen. DO NOT EDIT.\n" +
+		"\n" +
+		"package models\n" +
+		"\n" +
+		"import (\n" +
+		"\t\"cloud.google.com/go/civil\"\n" +
+		"\t\"encoding/json\"\n" +
+		"\t\"github.com/shopspring/decimal\"\n" +
+		"\t\"time\"\n" +
+		")\n" +
+		"\n" +
+		"type UserAccounts struct {\n" +
+		"\tId uint64 `mysql:\"id,defaultzero\" json:\"id\"`\n" +
+		"\t// their name\n" +
+		"\tUserName  string           `mysql:\"user_name\" json:\"user_name\"`\n" +
+		"\tActive    bool             `mysql:\"active,defaultzero\" json:\"active\"`\n" +
+		"\tBalance   *decimal.Decimal `mysql:\"balance\" json:\"balance\"`\n" +
+		"\tBirthday  *civil.Date      `mysql:\"birthday\" json:\"birthday\"`\n" +
+		"\tCreated   time.Time        `mysql:\"created,defaultzero\" json:\"created\"`\n" +
+		"\tSettings  json.RawMessage  `mysql:\"settings\" json:\"settings\"`\n" +
+		"\tAvatar    []byte           `mysql:\"avatar\" json:\"avatar\"`\n" +
+		"\tUserName2 int32            `mysql:\"user-name\" json:\"user-name\"`\n" +
+		"\tAB        float32          `mysql:\"a0x2cb\" json:\"a0x2cb\"`\n" +
+		"}\n"
+	if string(got) != want {
+		t.Errorf("GenerateStructs() =\n%s\nwant\n%s", got, want)
+	}
+}
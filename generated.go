@@ -0,0 +1,62 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SetSkipGenerated sets whether the table's generated columns are looked up from information_schema
+// and left out of inserts and upserts, like the fields tagged `generated`, since they can't be written
+func (in *Inserter) SetSkipGenerated(skip bool) *Inserter {
+	in.skipGenerated = skip
+	return in
+}
+
+// generatedColumns returns the lowercase names of the generated columns of the insert's table,
+// if the inserter skips them, otherwise nil
+func (in *Inserter) generatedColumns(ctx context.Context, queryTokens []queryToken) (map[string]struct{}, error) {
+	if !in.skipGenerated {
+		return nil, nil
+	}
+
+	table, err := rawTableNameFromQuery(queryTokens)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := in.db.TableColumns(ctx, table, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get generated columns: %w", err)
+	}
+
+	var generated map[string]struct{}
+	for _, c := range columns {
+		if !c.Generated() {
+			continue
+		}
+		if generated == nil {
+			generated = make(map[string]struct{})
+		}
+		generated[strings.ToLower(c.Name)] = struct{}{}
+	}
+
+	return generated, nil
+}
+
+// writableColumns returns the columns without the ones tagged `generated` or `readonly`,
+// or that are in the generated columns of the table
+func writableColumns(columns []string, colOpts map[string]insertColOpts, generated map[string]struct{}) []string {
+	writable := make([]string, 0, len(columns))
+	for _, c := range columns {
+		if !colOpts[c].writable() {
+			continue
+		}
+		if _, ok := generated[strings.ToLower(c)]; ok {
+			continue
+		}
+		writable = append(writable, c)
+	}
+
+	return writable
+}
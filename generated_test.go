@@ -0,0 +1,85 @@
+package mysql
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInserter_generatedColumns(t *testing.T) {
+	db := benchDatabase(t)
+
+	var queries []string
+	db.Log = func(detail LogDetail) {
+		queries = append(queries, detail.Query)
+	}
+
+	type row struct {
+		ID       int
+		Price    int
+		Quantity int
+		Total    int `mysql:"Total,generated"`
+	}
+
+	if err := db.Insert("Orders", []row{{ID: 1, Price: 2, Quantity: 3, Total: 6}}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "insert into`Orders`(`ID`,`Price`,`Quantity`)values(1,2,3)"
+	if len(queries) != 1 || queries[0] != want {
+		t.Errorf("Insert() ran %q, want %q", queries, want)
+	}
+
+	queries = nil
+	err := db.UpsertContext(context.Background(), "insert into`Orders`", []string{"ID"}, []string{"Price", "Total"},
+		"", nil, []row{{ID: 1, Price: 2, Quantity: 3, Total: 6}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want = "update `Orders` set`Price`=2 where`ID`<=>1"
+	if len(queries) != 1 || !strings.EqualFold(queries[0], want) {
+		t.Errorf("UpsertContext() ran %q, want %q", queries, want)
+	}
+}
+
+func Test_writableColumns(t *testing.T) {
+	colOpts := map[string]insertColOpts{"Total": {generated: true}}
+	generated := map[string]struct{}{"slug": {}}
+
+	got := writableColumns([]string{"ID", "Total", "Slug", "Name"}, colOpts, generated)
+	if want := []string{"ID", "Name"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("writableColumns() = %q, want %q", got, want)
+	}
+}
+
+func TestInserter_readonlyColumns(t *testing.T) {
+	db := benchDatabase(t)
+
+	var queries []string
+	db.Log = func(detail LogDetail) {
+		queries = append(queries, detail.Query)
+	}
+
+	type user struct {
+		ID         int    `mysql:"ID,readonly"`
+		Name       string `mysql:"Name"`
+		RowVersion int    `mysql:"RowVersion,readonly"`
+	}
+
+	if err := db.Insert("Users", user{ID: 1, Name: "a", RowVersion: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Repo[user](db, "Users").Update(context.Background(), user{ID: 1, Name: "a", RowVersion: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"insert into`Users`(`Name`)values(_utf8mb4 0x61 collate utf8mb4_unicode_ci)",
+		"update`Users`set`Name`=_utf8mb4 0x61 collate utf8mb4_unicode_ci where `ID`=1",
+	}
+	if !reflect.DeepEqual(queries, want) {
+		t.Errorf("Insert() and Update() ran %q, want %q", queries, want)
+	}
+}
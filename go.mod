@@ -14,6 +14,7 @@ require (
 	go.uber.org/zap v1.24.0
 	golang.org/x/crypto v0.25.0
 	golang.org/x/sync v0.8.0
+	google.golang.org/protobuf v1.34.2
 )
 
 require (
@@ -0,0 +1,242 @@
+// Package httpquery serves named, parameterized read-only queries as JSON over HTTP,
+// with per-query caching and rate limits, so simple read endpoints don't each
+// need their own handler.
+//
+// Queries are registered by name and served at the last element of the request's path,
+// so the handler is usually mounted with http.StripPrefix:
+//
+//	h := httpquery.New(db)
+//	h.Register(httpquery.Query{
+//		Name:   "user",
+//		SQL:    "select json_object('id',`ID`,'name',`Name`)from`Users`where`ID`=@@id",
+//		Params: []string{"id"},
+//		TTL:    time.Minute,
+//	})
+//	http.Handle("/q/", http.StripPrefix("/q/", h))
+package httpquery
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	mysql "github.com/StirlingMarketingGroup/cool-mysql"
+)
+
+// Query is a named query served by the handler
+type Query struct {
+	// Name is the path the query is served at
+	Name string
+
+	// SQL is the query, which must select a single json value, like a `json_object`
+	// or `json_arrayagg`, that's written as the response as is
+	SQL string
+
+	// Params are the names of the query's `@@` params, which are read from the URL's query string
+	// and are all required. A param given more than once is passed as a list, for `in(@@param)`.
+	// Any other params in the query string are rejected.
+	Params []string
+
+	// TTL is how long results are cached, passed to SelectJSON, where 0 means no caching
+	TTL time.Duration
+
+	// RateLimit is the number of requests per second allowed for the query across all clients,
+	// where 0 means no limit. Burst is the number of requests allowed at once, at least 1.
+	RateLimit float64
+	Burst     int
+}
+
+type registeredQuery struct {
+	Query
+	params  map[string]struct{}
+	limiter *limiter
+}
+
+// Handler is an http.Handler that executes registered queries
+type Handler struct {
+	db *mysql.Database
+
+	mu      sync.RWMutex
+	queries map[string]*registeredQuery
+
+	// ErrorLog is called with the errors of failed queries, which aren't written in responses
+	ErrorLog func(r *http.Request, err error)
+}
+
+// New returns a handler that executes its queries against the database's `Reads` connection
+func New(db *mysql.Database) *Handler {
+	return &Handler{
+		db:      db,
+		queries: make(map[string]*registeredQuery),
+	}
+}
+
+// Register adds the query to the handler, replacing any query with the same name
+func (h *Handler) Register(q Query) error {
+	if len(q.Name) == 0 {
+		return errors.New("httpquery: query needs a name")
+	}
+	if len(q.SQL) == 0 {
+		return fmt.Errorf("httpquery: query %q needs sql", q.Name)
+	}
+	if q.RateLimit < 0 {
+		return fmt.Errorf("httpquery: query %q has a negative rate limit", q.Name)
+	}
+
+	rq := &registeredQuery{
+		Query:  q,
+		params: make(map[string]struct{}, len(q.Params)),
+	}
+	for _, p := range q.Params {
+		rq.params[p] = struct{}{}
+	}
+	if q.RateLimit != 0 {
+		rq.limiter = newLimiter(q.RateLimit, q.Burst)
+	}
+
+	h.mu.Lock()
+	h.queries[q.Name] = rq
+	h.mu.Unlock()
+
+	return nil
+}
+
+// ServeHTTP executes the query named by the last element of the request's path
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		httpError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	h.mu.RLock()
+	q, ok := h.queries[path.Base(r.URL.Path)]
+	h.mu.RUnlock()
+	if !ok {
+		httpError(w, http.StatusNotFound)
+		return
+	}
+
+	params, err := q.parseParams(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if q.limiter != nil {
+		if wait := q.limiter.reserve(time.Now()); wait != 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			httpError(w, http.StatusTooManyRequests)
+			return
+		}
+	}
+
+	var j json.RawMessage
+	err = h.db.SelectJSONContext(r.Context(), &j, q.SQL, q.TTL, params)
+	if errors.Is(err, sql.ErrNoRows) {
+		httpError(w, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		if h.ErrorLog != nil {
+			h.ErrorLog(r, fmt.Errorf("httpquery: query %q failed: %w", q.Name, err))
+		}
+		httpError(w, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if q.TTL != 0 {
+		w.Header().Set("Cache-Control", "max-age="+strconv.Itoa(int(q.TTL.Seconds())))
+	}
+	if r.Method == http.MethodHead {
+		return
+	}
+	w.Write(j)
+}
+
+// parseParams returns the query's params from the request's query string
+func (q *registeredQuery) parseParams(r *http.Request) (mysql.Params, error) {
+	values := r.URL.Query()
+
+	for k := range values {
+		if _, ok := q.params[k]; !ok {
+			return nil, fmt.Errorf("unknown param %q", k)
+		}
+	}
+
+	params := make(mysql.Params, len(q.Params))
+	for _, p := range q.Params {
+		v, ok := values[p]
+		if !ok {
+			return nil, fmt.Errorf("missing param %q", p)
+		}
+
+		if len(v) == 1 {
+			params[p] = v[0]
+		} else {
+			params[p] = v
+		}
+	}
+
+	return params, nil
+}
+
+func httpError(w http.ResponseWriter, status int) {
+	writeJSONError(w, status, http.StatusText(status))
+}
+
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{msg})
+}
+
+// limiter is a token bucket allowing rate requests per second, up to burst at once
+type limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(rate float64, burst int) *limiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &limiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+}
+
+// reserve takes a token if there is one and returns 0,
+// otherwise it returns how long until there will be one
+func (l *limiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.last.IsZero() {
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
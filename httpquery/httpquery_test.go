@@ -0,0 +1,56 @@
+package httpquery
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandlerRejects(t *testing.T) {
+	h := New(nil)
+	err := h.Register(Query{
+		Name:   "user",
+		SQL:    "select json_object('id',`ID`)from`Users`where`ID`=@@id",
+		Params: []string{"id"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		want   int
+	}{
+		{"unknown query", http.MethodGet, "/nope?id=1", http.StatusNotFound},
+		{"post", http.MethodPost, "/user?id=1", http.StatusMethodNotAllowed},
+		{"missing param", http.MethodGet, "/user", http.StatusBadRequest},
+		{"unknown param", http.MethodGet, "/user?id=1&name=a", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(2, 2)
+	now := time.Now()
+
+	if l.reserve(now) != 0 || l.reserve(now) != 0 {
+		t.Fatal("burst requests were limited")
+	}
+	if wait := l.reserve(now); wait != 500*time.Millisecond {
+		t.Errorf("wait = %s, want 500ms", wait)
+	}
+	if wait := l.reserve(now.Add(500 * time.Millisecond)); wait != 0 {
+		t.Errorf("wait after refill = %s, want 0", wait)
+	}
+}
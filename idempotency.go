@@ -0,0 +1,82 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+
+	stdMysql "github.com/go-sql-driver/mysql"
+)
+
+// ErrAmbiguousWrite is matched by the errors of writes that weren't retried because they failed after
+// being sent, so they may or may not have been applied, see Database.NoRetryAfterSend
+var ErrAmbiguousWrite = errors.New("cool-mysql: write failed after it was sent and may have been applied")
+
+// AmbiguousWriteError is the error of a write that failed after it was sent
+type AmbiguousWriteError struct {
+	Err error
+}
+
+func (e AmbiguousWriteError) Error() string {
+	return ErrAmbiguousWrite.Error() + ": " + e.Err.Error()
+}
+
+func (e AmbiguousWriteError) Unwrap() error {
+	return e.Err
+}
+
+func (e AmbiguousWriteError) Is(target error) bool {
+	return target == ErrAmbiguousWrite
+}
+
+// isAmbiguousError returns true if the error could have happened after the statement
+// reached the server, like a lost connection, so it's unknown whether it was applied
+func isAmbiguousError(err error) bool {
+	if errors.Is(err, stdMysql.ErrInvalidConn) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var mysqlErr *stdMysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		// server gone away and lost connection during query
+		return mysqlErr.Number == 2006 || mysqlErr.Number == 2013
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+var idempotentKey = key(7)
+
+// NewContextWithIdempotentWrites returns a new context.Context whose writes are
+// safe to run more than once, so they're retried even with NoRetryAfterSend
+func NewContextWithIdempotentWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey, true)
+}
+
+// idempotentWrites returns true if the context's writes are safe to run more than once
+func idempotentWrites(ctx context.Context) bool {
+	idempotent, _ := ctx.Value(idempotentKey).(bool)
+	return idempotent
+}
+
+// SetNoRetryAfterSend sets whether writes are retried after errors that could have happened after
+// they reached the server, see NoRetryAfterSend
+func (db *Database) SetNoRetryAfterSend(noRetry bool) *Database {
+	db.NoRetryAfterSend = noRetry
+	return db
+}
+
+// SetIdempotencyKey makes the inserts safe to retry after errors that could have happened after they
+// reached the server, even with NoRetryAfterSend, by ignoring rows whose value for the column,
+// which needs a unique index, already exists. Inserts that already have an on duplicate key update
+// clause keep it, and it has to be safe to run twice for the same rows.
+//
+// Rows affected, like in HandleResult, are 0 for rows that were already inserted by an earlier attempt,
+// unless the DSN has clientFoundRows=true, where they're 1 either way.
+func (in *Inserter) SetIdempotencyKey(column string) *Inserter {
+	in.idempotencyKey = column
+
+	return in
+}
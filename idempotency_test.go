@@ -0,0 +1,57 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	stdMysql "github.com/go-sql-driver/mysql"
+)
+
+func Test_isAmbiguousError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"invalid conn", stdMysql.ErrInvalidConn, true},
+		{"wrapped invalid conn", fmt.Errorf("failed: %w", stdMysql.ErrInvalidConn), true},
+		{"unexpected eof", io.ErrUnexpectedEOF, true},
+		{"lost connection", &stdMysql.MySQLError{Number: 2013}, true},
+		{"net error", &net.OpError{Op: "read", Err: errors.New("connection reset")}, true},
+		{"deadlock", &stdMysql.MySQLError{Number: 1213}, false},
+		{"duplicate", &stdMysql.MySQLError{Number: 1062}, false},
+		{"other", errors.New("other"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isAmbiguousError(tt.err); got != tt.want {
+				t.Errorf("isAmbiguousError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmbiguousWriteError(t *testing.T) {
+	err := error(Error{Err: AmbiguousWriteError{Err: stdMysql.ErrInvalidConn}})
+
+	if !errors.Is(err, ErrAmbiguousWrite) {
+		t.Error("error doesn't match ErrAmbiguousWrite")
+	}
+	if !errors.Is(err, stdMysql.ErrInvalidConn) {
+		t.Error("error doesn't match its cause")
+	}
+}
+
+func Test_idempotentWrites(t *testing.T) {
+	ctx := context.Background()
+	if idempotentWrites(ctx) {
+		t.Error("background context has idempotent writes")
+	}
+	if !idempotentWrites(NewContextWithIdempotentWrites(ctx)) {
+		t.Error("idempotent context doesn't have idempotent writes")
+	}
+}
@@ -0,0 +1,55 @@
+package mysql
+
+import "strings"
+
+// IdentifierCase is how the names of tables in generated queries, and the keys of MapRows, are normalized.
+// Columns of structs are always matched without case sensitivity, like MySQL compares column names.
+type IdentifierCase int
+
+const (
+	// IdentifierCaseAuto lowercases the names of tables in generated queries if the server's
+	// lower_case_table_names is 1, since it stores and compares them in lowercase,
+	// and leaves them as they're written otherwise. The keys of MapRows are the column names as they're selected.
+	IdentifierCaseAuto IdentifierCase = iota
+
+	// IdentifierCasePreserve leaves the names of tables as they're written,
+	// and the keys of MapRows as they're selected, whatever the server does
+	IdentifierCasePreserve
+
+	// IdentifierCaseLower lowercases the names of tables in generated queries, and the keys of MapRows,
+	// so code can read rows like `row["id"]` however the query wrote the column, like structs are matched
+	IdentifierCaseLower
+)
+
+// SetIdentifierCase sets how the database normalizes the names of tables and the keys of MapRows,
+// see IdentifierCase
+func (db *Database) SetIdentifierCase(identifierCase IdentifierCase) *Database {
+	db.IdentifierCase = identifierCase
+	return db
+}
+
+// lowerTableNames returns true if the names of tables in generated queries are lowercased
+func (db *Database) lowerTableNames() bool {
+	switch db.IdentifierCase {
+	case IdentifierCaseLower:
+		return true
+	case IdentifierCaseAuto:
+		return db.ServerInfo().LowerCaseTableNames == 1
+	default:
+		return false
+	}
+}
+
+// normalizeTableName returns the name of a table, or its schema, as it's written in generated queries
+func (db *Database) normalizeTableName(name string) string {
+	if db.lowerTableNames() {
+		return strings.ToLower(name)
+	}
+
+	return name
+}
+
+// lowerMapRowKeys returns true if the keys of MapRows are lowercased
+func (db *Database) lowerMapRowKeys() bool {
+	return db.IdentifierCase == IdentifierCaseLower
+}
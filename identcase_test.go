@@ -0,0 +1,49 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDatabase_SetIdentifierCase(t *testing.T) {
+	tests := []struct {
+		name                string
+		identifierCase      IdentifierCase
+		lowerCaseTableNames int
+		wantInsert          string
+		wantKey             string
+	}{
+		{"auto", IdentifierCaseAuto, 0, "insert into`Analytics`.`Events`(`ID`)values(1)", "Text0"},
+		{"auto lower_case_table_names", IdentifierCaseAuto, 1, "insert into`analytics`.`events`(`ID`)values(1)", "Text0"},
+		{"preserve", IdentifierCasePreserve, 1, "insert into`Analytics`.`Events`(`ID`)values(1)", "Text0"},
+		{"lower", IdentifierCaseLower, 0, "insert into`analytics`.`events`(`ID`)values(1)", "text0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := benchDatabase(t).
+				SetServerInfo(ServerInfo{LowerCaseTableNames: tt.lowerCaseTableNames}).
+				SetIdentifierCase(tt.identifierCase).
+				OnSchema("Analytics")
+
+			var queries []string
+			db.Log = func(detail LogDetail) {
+				queries = append(queries, detail.Query)
+			}
+
+			if err := db.Insert("Events", struct{ ID int }{1}); err != nil {
+				t.Fatal(err)
+			}
+			if len(queries) != 1 || queries[0] != tt.wantInsert {
+				t.Errorf("Insert() ran %q, want %q", queries, tt.wantInsert)
+			}
+
+			var rows MapRows
+			if err := db.SelectContext(context.Background(), &rows, "select*from`Events`", 0); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := rows[0][tt.wantKey]; !ok {
+				t.Errorf("SelectContext() selected rows without the key %q", tt.wantKey)
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// inChunk is the param of a query's `in(@@Param)` that's split into chunks, see InChunkSize
+type inChunk struct {
+	name   string
+	chunks []any
+}
+
+// inChunkUnsafeWords are the words of queries whose results can't be merged from chunks of their keys,
+// like aggregates, which would be per chunk, and limits, which would be too
+var inChunkUnsafeWords = map[string]struct{}{
+	"limit": {}, "group": {}, "having": {}, "distinct": {}, "union": {}, "order": {}, "offset": {},
+	"count": {}, "sum": {}, "avg": {}, "min": {}, "max": {}, "group_concat": {}, "json_arrayagg": {},
+	"json_objectagg": {}, "bit_and": {}, "bit_or": {}, "bit_xor": {}, "std": {}, "stddev": {},
+	"stddev_pop": {}, "stddev_samp": {}, "var_pop": {}, "var_samp": {}, "variance": {}, "over": {},
+}
+
+// inChunks returns the chunks of the first param of the query used as the whole list of an `in()`
+// that has more than InChunkSize values, if the query's results can be merged from its chunks,
+// where queries with an order by can be if their rows are merged in its order, see Merge
+func inChunks(query string, params []any, mergedInOrder bool, valuerFuncs *valuers) (*inChunk, bool) {
+	size := InChunkSize
+	if size <= 0 || !strings.Contains(query, "@@") {
+		return nil, false
+	}
+
+	var queryTokens []queryToken
+	for _, t := range parseQuery(query) {
+		if t.kind != queryTokenKindMisc || strings.TrimSpace(t.string) != "" {
+			queryTokens = append(queryTokens, t)
+		}
+	}
+
+	var firstParamName string
+	var names []string
+	for _, t := range queryTokens {
+		switch t.kind {
+		case queryTokenKindWord:
+			word := strings.ToLower(t.string)
+			if word == "order" && mergedInOrder {
+				continue
+			}
+			if _, ok := inChunkUnsafeWords[word]; ok {
+				return nil, false
+			}
+		case queryTokenKindParam:
+			if len(firstParamName) == 0 {
+				firstParamName = t.string[2:]
+			}
+		}
+	}
+	for _, i := range inChunkConditions(queryTokens) {
+		names = append(names, queryTokens[i].string[2:])
+	}
+	if len(names) == 0 {
+		return nil, false
+	}
+
+	convertedParams := make([]Params, 0, len(params))
+	for _, p := range params {
+		cp, _ := convertValuerParams(firstParamName, p, valuerFuncs)
+		convertedParams = append(convertedParams, cp)
+	}
+	merged, _ := mergeParams(false, convertedParams, nil)
+
+	for _, name := range names {
+		v, ok := merged[strings.ToLower(name)]
+		if !ok {
+			continue
+		}
+		if _, ok := v.(driver.Valuer); ok || hasValuerFunc(valuerFuncs, reflect.TypeOf(v)) {
+			continue
+		}
+
+		rv := reflectUnwrap(reflect.ValueOf(v))
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array || rv.Type().Elem().Kind() == reflect.Uint8 || rv.Len() <= size {
+			continue
+		}
+
+		// the keys are deduplicated, so no row is selected, or written, by more than one chunk
+		elemType := rv.Type().Elem()
+		keys := reflect.MakeSlice(reflect.SliceOf(elemType), 0, rv.Len())
+		seen := make(map[any]struct{}, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			k := rv.Index(i)
+
+			// keys in interfaces can be of types that can't be compared, like slices
+			if kv := k.Interface(); kv == nil || reflect.TypeOf(kv).Comparable() {
+				if _, ok := seen[kv]; ok {
+					continue
+				}
+				seen[kv] = struct{}{}
+			}
+			keys = reflect.Append(keys, k)
+		}
+
+		c := &inChunk{name: name}
+		for start := 0; start < keys.Len(); start += size {
+			end := start + size
+			if end > keys.Len() {
+				end = keys.Len()
+			}
+			c.chunks = append(c.chunks, keys.Slice(start, end).Interface())
+		}
+
+		return c, true
+	}
+
+	return nil, false
+}
+
+// inChunkConditions returns the indexes of the params of the query's tokens that are the whole list
+// of an `in()` that's a condition every row has to meet, like the `in()` of "where`A`=1 and`ID`in(@@IDs)"
+// of a select, update, or delete. Lists anywhere else can't be split, since they'd change which rows match,
+// like beside an `or`, where a row matching the other side would be matched by every chunk,
+// or what they get, like in a set or the select list, or after a `not`.
+func inChunkConditions(queryTokens []queryToken) []int {
+	if len(queryTokens) == 0 || queryTokens[0].kind != queryTokenKindWord {
+		return nil
+	}
+	switch strings.ToLower(queryTokens[0].string) {
+	case "select", "update", "delete":
+	default:
+		return nil
+	}
+
+	// the where clause of the query itself, not of its subqueries
+	where := -1
+	depth := 0
+	for i, t := range queryTokens {
+		if t.kind == queryTokenKindParen {
+			if t.string == "(" {
+				depth++
+			} else {
+				depth--
+			}
+		} else if depth == 0 && t.kind == queryTokenKindWord && strings.EqualFold(t.string, "where") {
+			where = i
+			break
+		}
+	}
+	if where == -1 {
+		return nil
+	}
+
+	var params []int
+	condition := func(c []queryToken, start int) {
+		// a column, maybe qualified by its table, in a list that's only the param
+		l := len(c)
+		if l < 5 || !strings.EqualFold(c[l-4].string, "in") || c[l-3].string != "(" || c[l-2].kind != queryTokenKindParam || c[l-1].string != ")" {
+			return
+		}
+		for i, t := range c[:l-4] {
+			if i%2 == 0 && (t.kind != queryTokenKindWord || strings.EqualFold(t.string, "not")) && (t.kind != queryTokenKindString || t.string[0] != '`') ||
+				i%2 == 1 && t.string != "." {
+				return
+			}
+		}
+		if (l-4)%2 == 0 {
+			return
+		}
+
+		params = append(params, start+l-2)
+	}
+
+	start := where + 1
+	end := len(queryTokens)
+	between := false
+	depth = 0
+TOKENS:
+	for i := where + 1; i < len(queryTokens); i++ {
+		t := queryTokens[i]
+		if t.kind == queryTokenKindParen {
+			if t.string == "(" {
+				depth++
+			} else if depth--; depth < 0 {
+				end = i
+				break
+			}
+			continue
+		}
+		if depth != 0 {
+			continue
+		}
+
+		switch t.kind {
+		case queryTokenKindWord:
+			switch strings.ToLower(t.string) {
+			case "or", "xor":
+				return nil
+			case "between":
+				between = true
+			case "and":
+				// the and of a between is part of its condition
+				if between {
+					between = false
+					continue
+				}
+				condition(queryTokens[start:i], start)
+				start = i + 1
+			case "order", "group", "having", "window", "limit", "for", "lock", "into", "union":
+				end = i
+				break TOKENS
+			}
+		case queryTokenKindMisc:
+			if strings.Contains(t.string, "|") {
+				return nil
+			}
+		}
+	}
+	condition(queryTokens[start:end], start)
+
+	return params
+}
+
+// params returns the params of the query with the param replaced by the chunk
+func (c *inChunk) params(params []any, chunk any) []any {
+	return append(params[:len(params):len(params)], Params{c.name: chunk})
+}
+
+// queryInChunks selects into dest once per chunk, where multi-row dests get the rows of every chunk,
+// and single-row dests get the first row of the first chunk that has one
+func (db *Database) queryInChunks(conn handlerWithContext, ctx context.Context, c *inChunk, dest any, query string, cacheDuration time.Duration, params ...any) error {
+	_, multiRow := getElementTypeFromDest(reflect.ValueOf(dest))
+	for _, chunk := range c.chunks {
+		err := db.query(conn, ctx, dest, query, cacheDuration, c.params(params, chunk)...)
+		if !multiRow && errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil || !multiRow {
+			return err
+		}
+	}
+
+	if !multiRow {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// execInChunks executes the query once per chunk, where the result has the rows affected of every chunk
+func (db *Database) execInChunks(conn handlerWithContext, ctx context.Context, tx *Tx, c *inChunk, query string, params ...any) (sql.Result, error) {
+	var res inChunkResult
+	for _, chunk := range c.chunks {
+		r, err := db.exec(conn, ctx, tx, true, query, c.params(params, chunk)...)
+		if err != nil {
+			return nil, err
+		}
+
+		n, err := r.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		res.rowsAffected += n
+
+		if id, err := r.LastInsertId(); err == nil {
+			res.lastInsertID = id
+		}
+	}
+
+	return res, nil
+}
+
+// inChunkResult is the result of an exec split into chunks
+type inChunkResult struct {
+	rowsAffected int64
+	lastInsertID int64
+}
+
+// LastInsertId returns the last insert id of the last chunk
+func (r inChunkResult) LastInsertId() (int64, error) {
+	return r.lastInsertID, nil
+}
+
+// RowsAffected returns the rows affected by every chunk
+func (r inChunkResult) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
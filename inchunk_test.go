@@ -0,0 +1,108 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+)
+
+func TestInChunkSize(t *testing.T) {
+	defer func(size int) { InChunkSize = size }(InChunkSize)
+	InChunkSize = 2
+
+	db := benchDatabase(t)
+	var queries []string
+	db.Log = func(detail LogDetail) {
+		queries = append(queries, detail.Query)
+	}
+
+	ctx := context.Background()
+	ids := Params{"IDs": []int{1, 2, 2, 3, 4, 5}}
+
+	res, err := db.ExecContextResult(ctx, "update`users`set`Active`=0 where`ID`in(@@IDs)", ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"update`users`set`Active`=0 where`ID`in(1,2)",
+		"update`users`set`Active`=0 where`ID`in(3,4)",
+		"update`users`set`Active`=0 where`ID`in(5)",
+	}
+	if len(queries) != len(want) {
+		t.Fatalf("ExecContextResult() ran %q, want %q", queries, want)
+	}
+	for i := range want {
+		if queries[i] != want[i] {
+			t.Errorf("ExecContextResult() ran %q, want %q", queries, want)
+			break
+		}
+	}
+	if n, _ := res.RowsAffected(); n != 3 {
+		t.Errorf("ExecContextResult() affected %d rows, want 3", n)
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		merge       *Merge
+		wantQueries int
+		wantRows    int
+	}{
+		{"split", "select*from`users`where`ID`in(@@IDs)", nil, 3, 3000},
+		{"ordered", "select*from`users`where`ID`in(@@IDs)order by`Text0`", nil, 1, 1000},
+		{"merged in order", "select*from`users`where`ID`in(@@IDs)order by`Text0`", &Merge{QueryOrder: true}, 3, 3000},
+		{"merged unique", "select*from`users`where`ID`in(@@IDs)", &Merge{UniqueBy: []string{"text0"}}, 3, 1},
+		{"not in", "select*from`users`where`ID`not in(@@IDs)", nil, 1, 1000},
+		{"part of the list", "select*from`users`where`ID`in(0,@@IDs)", nil, 1, 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries = nil
+
+			var rows []benchWideRow
+			if err := db.SelectContext(ctx, &rows, tt.query, 0, ids, tt.merge); err != nil {
+				t.Fatal(err)
+			}
+			if len(queries) != tt.wantQueries || len(rows) != tt.wantRows {
+				t.Errorf("SelectContext() ran %d queries for %d rows, want %d for %d", len(queries), len(rows), tt.wantQueries, tt.wantRows)
+			}
+		})
+	}
+}
+
+func Test_inChunks(t *testing.T) {
+	defer func(size int) { InChunkSize = size }(InChunkSize)
+	InChunkSize = 2
+
+	ids := Params{"IDs": []int{1, 2, 3}}
+
+	tests := []struct {
+		name  string
+		query string
+		want  bool
+	}{
+		{"select", "select*from`users`where`ID`in(@@IDs)", true},
+		{"and", "select*from`users`where`Deleted`=0 and`users`.`ID`in(@@IDs)and`A`=1", true},
+		{"between", "delete from`users`where`Created`between @@Start and @@End and`ID`in(@@IDs)", true},
+		{"update", "update`users`set`Active`=0 where`ID`in(@@IDs)", true},
+		{"or", "select*from`users`where`ID`in(@@IDs)or`Deleted`=1", false},
+		{"or in parens", "select*from`users`where(`ID`in(@@IDs)or`Deleted`=1)", false},
+		{"pipes", "select*from`users`where`ID`in(@@IDs)||`Deleted`=1", false},
+		{"select list", "select`ID`in(@@IDs)`Flag`from`users`", false},
+		{"set", "update`users`set`Flag`=`ID`in(@@IDs)", false},
+		{"set with where", "update`users`set`Flag`=`ID`in(@@IDs)where`A`=1", false},
+		{"increment with or", "update`users`set`N`=`N`+1 where`ID`in(@@IDs)or`X`=1", false},
+		{"not", "select*from`users`where not`ID`in(@@IDs)", false},
+		{"not in", "select*from`users`where`ID`not in(@@IDs)", false},
+		{"compared", "select*from`users`where`ID`in(@@IDs)=0", false},
+		{"subquery", "select*from`users`where`A`in(select`A`from`b`where`ID`in(@@IDs))", false},
+		{"join", "select*from`users`join`b`on`b`.`ID`in(@@IDs)", false},
+		{"insert select", "insert into`b`select*from`users`where`ID`in(@@IDs)", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := inChunks(tt.query, []any{ids}, false, nil); got != tt.want {
+				t.Errorf("inChunks() chunked = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidUpsert is matched by the errors of upserts whose columns don't match
+// the table, see ValidateUpsert
+var ErrInvalidUpsert = errors.New("cool-mysql: invalid upsert")
+
+// TableIndex is an index's definition from information_schema
+type TableIndex struct {
+	Name    string
+	Unique  bool
+	Columns []string
+}
+
+// Primary returns true if the index is the table's primary key
+func (i TableIndex) Primary() bool {
+	return i.Name == "PRIMARY"
+}
+
+func (i TableIndex) String() string {
+	return i.Name + "(" + strings.Join(i.Columns, ",") + ")"
+}
+
+// TableIndexes returns the indexes of the table, with their columns in order,
+// from information_schema. The table can be schema qualified, otherwise
+// the connection's current schema is used.
+func (db *Database) TableIndexes(ctx context.Context, table string, cache time.Duration) ([]TableIndex, error) {
+	schema, name := splitTableName(table)
+
+	var schemaParam any
+	if len(schema) != 0 {
+		schemaParam = schema
+	}
+
+	var rows []struct {
+		IndexName  string `mysql:"INDEX_NAME"`
+		Unique     bool   `mysql:"UNIQUE"`
+		ColumnName string `mysql:"COLUMN_NAME"`
+	}
+	err := db.query(db.Reads, ctx, &rows, "select`INDEX_NAME`,`NON_UNIQUE`=0`UNIQUE`,coalesce(`COLUMN_NAME`,'')`COLUMN_NAME`"+
+		"from`information_schema`.`STATISTICS`"+
+		"where`TABLE_SCHEMA`=coalesce(@@Schema,database())and`TABLE_NAME`=@@Table "+
+		"order by`INDEX_NAME`,`SEQ_IN_INDEX`", cache, Params{
+		"Schema": schemaParam,
+		"Table":  name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get indexes for table %q: %w", table, err)
+	}
+
+	var indexes []TableIndex
+	for _, r := range rows {
+		if len(indexes) == 0 || indexes[len(indexes)-1].Name != r.IndexName {
+			indexes = append(indexes, TableIndex{Name: r.IndexName, Unique: r.Unique})
+		}
+
+		// functional key parts don't have a column
+		if len(r.ColumnName) != 0 {
+			i := &indexes[len(indexes)-1]
+			i.Columns = append(i.Columns, r.ColumnName)
+		}
+	}
+
+	return indexes, nil
+}
+
+// ValidateUpsert checks that the columns of an upsert into the table exist, and that its unique
+// columns include every column of one of the table's unique indexes, so they match at most one row.
+// Otherwise, upserts run an update for every row that can match the wrong rows, or nothing at all.
+func (db *Database) ValidateUpsert(ctx context.Context, table string, uniqueColumns, updateColumns []string) error {
+	columns, err := db.TableColumns(ctx, table, 0)
+	if err != nil {
+		return err
+	}
+
+	indexes, err := db.TableIndexes(ctx, table, 0)
+	if err != nil {
+		return err
+	}
+
+	return validateUpsert(table, columns, indexes, uniqueColumns, updateColumns)
+}
+
+func validateUpsert(table string, columns []TableColumn, indexes []TableIndex, uniqueColumns, updateColumns []string) error {
+	// column names aren't case sensitive
+	exists := make(map[string]struct{}, len(columns))
+	for _, c := range columns {
+		exists[strings.ToLower(c.Name)] = struct{}{}
+	}
+
+	for _, c := range uniqueColumns {
+		if _, ok := exists[strings.ToLower(c)]; !ok {
+			return fmt.Errorf("%w: unique column %q doesn't exist in table %q", ErrInvalidUpsert, c, table)
+		}
+	}
+	for _, c := range updateColumns {
+		if _, ok := exists[strings.ToLower(c)]; !ok {
+			return fmt.Errorf("%w: update column %q doesn't exist in table %q", ErrInvalidUpsert, c, table)
+		}
+	}
+
+	// upserts matched only by their where clause are up to the caller
+	if len(uniqueColumns) == 0 {
+		return nil
+	}
+
+	unique := make(map[string]struct{}, len(uniqueColumns))
+	for _, c := range uniqueColumns {
+		unique[strings.ToLower(c)] = struct{}{}
+	}
+
+	var uniqueIndexes []string
+	for _, i := range indexes {
+		if !i.Unique || len(i.Columns) == 0 {
+			continue
+		}
+		uniqueIndexes = append(uniqueIndexes, i.String())
+
+		covered := true
+		for _, c := range i.Columns {
+			if _, ok := unique[strings.ToLower(c)]; !ok {
+				covered = false
+				break
+			}
+		}
+		if covered {
+			return nil
+		}
+	}
+
+	if len(uniqueIndexes) == 0 {
+		return fmt.Errorf("%w: table %q has no unique indexes for unique columns %v", ErrInvalidUpsert, table, uniqueColumns)
+	}
+
+	return fmt.Errorf("%w: unique columns %v of table %q don't include all of the columns of any of its unique indexes: %s",
+		ErrInvalidUpsert, uniqueColumns, table, strings.Join(uniqueIndexes, ", "))
+}
+
+// SetValidateUpsert sets whether upserts are checked with ValidateUpsert before they run
+func (in *Inserter) SetValidateUpsert(validate bool) *Inserter {
+	in.validateUpsert = validate
+	return in
+}
@@ -0,0 +1,41 @@
+package mysql
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_validateUpsert(t *testing.T) {
+	columns := []TableColumn{{Name: "ID"}, {Name: "TenantID"}, {Name: "Email"}, {Name: "Name"}}
+	indexes := []TableIndex{
+		{Name: "PRIMARY", Unique: true, Columns: []string{"ID"}},
+		{Name: "Email", Unique: true, Columns: []string{"TenantID", "Email"}},
+		{Name: "Name", Columns: []string{"Name"}},
+	}
+
+	tests := []struct {
+		name           string
+		unique, update []string
+		wantErr        bool
+	}{
+		{"primary key", []string{"id"}, []string{"Name"}, false},
+		{"composite unique index", []string{"Email", "TenantID"}, []string{"Name"}, false},
+		{"superset of a unique index", []string{"ID", "Name"}, []string{"Email"}, false},
+		{"part of a unique index", []string{"Email"}, []string{"Name"}, true},
+		{"non-unique index", []string{"Name"}, []string{"Email"}, true},
+		{"missing unique column", []string{"UUID"}, nil, true},
+		{"missing update column", []string{"ID"}, []string{"Nmae"}, true},
+		{"where only", nil, []string{"Name"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateUpsert("users", columns, indexes, tt.unique, tt.update)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateUpsert() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidUpsert) {
+				t.Errorf("validateUpsert() error = %v, want ErrInvalidUpsert", err)
+			}
+		})
+	}
+}
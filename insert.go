@@ -1,15 +1,16 @@
 package mysql
 
 import (
-	"bytes"
 	"context"
 	"database/sql"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/fatih/structtag"
+	"google.golang.org/protobuf/proto"
 )
 
 type Inserter struct {
@@ -17,6 +18,15 @@ type Inserter struct {
 	conn handlerWithContext
 	tx   *Tx
 
+	returning []string
+
+	maxChunkBytes int
+
+	idempotencyKey string
+
+	validateUpsert bool
+	skipGenerated  bool
+
 	AfterChunkExec func(start time.Time)
 	AfterRowExec   func(start time.Time)
 	HandleResult   func(sql.Result)
@@ -40,6 +50,14 @@ func (in *Inserter) SetResultHandler(fn func(sql.Result)) *Inserter {
 	return in
 }
 
+// SetMaxChunkBytes sets the most bytes of each chunk's statement, instead of 80% of the
+// database's MaxInsertSize, like for a proxy with a smaller limit than the server's
+func (in *Inserter) SetMaxChunkBytes(n int) *Inserter {
+	in.maxChunkBytes = n
+
+	return in
+}
+
 func (in *Inserter) SetExecutor(conn handlerWithContext) *Inserter {
 	in.conn = conn
 
@@ -50,13 +68,39 @@ func (in *Inserter) Insert(insert string, source any) error {
 	return in.insert(context.Background(), insert, source)
 }
 
+// InsertContext is like Insert, with a context. If the context has a transaction of the inserter's
+// database, like one from GetOrCreateTxFromContext, and the inserter writes to the database's writes
+// connection, the insert is part of the transaction instead of being written outside of it.
 func (in *Inserter) InsertContext(ctx context.Context, insert string, source any) error {
-	return in.insert(ctx, insert, source)
+	return in.contextTx(ctx).insert(ctx, insert, source)
+}
+
+// contextTx returns a copy of the inserter that executes on the context's transaction, when it has one
+// of the inserter's database and the inserter hasn't been given a transaction or another executor
+func (in *Inserter) contextTx(ctx context.Context) *Inserter {
+	if in.tx != nil || in.conn != handlerWithContext(in.db.Writes) {
+		return in
+	}
+
+	tx := TxFromContext(ctx)
+	if tx == nil || tx.db != in.db || tx.Tx == nil {
+		return in
+	}
+
+	txIn := *in
+	txIn.conn = tx.Tx
+	txIn.tx = tx
+
+	return &txIn
 }
 
 var ErrNoColumnNames = fmt.Errorf("no column names given")
 
 func (in *Inserter) insert(ctx context.Context, query string, source any) (err error) {
+	if in.db.readOnly {
+		return ReadOnlyError{Op: "insert", Query: query}
+	}
+
 	sv := reflectUnwrap(reflect.ValueOf(source))
 	st := sv.Type()
 
@@ -75,8 +119,8 @@ func (in *Inserter) insert(ctx context.Context, query string, source any) (err e
 	}
 
 	queryTokens := parseQuery(query)
-	if len(queryTokens) == 1 {
-		query = "insert into`" + parseName(query) + "`"
+	if parts, ok := tableNameParts(queryTokens); ok {
+		query = "insert into" + in.db.quoteTableParts(parts)
 		queryTokens = parseQuery(query)
 	}
 
@@ -109,8 +153,36 @@ DUPE_KEY_SEARCH:
 		}
 	}
 
+	// `values()` is deprecated in newer versions of MySQL, in favor of a row alias
+	if len(onDuplicateKeyUpdate) != 0 && in.db.serverInfoContext(ctx).SupportsRowAlias() {
+		if rewritten, ok := valuesToRowAlias(onDuplicateKeyUpdate); ok {
+			onDuplicateKeyUpdate = "as" + quoteIdentifier(insertRowAlias) + rewritten
+		}
+	}
+
 	columnNames := colNamesFromQuery(parseQuery(insertPart))
 
+	var tableName string
+	var changeKind ChangeKind
+	var chunkRows []any
+	trackChanges := in.db.HandleChanges != nil
+	if trackChanges {
+		tableName, _ = tableNameFromQuery(queryTokens)
+		changeKind = ChangeInsert
+		if len(onDuplicateKeyUpdate) != 0 {
+			changeKind = ChangeUpsert
+		}
+	}
+
+	if len(in.idempotencyKey) != 0 {
+		// rows that were inserted by an attempt that seemed to fail are left alone by the retries
+		if len(onDuplicateKeyUpdate) == 0 {
+			col := quoteIdentifier(in.idempotencyKey)
+			onDuplicateKeyUpdate = "on duplicate key update" + col + "=" + col
+		}
+		ctx = NewContextWithIdempotentWrites(ctx)
+	}
+
 	currentRow := sv
 	currentRowIndex := 0
 	next := func() bool {
@@ -145,31 +217,43 @@ DUPE_KEY_SEARCH:
 	}
 
 	var colOpts map[string]insertColOpts
+
+	// mapColumns are the keys of the map rows of a channel so far, see addMapColumns,
+	// and generated are the table's generated columns, which are never written
+	var mapColumns map[string]struct{}
+	var generated map[string]struct{}
+	tablePart := insertPart
+
 	if len(columnNames) == 0 {
 		if typeHasColNames(rt) {
 			switch rt.Kind() {
 			case reflect.Map:
+				if st.Kind() != reflect.Chan {
+					columnNames = colNamesFromMaps(sv, multiRow)
+					break
+				}
+
+				// the rows of channels aren't known yet, so their columns are added as they come
 				columnNames = colNamesFromMap(currentRow)
+				mapColumns = make(map[string]struct{}, len(columnNames))
+				for _, c := range columnNames {
+					mapColumns[c] = struct{}{}
+				}
 			case reflect.Struct:
 				columnNames, colOpts, _, err = colNamesFromStruct(rt)
 				if err != nil {
 					return err
 				}
 			}
-		}
 
-		s := new(strings.Builder)
-		s.WriteByte('(')
-		for i, name := range columnNames {
-			if i != 0 {
-				s.WriteByte(',')
+			generated, err = in.generatedColumns(ctx, queryTokens)
+			if err != nil {
+				return err
 			}
-			s.WriteByte('`')
-			s.WriteString(name)
-			s.WriteByte('`')
+			columnNames = writableColumns(columnNames, colOpts, generated)
 		}
-		s.WriteByte(')')
-		insertPart += s.String()
+
+		insertPart += columnList(columnNames)
 	} else {
 		switch rt.Kind() {
 		case reflect.Struct:
@@ -185,55 +269,133 @@ DUPE_KEY_SEARCH:
 		return
 	}
 
+	tenant, hasTenant := TenantFromContext(ctx)
+	for _, opts := range colOpts {
+		if opts.tenant && !hasTenant {
+			return ErrNoTenant
+		}
+	}
+
 	insertPart += "values"
 
-	insertBuf := bytes.NewBufferString(insertPart)
-	rowBuf := new(bytes.Buffer)
+	var returning string
+	var returningIndexes [][]int
+	var returningRows []reflect.Value
+	if len(in.returning) != 0 {
+		if !in.db.serverInfoContext(ctx).SupportsReturning() {
+			return ErrReturningUnsupported
+		}
+		if rt.Kind() != reflect.Struct {
+			return fmt.Errorf("cool-mysql: returning needs struct rows, got %s", rt)
+		}
+
+		returning, returningIndexes, err = returningClause(in.returning, colOpts)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the rows are already marshaled, so only the parts of the query around them
+	// need their params interpolated, instead of parsing every row again
+	ctxParams := tenantParams(ctx, nil)
+	insertPart, _, err = in.db.interpolateParams(ctx, insertPart, ctxParams...)
+	if err != nil {
+		return fmt.Errorf("failed to interpolate params: %w", err)
+	}
+	if len(onDuplicateKeyUpdate) != 0 {
+		onDuplicateKeyUpdate, _, err = in.db.interpolateParams(ctx, onDuplicateKeyUpdate, ctxParams...)
+		if err != nil {
+			return fmt.Errorf("failed to interpolate params: %w", err)
+		}
+	}
+
+	// rows are marshaled straight into the insert buffer, which is reused for every chunk,
+	// so it's only grown until it fits a chunk instead of being rebuilt for every row
+	insertBuf := append(make([]byte, 0, len(insertPart)+1024), insertPart...)
+	var rowBuf []byte
 	var rowBuffered bool
 
 	resetBuf := func() {
-		insertBuf.Truncate(len(insertPart))
+		insertBuf = insertBuf[:len(insertPart)]
 		rowBuffered = false
 	}
 
 	multiCol := isMultiColumn(rt)
 
-	buildRow := func(row reflect.Value) (string, error) {
-		rowBuf.Reset()
+	var valuers map[string]ColumnValuer
+	if len(in.db.columnValuers) != 0 {
+		table, _ := tableNameFromQuery(queryTokens)
+		valuers = in.db.columnValuersOf(table, columnNames)
+	}
 
-		rowBuf.WriteByte('(')
+	// buildRow appends the row to the insert buffer
+	buildRow := func(row reflect.Value) error {
+		insertBuf = append(insertBuf, '(')
 
 		writeValue := func(r reflect.Value, opts marshalOpt, fieldName string) error {
 			r = reflectUnwrap(r)
 
 			if !r.IsValid() {
-				rowBuf.WriteString("null")
+				insertBuf = append(insertBuf, "null"...)
 				return nil
 			}
 
 			v := r.Interface()
 
-			b, err := marshal(v, opts|marshalOptJSONSlice, fieldName, in.db.valuerFuncs)
+			b, err := appendMarshal(insertBuf, v, opts|marshalOptJSONSlice, fieldName, in.db.valuers())
 			if err != nil {
-				return fmt.Errorf("failed to marshal value: %w", err)
+				return fmt.Errorf("failed to marshal value: %w", withParamError(err, func(e *ParamError) {
+					e.Column = fieldName
+				}))
 			}
-			rowBuf.Write(b)
+			insertBuf = b
+
+			return nil
+		}
+
+		// writeColumnValue appends the value by the column's valuer, see Database.AddColumnValuer
+		writeColumnValue := func(fn ColumnValuer, r reflect.Value, col string) error {
+			var v any
+			if r.IsValid() {
+				v = r.Interface()
+			}
+
+			b, err := appendColumnValue(insertBuf, fn, col, v)
+			if err != nil {
+				return err
+			}
+			insertBuf = b
 
 			return nil
 		}
 
 		switch k := row.Kind(); true {
 		case !multiCol:
-			writeValue(row, marshalOptNone, "")
+			if err := writeValue(row, marshalOptNone, ""); err != nil {
+				return err
+			}
 		case k == reflect.Struct:
 			for i, col := range columnNames {
 				if i != 0 {
-					rowBuf.WriteByte(',')
+					insertBuf = append(insertBuf, ',')
 				}
 
 				f := row.FieldByIndex(colOpts[col].index)
 				v := reflectUnwrap(f)
 
+				if colOpts[col].tenant {
+					// the tenant column always comes from the context, so a row
+					// can't be written for another tenant by mistake
+					if !isZero(f.Interface()) && !sameTenant(f.Interface(), tenant) {
+						return fmt.Errorf("%w: got %v, want %v", ErrTenantMismatch, f.Interface(), tenant)
+					}
+
+					if err := writeValue(reflect.ValueOf(tenant), marshalOptNone, col); err != nil {
+						return err
+					}
+					continue
+				}
+
 				if colOpts[col].insertDefault {
 					pv := v
 					if v.Kind() != reflect.Ptr {
@@ -244,55 +406,127 @@ DUPE_KEY_SEARCH:
 					if v, ok := pv.Interface().(Zeroer); ok {
 						if pv.IsNil() {
 							if _, ok := pv.Type().Elem().MethodByName("IsZero"); ok {
-								rowBuf.WriteString("default")
+								insertBuf = append(insertBuf, "default"...)
 								continue
 							}
 						}
 
 						if v.IsZero() {
-							rowBuf.WriteString("default")
+							insertBuf = append(insertBuf, "default"...)
 							continue
 						}
 					}
 
 					if !f.IsValid() || f.IsZero() {
-						rowBuf.WriteString("default")
+						insertBuf = append(insertBuf, "default"...)
 						continue
 					}
 				}
 
+				if fn, ok := valuers[col]; ok {
+					if err := writeColumnValue(fn, f, col); err != nil {
+						return err
+					}
+					continue
+				}
+
+				if colOpts[col].encrypted {
+					ciphertext, err := in.db.encryptValue(f)
+					if err != nil {
+						return fmt.Errorf("failed to encrypt column %q: %w", col, err)
+					}
+
+					if err := writeValue(reflect.ValueOf(ciphertext), marshalOptNone, col); err != nil {
+						return err
+					}
+					continue
+				}
+
+				if m, ok := f.Interface().(proto.Message); ok && colOpts[col].protoJSON {
+					j, err := marshalProto(m, true)
+					if err != nil {
+						return fmt.Errorf("failed to marshal column %q: %w", col, err)
+					}
+
+					if err := writeValue(reflect.ValueOf(j), marshalOptNone, col); err != nil {
+						return err
+					}
+					continue
+				}
+
+				if typeHasEncryptedFields(v.Type()) {
+					v, err = in.db.encryptNested(v)
+					if err != nil {
+						return fmt.Errorf("failed to encrypt column %q: %w", col, err)
+					}
+				}
+
+				if colOpts[col].hasTimeZone && v.IsValid() {
+					if t, ok := v.Interface().(time.Time); ok && !t.IsZero() {
+						loc, err := in.db.timeZone(colOpts[col].timeZone)
+						if err != nil {
+							return fmt.Errorf("failed to get time zone of column %q: %w", col, err)
+						}
+						v = reflect.ValueOf(zonedTime{t: t, loc: loc})
+					}
+				}
+
 				marshalOpts := marshalOptNone
 				if colOpts[col].defaultZero {
 					marshalOpts |= marshalOptDefaultZero
 				}
-				writeValue(v, marshalOpts, col)
+				if err := writeValue(v, marshalOpts, col); err != nil {
+					return withParamError(err, func(e *ParamError) {
+						e.Field = row.Type().Name() + "." + row.Type().FieldByIndex(colOpts[col].index).Name
+					})
+				}
 			}
 		case k == reflect.Map:
 			for i, col := range columnNames {
 				if i != 0 {
-					rowBuf.WriteByte(',')
+					insertBuf = append(insertBuf, ',')
 				}
 
 				v := row.MapIndex(reflect.ValueOf(col))
 				if !v.IsValid() {
-					rowBuf.WriteString("default")
+					insertBuf = append(insertBuf, "default"...)
+					continue
+				}
+
+				if fn, ok := valuers[col]; ok {
+					if err := writeColumnValue(fn, v, col); err != nil {
+						return err
+					}
 					continue
 				}
 
-				writeValue(v, marshalOptNone, col)
+				if err := writeValue(v, marshalOptNone, col); err != nil {
+					return err
+				}
 			}
 		case k == reflect.Slice || k == reflect.Array:
 			for i := 0; i < row.Len(); i++ {
 				if i != 0 {
-					rowBuf.WriteByte(',')
+					insertBuf = append(insertBuf, ',')
+				}
+
+				if i < len(columnNames) {
+					if fn, ok := valuers[columnNames[i]]; ok {
+						if err := writeColumnValue(fn, row.Index(i), columnNames[i]); err != nil {
+							return err
+						}
+						continue
+					}
 				}
 
-				writeValue(row.Index(i), marshalOptNone, "")
+				if err := writeValue(row.Index(i), marshalOptNone, ""); err != nil {
+					return err
+				}
 			}
 		}
 
-		rowBuf.WriteByte(')')
-		return rowBuf.String(), nil
+		insertBuf = append(insertBuf, ')')
+		return nil
 	}
 
 	var start time.Time
@@ -303,11 +537,34 @@ DUPE_KEY_SEARCH:
 			return nil
 		}
 
-		insertBuf.WriteString(onDuplicateKeyUpdate)
+		insertBuf = append(insertBuf, onDuplicateKeyUpdate...)
 
-		result, err := in.db.exec(in.conn, ctx, in.tx, true, insertBuf.String())
-		if err != nil {
-			return err
+		var result sql.Result
+		if len(returning) != 0 {
+			insertBuf = append(insertBuf, returning...)
+
+			returned := reflect.New(reflect.SliceOf(rt))
+			err := in.db.queryReturning(in.conn, ctx, in.tx, returned.Interface(), string(insertBuf))
+			if err != nil {
+				return err
+			}
+
+			returned = returned.Elem()
+			if returned.Len() != len(returningRows) {
+				return fmt.Errorf("cool-mysql: inserted %d rows but %d were returned", len(returningRows), returned.Len())
+			}
+			for i, r := range returningRows {
+				for _, index := range returningIndexes {
+					r.FieldByIndex(index).Set(returned.Index(i).FieldByIndex(index))
+				}
+			}
+			returningRows = returningRows[:0]
+		} else {
+			var err error
+			result, err = in.db.execMarshaled(in.conn, ctx, in.tx, string(insertBuf))
+			if err != nil {
+				return err
+			}
 		}
 
 		if in.AfterChunkExec != nil {
@@ -319,34 +576,107 @@ DUPE_KEY_SEARCH:
 			in.HandleResult(result)
 		}
 
+		if trackChanges {
+			events := make([]ChangeEvent, len(chunkRows))
+			for i, r := range chunkRows {
+				events[i] = ChangeEvent{
+					Table: tableName,
+					Kind:  changeKind,
+					After: r,
+				}
+			}
+			in.db.reportChanges(in.tx, events)
+			chunkRows = chunkRows[:0]
+		}
+
 		resetBuf()
 		return nil
 	}
 
+	// rows from a channel of maps can have keys that the rows before them didn't, which are added to the columns
+	// of the chunks from then on, since the rows after them could have them too, see colNamesFromMaps
+	addMapColumns := func(row reflect.Value) error {
+		added := false
+		iter := row.MapRange()
+		for iter.Next() {
+			col := iter.Key().String()
+			if _, ok := mapColumns[col]; ok {
+				continue
+			}
+			mapColumns[col] = struct{}{}
+
+			if len(writableColumns([]string{col}, nil, generated)) != 0 {
+				columnNames = append(columnNames, col)
+				added = true
+			}
+		}
+		if !added {
+			return nil
+		}
+
+		if err := insert(); err != nil {
+			return err
+		}
+
+		sort.Strings(columnNames)
+		insertPart, _, err = in.db.interpolateParams(ctx, tablePart+columnList(columnNames)+"values", ctxParams...)
+		if err != nil {
+			return fmt.Errorf("failed to interpolate params: %w", err)
+		}
+		insertBuf = append(insertBuf[:0], insertPart...)
+
+		if len(in.db.columnValuers) != 0 {
+			table, _ := tableNameFromQuery(queryTokens)
+			valuers = in.db.columnValuersOf(table, columnNames)
+		}
+
+		return nil
+	}
+
 	for {
 		start = time.Now()
 
-		var row string
-		row, err = buildRow(currentRow)
-		if err != nil {
+		if mapColumns != nil && currentRow.IsValid() {
+			if err = addMapColumns(currentRow); err != nil {
+				return err
+			}
+		}
+
+		rowStart := len(insertBuf)
+		if rowBuffered {
+			insertBuf = append(insertBuf, ',')
+		}
+
+		if err = buildRow(currentRow); err != nil {
 			return err
 		}
 
-		// buffer will be too big with this row, exec first and reset buffer
-		if insertBuf.Len()+len(row)+len(onDuplicateKeyUpdate) > int(float64(in.db.MaxInsertSize.Get())*0.80) {
+		// buffer is too big with this row, so exec the rows before it first,
+		// and then start the next chunk with it
+		if rowBuffered && len(insertBuf)+len(onDuplicateKeyUpdate)+len(returning) > in.chunkBytes(ctx) {
+			rowBuf = append(rowBuf[:0], insertBuf[rowStart+1:]...)
+			insertBuf = insertBuf[:rowStart]
+
 			if err = insert(); err != nil {
 				return
 			}
-		}
 
-		if rowBuffered {
-			insertBuf.WriteByte(',')
+			insertBuf = append(insertBuf, rowBuf...)
 		}
 
-		insertBuf.WriteString(row)
-
 		rowBuffered = true
 
+		if len(returning) != 0 {
+			if !currentRow.CanSet() {
+				return fmt.Errorf("cool-mysql: rows must be pointers or in a slice to be populated by returning")
+			}
+			returningRows = append(returningRows, currentRow)
+		}
+
+		if trackChanges && currentRow.IsValid() {
+			chunkRows = append(chunkRows, currentRow.Interface())
+		}
+
 		if in.AfterRowExec != nil {
 			in.AfterRowExec(start)
 		}
@@ -363,11 +693,70 @@ DUPE_KEY_SEARCH:
 	return nil
 }
 
+// columnList returns the parenthesized list of the quoted columns
+func columnList(columns []string) string {
+	s := new(strings.Builder)
+	s.WriteByte('(')
+	for i, name := range columns {
+		if i != 0 {
+			s.WriteByte(',')
+		}
+		s.WriteByte('`')
+		s.WriteString(name)
+		s.WriteByte('`')
+	}
+	s.WriteByte(')')
+
+	return s.String()
+}
+
+// chunkBytes returns the most bytes of each chunk's statement
+func (in *Inserter) chunkBytes(ctx context.Context) int {
+	if in.maxChunkBytes > 0 {
+		return in.maxChunkBytes
+	}
+
+	return int(float64(in.db.maxInsertSize(ctx)) * 0.80)
+}
+
 func colNamesFromMap(v reflect.Value) (columns []string) {
 	keys := make([]string, 0, v.Len())
 	for _, k := range v.MapKeys() {
 		keys = append(keys, k.String())
 	}
+
+	// sorted so the same rows always make the same query
+	sort.Strings(keys)
+	return keys
+}
+
+// colNamesFromMaps returns the union of the keys of the map rows, so rows with different keys, like ones decoded
+// from JSON, can be inserted together, where each row inserts the defaults of the columns it doesn't have
+func colNamesFromMaps(v reflect.Value, multiRow bool) []string {
+	if !multiRow {
+		return colNamesFromMap(v)
+	}
+
+	seen := make(map[string]struct{})
+	var keys []string
+	for i := 0; i < v.Len(); i++ {
+		row := reflectUnwrap(v.Index(i))
+		if !row.IsValid() {
+			continue
+		}
+
+		iter := row.MapRange()
+		for iter.Next() {
+			k := iter.Key().String()
+			if _, ok := seen[k]; !ok {
+				seen[k] = struct{}{}
+				keys = append(keys, k)
+			}
+		}
+	}
+
+	// sorted so the same rows always make the same query
+	sort.Strings(keys)
 	return keys
 }
 
@@ -375,6 +764,21 @@ type insertColOpts struct {
 	index         []int
 	insertDefault bool
 	defaultZero   bool
+	tenant        bool
+	encrypted     bool
+	protoJSON     bool
+	generated     bool
+	readonly      bool
+
+	// timeZone is the zone of a time column, if hasTimeZone, see tagTimeZone
+	timeZone    string
+	hasTimeZone bool
+}
+
+// writable returns false if the column is tagged `generated` or `readonly`,
+// so it's never written by inserts or updates, even though it's still selected
+func (o insertColOpts) writable() bool {
+	return !o.generated && !o.readonly
 }
 
 func colNamesFromStruct(t reflect.Type) (columns []string, colOpts map[string]insertColOpts, colFieldMap map[string]string, err error) {
@@ -395,7 +799,8 @@ func colNamesFromStruct(t reflect.Type) (columns []string, colOpts map[string]in
 
 		t, _ := structtag.Parse(string(f.Tag))
 		if t, _ := t.Get("mysql"); t != nil {
-			if t.Name == "-" {
+			// the fields that Load fills with their children aren't columns
+			if t.Name == "-" || t.HasOption("load") {
 				continue
 			}
 
@@ -408,6 +813,12 @@ func colNamesFromStruct(t reflect.Type) (columns []string, colOpts map[string]in
 
 			opts.insertDefault = t.HasOption("insertDefault") || t.HasOption("omitempty")
 			opts.defaultZero = t.HasOption("defaultzero")
+			opts.tenant = t.HasOption("tenant")
+			opts.encrypted = t.HasOption("encrypted")
+			opts.protoJSON = t.HasOption("protojson")
+			opts.generated = t.HasOption("generated")
+			opts.readonly = t.HasOption("readonly")
+			opts.timeZone, opts.hasTimeZone = tagTimeZone(t)
 		}
 
 		columns = append(columns, column)
@@ -0,0 +1,184 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+
+	"github.com/StirlingMarketingGroup/cool-mysql/internal/testdriver"
+)
+
+func TestInserter_InsertContextTx(t *testing.T) {
+	d := &testdriver.Driver{Columns: []string{"0"}, Row: []driver.Value{int64(0)}, Tx: true}
+	db := testDatabase(t, d)
+	conn := db.Writes
+
+	// reads are a pool of their own, so queries are only on the writes' pool inside a tx of it
+	db.Reads = sql.OpenDB(d)
+	t.Cleanup(func() {
+		db.Reads.Close()
+	})
+
+	inTx := make(map[string]bool)
+	db.Log = func(detail LogDetail) {
+		inTx[detail.Query] = detail.Tx != nil
+	}
+
+	tx, cancel, err := db.BeginTx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+
+	other := benchDatabase(t)
+	other.Writes, other.Reads = conn, conn
+	otherTx, otherCancel, err := other.BeginTx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer otherCancel()
+
+	type row struct {
+		ID int
+	}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		insert func(ctx context.Context, table string) error
+		wantTx bool
+	}{
+		{
+			name: "insert without tx",
+			ctx:  context.Background(),
+			insert: func(ctx context.Context, table string) error {
+				return db.InsertContext(ctx, table, row{1})
+			},
+		},
+		{
+			name: "insert with tx",
+			ctx:  NewContextWithTx(context.Background(), tx),
+			insert: func(ctx context.Context, table string) error {
+				return db.InsertContext(ctx, table, row{1})
+			},
+			wantTx: true,
+		},
+		{
+			name: "upsert with tx",
+			ctx:  NewContextWithTx(context.Background(), tx),
+			insert: func(ctx context.Context, table string) error {
+				return db.UpsertContext(ctx, table, []string{"ID"}, nil, "", nil, row{1})
+			},
+			wantTx: true,
+		},
+		{
+			name: "insert with options and tx",
+			ctx:  NewContextWithTx(context.Background(), tx),
+			insert: func(ctx context.Context, table string) error {
+				return db.I().SetMaxChunkBytes(1<<10).InsertContext(ctx, table, row{1})
+			},
+			wantTx: true,
+		},
+		{
+			name: "insert with another executor",
+			ctx:  NewContextWithTx(context.Background(), tx),
+			insert: func(ctx context.Context, table string) error {
+				return db.I().SetExecutor(db.Reads).InsertContext(ctx, table, row{1})
+			},
+		},
+		{
+			name: "insert with another database's tx",
+			ctx:  NewContextWithTx(context.Background(), otherTx),
+			insert: func(ctx context.Context, table string) error {
+				return db.InsertContext(ctx, table, row{1})
+			},
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := "t" + string(rune('a'+i))
+			if err := tt.insert(tt.ctx, table); err != nil {
+				t.Fatal(err)
+			}
+
+			query := "insert into`" + table + "`(`ID`)values(1)"
+			gotTx, ok := inTx[query]
+			if !ok {
+				t.Fatalf("didn't run %q, ran %v", query, inTx)
+			}
+			if gotTx != tt.wantTx {
+				t.Errorf("ran the insert in a tx = %v, want %v", gotTx, tt.wantTx)
+			}
+		})
+	}
+}
+
+func TestInserter_InsertHeterogeneousMaps(t *testing.T) {
+	rows := func() []map[string]any {
+		return []map[string]any{
+			{"a": 1},
+			{"a": 2, "b": 3},
+			{"b": 4},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		source func() any
+		want   []string
+	}{
+		{
+			name:   "slice",
+			source: func() any { return rows() },
+			want:   []string{"insert into`t`(`a`,`b`)values(1,default),(2,3),(default,4)"},
+		},
+		{
+			name: "channel",
+			source: func() any {
+				ch := make(chan map[string]any, 3)
+				for _, r := range rows() {
+					ch <- r
+				}
+				close(ch)
+				return ch
+			},
+			want: []string{
+				"insert into`t`(`a`)values(1)",
+				"insert into`t`(`a`,`b`)values(2,3),(default,4)",
+			},
+		},
+		{
+			name: "channel with new columns sorted",
+			source: func() any {
+				ch := make(chan map[string]any, 2)
+				ch <- map[string]any{"b": 1}
+				ch <- map[string]any{"a": 2, "c": 3}
+				close(ch)
+				return ch
+			},
+			want: []string{
+				"insert into`t`(`b`)values(1)",
+				"insert into`t`(`a`,`b`,`c`)values(2,default,3)",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := benchDatabase(t)
+
+			var queries []string
+			db.Log = func(detail LogDetail) {
+				queries = append(queries, detail.Query)
+			}
+
+			if err := db.Insert("t", tt.source()); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(queries, tt.want) {
+				t.Errorf("Insert() ran %q, want %q", queries, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// Package testdriver is the fake database/sql driver of cool-mysql's tests and benchmarks,
+// so queries can be run through the whole package without a MySQL server
+package testdriver
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Driver is a driver whose every query returns the same rows, reusing its buffers between rows
+// like the mysql driver, and whose every exec affects one row, unless ExecFunc or QueryFunc say otherwise
+type Driver struct {
+	Columns []string
+	Row     []driver.Value
+	Rows    int
+
+	// ExecFunc, if set, returns the result of every exec instead
+	ExecFunc func(ctx context.Context, query string) (driver.Result, error)
+
+	// QueryFunc, if set, returns the rows of every query instead
+	QueryFunc func(ctx context.Context, query string) (driver.Rows, error)
+
+	// Tx lets the driver's connections begin transactions, which do nothing
+	Tx bool
+
+	// Execs is the number of execs run, like the chunks of an insert
+	Execs atomic.Int64
+
+	// Closes is the number of connections closed, like ones discarded as bad
+	Closes atomic.Int64
+}
+
+// Wide returns a driver whose rows have columns Text0, Text1, and so on, of width bytes each
+func Wide(columns, width, rows int) *Driver {
+	d := &Driver{Rows: rows}
+	for i := 0; i < columns; i++ {
+		d.Columns = append(d.Columns, "Text"+strconv.Itoa(i))
+		d.Row = append(d.Row, []byte(strings.Repeat("x", width)))
+	}
+
+	return d
+}
+
+func (d *Driver) Open(name string) (driver.Conn, error) { return conn{d}, nil }
+
+func (d *Driver) Connect(ctx context.Context) (driver.Conn, error) { return conn{d}, nil }
+
+func (d *Driver) Driver() driver.Driver { return d }
+
+type conn struct{ d *Driver }
+
+func (c conn) Prepare(query string) (driver.Stmt, error) { return stmt(c), nil }
+
+func (c conn) Close() error {
+	c.d.Closes.Add(1)
+	return nil
+}
+
+func (c conn) Begin() (driver.Tx, error) {
+	if !c.d.Tx {
+		return nil, driver.ErrSkip
+	}
+
+	return c, nil
+}
+
+func (c conn) Commit() error   { return nil }
+func (c conn) Rollback() error { return nil }
+
+func (c conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.d.Execs.Add(1)
+	if c.d.ExecFunc != nil {
+		return c.d.ExecFunc(ctx, query)
+	}
+
+	return driver.RowsAffected(1), nil
+}
+
+func (c conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if c.d.QueryFunc != nil {
+		return c.d.QueryFunc(ctx, query)
+	}
+
+	return &rows{columns: c.d.Columns, row: c.d.Row, n: c.d.Rows}, nil
+}
+
+type stmt struct{ d *Driver }
+
+func (s stmt) Close() error                                    { return nil }
+func (s stmt) NumInput() int                                   { return -1 }
+func (s stmt) Exec(args []driver.Value) (driver.Result, error) { return nil, driver.ErrSkip }
+
+func (s stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return conn(s).QueryContext(context.Background(), "", nil)
+}
+
+// NewRows returns rows of the columns, for QueryFunc, with each of the values as a row
+func NewRows(columns []string, values ...[]driver.Value) driver.Rows {
+	return &rows{columns: columns, values: values, n: len(values)}
+}
+
+type rows struct {
+	columns []string
+	row     []driver.Value
+	values  [][]driver.Value
+	n       int
+	i       int
+}
+
+// Columns returns a copy of the columns, like real drivers, since selects lowercase them in place
+func (r *rows) Columns() []string { return append([]string(nil), r.columns...) }
+func (r *rows) Close() error      { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.i == r.n {
+		return io.EOF
+	}
+
+	if r.values != nil {
+		copy(dest, r.values[r.i])
+	} else {
+		copy(dest, r.row)
+	}
+	r.i++
+
+	return nil
+}
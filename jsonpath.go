@@ -0,0 +1,88 @@
+package mysql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrNoWhere is returned by updates that would change every row of a table
+var ErrNoWhere = errors.New("cool-mysql: update needs a where clause")
+
+// SelectJSONPath selects the value at the json path, like `$.address.city`, of the column
+// for every row of the table that matches the where clause, unmarshaling each into T.
+// Rows without a value at the path are left out.
+func SelectJSONPath[T any](ctx context.Context, db *Database, table, column, path, where string, cache time.Duration, params ...any) ([]T, error) {
+	q := "select json_extract(" + quoteIdentifier(column) + ",@@__JSONPath)`Value`from" + db.quoteTable(table) +
+		"where json_contains_path(" + quoteIdentifier(column) + ",'one',@@__JSONPath)"
+	if len(strings.TrimSpace(where)) != 0 {
+		q += "and(" + where + ")"
+	}
+
+	var values []json.RawMessage
+	err := db.SelectContext(ctx, &values, q, cache, append(append(make([]any, 0, len(params)+1), params...), Params{
+		"__JSONPath": path,
+	})...)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]T, len(values))
+	for i, v := range values {
+		if err := json.Unmarshal(v, &out[i]); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal json path %q: %w", path, err)
+		}
+	}
+
+	return out, nil
+}
+
+// UpdateJSONPatch merges the patch, marshaled to json, into the json column of every row
+// that matches the where clause with `json_merge_patch`, where null values in the
+// patch remove their keys. Rows with a null column are treated as empty objects.
+func (db *Database) UpdateJSONPatch(ctx context.Context, table, column string, patch any, where string, params ...any) error {
+	j, err := json.Marshal(patch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal json patch: %w", err)
+	}
+
+	return db.updateJSON(ctx, table, column,
+		"json_merge_patch(coalesce("+quoteIdentifier(column)+",'{}'),@@__JSONValue)",
+		where, params, Params{"__JSONValue": json.RawMessage(j)})
+}
+
+// UpdateJSONSet sets the value at the json path, like `$.address.city`, of the json column
+// of every row that matches the where clause with `json_set`, where the value is marshaled to json
+func (db *Database) UpdateJSONSet(ctx context.Context, table, column, path string, value any, where string, params ...any) error {
+	j, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal json value: %w", err)
+	}
+
+	// json_extract of the whole document is how both MySQL and MariaDB
+	// take a string as json instead of as a json string
+	return db.updateJSON(ctx, table, column,
+		"json_set(coalesce("+quoteIdentifier(column)+",'{}'),@@__JSONPath,json_extract(@@__JSONValue,'$'))",
+		where, params, Params{"__JSONPath": path, "__JSONValue": json.RawMessage(j)})
+}
+
+// UpdateJSONRemove removes the value at the json path of the json column
+// of every row that matches the where clause with `json_remove`
+func (db *Database) UpdateJSONRemove(ctx context.Context, table, column, path string, where string, params ...any) error {
+	return db.updateJSON(ctx, table, column,
+		"json_remove("+quoteIdentifier(column)+",@@__JSONPath)",
+		where, params, Params{"__JSONPath": path})
+}
+
+func (db *Database) updateJSON(ctx context.Context, table, column, expr, where string, params []any, jsonParams Params) error {
+	if len(strings.TrimSpace(where)) == 0 {
+		return ErrNoWhere
+	}
+
+	q := "update" + db.quoteTable(table) + "set" + quoteIdentifier(column) + "=" + expr + "where(" + where + ")"
+
+	return db.ExecContext(ctx, q, append(append(make([]any, 0, len(params)+1), params...), jsonParams)...)
+}
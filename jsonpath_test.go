@@ -0,0 +1,94 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/StirlingMarketingGroup/cool-mysql/internal/testdriver"
+)
+
+func TestSelectJSONPath(t *testing.T) {
+	db := testDatabase(t, &testdriver.Driver{
+		Columns: []string{"Value"},
+		Row:     []driver.Value{[]byte(`"Austin"`)},
+		Rows:    2,
+	})
+
+	var queries []string
+	db.Log = func(detail LogDetail) {
+		queries = append(queries, detail.Query)
+	}
+
+	cities, err := SelectJSONPath[string](context.Background(), db, "users", "Address", "$.city", "`Active`or`Admin`", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Austin", "Austin"}; !reflect.DeepEqual(cities, want) {
+		t.Errorf("SelectJSONPath() = %v, want %v", cities, want)
+	}
+
+	if len(queries) != 1 || !strings.HasPrefix(queries[0], "select json_extract(`Address`,") ||
+		!strings.Contains(queries[0], "from`users`where json_contains_path(`Address`,'one',") ||
+		!strings.HasSuffix(queries[0], "and(`Active`or`Admin`)") {
+		t.Errorf("SelectJSONPath() ran %q", queries)
+	}
+}
+
+func TestDatabase_UpdateJSON(t *testing.T) {
+	db := benchDatabase(t)
+
+	var queries []string
+	db.Log = func(detail LogDetail) {
+		queries = append(queries, detail.Query)
+	}
+
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		update func(where string) error
+		prefix string
+	}{
+		{
+			name: "patch",
+			update: func(where string) error {
+				return db.UpdateJSONPatch(ctx, "users", "Address", map[string]any{"city": nil}, where)
+			},
+			prefix: "update`users`set`Address`=json_merge_patch(coalesce(`Address`,'{}'),",
+		},
+		{
+			name: "set",
+			update: func(where string) error {
+				return db.UpdateJSONSet(ctx, "users", "Address", "$.city", "Austin", where)
+			},
+			prefix: "update`users`set`Address`=json_set(coalesce(`Address`,'{}'),",
+		},
+		{
+			name: "remove",
+			update: func(where string) error {
+				return db.UpdateJSONRemove(ctx, "users", "Address", "$.city", where)
+			},
+			prefix: "update`users`set`Address`=json_remove(`Address`,",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.update(" "); !errors.Is(err, ErrNoWhere) {
+				t.Errorf("update without a where clause error = %v, want ErrNoWhere", err)
+			}
+
+			queries = nil
+			if err := tt.update("`Active`or`Admin`"); err != nil {
+				t.Fatal(err)
+			}
+
+			// the where clause is parenthesized, so its `or` can't escape it
+			if len(queries) != 1 || !strings.HasPrefix(queries[0], tt.prefix) || !strings.HasSuffix(queries[0], "where(`Active`or`Admin`)") {
+				t.Errorf("update ran %q, want it to start with %q", queries, tt.prefix)
+			}
+		})
+	}
+}
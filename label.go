@@ -0,0 +1,86 @@
+package mysql
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// SetApplicationName sets the name of the service sending the database's queries, which is added
+// to the comment at the end of every one of them, like `/*application='checkout'*/`, so the load in
+// performance_schema, the process list, and the slow log can be attributed to it. See WithLabels.
+func (db *Database) SetApplicationName(name string) *Database {
+	db.applicationName = name
+	return db
+}
+
+// ApplicationName returns the name set by SetApplicationName
+func (db *Database) ApplicationName() string {
+	return db.applicationName
+}
+
+var labelsKey = key(11)
+
+// WithLabels returns a new context.Context with the labels, like the endpoint or job a query is run for,
+// added to the comment at the end of every query run with it, along with the database's application name,
+// like `/*application='checkout',endpoint='%2Fcart'*/`. The labels are written like sqlcommenter writes them,
+// sorted by key and with their values URL encoded, so tools that parse them can read them.
+// Labels with the same keys as the context's labels replace them.
+func WithLabels(ctx context.Context, labels map[string]string) context.Context {
+	merged := make(map[string]string, len(labels))
+	for k, v := range LabelsFromContext(ctx) {
+		merged[k] = v
+	}
+	for k, v := range labels {
+		merged[k] = v
+	}
+
+	return context.WithValue(ctx, labelsKey, merged)
+}
+
+// LabelsFromContext returns the labels of the context, see WithLabels
+func LabelsFromContext(ctx context.Context) map[string]string {
+	labels, _ := ctx.Value(labelsKey).(map[string]string)
+	return labels
+}
+
+// labelsComment returns the comment of the application name and the context's labels, or nothing if there are none
+func (db *Database) labelsComment(ctx context.Context) string {
+	labels := LabelsFromContext(ctx)
+	if len(db.applicationName) == 0 && len(labels) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(labels)+1)
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	if _, ok := labels["application"]; !ok && len(db.applicationName) != 0 {
+		keys = append(keys, "application")
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("/*")
+	for i, k := range keys {
+		v, ok := labels[k]
+		if !ok {
+			v = db.applicationName
+		}
+
+		if i != 0 {
+			b.WriteByte(',')
+		}
+
+		// url encoding leaves nothing in the comment that could end it early,
+		// and spaces as %20 keep a first key from starting a `/*+` optimizer hint
+		b.WriteString(strings.ReplaceAll(url.QueryEscape(k), "+", "%20"))
+		b.WriteString("='")
+		b.WriteString(strings.ReplaceAll(url.QueryEscape(v), "+", "%20"))
+		b.WriteByte('\'')
+	}
+	b.WriteString("*/")
+
+	return b.String()
+}
@@ -0,0 +1,34 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithLabels(t *testing.T) {
+	ctx := WithLabels(context.Background(), map[string]string{"endpoint": "/cart", "job": "nightly"})
+	ctx = WithLabels(ctx, map[string]string{"job": "hourly */ drop"})
+
+	tests := []struct {
+		name            string
+		applicationName string
+		comment         string
+		ctx             context.Context
+		want            string
+	}{
+		{"none", "", "", context.Background(), "select 1"},
+		{"application", "checkout", "", context.Background(), "select 1/*application='checkout'*/"},
+		{"labels", "", "", ctx, "select 1/*endpoint='%2Fcart',job='hourly%20%2A%2F%20drop'*/"},
+		{"application and labels", "checkout", "", ctx, "select 1/*application='checkout',endpoint='%2Fcart',job='hourly%20%2A%2F%20drop'*/"},
+		{"application label", "checkout", "", WithLabels(ctx, map[string]string{"application": "cart"}), "select 1/*application='cart',endpoint='%2Fcart',job='hourly%20%2A%2F%20drop'*/"},
+		{"view comment", "checkout", "report", context.Background(), "select 1/* report*//*application='checkout'*/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := new(Database).SetApplicationName(tt.applicationName).With(WithComment(tt.comment))
+			if got := db.commented(tt.ctx, "select 1"); got != tt.want {
+				t.Errorf("commented() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
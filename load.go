@@ -0,0 +1,156 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/fatih/structtag"
+)
+
+// Load selects the children of all of the parents with one query per chunk of parent keys, instead of one
+// query per parent, and appends each child to the field of its parent tagged `load`, like
+//
+//	type Order struct {
+//		ID    int
+//		Lines []OrderLine `mysql:",load"`
+//	}
+//
+//	var lines []OrderLine
+//	err := mysql.Load(ctx, db, orders, "ID", &lines, "select*from`order_lines`where`OrderID`in(@@Keys)", "OrderID")
+//
+// The query selects the children of the parent keys in `@@Keys`, and childKey is the column of the children
+// that matches the parentKey column of the parents. Every child selected is also put in children, if it isn't nil.
+// The fields tagged `load` aren't columns, so they're never selected or written themselves.
+func Load[P, C any](ctx context.Context, db *Database, parents []P, parentKey string, children *[]C, childQuery string, childKey string, params ...any) error {
+	pt := reflectUnwrapType(reflect.TypeOf((*P)(nil)).Elem())
+	ct := reflect.TypeOf((*C)(nil)).Elem()
+	if pt.Kind() != reflect.Struct {
+		return fmt.Errorf("cool-mysql: parents must be structs, got %s", pt)
+	}
+
+	parentKeyIndex, err := columnIndex(pt, parentKey)
+	if err != nil {
+		return err
+	}
+	childKeyIndex, err := columnIndex(reflectUnwrapType(ct), childKey)
+	if err != nil {
+		return err
+	}
+	loadIndex, err := loadFieldIndex(pt, ct)
+	if err != nil {
+		return err
+	}
+
+	// the parents are grouped by their keys, and their children are reset so loading again doesn't duplicate them
+	parentKeyType := reflectUnwrapType(pt.FieldByIndex(parentKeyIndex).Type)
+	keys := make([]any, 0, len(parents))
+	byKey := make(map[any][]reflect.Value, len(parents))
+	for i := range parents {
+		p := reflectUnwrap(reflect.ValueOf(&parents[i]))
+		if p.Kind() != reflect.Struct {
+			continue
+		}
+
+		load := p.FieldByIndex(loadIndex)
+		load.Set(reflect.Zero(load.Type()))
+
+		k := reflectUnwrap(p.FieldByIndex(parentKeyIndex))
+		if k.Kind() == reflect.Pointer {
+			continue
+		}
+		key := k.Interface()
+		if _, ok := byKey[key]; !ok {
+			keys = append(keys, key)
+		}
+		byKey[key] = append(byKey[key], load)
+	}
+
+	size := LoadChunkSize
+	if size < 1 {
+		size = 1
+	}
+
+	var all []C
+	for start := 0; start < len(keys); start += size {
+		end := start + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		var chunk []C
+		err := db.SelectContext(ctx, &chunk, childQuery, 0, append(params[:len(params):len(params)], Params{"Keys": keys[start:end]})...)
+		if err != nil {
+			return fmt.Errorf("failed to load children: %w", err)
+		}
+		all = append(all, chunk...)
+	}
+
+	for i := range all {
+		c := reflectUnwrap(reflect.ValueOf(&all[i]))
+		if c.Kind() != reflect.Struct {
+			continue
+		}
+
+		k := reflectUnwrap(c.FieldByIndex(childKeyIndex))
+		if k.Kind() == reflect.Pointer {
+			continue
+		}
+		if k.Type() != parentKeyType {
+			if !k.Type().ConvertibleTo(parentKeyType) {
+				return fmt.Errorf("cool-mysql: child key column %q of %s can't be compared to parent key column %q of %s", childKey, ct, parentKey, pt)
+			}
+			k = k.Convert(parentKeyType)
+		}
+
+		for _, load := range byKey[k.Interface()] {
+			child := reflect.ValueOf(&all[i]).Elem()
+			if load.Type().Elem() != ct {
+				child = child.Addr()
+			}
+			load.Set(reflect.Append(load, child))
+		}
+	}
+
+	if children != nil {
+		*children = all
+	}
+
+	return nil
+}
+
+// columnIndex returns the index of the struct's field of the column
+func columnIndex(t reflect.Type, column string) ([]int, error) {
+	_, colOpts, _, err := colNamesFromStruct(t)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, ok := colOpts[column]
+	if !ok {
+		return nil, fmt.Errorf("cool-mysql: %s has no column %q", t, column)
+	}
+
+	return opts.index, nil
+}
+
+// loadFieldIndex returns the index of the struct's field tagged `load` that's a slice of the children
+func loadFieldIndex(t reflect.Type, child reflect.Type) ([]int, error) {
+	for _, i := range StructFieldIndexes(t) {
+		f := t.FieldByIndex(i)
+		tags, _ := structtag.Parse(string(f.Tag))
+		if tags == nil {
+			continue
+		}
+		tag, _ := tags.Get("mysql")
+		if _, ok := tagOption(tag, "load"); !ok {
+			continue
+		}
+
+		if f.Type.Kind() == reflect.Slice && (f.Type.Elem() == child || f.Type.Elem() == reflect.PointerTo(child)) {
+			return i, nil
+		}
+	}
+
+	return nil, fmt.Errorf("cool-mysql: %s has no field of []%s or []*%s tagged `load`", t, child, child)
+}
@@ -0,0 +1,56 @@
+package mysql
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	db := benchDatabase(t)
+
+	var queries int
+	db.Log = func(detail LogDetail) {
+		queries++
+	}
+
+	type child struct {
+		Text0 string
+		Text1 string
+	}
+	type parent struct {
+		Key      string
+		Children []*child `mysql:",load"`
+	}
+
+	// every query of the bench database returns the same 1000 rows
+	x := strings.Repeat("x", 1024)
+	parents := []parent{{Key: x}, {Key: "y"}, {Key: x}}
+
+	defer func(size int) { LoadChunkSize = size }(LoadChunkSize)
+	LoadChunkSize = 1
+
+	var children []child
+	err := Load(context.Background(), db, parents, "Key", &children, "select*from`children`where`Text0`in(@@Keys)", "Text0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if queries != 2 {
+		t.Errorf("Load() ran %d queries, want one for each of the 2 unique keys", queries)
+	}
+	if len(children) != 2000 {
+		t.Errorf("Load() selected %d children, want 2000", len(children))
+	}
+	if len(parents[0].Children) != 2000 || len(parents[1].Children) != 0 || len(parents[2].Children) != 2000 {
+		t.Errorf("Load() loaded %d, %d, and %d children, want 2000, 0, and 2000",
+			len(parents[0].Children), len(parents[1].Children), len(parents[2].Children))
+	}
+	if parents[0].Children[0] != &children[0] {
+		t.Error("Load() should load pointers to the selected children")
+	}
+
+	if err := Load(context.Background(), db, parents, "Key", (*[]child)(nil), "", "Missing"); err == nil {
+		t.Error("Load() with a missing child key column should fail")
+	}
+}
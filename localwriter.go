@@ -0,0 +1,375 @@
+package mysql
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LocalWriterOptions configure how a LocalWriter rotates its files
+type LocalWriterOptions struct {
+	// MaxFileSize rotates to a new file once the current one has this many bytes
+	// of statements, where 0 means no limit
+	MaxFileSize int64
+
+	// MaxFileAge rotates to a new file once the current one is this old,
+	// where 0 means no limit
+	MaxFileAge time.Duration
+
+	// Gzip compresses the files
+	Gzip bool
+}
+
+// localWriterManifest is the file listing the finished files of a LocalWriter's dir, in order
+const localWriterManifest = "MANIFEST"
+
+// localWriterProgress is the file recording how far ReplayLocalWrites got
+const localWriterProgress = "REPLAY_PROGRESS"
+
+// LocalWriter captures writes as SQL statements in local files instead of executing them,
+// to be replayed later with ReplayLocalWrites, like when the database is unreachable
+// or for moving writes between environments. Files are rotated by size or age, and
+// each finished file is added to a manifest in the order the statements were written.
+type LocalWriter struct {
+	db   *Database
+	dir  string
+	opts LocalWriterOptions
+
+	mu      sync.Mutex
+	seq     int
+	name    string
+	file    *os.File
+	w       *bufio.Writer
+	gz      *gzip.Writer
+	size    int64
+	opened  time.Time
+	written int
+}
+
+var _ Execer = new(LocalWriter)
+
+// NewLocalWriter returns a writer of statements to files in dir, which is created if needed.
+// The db is only used to interpolate params, so it can't be nil, but doesn't need to be connected.
+// Writing continues after the last file in the dir's manifest.
+func NewLocalWriter(db *Database, dir string, opts LocalWriterOptions) (*LocalWriter, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create local writer dir: %w", err)
+	}
+
+	files, err := readLocalWriterManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalWriter{
+		db:   db,
+		dir:  dir,
+		opts: opts,
+		seq:  len(files),
+	}, nil
+}
+
+// Exec writes the query with its params interpolated
+func (w *LocalWriter) Exec(query string, params ...any) error {
+	return w.ExecContext(context.Background(), query, params...)
+}
+
+// ExecContext writes the query with its params interpolated
+func (w *LocalWriter) ExecContext(ctx context.Context, query string, params ...any) error {
+	_, err := w.ExecContextResult(ctx, query, params...)
+	return err
+}
+
+// ExecResult writes the query with its params interpolated, where the result has no rows affected
+func (w *LocalWriter) ExecResult(query string, params ...any) (sql.Result, error) {
+	return w.ExecContextResult(context.Background(), query, params...)
+}
+
+// ExecContextResult writes the query with its params interpolated, where the result has no rows affected
+func (w *LocalWriter) ExecContextResult(ctx context.Context, query string, params ...any) (sql.Result, error) {
+	params = tenantParams(ctx, params)
+
+	replacedQuery, _, err := w.db.interpolateParams(ctx, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to interpolate params: %w", err)
+	}
+
+	if err := w.write(replacedQuery); err != nil {
+		return nil, err
+	}
+
+	return driver.RowsAffected(0), nil
+}
+
+func (w *LocalWriter) write(stmt string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file != nil && ((w.opts.MaxFileSize > 0 && w.size >= w.opts.MaxFileSize) ||
+		(w.opts.MaxFileAge > 0 && time.Since(w.opened) >= w.opts.MaxFileAge)) {
+		if err := w.finish(); err != nil {
+			return err
+		}
+	}
+
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+
+	var out io.Writer = w.w
+	if w.gz != nil {
+		out = w.gz
+	}
+
+	stmt = strings.TrimSpace(stmt)
+	n, err := io.WriteString(out, stmt+";\n")
+	w.size += int64(n)
+	w.written++
+	if err != nil {
+		return fmt.Errorf("failed to write statement: %w", err)
+	}
+
+	return nil
+}
+
+func (w *LocalWriter) open() error {
+	w.seq++
+	w.name = fmt.Sprintf("%06d.sql", w.seq)
+	if w.opts.Gzip {
+		w.name += ".gz"
+	}
+
+	f, err := os.OpenFile(filepath.Join(w.dir, w.name), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create local writer file: %w", err)
+	}
+
+	w.file = f
+	w.w = bufio.NewWriter(f)
+	if w.opts.Gzip {
+		w.gz = gzip.NewWriter(w.w)
+	}
+	w.size = 0
+	w.written = 0
+	w.opened = time.Now()
+
+	return nil
+}
+
+// finish closes the current file and adds it to the manifest
+func (w *LocalWriter) finish() error {
+	if w.gz != nil {
+		if err := w.gz.Close(); err != nil {
+			return fmt.Errorf("failed to close gzip writer: %w", err)
+		}
+		w.gz = nil
+	}
+	if err := w.w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush local writer file: %w", err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync local writer file: %w", err)
+	}
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to close local writer file: %w", err)
+	}
+	w.file = nil
+
+	m, err := os.OpenFile(filepath.Join(w.dir, localWriterManifest), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open manifest: %w", err)
+	}
+	defer m.Close()
+
+	if _, err := fmt.Fprintf(m, "%s %d\n", w.name, w.written); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	return m.Sync()
+}
+
+// Rotate finishes the current file, adding it to the manifest, so its statements can be replayed
+func (w *LocalWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+
+	return w.finish()
+}
+
+// Close finishes the current file. Statements in a file that wasn't finished,
+// like after a crash, aren't in the manifest and aren't replayed.
+func (w *LocalWriter) Close() error {
+	return w.Rotate()
+}
+
+type localWriterFile struct {
+	name       string
+	statements int
+}
+
+func readLocalWriterManifest(dir string) ([]localWriterFile, error) {
+	b, err := os.ReadFile(filepath.Join(dir, localWriterManifest))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var files []localWriterFile
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		if len(line) == 0 {
+			continue
+		}
+
+		var f localWriterFile
+		if _, err := fmt.Sscanf(line, "%s %d", &f.name, &f.statements); err != nil {
+			return nil, fmt.Errorf("invalid manifest line %q: %w", line, err)
+		}
+		files = append(files, f)
+	}
+
+	return files, nil
+}
+
+// ReplayProgress is how far ReplayLocalWrites got
+type ReplayProgress struct {
+	// File is the name of the file being replayed
+	File string
+
+	// Statement is the number of statements of the file that were executed
+	Statement int
+
+	// Files and Statements are the totals from the manifest
+	Files      int
+	Statements int
+}
+
+// ReplayLocalWrites executes the statements written by a LocalWriter to dir against the database,
+// in the order of the manifest, calling progress, if it isn't nil, after every statement.
+// Progress is saved in the dir after every statement, so calling it again after a failure
+// resumes after the last executed statement. Only a crash between executing a statement
+// and saving the progress can execute that statement again.
+func ReplayLocalWrites(ctx context.Context, db *Database, dir string, progress func(ReplayProgress)) error {
+	files, err := readLocalWriterManifest(dir)
+	if err != nil {
+		return err
+	}
+
+	var done ReplayProgress
+	b, err := os.ReadFile(filepath.Join(dir, localWriterProgress))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read replay progress: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(b, &done); err != nil {
+			return fmt.Errorf("failed to unmarshal replay progress: %w", err)
+		}
+	}
+
+	p := ReplayProgress{Files: len(files)}
+	for _, f := range files {
+		p.Statements += f.statements
+	}
+
+	skipping := len(done.File) != 0
+	for _, f := range files {
+		skip := 0
+		if skipping {
+			if f.name != done.File {
+				continue
+			}
+			skipping = false
+			skip = done.Statement
+		}
+
+		statements, err := readLocalWriterFile(filepath.Join(dir, f.name))
+		if err != nil {
+			return err
+		}
+
+		p.File = f.name
+		for i, stmt := range statements {
+			if i < skip {
+				continue
+			}
+
+			if err := db.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("failed to replay statement %d of %q: %w", i+1, f.name, err)
+			}
+
+			p.Statement = i + 1
+			if err := saveReplayProgress(dir, p); err != nil {
+				return err
+			}
+			if progress != nil {
+				progress(p)
+			}
+		}
+	}
+	if skipping {
+		return fmt.Errorf("cool-mysql: replay progress file %q isn't in the manifest", done.File)
+	}
+
+	return nil
+}
+
+func readLocalWriterFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open local writer file: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip local writer file: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read local writer file: %w", err)
+	}
+
+	return splitStatements(string(b)), nil
+}
+
+func saveReplayProgress(dir string, p ReplayProgress) error {
+	j, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to marshal replay progress: %w", err)
+	}
+
+	// written to a temp file and renamed so a crash can't leave it half written
+	path := filepath.Join(dir, localWriterProgress)
+	if err := os.WriteFile(path+".tmp", j, 0o644); err != nil {
+		return fmt.Errorf("failed to write replay progress: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to write replay progress: %w", err)
+	}
+
+	return nil
+}
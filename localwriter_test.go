@@ -0,0 +1,60 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLocalWriter(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := NewLocalWriter(new(Database), dir, LocalWriterOptions{MaxFileSize: 10, Gzip: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := w.Exec("update`Users`set`N`=@@N where`ID`=1", Params{"N": i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := readLocalWriterManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []localWriterFile{{"000001.sql.gz", 1}, {"000002.sql.gz", 1}, {"000003.sql.gz", 1}}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("manifest = %+v, want %+v", files, want)
+	}
+
+	statements, err := readLocalWriterFile(dir + "/000002.sql.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"update`Users`set`N`=1 where`ID`=1"}; !reflect.DeepEqual(statements, want) {
+		t.Errorf("statements = %q, want %q", statements, want)
+	}
+
+	// a new writer continues after the manifest
+	w, err = NewLocalWriter(new(Database), dir, LocalWriterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Exec("delete from`Users`"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err = readLocalWriterManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 4 || files[3].name != "000004.sql" {
+		t.Errorf("manifest = %+v", files)
+	}
+}
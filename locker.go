@@ -0,0 +1,219 @@
+package mysql
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redsync/redsync/v4"
+	"github.com/go-redsync/redsync/v4/redis/goredis/v9"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrLockTaken is returned by lockers for keys that are already locked
+var ErrLockTaken = errors.New("cool-mysql: lock is taken")
+
+// Locker locks the cache keys of cached queries that miss the cache, so only one of them selects
+// from the database and fills the cache, while the others wait for it, see UseLocker and CacheLock.
+type Locker interface {
+	// TryLock tries once to lock the key for at most ttl, returning ErrLockTaken if it's already locked.
+	// The returned func unlocks the key, unless it expired and was locked again since.
+	TryLock(ctx context.Context, key string, ttl time.Duration) (unlock func() error, err error)
+}
+
+// UseLocker sets the locker of cache keys, instead of the redis of EnableRedis,
+// for deployments whose locks can't or shouldn't be taken in the cache's redis,
+// like ones behind a proxy without scripting. The cache itself is still redis.
+func (db *Database) UseLocker(l Locker) *Database {
+	db.locker = l
+	return db
+}
+
+// cacheLocker returns the locker set by UseLocker, or the redis locker of EnableRedis
+func (db *Database) cacheLocker() Locker {
+	if db.locker != nil {
+		return db.locker
+	}
+
+	return db.redisLocker
+}
+
+// lockToken returns a random value for a lock, so only its holder can unlock it
+func lockToken() ([]byte, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate lock token: %w", err)
+	}
+
+	return []byte(hex.EncodeToString(b)), nil
+}
+
+type redisLocker struct {
+	rs *redsync.Redsync
+}
+
+// NewRedisLocker returns a locker of redsync mutexes in redis, which is what EnableRedis uses
+func NewRedisLocker(client redis.UniversalClient) Locker {
+	return redisLocker{rs: redsync.New(goredis.NewPool(client))}
+}
+
+func (l redisLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (func() error, error) {
+	mutex := l.rs.NewMutex(key, redsync.WithTries(1), redsync.WithExpiry(ttl))
+	if err := mutex.LockContext(ctx); err != nil {
+		var taken *redsync.ErrTaken
+		if errors.Is(err, redsync.ErrFailed) || errors.As(err, &taken) {
+			return nil, ErrLockTaken
+		}
+		return nil, err
+	}
+
+	return func() error {
+		_, err := mutex.Unlock()
+		return err
+	}, nil
+}
+
+type mutexLocker struct {
+	mx    sync.Mutex
+	locks map[string]mutexLock
+
+	// lastToken is the token of the last lock, so unlocks of expired locks don't unlock the next ones
+	lastToken uint64
+}
+
+type mutexLock struct {
+	token   uint64
+	expires time.Time
+}
+
+// NewMutexLocker returns a locker of this process's memory, which only stops the queries of the process
+// from filling the same cache keys at once, but doesn't need a round trip for each lock
+func NewMutexLocker() Locker {
+	return &mutexLocker{locks: make(map[string]mutexLock)}
+}
+
+func (l *mutexLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (func() error, error) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	now := time.Now()
+	if lock, ok := l.locks[key]; ok && now.Before(lock.expires) {
+		return nil, ErrLockTaken
+	}
+
+	l.lastToken++
+	lock := mutexLock{token: l.lastToken, expires: now.Add(ttl)}
+	l.locks[key] = lock
+
+	return func() error {
+		l.mx.Lock()
+		defer l.mx.Unlock()
+
+		if l.locks[key].token == lock.token {
+			delete(l.locks, key)
+		}
+		return nil
+	}, nil
+}
+
+// MemcacheClient is the part of a memcached client that a memcached locker needs.
+// With github.com/bradfitz/gomemcache, Add is its Add, Gets is its Get, which returns the CAS id
+// of the item, and CompareAndDelete is its CompareAndSwap of the item with an Expiration of -1,
+// which memcached expires right away.
+type MemcacheClient interface {
+	// Add sets the key only if it isn't set, returning false if it is
+	Add(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+
+	// Gets returns the key's value and CAS id, with ok false if it isn't set
+	Gets(ctx context.Context, key string) (value []byte, cas uint64, ok bool, err error)
+
+	// CompareAndDelete deletes the key only if its CAS id is still cas, returning false if it isn't
+	CompareAndDelete(ctx context.Context, key string, cas uint64) (bool, error)
+}
+
+type memcacheLocker struct {
+	client MemcacheClient
+}
+
+// NewMemcacheLocker returns a locker of memcached keys, which are added if they aren't set,
+// and deleted by compare and swap if they still have the token they were locked with
+func NewMemcacheLocker(client MemcacheClient) Locker {
+	return memcacheLocker{client: client}
+}
+
+func (l memcacheLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (func() error, error) {
+	token, err := lockToken()
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := l.client.Add(ctx, key, token, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add memcached lock: %w", err)
+	}
+	if !ok {
+		return nil, ErrLockTaken
+	}
+
+	return func() error {
+		value, cas, ok, err := l.client.Gets(context.Background(), key)
+		if err != nil {
+			return fmt.Errorf("failed to get memcached lock: %w", err)
+		}
+		if !ok || string(value) != string(token) {
+			return nil
+		}
+
+		if _, err := l.client.CompareAndDelete(context.Background(), key, cas); err != nil {
+			return fmt.Errorf("failed to delete memcached lock: %w", err)
+		}
+		return nil
+	}, nil
+}
+
+// EtcdClient is the part of an etcd client that an etcd locker needs. With go.etcd.io/etcd/client/v3,
+// PutIfAbsent is a transaction that puts the key with a lease granted for the ttl if the key's
+// create revision is 0, and DeleteIfValue is a transaction that deletes it if its value is still the same.
+type EtcdClient interface {
+	// PutIfAbsent puts the key, expiring after ttl, only if it doesn't exist, returning false if it does
+	PutIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+
+	// DeleteIfValue deletes the key only if its value is still value, returning false if it isn't
+	DeleteIfValue(ctx context.Context, key string, value []byte) (bool, error)
+}
+
+type etcdLocker struct {
+	client EtcdClient
+}
+
+// NewEtcdLocker returns a locker of etcd keys, which are put if they don't exist,
+// and deleted if they still have the token they were locked with
+func NewEtcdLocker(client EtcdClient) Locker {
+	return etcdLocker{client: client}
+}
+
+func (l etcdLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (func() error, error) {
+	token, err := lockToken()
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := l.client.PutIfAbsent(ctx, key, token, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to put etcd lock: %w", err)
+	}
+	if !ok {
+		return nil, ErrLockTaken
+	}
+
+	return func() error {
+		if _, err := l.client.DeleteIfValue(context.Background(), key, token); err != nil {
+			return fmt.Errorf("failed to delete etcd lock: %w", err)
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,128 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLockStore is a memcached and etcd client of an in-memory map, whose keys never expire
+type fakeLockStore struct {
+	mx   sync.Mutex
+	data map[string][]byte
+	cas  map[string]uint64
+}
+
+func newFakeLockStore() *fakeLockStore {
+	return &fakeLockStore{data: make(map[string][]byte), cas: make(map[string]uint64)}
+}
+
+func (s *fakeLockStore) Add(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if _, ok := s.data[key]; ok {
+		return false, nil
+	}
+	s.data[key] = value
+	s.cas[key]++
+	return true, nil
+}
+
+func (s *fakeLockStore) Gets(ctx context.Context, key string) ([]byte, uint64, bool, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	v, ok := s.data[key]
+	return v, s.cas[key], ok, nil
+}
+
+func (s *fakeLockStore) CompareAndDelete(ctx context.Context, key string, cas uint64) (bool, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if _, ok := s.data[key]; !ok || s.cas[key] != cas {
+		return false, nil
+	}
+	delete(s.data, key)
+	return true, nil
+}
+
+func (s *fakeLockStore) PutIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return s.Add(ctx, key, value, ttl)
+}
+
+func (s *fakeLockStore) DeleteIfValue(ctx context.Context, key string, value []byte) (bool, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if string(s.data[key]) != string(value) {
+		return false, nil
+	}
+	delete(s.data, key)
+	return true, nil
+}
+
+func TestLockers(t *testing.T) {
+	tests := []struct {
+		name   string
+		locker Locker
+	}{
+		{"mutex", NewMutexLocker()},
+		{"memcache", NewMemcacheLocker(newFakeLockStore())},
+		{"etcd", NewEtcdLocker(newFakeLockStore())},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			unlock, err := tt.locker.TryLock(ctx, "a", time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tt.locker.TryLock(ctx, "a", time.Minute); !errors.Is(err, ErrLockTaken) {
+				t.Fatalf("TryLock() of a locked key error = %v, want %v", err, ErrLockTaken)
+			}
+
+			unlockB, err := tt.locker.TryLock(ctx, "b", time.Minute)
+			if err != nil {
+				t.Fatalf("TryLock() of another key error = %v", err)
+			}
+			defer unlockB()
+
+			if err := unlock(); err != nil {
+				t.Fatal(err)
+			}
+			unlock, err = tt.locker.TryLock(ctx, "a", time.Minute)
+			if err != nil {
+				t.Fatalf("TryLock() of an unlocked key error = %v", err)
+			}
+			defer unlock()
+		})
+	}
+}
+
+func TestMutexLockerExpiry(t *testing.T) {
+	l := NewMutexLocker()
+	ctx := context.Background()
+
+	expired, err := l.TryLock(ctx, "a", time.Nanosecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+
+	if _, err := l.TryLock(ctx, "a", time.Minute); err != nil {
+		t.Fatalf("TryLock() of an expired key error = %v", err)
+	}
+
+	// the expired lock's holder can't unlock the lock that replaced it
+	if err := expired(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.TryLock(ctx, "a", time.Minute); !errors.Is(err, ErrLockTaken) {
+		t.Fatalf("TryLock() after an expired unlock error = %v, want %v", err, ErrLockTaken)
+	}
+}
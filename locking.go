@@ -0,0 +1,141 @@
+package mysql
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// ErrLockingOutsideTx is returned when a locking clause is used outside of a transaction,
+// where the locks would be released as soon as the select finished
+var ErrLockingOutsideTx = errors.New("cool-mysql: locking clauses can only be used in a transaction")
+
+// LockingClause is a per-query option that locks the selected rows,
+// passed along with the query's params like
+// `tx.SelectContext(ctx, &rows, query, 0, params, mysql.ForShare().SkipLocked())`
+type LockingClause struct {
+	share      bool
+	of         []string
+	noWait     bool
+	skipLocked bool
+}
+
+// ForUpdate locks the selected rows with `FOR UPDATE`
+func ForUpdate() LockingClause {
+	return LockingClause{}
+}
+
+// ForShare locks the selected rows with `FOR SHARE`
+func ForShare() LockingClause {
+	return LockingClause{share: true}
+}
+
+// Of only locks the rows of the given tables
+func (l LockingClause) Of(tables ...string) LockingClause {
+	l.of = append(append([]string(nil), l.of...), tables...)
+	return l
+}
+
+// NoWait returns an error right away instead of waiting for rows locked by other transactions
+func (l LockingClause) NoWait() LockingClause {
+	l.noWait = true
+	l.skipLocked = false
+	return l
+}
+
+// SkipLocked leaves out rows locked by other transactions instead of waiting for them
+func (l LockingClause) SkipLocked() LockingClause {
+	l.skipLocked = true
+	l.noWait = false
+	return l
+}
+
+func (l LockingClause) String() string {
+	return l.sql(FlavorMySQL)
+}
+
+// sql returns the clause in the server's syntax, where MariaDB
+// has no `for share` and can't limit locks to some of the tables
+func (l LockingClause) sql(flavor ServerFlavor) string {
+	s := new(strings.Builder)
+	switch {
+	case l.share && flavor == FlavorMariaDB:
+		s.WriteString("lock in share mode")
+	case l.share:
+		s.WriteString("for share")
+	default:
+		s.WriteString("for update")
+	}
+
+	if len(l.of) != 0 && flavor != FlavorMariaDB {
+		s.WriteString(" of")
+		for i, t := range l.of {
+			if i != 0 {
+				s.WriteByte(',')
+			}
+			s.WriteString(quoteIdentifier(t))
+		}
+	}
+
+	switch {
+	case l.noWait:
+		s.WriteString(" nowait")
+	case l.skipLocked:
+		s.WriteString(" skip locked")
+	}
+
+	return s.String()
+}
+
+// lockingClauseFromParams removes the locking clause from the params,
+// returning an error if it's used outside of a transaction
+func lockingClauseFromParams(conn handlerWithContext, params []any) (*LockingClause, []any, error) {
+	var lock *LockingClause
+	var rest []any
+	for i, p := range params {
+		var l LockingClause
+		switch v := p.(type) {
+		case LockingClause:
+			l = v
+		case *LockingClause:
+			if v == nil {
+				continue
+			}
+			l = *v
+		default:
+			if rest != nil {
+				rest = append(rest, p)
+			}
+			continue
+		}
+
+		if lock != nil {
+			return nil, nil, errors.New("cool-mysql: a query can only have one locking clause")
+		}
+		lock = &l
+
+		if rest == nil {
+			rest = append(make([]any, 0, len(params)-1), params[:i]...)
+		}
+	}
+
+	if lock == nil {
+		return nil, params, nil
+	}
+
+	if _, ok := conn.(*sql.Tx); !ok {
+		return nil, nil, ErrLockingOutsideTx
+	}
+
+	return lock, rest, nil
+}
+
+// appendLockingClause adds the locking clause to the end of the interpolated query,
+// after any trailing semicolon, and on its own line so a trailing comment can't hide it
+func appendLockingClause(query string, lock *LockingClause, flavor ServerFlavor) string {
+	if lock == nil {
+		return query
+	}
+
+	return strings.TrimRight(query, "; \t\r\n") + "\n" + lock.sql(flavor)
+}
@@ -0,0 +1,66 @@
+package mysql
+
+import "testing"
+
+func TestLockingClause(t *testing.T) {
+	tests := []struct {
+		name string
+		lock LockingClause
+		want string
+	}{
+		{"update", ForUpdate(), "for update"},
+		{"share", ForShare(), "for share"},
+		{"skip locked", ForShare().SkipLocked(), "for share skip locked"},
+		{"nowait", ForUpdate().NoWait(), "for update nowait"},
+		{"last wait option wins", ForUpdate().NoWait().SkipLocked(), "for update skip locked"},
+		{"of", ForUpdate().Of("Jobs", "Workers").SkipLocked(), "for update of`Jobs`,`Workers` skip locked"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.lock.String(); got != tt.want {
+				t.Errorf("LockingClause.String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLockingClauseMariaDB(t *testing.T) {
+	tests := []struct {
+		name string
+		lock LockingClause
+		want string
+	}{
+		{"update", ForUpdate(), "for update"},
+		{"share", ForShare().SkipLocked(), "lock in share mode skip locked"},
+		{"of", ForUpdate().Of("Jobs").NoWait(), "for update nowait"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.lock.sql(FlavorMariaDB); got != tt.want {
+				t.Errorf("LockingClause.sql(FlavorMariaDB) = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppendLockingClause(t *testing.T) {
+	lock := ForUpdate()
+	tests := []struct {
+		name  string
+		query string
+		lock  *LockingClause
+		want  string
+	}{
+		{"no lock", "select 1;", nil, "select 1;"},
+		{"plain", "select*from`Jobs`", &lock, "select*from`Jobs`\nfor update"},
+		{"trailing semicolon", "select*from`Jobs`;\n", &lock, "select*from`Jobs`\nfor update"},
+		{"trailing comment", "select*from`Jobs`-- jobs", &lock, "select*from`Jobs`-- jobs\nfor update"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := appendLockingClause(tt.query, tt.lock, FlavorMySQL); got != tt.want {
+				t.Errorf("appendLockingClause() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -7,9 +7,44 @@ import (
 // MaxExecutionTime is the total time we would like our queries to be able to execute.
 // Since we are using 30 second limited AWS Lambda functions, we'll default this time to
 // 90% of 30 seconds (27 seconds), with the goal of letting our process clean up and correctly
-// log any failed queries
+// log any failed queries. Queries whose context has a closer deadline stop being retried
+// once their next attempt couldn't finish before it, see ErrRetryDeadline.
 var MaxExecutionTime = time.Duration(getenvInt64("COOL_MAX_EXECUTION_TIME_TIME", int64(float64(30)*.9))) * time.Second
 
 var MaxConnectionTime = MaxExecutionTime
 
-var RedisLockRetryDelay = time.Duration(getenvFloat("COOL_REDIS_LOCK_RETRY_DELAY", .020)) * time.Second
+// RedisLockRetryDelay is the first wait of cached queries for the lock of their cache key, which doubles
+// with each try, up to RedisLockMaxRetryDelay, see CacheLock
+var RedisLockRetryDelay = time.Duration(getenvFloat("COOL_REDIS_LOCK_RETRY_DELAY", .020) * float64(time.Second))
+
+// RedisLockMaxRetryDelay is the longest wait of cached queries between their tries of their cache key's lock
+var RedisLockMaxRetryDelay = time.Duration(getenvFloat("COOL_REDIS_LOCK_MAX_RETRY_DELAY", .5) * float64(time.Second))
+
+// MaxCacheSize is the largest result, in encoded bytes, that's cached. Bigger results
+// stop being encoded once they pass it and aren't cached, which is also what redis would
+// do with values over its own limit of 512MB. Zero means no limit.
+var MaxCacheSize = int(getenvInt64("COOL_MAX_CACHE_SIZE", 512<<20))
+
+// ExistsManyChunkSize is the most keys ExistsMany checks with each query
+var ExistsManyChunkSize = int(getenvInt64("COOL_EXISTS_MANY_CHUNK_SIZE", 1000))
+
+// UnionChunkSize is the most branches of each `union all` query SelectUnion sends
+var UnionChunkSize = int(getenvInt64("COOL_UNION_CHUNK_SIZE", 100))
+
+// InChunkSize is the most values of a param that's the whole list of an `in()`, like `in(@@IDs)`,
+// that a select or exec sends at once. Queries with more are split into one query per chunk of the values,
+// so they don't exceed max_allowed_packet, where selects get the rows of every chunk, and execs the rows
+// affected. Only an `in()` that's a condition of the where clause every row has to meet, joined to the
+// others by `and`, of a select, update, or delete is split, not one beside an `or`, in a set, or in the
+// select list. Queries whose results couldn't be merged from their chunks, like ones with aggregates, limits,
+// or an order by that their rows aren't merged in by a Merge, are never split, and execs outside
+// of a transaction aren't atomic across their chunks.
+// Zero means queries are never split.
+var InChunkSize = int(getenvInt64("COOL_IN_CHUNK_SIZE", 0))
+
+// LoadChunkSize is the most parent keys Load selects the children of with each query
+var LoadChunkSize = int(getenvInt64("COOL_LOAD_CHUNK_SIZE", 1000))
+
+// ClientFoundRows is whether the connections made by New count the rows that updates match as affected,
+// instead of only the rows they change. Upserts check which rows exist correctly either way.
+var ClientFoundRows = getenvBool("COOL_CLIENT_FOUND_ROWS", true)
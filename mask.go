@@ -0,0 +1,194 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fatih/structtag"
+)
+
+// MaskFunc masks the value of a field tagged like `mysql:"Email,mask=email"`
+type MaskFunc func(v string) string
+
+// DefaultMasks are the masks available to every MaskPolicy by name
+var DefaultMasks = map[string]MaskFunc{
+	"email": MaskEmail,
+	"full":  MaskFull,
+	"last4": MaskLast4,
+}
+
+// MaskPolicy decides which selected fields are masked
+type MaskPolicy struct {
+	// Masks are the masks by the name used in the struct tags,
+	// checked before DefaultMasks
+	Masks map[string]MaskFunc
+
+	// AllowedRoles are the roles that see the real values of masked fields
+	AllowedRoles []string
+}
+
+// SetMaskPolicy enables masking of selected struct fields tagged like `mysql:"Email,mask=email"`.
+// Masked string fields are replaced by the named mask's result, and masked fields of any
+// other type are zeroed, unless the context has one of the policy's allowed roles.
+func (db *Database) SetMaskPolicy(policy *MaskPolicy) *Database {
+	db.MaskPolicy = policy
+
+	return db
+}
+
+var rolesKey = key(6)
+
+// WithRoles returns a new context.Context with the given roles,
+// used to decide if masked fields are shown
+func WithRoles(ctx context.Context, roles ...string) context.Context {
+	return context.WithValue(ctx, rolesKey, roles)
+}
+
+// RolesFromContext returns the roles from a context.Context
+func RolesFromContext(ctx context.Context) []string {
+	roles, _ := ctx.Value(rolesKey).([]string)
+	return roles
+}
+
+// unmasked returns true if the context has one of the allowed roles
+func (p *MaskPolicy) unmasked(ctx context.Context) bool {
+	for _, r := range RolesFromContext(ctx) {
+		for _, a := range p.AllowedRoles {
+			if r == a {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (p *MaskPolicy) mask(name string) (MaskFunc, bool) {
+	if fn, ok := p.Masks[name]; ok {
+		return fn, true
+	}
+
+	fn, ok := DefaultMasks[name]
+	return fn, ok
+}
+
+type maskedField struct {
+	index []int
+	mask  MaskFunc
+}
+
+// maskedFieldsFromStruct returns the fields of the struct tagged with a mask
+func (p *MaskPolicy) maskedFieldsFromStruct(t reflect.Type) ([]maskedField, error) {
+	t = reflectUnwrapType(t)
+	if t.Kind() != reflect.Struct || !isMultiValueElement(t) {
+		return nil, nil
+	}
+
+	var fields []maskedField
+	for _, i := range StructFieldIndexes(t) {
+		f := t.FieldByIndex(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		tags, err := structtag.Parse(string(f.Tag))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse struct tag %q: %w", f.Tag, err)
+		}
+
+		mysqlTag, _ := tags.Get("mysql")
+		if mysqlTag == nil {
+			continue
+		}
+
+		for _, o := range mysqlTag.Options {
+			if !strings.HasPrefix(o, "mask=") {
+				continue
+			}
+			name := strings.TrimPrefix(o, "mask=")
+
+			fn, ok := p.mask(name)
+			if !ok {
+				return nil, fmt.Errorf("cool-mysql: unknown mask %q on field %q", name, f.Name)
+			}
+
+			fields = append(fields, maskedField{index: i, mask: fn})
+		}
+	}
+
+	return fields, nil
+}
+
+// maskRow returns a copy of the row with its masked fields masked,
+// leaving the original untouched so it can still be cached
+func maskRow(row reflect.Value, fields []maskedField) reflect.Value {
+	if row.Kind() == reflect.Pointer {
+		if row.IsNil() {
+			return row
+		}
+
+		masked := reflect.New(row.Type().Elem())
+		masked.Elem().Set(maskRow(row.Elem(), fields))
+		return masked
+	}
+
+	masked := reflect.New(row.Type()).Elem()
+	masked.Set(row)
+
+	for _, mf := range fields {
+		f := masked.FieldByIndex(mf.index)
+		switch {
+		case f.Kind() == reflect.String:
+			f.SetString(mf.mask(f.String()))
+		case f.Kind() == reflect.Pointer && f.Type().Elem().Kind() == reflect.String:
+			if !f.IsNil() {
+				s := mf.mask(f.Elem().String())
+				f.Set(reflect.New(f.Type().Elem()))
+				f.Elem().SetString(s)
+			}
+		default:
+			f.Set(reflect.Zero(f.Type()))
+		}
+	}
+
+	return masked
+}
+
+// MaskEmail keeps the first character of the local part and the domain,
+// so "jane@example.com" becomes "j***@example.com"
+func MaskEmail(v string) string {
+	at := strings.LastIndexByte(v, '@')
+	if at < 1 {
+		return MaskFull(v)
+	}
+
+	_, size := utf8.DecodeRuneInString(v)
+	return v[:size] + "***" + v[at:]
+}
+
+// MaskFull replaces any non-empty value with asterisks, without revealing its length
+func MaskFull(v string) string {
+	if len(v) == 0 {
+		return v
+	}
+
+	return "****"
+}
+
+// MaskLast4 keeps only the last four characters, so "4111111111111111" becomes "****1111"
+func MaskLast4(v string) string {
+	if utf8.RuneCountInString(v) <= 4 {
+		return MaskFull(v)
+	}
+
+	i := len(v)
+	for n := 0; n < 4; n++ {
+		_, size := utf8.DecodeLastRuneInString(v[:i])
+		i -= size
+	}
+
+	return "****" + v[i:]
+}
@@ -0,0 +1,28 @@
+package mysql
+
+import "testing"
+
+func TestMasks(t *testing.T) {
+	tests := []struct {
+		name string
+		mask MaskFunc
+		in   string
+		want string
+	}{
+		{"email", MaskEmail, "jane@example.com", "j***@example.com"},
+		{"email unicode", MaskEmail, "élan@example.com", "é***@example.com"},
+		{"email no at", MaskEmail, "jane", "****"},
+		{"email empty", MaskEmail, "", ""},
+		{"full", MaskFull, "secret", "****"},
+		{"full empty", MaskFull, "", ""},
+		{"last4", MaskLast4, "4111111111111111", "****1111"},
+		{"last4 short", MaskLast4, "1234", "****"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.mask(tt.in); got != tt.want {
+				t.Errorf("mask(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
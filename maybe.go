@@ -0,0 +1,49 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// maybe returns false instead of sql.ErrNoRows
+func maybe(err error) (bool, error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// SelectMaybe selects a single row into dest like Select, but returns false instead of sql.ErrNoRows
+// if there's no row, leaving dest as it was
+func (db *Database) SelectMaybe(dest any, q string, cache time.Duration, params ...any) (found bool, err error) {
+	return maybe(db.Select(dest, q, cache, params...))
+}
+
+// SelectMaybeContext selects a single row into dest like SelectContext, but returns false instead of sql.ErrNoRows
+// if there's no row, leaving dest as it was
+func (db *Database) SelectMaybeContext(ctx context.Context, dest any, q string, cache time.Duration, params ...any) (found bool, err error) {
+	return maybe(db.SelectContext(ctx, dest, q, cache, params...))
+}
+
+// SelectMaybe selects a single row into dest in the transaction, but returns false instead of sql.ErrNoRows
+// if there's no row, leaving dest as it was
+func (tx *Tx) SelectMaybe(dest any, q string, cache time.Duration, params ...any) (found bool, err error) {
+	return maybe(tx.Select(dest, q, cache, params...))
+}
+
+// SelectMaybeContext selects a single row into dest in the transaction, but returns false instead of sql.ErrNoRows
+// if there's no row, leaving dest as it was
+func (tx *Tx) SelectMaybeContext(ctx context.Context, dest any, q string, cache time.Duration, params ...any) (found bool, err error) {
+	return maybe(tx.SelectContext(ctx, dest, q, cache, params...))
+}
+
+// SelectOneMaybe selects a single row as T with a *Database or *Tx, returning false instead of sql.ErrNoRows
+// if there's no row, like `user, found, err := mysql.SelectOneMaybe[User](ctx, db, query, 0, params)`
+func SelectOneMaybe[T any](ctx context.Context, q Querier, query string, cache time.Duration, params ...any) (T, bool, error) {
+	var row T
+	found, err := maybe(q.SelectContext(ctx, &row, query, cache, params...))
+	return row, found, err
+}
@@ -0,0 +1,36 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func Test_maybe(t *testing.T) {
+	errOther := errors.New("other")
+	tests := []struct {
+		name      string
+		err       error
+		wantFound bool
+		wantErr   error
+	}{
+		{"found", nil, true, nil},
+		{"no rows", sql.ErrNoRows, false, nil},
+		{"wrapped no rows", Wrap(sql.ErrNoRows, "", "", nil), false, nil},
+		{"other error", errOther, false, errOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := maybe(tt.err)
+			if found != tt.wantFound || err != tt.wantErr {
+				t.Errorf("maybe() = %v, %v, want %v, %v", found, err, tt.wantFound, tt.wantErr)
+			}
+		})
+	}
+
+	row, found, err := SelectOneMaybe[benchWideRow](context.Background(), benchDatabase(t), "select*from`users`limit 1", 0)
+	if err != nil || !found || len(row.Text0) == 0 {
+		t.Errorf("SelectOneMaybe() = %v, %v, want the row", found, err)
+	}
+}
@@ -0,0 +1,488 @@
+package mysql
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Merge sets how the rows of a select that fans out into many queries are merged, like SelectAllShards,
+// SelectParallel, and selects whose in() lists are split by InChunkSize. It's passed along with
+// the query's params, like `mysql.SelectAllShards[User](ctx, s, query, 0, params, mysql.Merge{OrderBy: []string{"ID"}})`,
+// and can be passed to any other select into a slice too.
+//
+// Without a Merge, the rows are in the order of the queries, shard by shard, range by range, or chunk by chunk,
+// and then in the order each query returned them. So the rows aren't sorted as a whole even if each query's are,
+// and a row returned by more than one query, like one copied to many shards, is returned once by each of them.
+type Merge struct {
+	// OrderBy sorts the merged rows by the columns, each optionally followed by "desc", like "CreatedAt desc".
+	// Nulls come first, like MySQL sorts them. The sort is stable, so rows that tie stay in the order of their queries.
+	OrderBy []string
+
+	// QueryOrder sorts the merged rows by the query's own order by clause, after the columns of OrderBy.
+	// The order by can only have columns, not expressions or positions. A select with an order by is
+	// only split by InChunkSize if it's passed a Merge with QueryOrder.
+	QueryOrder bool
+
+	// UniqueBy keeps only the first of the merged rows with the same values of the columns,
+	// after they're sorted, dropping the duplicates returned by the other queries.
+	// Strings are only the same if they're the same bytes, whatever their collation.
+	UniqueBy []string
+
+	// CompareStrings returns -1, 0, or 1 if a sorts before, with, or after b, for sorting the strings of the
+	// columns in the order of their collation, like a case-insensitive collator's CompareString from
+	// golang.org/x/text/collate. Without it, strings are sorted byte by byte, which is only the order
+	// MySQL sorts them in with binary collations, like utf8mb4_bin, and not the default ones,
+	// like utf8mb4_0900_ai_ci, where "a" sorts with "A" instead of after "Z".
+	CompareStrings func(a, b string) int
+}
+
+// mergeFromParams removes the merge from the params
+func mergeFromParams(params []any) (*Merge, []any, error) {
+	var merge *Merge
+	var rest []any
+	for i, p := range params {
+		var m Merge
+		switch v := p.(type) {
+		case Merge:
+			m = v
+		case *Merge:
+			if v == nil {
+				// a nil merge is dropped like any other, so it isn't taken for a param
+				if rest == nil {
+					rest = append(make([]any, 0, len(params)-1), params[:i]...)
+				}
+				continue
+			}
+			m = *v
+		default:
+			if rest != nil {
+				rest = append(rest, p)
+			}
+			continue
+		}
+
+		if merge != nil {
+			return nil, nil, errors.New("cool-mysql: a query can only have one merge")
+		}
+		merge = &m
+
+		if rest == nil {
+			rest = append(make([]any, 0, len(params)-1), params[:i]...)
+		}
+	}
+
+	if rest == nil {
+		return merge, params, nil
+	}
+
+	return merge, rest, nil
+}
+
+// mergeColumn is a column the merged rows are sorted or deduplicated by
+type mergeColumn struct {
+	desc bool
+	get  func(row reflect.Value) any
+
+	// compareStrings is the merge's CompareStrings
+	compareStrings func(a, b string) int
+}
+
+// apply sorts and deduplicates the rows of the slice the pointer points to, from the query they were selected by
+func (m *Merge) apply(dest any, query string) error {
+	order, unique, err := m.columns(dest, query)
+	if err != nil {
+		return err
+	}
+
+	mergeRows(dest, order, unique)
+	return nil
+}
+
+// columns returns the columns the rows of the dest are sorted and deduplicated by,
+// or an error if the dest isn't a pointer to a slice of rows with the columns
+func (m *Merge) columns(dest any, query string) (order, unique []mergeColumn, err error) {
+	if m == nil {
+		return nil, nil, nil
+	}
+
+	destRef := reflect.ValueOf(dest)
+	if destRef.Kind() != reflect.Pointer || destRef.Elem().Kind() != reflect.Slice || destRef.Elem().Type().Elem().Kind() == reflect.Uint8 {
+		return nil, nil, fmt.Errorf("cool-mysql: rows can only be merged into a pointer to a slice, not %T", dest)
+	}
+	rowType := destRef.Elem().Type().Elem()
+
+	orderBy := m.OrderBy
+	if m.QueryOrder {
+		queryOrderBy, err := queryOrderBy(query)
+		if err != nil {
+			return nil, nil, err
+		}
+		orderBy = append(orderBy[:len(orderBy):len(orderBy)], queryOrderBy...)
+	}
+
+	for _, c := range orderBy {
+		fields := strings.Fields(c)
+		if len(fields) == 0 || len(fields) > 2 || len(fields) == 2 && !strings.EqualFold(fields[1], "asc") && !strings.EqualFold(fields[1], "desc") {
+			return nil, nil, fmt.Errorf("cool-mysql: can't merge rows ordered by %q", c)
+		}
+
+		col, err := mergeColumnOf(rowType, fields[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		col.desc = len(fields) == 2 && strings.EqualFold(fields[1], "desc")
+		col.compareStrings = m.CompareStrings
+		order = append(order, col)
+	}
+
+	for _, c := range m.UniqueBy {
+		col, err := mergeColumnOf(rowType, c)
+		if err != nil {
+			return nil, nil, err
+		}
+		unique = append(unique, col)
+	}
+
+	return order, unique, nil
+}
+
+// mergeRows sorts the rows of the slice the pointer points to by the order columns,
+// and then keeps only the first of the rows with the same values of the unique columns
+func mergeRows(dest any, order, unique []mergeColumn) {
+	if len(order) == 0 && len(unique) == 0 {
+		return
+	}
+	rows := reflect.ValueOf(dest).Elem()
+
+	if len(order) != 0 {
+		sorted := reflect.MakeSlice(rows.Type(), rows.Len(), rows.Len())
+		reflect.Copy(sorted, rows)
+
+		keys := make([][]any, sorted.Len())
+		for i := range keys {
+			keys[i] = make([]any, len(order))
+			for j, col := range order {
+				keys[i][j] = col.get(sorted.Index(i))
+			}
+		}
+
+		indexes := make([]int, sorted.Len())
+		for i := range indexes {
+			indexes[i] = i
+		}
+		sort.SliceStable(indexes, func(a, b int) bool {
+			for j, col := range order {
+				c := compareMergeValues(keys[indexes[a]][j], keys[indexes[b]][j], col.compareStrings)
+				if c == 0 {
+					continue
+				}
+				if col.desc {
+					return c > 0
+				}
+				return c < 0
+			}
+			return false
+		})
+
+		for i, j := range indexes {
+			rows.Index(i).Set(sorted.Index(j))
+		}
+	}
+
+	if len(unique) != 0 {
+		seen := make(map[string]struct{}, rows.Len())
+		n := 0
+		for i := 0; i < rows.Len(); i++ {
+			var key strings.Builder
+			for _, col := range unique {
+				v := col.get(rows.Index(i))
+				fmt.Fprintf(&key, "%T\x00%v\x01", v, v)
+			}
+			if _, ok := seen[key.String()]; ok {
+				continue
+			}
+			seen[key.String()] = struct{}{}
+
+			rows.Index(n).Set(rows.Index(i))
+			n++
+		}
+		rows.Set(rows.Slice(0, n))
+	}
+}
+
+// mergeColumnOf returns the column of rows of the type, which are structs, or maps like MapRow,
+// matched without case sensitivity like selects match their columns
+func mergeColumnOf(rowType reflect.Type, column string) (mergeColumn, error) {
+	t := reflectUnwrapType(rowType)
+
+	switch {
+	case t.Kind() == reflect.Map && t.Key().Kind() == reflect.String:
+		return mergeColumn{get: func(row reflect.Value) any {
+			row = reflectUnwrap(row)
+			if row.Kind() != reflect.Map || row.IsNil() {
+				return nil
+			}
+
+			if v := row.MapIndex(reflect.ValueOf(column).Convert(t.Key())); v.IsValid() {
+				return mergeValue(v)
+			}
+			iter := row.MapRange()
+			for iter.Next() {
+				if strings.EqualFold(iter.Key().String(), column) {
+					return mergeValue(iter.Value())
+				}
+			}
+			return nil
+		}}, nil
+	case t.Kind() == reflect.Struct && t != timeType:
+		columns, colOpts, _, err := colNamesFromStruct(t)
+		if err != nil {
+			return mergeColumn{}, err
+		}
+
+		for _, c := range columns {
+			if !strings.EqualFold(c, column) {
+				continue
+			}
+
+			index := colOpts[c].index
+			return mergeColumn{get: func(row reflect.Value) any {
+				row = reflectUnwrap(row)
+				if row.Kind() != reflect.Struct {
+					return nil
+				}
+
+				v, err := row.FieldByIndexErr(index)
+				if err != nil {
+					return nil
+				}
+				return mergeValue(v)
+			}}, nil
+		}
+
+		return mergeColumn{}, fmt.Errorf("cool-mysql: %s has no column %q", t, column)
+	}
+
+	return mergeColumn{}, fmt.Errorf("cool-mysql: rows of %s don't have columns to merge them by", rowType)
+}
+
+// mergeValue returns the value of the column to compare, without its pointers, and with
+// the values of valuers and times in UTC, so equal values compare and format the same
+func mergeValue(v reflect.Value) any {
+	v = reflectUnwrap(v)
+	if !v.IsValid() || (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+		return nil
+	}
+
+	i := v.Interface()
+	if valuer, ok := i.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil || value == nil {
+			return nil
+		}
+		i = value
+	}
+	if t, ok := i.(time.Time); ok {
+		return t.UTC()
+	}
+
+	return i
+}
+
+// compareMergeValues returns -1, 0, or 1 if a is less than, equal to, or more than b,
+// where nulls come first, and values of different types compare as strings.
+// Strings and bytes are compared by compareStrings, if it's set.
+func compareMergeValues(a, b any, compareStrings func(a, b string) int) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch {
+	case isIntKind(av.Kind()) && isIntKind(bv.Kind()):
+		return compareOrdered(av.Int(), bv.Int())
+	case isUintKind(av.Kind()) && isUintKind(bv.Kind()):
+		return compareOrdered(av.Uint(), bv.Uint())
+	case isNumberKind(av.Kind()) && isNumberKind(bv.Kind()):
+		return compareOrdered(numberFloat(av), numberFloat(bv))
+	}
+
+	if compareStrings != nil {
+		as, aOK := mergeString(a)
+		bs, bOK := mergeString(b)
+		if aOK && bOK {
+			return compareStrings(as, bs)
+		}
+	}
+
+	switch a := a.(type) {
+	case time.Time:
+		if b, ok := b.(time.Time); ok {
+			return compareOrdered(a.UnixNano(), b.UnixNano())
+		}
+	case bool:
+		if b, ok := b.(bool); ok {
+			return compareOrdered(strconv.FormatBool(a), strconv.FormatBool(b))
+		}
+	case []byte:
+		if b, ok := b.([]byte); ok {
+			return bytes.Compare(a, b)
+		}
+	}
+
+	return compareOrdered(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// mergeString returns the value as a string if it's a string or bytes
+func mergeString(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.String {
+		return rv.String(), true
+	}
+	return "", false
+}
+
+func compareOrdered[T int64 | uint64 | float64 | string](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func isIntKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
+
+func isUintKind(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uintptr
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	return isIntKind(k) || isUintKind(k) || k == reflect.Float32 || k == reflect.Float64
+}
+
+func numberFloat(v reflect.Value) float64 {
+	switch {
+	case isIntKind(v.Kind()):
+		return float64(v.Int())
+	case isUintKind(v.Kind()):
+		return float64(v.Uint())
+	}
+	return v.Float()
+}
+
+// queryOrderBy returns the columns of the query's top level order by clause, each followed by " desc"
+// if it's descending, or an error if it has anything but columns
+func queryOrderBy(query string) ([]string, error) {
+	var queryTokens []queryToken
+	for _, t := range parseQuery(query) {
+		if t.kind != queryTokenKindMisc || strings.TrimSpace(t.string) != "" {
+			queryTokens = append(queryTokens, t)
+		}
+	}
+
+	// the order by of the outermost query is the last one outside of any parens
+	start := -1
+	depth := 0
+	for i, t := range queryTokens {
+		switch {
+		case t.kind == queryTokenKindParen && t.string == "(":
+			depth++
+		case t.kind == queryTokenKindParen && t.string == ")":
+			depth--
+		case depth == 0 && t.kind == queryTokenKindWord && strings.EqualFold(t.string, "order") &&
+			i+1 < len(queryTokens) && strings.EqualFold(queryTokens[i+1].string, "by"):
+			start = i + 2
+		}
+	}
+	if start == -1 {
+		return nil, nil
+	}
+
+	var orderBy []string
+	var parts []string
+	desc := false
+
+	// afterName is true after a part of a column's name, where a dot, direction, or comma can come,
+	// and directed is true after its direction, where only a comma can
+	afterName, directed := false, false
+	end := func() error {
+		if len(parts) == 0 || !afterName && !directed {
+			return fmt.Errorf("cool-mysql: can't merge rows by the order by of the query, since it isn't only columns")
+		}
+		col := parts[len(parts)-1]
+		if desc {
+			col += " desc"
+		}
+		orderBy = append(orderBy, col)
+		parts, desc, afterName, directed = nil, false, false, false
+		return nil
+	}
+
+tokens:
+	for _, t := range queryTokens[start:] {
+		isWord := func(words ...string) bool {
+			if t.kind != queryTokenKindWord {
+				return false
+			}
+			for _, w := range words {
+				if strings.EqualFold(t.string, w) {
+					return true
+				}
+			}
+			return false
+		}
+
+		switch {
+		case t.kind == queryTokenKindComma:
+			if err := end(); err != nil {
+				return nil, err
+			}
+		case (afterName || directed) && isWord("limit", "for", "lock"):
+			break tokens
+		case afterName && isWord("asc", "desc"):
+			desc = isWord("desc")
+			afterName, directed = false, true
+		case afterName && t.kind == queryTokenKindMisc && t.string == ".":
+			afterName = false
+		case !afterName && !directed && (t.kind == queryTokenKindWord && !isNumeric(t.string) || t.kind == queryTokenKindString && t.string[0] == '`'):
+			parts = append(parts, unquoteName(t.string))
+			afterName = true
+		default:
+			return nil, fmt.Errorf("cool-mysql: can't merge rows by the order by of the query, since it isn't only columns")
+		}
+	}
+	if err := end(); err != nil {
+		return nil, err
+	}
+
+	return orderBy, nil
+}
+
+// isNumeric returns true if the word is a number, like the positions of an order by
+func isNumeric(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
@@ -0,0 +1,125 @@
+package mysql
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMerge_apply(t *testing.T) {
+	type row struct {
+		ID    int
+		Shard int
+		Name  *string
+	}
+	name := func(s string) *string { return &s }
+	rows := []row{
+		{3, 0, name("c")},
+		{1, 0, name("a")},
+		{2, 1, nil},
+		{1, 1, name("a")},
+	}
+
+	tests := []struct {
+		name    string
+		merge   Merge
+		query   string
+		want    []int
+		wantErr bool
+	}{
+		{"none", Merge{}, "", []int{3, 1, 2, 1}, false},
+		{"order by", Merge{OrderBy: []string{"id"}}, "", []int{1, 1, 2, 3}, false},
+		{"order by desc", Merge{OrderBy: []string{"ID desc"}}, "", []int{3, 2, 1, 1}, false},
+		{"nulls first", Merge{OrderBy: []string{"Name"}}, "", []int{2, 1, 1, 3}, false},
+		{"query order", Merge{QueryOrder: true}, "select*from`t`order by`t`.`Name`desc,ID limit 10", []int{3, 1, 1, 2}, false},
+		{"unique", Merge{UniqueBy: []string{"ID"}}, "", []int{3, 1, 2}, false},
+		{"unique after order", Merge{OrderBy: []string{"Shard desc"}, UniqueBy: []string{"ID"}}, "", []int{2, 1, 3}, false},
+		{"no column", Merge{OrderBy: []string{"Missing"}}, "", nil, true},
+		{"query order expression", Merge{QueryOrder: true}, "select*from`t`order by rand()", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := append([]row(nil), rows...)
+			err := tt.merge.apply(&merged, tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			ids := make([]int, len(merged))
+			for i, r := range merged {
+				ids[i] = r.ID
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("apply() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerge_applyCompareStrings(t *testing.T) {
+	type row struct {
+		ID   int
+		Name string
+	}
+	rows := []row{{1, "b"}, {2, "A"}, {3, "a"}, {4, "B"}}
+
+	ids := func(rows []row) []int {
+		ids := make([]int, len(rows))
+		for i, r := range rows {
+			ids[i] = r.ID
+		}
+		return ids
+	}
+
+	// without a comparator, uppercase comes first, like a binary collation
+	merged := append([]row(nil), rows...)
+	if err := (&Merge{OrderBy: []string{"Name"}}).apply(&merged, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ids(merged), []int{2, 4, 3, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("apply() = %v, want %v", got, want)
+	}
+
+	// like a case-insensitive collation, with ties in the order of the queries
+	merged = append([]row(nil), rows...)
+	caseInsensitive := func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	}
+	if err := (&Merge{OrderBy: []string{"Name"}, CompareStrings: caseInsensitive}).apply(&merged, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ids(merged), []int{2, 3, 1, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("apply() with CompareStrings = %v, want %v", got, want)
+	}
+}
+
+func Test_queryOrderBy(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    []string
+		wantErr bool
+	}{
+		{"select*from`t`", nil, false},
+		{"select*from`t`order by`ID`", []string{"ID"}, false},
+		{"select*from`t`order by `a`.`ID` desc, Name asc limit 5", []string{"ID desc", "Name"}, false},
+		{"select*from(select*from`t`order by`ID`)`t`order by`Name`", []string{"Name"}, false},
+		{"select*from`t`order by`ID`for update", []string{"ID"}, false},
+		{"select*from`t`order by 1", nil, true},
+		{"select*from`t`order by`ID`+1", nil, true},
+		{"select*from`t`order by`ID`,", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := queryOrderBy(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("queryOrderBy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("queryOrderBy() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
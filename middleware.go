@@ -0,0 +1,63 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// QueryKind is whether a query passed through middleware is a select, an exec, or an exists check
+type QueryKind int
+
+const (
+	QueryKindSelect QueryKind = iota
+	QueryKindExec
+	QueryKindExists
+)
+
+// Query is a select, exec, or exists check passed through middleware. Middleware can change
+// its fields before calling the next func, like to rewrite the query or change
+// how long it's cached, and inspect them after, like the rows selected into Dest.
+type Query struct {
+	Kind QueryKind
+
+	Query  string
+	Params []any
+
+	// Dest is the destination of selects, which is a func called for each row of counts,
+	// and Cache the cache duration of selects and exists checks
+	Dest  any
+	Cache time.Duration
+
+	// Result is the result of execs, once the next func returns
+	Result sql.Result
+
+	// Exists is the result of exists checks, once the next func returns
+	Exists bool
+}
+
+// QueryFunc runs a query
+type QueryFunc func(ctx context.Context, q *Query) error
+
+// Middleware wraps how queries are run, calling next to run the query,
+// or returning without calling it to skip it
+type Middleware func(next QueryFunc) QueryFunc
+
+// Use adds middleware to every select, exec, and exists check of the database, including the ones in its
+// transactions. Middleware added first is the outermost, so it's called first and returns last.
+// Inserts and upserts go through it once for each chunk of rows they're split into, as execs
+// whose rows are already in the query, and selects for the ones with returned columns.
+func (db *Database) Use(middleware ...Middleware) *Database {
+	// copied so clones of the database don't share added middleware
+	db.middleware = append(db.middleware[:len(db.middleware):len(db.middleware)], middleware...)
+	return db
+}
+
+// withMiddleware returns the func wrapped by every middleware of the database
+func (db *Database) withMiddleware(fn QueryFunc) QueryFunc {
+	for i := len(db.middleware) - 1; i >= 0; i-- {
+		fn = db.middleware[i](fn)
+	}
+
+	return fn
+}
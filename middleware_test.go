@@ -0,0 +1,126 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDatabase_Use(t *testing.T) {
+	db := benchDatabase(t)
+
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next QueryFunc) QueryFunc {
+			return func(ctx context.Context, q *Query) error {
+				calls = append(calls, name+" before")
+				err := next(ctx, q)
+				calls = append(calls, name+" after")
+				return err
+			}
+		}
+	}
+
+	db.Use(record("outer"), record("inner"))
+
+	var rows []benchWideRow
+	if err := db.SelectContext(context.Background(), &rows, "select*from`Wide`", 0); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1000 {
+		t.Errorf("SelectContext() selected %d rows, want 1000", len(rows))
+	}
+	if want := []string{"outer before", "inner before", "inner after", "outer after"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("middleware calls = %v, want %v", calls, want)
+	}
+
+	errSkipped := errors.New("skipped")
+	clone := db.Clone().Use(func(next QueryFunc) QueryFunc {
+		return func(ctx context.Context, q *Query) error {
+			if q.Kind == QueryKindExec {
+				return errSkipped
+			}
+			return next(ctx, q)
+		}
+	})
+	if err := clone.ExecContext(context.Background(), "delete from`Wide`"); !errors.Is(err, errSkipped) {
+		t.Errorf("ExecContext() = %v, want the middleware's error", err)
+	}
+	if len(db.middleware) != 2 {
+		t.Errorf("Use() on a clone changed the original's middleware")
+	}
+}
+
+func TestDatabase_UseCount(t *testing.T) {
+	db := benchDatabase(t)
+
+	var queries []*Query
+	db.Use(func(next QueryFunc) QueryFunc {
+		return func(ctx context.Context, q *Query) error {
+			queries = append(queries, q)
+			return next(ctx, q)
+		}
+	})
+
+	count, err := db.CountContext(context.Background(), "select*from`Wide`", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1000 {
+		t.Errorf("CountContext() = %d, want 1000", count)
+	}
+	if len(queries) != 1 || queries[0].Kind != QueryKindSelect || queries[0].Query != "select*from`Wide`" {
+		t.Errorf("CountContext() went through the middleware with %+v, want its select", queries)
+	}
+}
+
+func TestDatabase_UseExistsAndInsert(t *testing.T) {
+	db := benchDatabase(t)
+
+	var kinds []QueryKind
+	var queries []string
+	db.Use(func(next QueryFunc) QueryFunc {
+		return func(ctx context.Context, q *Query) error {
+			kinds = append(kinds, q.Kind)
+			queries = append(queries, q.Query)
+			return next(ctx, q)
+		}
+	})
+
+	exists, err := db.ExistsContext(context.Background(), "select*from`Wide`", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Error("ExistsContext() = false, want true")
+	}
+
+	rows := []benchWideRow{{Text0: "a"}, {Text0: "b"}}
+	if err := db.Insert("Wide", rows); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []QueryKind{QueryKindExists, QueryKindExec}; !reflect.DeepEqual(kinds, want) {
+		t.Fatalf("middleware kinds = %v, want %v", kinds, want)
+	}
+	if !strings.HasPrefix(queries[1], "insert into`Wide`") || !strings.Contains(queries[1], "0x62") {
+		t.Errorf("middleware insert query = %q, want the chunk with its rows", queries[1])
+	}
+
+	var rewrote bool
+	db.Use(func(next QueryFunc) QueryFunc {
+		return func(ctx context.Context, q *Query) error {
+			if q.Kind == QueryKindExists {
+				rewrote = true
+				q.Exists = false
+				return nil
+			}
+			return next(ctx, q)
+		}
+	})
+	if exists, err := db.ExistsContext(context.Background(), "select*from`Wide`", 0); err != nil || exists || !rewrote {
+		t.Errorf("ExistsContext() = %v, %v, want the middleware's false", exists, err)
+	}
+}
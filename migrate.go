@@ -0,0 +1,332 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MigrationsTable is the table that records which migrations have been applied
+var MigrationsTable = getenv("COOL_MYSQL_MIGRATIONS_TABLE", "schema_migrations")
+
+// ErrDirtyMigration is returned when a previous migration failed part way through
+// and the schema needs to be fixed by hand before migrating again
+var ErrDirtyMigration = errors.New("cool-mysql: database has a dirty migration")
+
+// MigrationFunc applies or reverts a migration written in Go
+type MigrationFunc func(ctx context.Context, db *Database) error
+
+// Migration is a single versioned schema change
+type Migration struct {
+	Version int64
+	Name    string
+
+	Up   MigrationFunc
+	Down MigrationFunc
+}
+
+// AppliedMigration is a row of the migrations table
+type AppliedMigration struct {
+	Version   int64
+	Name      string
+	Dirty     bool
+	AppliedAt time.Time
+}
+
+var migrationFileRegexp = regexp.MustCompile(`^(\d+)_(.+?)(?:\.(up|down))?\.sql$`)
+
+// Migrate applies every migration from fsys and goMigrations that hasn't been
+// applied yet, in version order. SQL migrations are files named like
+// `0001_create_users.up.sql` and `0001_create_users.down.sql` in the root of fsys.
+// Only one process can migrate at a time, and a failed migration leaves its version
+// marked dirty, making every later call return ErrDirtyMigration.
+func (db *Database) Migrate(ctx context.Context, fsys fs.FS, goMigrations ...Migration) error {
+	return db.migrate(ctx, fsys, goMigrations, func(migrations []Migration, applied map[int64]AppliedMigration) error {
+		for _, m := range migrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+
+			if m.Up == nil {
+				return fmt.Errorf("cool-mysql: migration %d %q has no up migration", m.Version, m.Name)
+			}
+
+			if err := db.runMigration(ctx, m, m.Up, true); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// MigrateDown reverts the last `steps` applied migrations, newest first
+func (db *Database) MigrateDown(ctx context.Context, fsys fs.FS, steps int, goMigrations ...Migration) error {
+	return db.migrate(ctx, fsys, goMigrations, func(migrations []Migration, applied map[int64]AppliedMigration) error {
+		for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+			m := migrations[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+
+			if m.Down == nil {
+				return fmt.Errorf("cool-mysql: migration %d %q has no down migration", m.Version, m.Name)
+			}
+
+			if err := db.runMigration(ctx, m, m.Down, false); err != nil {
+				return err
+			}
+			steps--
+		}
+
+		return nil
+	})
+}
+
+// AppliedMigrations returns the migrations that have been applied, in version order
+func (db *Database) AppliedMigrations(ctx context.Context) ([]AppliedMigration, error) {
+	var applied []AppliedMigration
+	err := db.SelectWritesContext(ctx, &applied, "select`Version`,`Name`,`Dirty`,`AppliedAt`from"+quoteIdentifier(MigrationsTable)+"order by`Version`", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+func (db *Database) migrate(ctx context.Context, fsys fs.FS, goMigrations []Migration, run func(migrations []Migration, applied map[int64]AppliedMigration) error) error {
+	migrations, err := loadMigrations(fsys, goMigrations)
+	if err != nil {
+		return err
+	}
+
+	table := quoteIdentifier(MigrationsTable)
+	lockTable := quoteIdentifier(MigrationsTable + "_lock")
+
+	err = db.ExecContext(ctx, "create table if not exists"+table+"("+
+		"`Version`bigint not null primary key,"+
+		"`Name`varchar(255)not null,"+
+		"`Dirty`tinyint(1)not null default 0,"+
+		"`AppliedAt`datetime(6)not null default current_timestamp(6))")
+	if err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	err = db.ExecContext(ctx, "create table if not exists"+lockTable+"(`ID`tinyint not null primary key)")
+	if err != nil {
+		return fmt.Errorf("failed to create migrations lock table: %w", err)
+	}
+
+	err = db.ExecContext(ctx, "insert ignore into"+lockTable+"values(1)")
+	if err != nil {
+		return fmt.Errorf("failed to create migrations lock row: %w", err)
+	}
+
+	// the lock row stays locked by this transaction while the migrations run on
+	// other connections, since ddl would implicitly commit the transaction
+	lockTx, cancel, err := db.BeginTxContext(ctx)
+	defer cancel()
+	if err != nil {
+		return fmt.Errorf("failed to begin migrations lock tx: %w", err)
+	}
+
+	if _, err = lockTx.ExistsContext(ctx, "select 0 from"+lockTable, 0, ForUpdate()); err != nil {
+		return fmt.Errorf("failed to lock migrations: %w", err)
+	}
+
+	appliedMigrations, err := db.AppliedMigrations(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+
+	applied := make(map[int64]AppliedMigration, len(appliedMigrations))
+	for _, m := range appliedMigrations {
+		if m.Dirty {
+			return fmt.Errorf("%w: version %d %q", ErrDirtyMigration, m.Version, m.Name)
+		}
+		applied[m.Version] = m
+	}
+
+	if err := run(migrations, applied); err != nil {
+		return err
+	}
+
+	return lockTx.Commit()
+}
+
+func (db *Database) runMigration(ctx context.Context, m Migration, fn MigrationFunc, up bool) error {
+	table := quoteIdentifier(MigrationsTable)
+
+	// the migrations table is shared by every tenant
+	tableCtx := AllowAllTenants(ctx)
+
+	var err error
+	if up {
+		err = db.ExecContext(tableCtx, "insert into"+table+"(`Version`,`Name`,`Dirty`)values(@@Version,@@Name,1)", m)
+	} else {
+		err = db.ExecContext(tableCtx, "update"+table+"set`Dirty`=1 where`Version`=@@Version", m)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to mark migration %d %q as dirty: %w", m.Version, m.Name, err)
+	}
+
+	if err := fn(ctx, db); err != nil {
+		return fmt.Errorf("migration %d %q failed: %w", m.Version, m.Name, err)
+	}
+
+	if up {
+		err = db.ExecContext(tableCtx, "update"+table+"set`Dirty`=0,`AppliedAt`=current_timestamp(6)where`Version`=@@Version", m)
+	} else {
+		err = db.ExecContext(tableCtx, "delete from"+table+"where`Version`=@@Version", m)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record migration %d %q: %w", m.Version, m.Name, err)
+	}
+
+	return nil
+}
+
+func loadMigrations(fsys fs.FS, goMigrations []Migration) ([]Migration, error) {
+	byVersion := make(map[int64]*Migration)
+
+	if fsys != nil {
+		entries, err := fs.ReadDir(fsys, ".")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migrations: %w", err)
+		}
+
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+
+			match := migrationFileRegexp.FindStringSubmatch(e.Name())
+			if match == nil {
+				continue
+			}
+
+			version, err := strconv.ParseInt(match[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid migration version in %q: %w", e.Name(), err)
+			}
+
+			b, err := fs.ReadFile(fsys, e.Name())
+			if err != nil {
+				return nil, fmt.Errorf("failed to read migration %q: %w", e.Name(), err)
+			}
+
+			m := byVersion[version]
+			if m == nil {
+				m = &Migration{Version: version, Name: match[2]}
+				byVersion[version] = m
+			} else if m.Name != match[2] {
+				return nil, fmt.Errorf("cool-mysql: migration version %d is used by both %q and %q", version, m.Name, match[2])
+			}
+
+			fn := sqlMigration(string(b))
+			if match[3] == "down" {
+				m.Down = fn
+			} else {
+				m.Up = fn
+			}
+		}
+	}
+
+	for _, gm := range goMigrations {
+		if _, ok := byVersion[gm.Version]; ok {
+			return nil, fmt.Errorf("cool-mysql: migration version %d is defined more than once", gm.Version)
+		}
+		gm := gm
+		byVersion[gm.Version] = &gm
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func sqlMigration(src string) MigrationFunc {
+	return func(ctx context.Context, db *Database) error {
+		for _, stmt := range splitStatements(src) {
+			if _, err := db.exec(db.Writes, ctx, nil, true, stmt); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// splitStatements splits a script on semicolons outside of strings and comments,
+// dropping the comments. `DELIMITER` isn't supported, so statements that contain
+// semicolons themselves, like stored procedures, need to be in Go migrations.
+func splitStatements(src string) []string {
+	var statements []string
+	stmt := new(strings.Builder)
+
+	push := func() {
+		if s := strings.TrimSpace(stmt.String()); len(s) != 0 {
+			statements = append(statements, s)
+		}
+		stmt.Reset()
+	}
+
+	l := len(src)
+	for i := 0; i < l; i++ {
+		switch b := src[i]; {
+		case b == '\'', b == '"', b == '`':
+			start := i
+			for i++; i < l; i++ {
+				if src[i] == '\\' && b != '`' {
+					i++
+				} else if src[i] == b {
+					if i+1 < l && src[i+1] == b {
+						i++
+					} else {
+						break
+					}
+				}
+			}
+			if i >= l {
+				i = l - 1
+			}
+			stmt.WriteString(src[start : i+1])
+		case b == '#',
+			b == '-' && i+1 < l && src[i+1] == '-' && (i+2 == l || src[i+2] == ' ' || src[i+2] == '\t' || src[i+2] == '\n' || src[i+2] == '\r'):
+			end := strings.IndexByte(src[i:], '\n')
+			if end == -1 {
+				i = l
+			} else {
+				i += end
+			}
+			stmt.WriteByte('\n')
+		case b == '/' && i+1 < l && src[i+1] == '*':
+			end := strings.Index(src[i+2:], "*/")
+			if end == -1 {
+				i = l
+			} else {
+				i += 2 + end + 1
+			}
+			stmt.WriteByte(' ')
+		case b == ';':
+			push()
+		default:
+			stmt.WriteByte(b)
+		}
+	}
+	push()
+
+	return statements
+}
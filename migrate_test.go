@@ -0,0 +1,87 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func Test_splitStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			name: "single",
+			src:  "create table`a`(`ID`int)",
+			want: []string{"create table`a`(`ID`int)"},
+		},
+		{
+			name: "multiple with trailing semicolon",
+			src:  "create table a (id int);\n\ninsert into a values (1);\n",
+			want: []string{"create table a (id int)", "insert into a values (1)"},
+		},
+		{
+			name: "semicolons in strings",
+			src:  "insert into a values ('a;b', \"c;\\\"d\", 'it''s;');select 1",
+			want: []string{"insert into a values ('a;b', \"c;\\\"d\", 'it''s;')", "select 1"},
+		},
+		{
+			name: "comments",
+			src:  "-- don't; split\ncreate table a (id int); # here's one; too\n/* and; this */ select 1",
+			want: []string{"create table a (id int)", "select 1"},
+		},
+		{
+			name: "double dash without space",
+			src:  "select 1--1",
+			want: []string{"select 1--1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.src); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_loadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_add_email.up.sql":      {Data: []byte("alter table users add email text")},
+		"0002_add_email.down.sql":    {Data: []byte("alter table users drop email")},
+		"0001_create_users.sql":      {Data: []byte("create table users (id int)")},
+		"README.md":                  {Data: []byte("not a migration")},
+		"0010_backfill_emails.up.go": {Data: []byte("not a migration either")},
+	}
+
+	migrations, err := loadMigrations(fsys, []Migration{{Version: 3, Name: "go"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type migration struct {
+		Version int64
+		Name    string
+		HasUp   bool
+		HasDown bool
+	}
+	got := make([]migration, len(migrations))
+	for i, m := range migrations {
+		got[i] = migration{m.Version, m.Name, m.Up != nil, m.Down != nil}
+	}
+
+	want := []migration{
+		{1, "create_users", true, false},
+		{2, "add_email", true, true},
+		{3, "go", false, false},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loadMigrations() = %v, want %v", got, want)
+	}
+
+	if _, err := loadMigrations(fsys, []Migration{{Version: 2, Name: "dupe"}}); err == nil {
+		t.Errorf("loadMigrations() with duplicate version should error")
+	}
+}
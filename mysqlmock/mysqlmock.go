@@ -0,0 +1,402 @@
+// Package mysqlmock is an in-memory fake of the database for unit tests, implementing mysql.Handler,
+// so code that takes a mysql.Handler can be tested without a server or regexp matched SQL.
+//
+// Results are programmed per query pattern, which is matched against the query with its params
+// interpolated, and every call is recorded for assertions:
+//
+//	db := mysqlmock.New()
+//	db.On("from`Users`where`ID`=1").Return([]User{{ID: 1, Name: "Ann"}})
+//	db.On("update`Users`").ReturnResult(1, 0)
+//
+//	err := svc.Rename(ctx, db, 1, "Bob")
+//
+//	calls := db.Calls()
+package mysqlmock
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	mysql "github.com/StirlingMarketingGroup/cool-mysql"
+)
+
+// ErrUnexpectedQuery is returned for queries that don't match any pattern
+var ErrUnexpectedQuery = errors.New("mysqlmock: unexpected query")
+
+// Call is a recorded call to the fake database
+type Call struct {
+	// Method is the name of the method that was called, like "SelectContext"
+	Method string
+
+	// Query is the query with its params interpolated, or the insert statement of inserts and upserts
+	Query string
+
+	// Params are the query's params merged into one map
+	Params mysql.Params
+
+	// Source is the source of inserts and upserts
+	Source any
+}
+
+// Expectation is the programmed result of the queries matching a pattern
+type Expectation struct {
+	pattern string
+
+	rows     any
+	err      error
+	result   sql.Result
+	exists   bool
+	hasExist bool
+
+	matched int
+}
+
+// Return sets the rows selected by the matching queries, as a slice of the dest's element type
+// or a single element. Exists is true if there are any rows.
+func (e *Expectation) Return(rows any) *Expectation {
+	e.rows = rows
+	return e
+}
+
+// ReturnError sets the error returned by the matching queries
+func (e *Expectation) ReturnError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// ReturnResult sets the result of the matching execs
+func (e *Expectation) ReturnResult(rowsAffected, lastInsertID int64) *Expectation {
+	e.result = result{rowsAffected: rowsAffected, lastInsertID: lastInsertID}
+	return e
+}
+
+// ReturnExists sets the result of the matching Exists calls
+func (e *Expectation) ReturnExists(exists bool) *Expectation {
+	e.exists = exists
+	e.hasExist = true
+	return e
+}
+
+type result struct {
+	rowsAffected, lastInsertID int64
+}
+
+func (r result) LastInsertId() (int64, error) { return r.lastInsertID, nil }
+func (r result) RowsAffected() (int64, error) { return r.rowsAffected, nil }
+
+// DB is a fake database
+type DB struct {
+	mu           sync.Mutex
+	expectations []*Expectation
+	calls        []Call
+}
+
+var _ mysql.Handler = new(DB)
+
+// New returns a fake database without any expectations
+func New() *DB {
+	return new(DB)
+}
+
+// On adds an expectation for the queries containing the pattern, ignoring case and
+// whitespace. The last added expectation that matches is used, so general
+// patterns can be set up first and overridden by more specific ones later.
+func (db *DB) On(pattern string) *Expectation {
+	e := &Expectation{pattern: normalize(pattern)}
+
+	db.mu.Lock()
+	db.expectations = append(db.expectations, e)
+	db.mu.Unlock()
+
+	return e
+}
+
+// Calls returns the recorded calls, in order
+func (db *DB) Calls() []Call {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return append([]Call(nil), db.calls...)
+}
+
+// Reset removes every expectation and recorded call
+func (db *DB) Reset() {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.expectations = nil
+	db.calls = nil
+}
+
+// ExpectationsWereMet returns an error listing the patterns that never matched a query
+func (db *DB) ExpectationsWereMet() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var unmatched []string
+	for _, e := range db.expectations {
+		if e.matched == 0 {
+			unmatched = append(unmatched, fmt.Sprintf("%q", e.pattern))
+		}
+	}
+	if len(unmatched) != 0 {
+		return fmt.Errorf("mysqlmock: patterns never matched: %s", strings.Join(unmatched, ", "))
+	}
+
+	return nil
+}
+
+// normalize removes whitespace and lowercases the query, since the
+// queries of this library are usually written with as little whitespace as possible
+func normalize(q string) string {
+	return strings.ToLower(strings.Join(strings.Fields(q), ""))
+}
+
+// call records the call and returns the expectation matching its query
+func (db *DB) call(method, query string, params []any, source any) (*Expectation, error) {
+	c := Call{
+		Method: method,
+		Query:  query,
+		Source: source,
+	}
+
+	if source == nil {
+		var err error
+		c.Query, c.Params, err = mysql.InterpolateParams(query, nil, nil, params...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to interpolate params: %w", err)
+		}
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.calls = append(db.calls, c)
+
+	q := normalize(c.Query)
+	for i := len(db.expectations) - 1; i >= 0; i-- {
+		e := db.expectations[i]
+		if strings.Contains(q, e.pattern) {
+			e.matched++
+			return e, e.err
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnexpectedQuery, c.Query)
+}
+
+// setDest copies the expectation's rows into the dest, like the real database would
+func setDest(dest, rows any) error {
+	destRef := reflect.ValueOf(dest)
+
+	var rowsRef reflect.Value
+	if rows != nil {
+		rowsRef = reflect.ValueOf(rows)
+	}
+
+	var elements []reflect.Value
+	if rowsRef.IsValid() && (rowsRef.Kind() == reflect.Slice || rowsRef.Kind() == reflect.Array) &&
+		!(destRef.Kind() == reflect.Pointer && rowsRef.Type().AssignableTo(destRef.Type().Elem())) {
+		for i := 0; i < rowsRef.Len(); i++ {
+			elements = append(elements, rowsRef.Index(i))
+		}
+	} else if rowsRef.IsValid() {
+		elements = []reflect.Value{rowsRef}
+	}
+
+	switch destRef.Kind() {
+	case reflect.Chan:
+		for _, el := range elements {
+			if !el.Type().AssignableTo(destRef.Type().Elem()) {
+				return fmt.Errorf("mysqlmock: can't send %s to %s", el.Type(), destRef.Type())
+			}
+			destRef.Send(el)
+		}
+		return nil
+	case reflect.Func:
+		for _, el := range elements {
+			if destRef.Type().NumIn() != 1 || !el.Type().AssignableTo(destRef.Type().In(0)) {
+				return fmt.Errorf("mysqlmock: can't call %s with %s", destRef.Type(), el.Type())
+			}
+			destRef.Call([]reflect.Value{el})
+		}
+		return nil
+	case reflect.Pointer:
+		dv := destRef.Elem()
+
+		if rowsRef.IsValid() && rowsRef.Type().AssignableTo(dv.Type()) {
+			dv.Set(rowsRef)
+			return nil
+		}
+
+		if dv.Kind() == reflect.Slice {
+			s := reflect.MakeSlice(dv.Type(), 0, len(elements))
+			for _, el := range elements {
+				if !el.Type().AssignableTo(dv.Type().Elem()) {
+					return fmt.Errorf("mysqlmock: can't set %s in %s", el.Type(), dv.Type())
+				}
+				s = reflect.Append(s, el)
+			}
+			dv.Set(s)
+			return nil
+		}
+
+		if len(elements) == 0 {
+			return sql.ErrNoRows
+		}
+		if !elements[0].Type().AssignableTo(dv.Type()) {
+			return fmt.Errorf("mysqlmock: can't set %s to %s", elements[0].Type(), dv.Type())
+		}
+		dv.Set(elements[0])
+		return nil
+	}
+
+	return fmt.Errorf("mysqlmock: unsupported dest %T", dest)
+}
+
+// hasRows returns true if the rows aren't nil or empty
+func (db *DB) Count(query string, cache time.Duration, params ...any) (int, error) {
+	return db.CountContext(context.Background(), query, cache, params...)
+}
+
+func (db *DB) CountContext(ctx context.Context, query string, cache time.Duration, params ...any) (int, error) {
+	e, err := db.call("CountContext", query, params, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	return countRows(e.rows), nil
+}
+
+// countRows returns the number of rows, where a single row that isn't in a slice counts as one
+func countRows(rows any) int {
+	if !hasRows(rows) {
+		return 0
+	}
+
+	v := reflect.ValueOf(rows)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return v.Len()
+	}
+
+	return 1
+}
+
+func hasRows(rows any) bool {
+	if rows == nil {
+		return false
+	}
+
+	v := reflect.ValueOf(rows)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return v.Len() != 0
+	case reflect.Pointer, reflect.Interface:
+		return !v.IsNil()
+	}
+
+	return true
+}
+
+func (db *DB) Insert(insert string, source any) error {
+	return db.InsertContext(context.Background(), insert, source)
+}
+
+func (db *DB) InsertContext(ctx context.Context, insert string, source any) error {
+	_, err := db.call("InsertContext", insert, nil, source)
+	return err
+}
+
+func (db *DB) ExecContextResult(ctx context.Context, query string, params ...any) (sql.Result, error) {
+	e, err := db.call("ExecContextResult", query, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	if e.result == nil {
+		return result{}, nil
+	}
+
+	return e.result, nil
+}
+
+func (db *DB) ExecContext(ctx context.Context, query string, params ...any) error {
+	_, err := db.ExecContextResult(ctx, query, params...)
+	return err
+}
+
+func (db *DB) ExecResult(query string, params ...any) (sql.Result, error) {
+	return db.ExecContextResult(context.Background(), query, params...)
+}
+
+func (db *DB) Exec(query string, params ...any) error {
+	_, err := db.ExecContextResult(context.Background(), query, params...)
+	return err
+}
+
+func (db *DB) Select(dest any, q string, cache time.Duration, params ...any) error {
+	return db.SelectContext(context.Background(), dest, q, cache, params...)
+}
+
+func (db *DB) SelectRows(q string, cache time.Duration, params ...any) (mysql.Rows, error) {
+	var rows mysql.Rows
+	err := db.SelectContext(context.Background(), &rows, q, cache, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+func (db *DB) SelectContext(ctx context.Context, dest any, q string, cache time.Duration, params ...any) error {
+	e, err := db.call("SelectContext", q, params, nil)
+	if err != nil {
+		return err
+	}
+
+	return setDest(dest, e.rows)
+}
+
+func (db *DB) SelectJSON(dest any, query string, cache time.Duration, params ...any) error {
+	return db.SelectJSONContext(context.Background(), dest, query, cache, params...)
+}
+
+func (db *DB) SelectJSONContext(ctx context.Context, dest any, query string, cache time.Duration, params ...any) error {
+	e, err := db.call("SelectJSONContext", query, params, nil)
+	if err != nil {
+		return err
+	}
+
+	return setDest(dest, e.rows)
+}
+
+func (db *DB) Exists(query string, cache time.Duration, params ...any) (bool, error) {
+	return db.ExistsContext(context.Background(), query, cache, params...)
+}
+
+func (db *DB) ExistsContext(ctx context.Context, query string, cache time.Duration, params ...any) (bool, error) {
+	e, err := db.call("ExistsContext", query, params, nil)
+	if err != nil {
+		return false, err
+	}
+	if e.hasExist {
+		return e.exists, nil
+	}
+
+	return hasRows(e.rows), nil
+}
+
+func (db *DB) Upsert(insert string, uniqueColumns, updateColumns []string, where string, whereParams mysql.Params, source any) error {
+	return db.UpsertContext(context.Background(), insert, uniqueColumns, updateColumns, where, whereParams, source)
+}
+
+func (db *DB) UpsertContext(ctx context.Context, insert string, uniqueColumns, updateColumns []string, where string, whereParams mysql.Params, source any) error {
+	_, err := db.call("UpsertContext", insert, nil, source)
+	return err
+}
@@ -0,0 +1,76 @@
+package mysqlmock
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	mysql "github.com/StirlingMarketingGroup/cool-mysql"
+)
+
+type user struct {
+	ID   int
+	Name string
+}
+
+func TestDB(t *testing.T) {
+	db := New()
+	db.On("select * from `Users`").Return([]user{{1, "Ann"}, {2, "Bob"}})
+	db.On("from `Users` where `ID` = 3").Return(nil)
+	db.On("update `Users`").ReturnResult(2, 0)
+
+	var users []user
+	if err := db.Select(&users, "select*from`Users`", 0); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[1].Name != "Bob" {
+		t.Errorf("users = %+v", users)
+	}
+
+	var u user
+	if err := db.Select(&u, "select*from`Users`", 0); err != nil || u.ID != 1 {
+		t.Errorf("single select = %+v, %v", u, err)
+	}
+
+	ch := make(chan user, 2)
+	if err := db.Select(ch, "select * from `Users`", 0); err != nil || len(ch) != 2 {
+		t.Errorf("chan select got %d rows, %v", len(ch), err)
+	}
+
+	if err := db.Select(&u, "select*from`Users`where`ID`=@@ID", 0, mysql.Params{"ID": 3}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("missing row error = %v, want sql.ErrNoRows", err)
+	}
+
+	if exists, err := db.Exists("select*from`Users`", 0); err != nil || !exists {
+		t.Errorf("Exists() = %v, %v", exists, err)
+	}
+
+	if count, err := mysql.Count[int64](context.Background(), db, "select*from`Users`", 0); err != nil || count != 2 {
+		t.Errorf("Count() = %v, %v", count, err)
+	}
+
+	res, err := db.ExecResult("update`Users`set`Name`=@@Name", mysql.Params{"Name": "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := res.RowsAffected(); n != 2 {
+		t.Errorf("RowsAffected() = %d, want 2", n)
+	}
+
+	if err := db.Exec("delete from`Users`"); !errors.Is(err, ErrUnexpectedQuery) {
+		t.Errorf("unexpected query error = %v", err)
+	}
+
+	calls := db.Calls()
+	if len(calls) != 8 {
+		t.Fatalf("got %d calls, want 8", len(calls))
+	}
+	if want := "update`Users`set`Name`=_utf8mb4 0x78 collate utf8mb4_unicode_ci"; calls[6].Query != want {
+		t.Errorf("recorded query = %q, want %q", calls[6].Query, want)
+	}
+
+	if err := db.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
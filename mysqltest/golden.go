@@ -0,0 +1,104 @@
+package mysqltest
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mysql "github.com/StirlingMarketingGroup/cool-mysql"
+)
+
+// update rewrites golden files with the SQL the tests got, like `go test ./... -mysqltest.update`
+var update = flag.Bool("mysqltest.update", false, "update the golden SQL files")
+
+// AssertSQL fails the test if got doesn't match the golden file, so changes to the
+// generated SQL show up as diffs of the golden files. Running the tests with
+// `-mysqltest.update` writes got to the golden file instead.
+func AssertSQL(t testing.TB, got, goldenFile string) {
+	t.Helper()
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(goldenFile), 0o755); err != nil {
+			t.Fatalf("mysqltest: failed to create golden file dir: %v", err)
+		}
+		if err := os.WriteFile(goldenFile, []byte(got), 0o644); err != nil {
+			t.Fatalf("mysqltest: failed to write golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(goldenFile)
+	if err != nil {
+		t.Fatalf("mysqltest: failed to read golden file, run with -mysqltest.update to create it: %v", err)
+	}
+
+	if got != string(want) {
+		t.Errorf("mysqltest: SQL doesn't match %s, run with -mysqltest.update if the change is expected\ngot:\n%s\nwant:\n%s", goldenFile, got, want)
+	}
+}
+
+// Recorder records the queries run by a database
+type Recorder struct {
+	mu      sync.Mutex
+	queries []string
+}
+
+// Queries returns the recorded queries, in order
+func (r *Recorder) Queries() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.queries...)
+}
+
+// String returns the recorded queries, each followed by a semicolon and a new line,
+// ready to be compared with AssertSQL
+func (r *Recorder) String() string {
+	s := new(strings.Builder)
+	for _, q := range r.Queries() {
+		s.WriteString(q)
+		s.WriteString(";\n")
+	}
+
+	return s.String()
+}
+
+// RecordSQL records the queries run by the database from now until the end of the test,
+// calling the database's existing Log func too
+func RecordSQL(t testing.TB, db *mysql.Database) *Recorder {
+	r := new(Recorder)
+
+	log := db.Log
+	db.Log = func(detail mysql.LogDetail) {
+		if detail.Attempt <= 1 && !detail.CacheHit {
+			r.mu.Lock()
+			r.queries = append(r.queries, detail.Query)
+			r.mu.Unlock()
+		}
+
+		if log != nil {
+			log(detail)
+		}
+	}
+	t.Cleanup(func() {
+		db.Log = log
+	})
+
+	return r
+}
+
+// FreezeNow sets the built in `@@now` param to the given time until the end of the test,
+// so queries using it are deterministic. Tests using it can't run in parallel.
+func FreezeNow(t testing.TB, now time.Time) {
+	prev := mysql.NowFunc
+	mysql.NowFunc = func() time.Time {
+		return now
+	}
+	t.Cleanup(func() {
+		mysql.NowFunc = prev
+	})
+}
@@ -0,0 +1,41 @@
+package mysqltest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	mysql "github.com/StirlingMarketingGroup/cool-mysql"
+)
+
+func TestAssertSQL(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "testdata", "insert.sql")
+
+	*update = true
+	AssertSQL(t, "insert into`Users`values(1);\n", golden)
+	*update = false
+
+	b, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "insert into`Users`values(1);\n" {
+		t.Errorf("golden file = %q", b)
+	}
+
+	AssertSQL(t, "insert into`Users`values(1);\n", golden)
+}
+
+func TestFreezeNow(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	FreezeNow(t, now)
+
+	q, _, err := mysql.InterpolateParams("select@@now", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "selectconvert_tz('2020-01-02 03:04:05.000000','UTC',@@session.time_zone)"; q != want {
+		t.Errorf("query = %q, want %q", q, want)
+	}
+}
@@ -0,0 +1,171 @@
+// Package mysqltest gives each test its own database on a real MySQL server,
+// with the schema applied, for tests that need real SQL semantics like
+// JSON_TABLE or CTEs instead of mocked results.
+//
+// The server is the one in the COOL_MYSQL_TEST_DSN environment variable, or one
+// started by SetStarter, like a testcontainer:
+//
+//	func TestMain(m *testing.M) {
+//		mysqltest.SetStarter(func(ctx context.Context) (string, func(), error) {
+//			c, err := tcmysql.Run(ctx, "mysql:8.0")
+//			if err != nil {
+//				return "", nil, err
+//			}
+//			dsn, err := c.ConnectionString(ctx)
+//			return dsn, func() { c.Terminate(context.Background()) }, err
+//		})
+//		code := m.Run()
+//		mysqltest.Stop()
+//		os.Exit(code)
+//	}
+//
+//	func TestUsers(t *testing.T) {
+//		db := mysqltest.New(t, os.DirFS("migrations"))
+//		...
+//	}
+//
+// Tests are skipped if there's neither a DSN nor a starter.
+package mysqltest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"testing"
+
+	mysql "github.com/StirlingMarketingGroup/cool-mysql"
+	driver "github.com/go-sql-driver/mysql"
+)
+
+// DSNEnv is the environment variable with the DSN of the server tests run against,
+// where the DSN's database is ignored
+var DSNEnv = "COOL_MYSQL_TEST_DSN"
+
+// Starter starts a MySQL server, returning its DSN and a func that stops it
+type Starter func(ctx context.Context) (dsn string, stop func(), err error)
+
+var server struct {
+	sync.Mutex
+
+	starter Starter
+	started bool
+	dsn     string
+	stop    func()
+	err     error
+}
+
+// SetStarter sets the func that starts the server when DSNEnv isn't set.
+// The server is started by the first call to New and shared by every test,
+// so Stop should be called once the tests are done.
+func SetStarter(start Starter) {
+	server.Lock()
+	defer server.Unlock()
+
+	server.starter = start
+}
+
+// Stop stops the server started by the starter, if there is one
+func Stop() {
+	server.Lock()
+	defer server.Unlock()
+
+	if server.stop != nil {
+		server.stop()
+	}
+	server.started = false
+	server.dsn, server.stop, server.err = "", nil, nil
+}
+
+// serverDSN returns the DSN of the server, starting it if needed
+func serverDSN(ctx context.Context) (string, error) {
+	if dsn := os.Getenv(DSNEnv); len(dsn) != 0 {
+		return dsn, nil
+	}
+
+	server.Lock()
+	defer server.Unlock()
+
+	if server.starter == nil {
+		return "", nil
+	}
+
+	if !server.started {
+		server.started = true
+		server.dsn, server.stop, server.err = server.starter(ctx)
+		if server.err != nil {
+			server.err = fmt.Errorf("mysqltest: failed to start server: %w", server.err)
+		}
+	}
+
+	return server.dsn, server.err
+}
+
+// New creates a database just for the test, applies the migrations from schema to it
+// with Database.Migrate, and returns it, dropping the database when the test is done.
+// Schema can be nil for an empty database.
+func New(t testing.TB, schema fs.FS, goMigrations ...mysql.Migration) *mysql.Database {
+	t.Helper()
+
+	ctx := context.Background()
+
+	dsn, err := serverDSN(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dsn) == 0 {
+		t.Skipf("mysqltest: %s isn't set and there's no starter", DSNEnv)
+	}
+
+	cfg, err := driver.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("mysqltest: invalid dsn: %v", err)
+	}
+	cfg.ParseTime = true
+	cfg.InterpolateParams = true
+
+	name := make([]byte, 8)
+	if _, err := rand.Read(name); err != nil {
+		t.Fatal(err)
+	}
+	cfg.DBName = "test_" + hex.EncodeToString(name)
+
+	serverCfg := cfg.Clone()
+	serverCfg.DBName = ""
+	admin, err := mysql.NewFromDSN(serverCfg.FormatDSN(), serverCfg.FormatDSN())
+	if err != nil {
+		t.Fatalf("mysqltest: failed to connect: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Writes.Close()
+	})
+
+	err = admin.ExecContext(ctx, "create database`"+cfg.DBName+"`")
+	if err != nil {
+		t.Fatalf("mysqltest: failed to create database: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := admin.ExecContext(context.Background(), "drop database if exists`"+cfg.DBName+"`"); err != nil {
+			t.Errorf("mysqltest: failed to drop database %q: %v", cfg.DBName, err)
+		}
+	})
+
+	db, err := mysql.NewFromDSN(cfg.FormatDSN(), cfg.FormatDSN())
+	if err != nil {
+		t.Fatalf("mysqltest: failed to connect: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Writes.Close()
+	})
+
+	if schema != nil || len(goMigrations) != 0 {
+		if err := db.Migrate(ctx, schema, goMigrations...); err != nil {
+			t.Fatalf("mysqltest: failed to apply schema: %v", err)
+		}
+	}
+
+	return db
+}
@@ -0,0 +1,122 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrNamedLockTimeout is returned when a named lock couldn't be acquired before the timeout
+var ErrNamedLockTimeout = errors.New("cool-mysql: timed out waiting for named lock")
+
+// NamedLockReleaseTimeout is how long releasing a named lock can take
+// before the connection holding it is closed instead
+var NamedLockReleaseTimeout = 5 * time.Second
+
+// WithNamedLock runs fn while holding the MySQL advisory lock with the given name,
+// so only one caller across every process using the database can run it at a time.
+// The lock is taken with `GET_LOCK` on a single pinned connection, waiting up to timeout,
+// or forever if timeout is negative, and returns ErrNamedLockTimeout if it can't be acquired.
+// Taking the lock is a single attempt, without the database's Timeouts or retries, since it
+// can wait as long as timeout on purpose. The lock is released when fn returns or panics.
+// If the context is canceled first, the pinned connection is closed, which releases the lock
+// right away, so fn should return once its context is done, since it no longer holds the lock.
+// Lock names are limited to 64 characters by MySQL.
+func (db *Database) WithNamedLock(ctx context.Context, name string, timeout time.Duration, fn func(ctx context.Context) error) (err error) {
+	conn, err := db.Writes.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection for named lock: %w", err)
+	}
+	defer conn.Close()
+
+	seconds := -1
+	if timeout >= 0 {
+		seconds = int(math.Ceil(timeout.Seconds()))
+	}
+
+	acquired, err := db.namedLockQuery(ctx, conn, "select get_lock(@@Name,@@Timeout)", Params{
+		"Name":    name,
+		"Timeout": seconds,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get named lock %q: %w", name, err)
+	}
+	if acquired == nil {
+		return fmt.Errorf("cool-mysql: failed to get named lock %q", name)
+	}
+	if *acquired != 1 {
+		return fmt.Errorf("%w %q", ErrNamedLockTimeout, name)
+	}
+
+	// the lock belongs to the connection's session, so closing the connection
+	// when the context is canceled releases it without waiting for fn
+	done := make(chan struct{})
+	closed := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+			closed <- true
+		case <-done:
+			closed <- false
+		}
+	}()
+
+	defer func() {
+		close(done)
+		if <-closed {
+			return
+		}
+
+		// the lock is released even if ctx is canceled, and if that fails the connection
+		// is closed instead, since returning it to the pool would keep the lock held
+		releaseCtx, cancel := context.WithTimeout(context.Background(), NamedLockReleaseTimeout)
+		defer cancel()
+
+		_, releaseErr := db.namedLockQuery(releaseCtx, conn, "select release_lock(@@Name)", Params{
+			"Name": name,
+		})
+		if releaseErr != nil {
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+
+			if err == nil {
+				err = fmt.Errorf("failed to release named lock %q: %w", name, releaseErr)
+			}
+		}
+	}()
+
+	return fn(ctx)
+}
+
+// namedLockQuery runs the query of a named lock once on its connection, and returns its result
+func (db *Database) namedLockQuery(ctx context.Context, conn *sql.Conn, query string, params Params) (*int, error) {
+	replacedQuery, normalizedParams, err := db.interpolateParams(ctx, query, params)
+	if err != nil {
+		return nil, interpolateError(query, err)
+	}
+
+	var result *int
+	start := time.Now()
+	err = conn.QueryRowContext(ctx, db.commented(ctx, replacedQuery)).Scan(&result)
+	db.callLog(withQueryName(ctx, LogDetail{
+		Query:    replacedQuery,
+		Params:   normalizedParams,
+		Duration: time.Since(start),
+		Attempt:  1,
+		Error:    err,
+	}))
+	if err != nil {
+		return nil, Error{
+			Err:           err,
+			OriginalQuery: query,
+			ReplacedQuery: replacedQuery,
+			Params:        normalizedParams,
+		}
+	}
+
+	return result, nil
+}
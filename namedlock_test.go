@@ -0,0 +1,112 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/StirlingMarketingGroup/cool-mysql/internal/testdriver"
+)
+
+// namedLockDriver returns a driver whose get_lock waits for wait and then returns acquired,
+// and whose release_lock always releases, counting the calls of each
+func namedLockDriver(wait time.Duration, acquired int64, gets, releases *int32) *testdriver.Driver {
+	return &testdriver.Driver{
+		QueryFunc: func(ctx context.Context, query string) (driver.Rows, error) {
+			if strings.HasPrefix(query, "select release_lock(") {
+				atomic.AddInt32(releases, 1)
+				return testdriver.NewRows([]string{"r"}, []driver.Value{int64(1)}), nil
+			}
+
+			atomic.AddInt32(gets, 1)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+			return testdriver.NewRows([]string{"r"}, []driver.Value{acquired}), nil
+		},
+	}
+}
+
+func TestDatabase_WithNamedLock(t *testing.T) {
+	var gets, releases int32
+	db := testDatabase(t, namedLockDriver(0, 1, &gets, &releases))
+
+	errFn := errors.New("fn failed")
+	err := db.WithNamedLock(context.Background(), "job", time.Second, func(ctx context.Context) error {
+		if atomic.LoadInt32(&releases) != 0 {
+			t.Error("WithNamedLock() released the lock before fn returned")
+		}
+		return errFn
+	})
+	if !errors.Is(err, errFn) {
+		t.Errorf("WithNamedLock() error = %v, want fn's error", err)
+	}
+	if gets != 1 || releases != 1 {
+		t.Errorf("WithNamedLock() got the lock %d times and released it %d times, want once each", gets, releases)
+	}
+}
+
+func TestDatabase_WithNamedLockTimeout(t *testing.T) {
+	var gets, releases int32
+	db := testDatabase(t, namedLockDriver(0, 0, &gets, &releases))
+
+	err := db.WithNamedLock(context.Background(), "job", time.Second, func(ctx context.Context) error {
+		t.Error("WithNamedLock() ran fn without the lock")
+		return nil
+	})
+	if !errors.Is(err, ErrNamedLockTimeout) {
+		t.Errorf("WithNamedLock() error = %v, want ErrNamedLockTimeout", err)
+	}
+	if releases != 0 {
+		t.Errorf("WithNamedLock() released a lock it didn't get")
+	}
+}
+
+func TestDatabase_WithNamedLockWaits(t *testing.T) {
+	var gets, releases int32
+	db := testDatabase(t, namedLockDriver(100*time.Millisecond, 1, &gets, &releases))
+
+	// waiting for the lock isn't an attempt that times out and is retried
+	db.SetTimeouts(Timeouts{Attempt: 10 * time.Millisecond})
+
+	err := db.WithNamedLock(context.Background(), "job", -1, func(ctx context.Context) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gets != 1 {
+		t.Errorf("WithNamedLock() tried to get the lock %d times, want once", gets)
+	}
+}
+
+func TestDatabase_WithNamedLockCanceled(t *testing.T) {
+	var gets, releases int32
+	d := namedLockDriver(0, 1, &gets, &releases)
+	db := testDatabase(t, d)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := db.WithNamedLock(ctx, "job", time.Second, func(ctx context.Context) error {
+		cancel()
+
+		// the lock's connection is closed when the context is canceled, while fn is still running
+		deadline := time.Now().Add(5 * time.Second)
+		for d.Closes.Load() == 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("WithNamedLock() didn't close the lock's connection")
+			}
+			time.Sleep(time.Millisecond)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if releases != 0 {
+		t.Errorf("WithNamedLock() released the lock of a closed connection")
+	}
+}
@@ -0,0 +1,167 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnknownQuery is matched by the error of running a query name that isn't registered
+var ErrUnknownQuery = errors.New("cool-mysql: unknown query name")
+
+// Pool is the connection pool a named query runs on
+type Pool int
+
+const (
+	// PoolReads runs the query on the reads connection, the default for selects
+	PoolReads Pool = iota
+
+	// PoolWrites runs the query on the writes connection, like for selects that
+	// have to see writes that may not have replicated yet
+	PoolWrites
+)
+
+func (p Pool) String() string {
+	if p == PoolWrites {
+		return "writes"
+	}
+	return "reads"
+}
+
+// MarshalText implements encoding.TextMarshaler, so policies can be kept in config files
+func (p Pool) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting "reads" or "writes"
+func (p *Pool) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "reads", "":
+		*p = PoolReads
+	case "writes":
+		*p = PoolWrites
+	default:
+		return fmt.Errorf("cool-mysql: unknown pool %q", text)
+	}
+	return nil
+}
+
+// Policy is how a named query runs, which can be changed while it's in use
+// with SetQueryPolicy without changing the code that runs it
+type Policy struct {
+	// TTL is how long the query's results are cached, where 0 means they aren't
+	TTL time.Duration
+
+	// Pool is the connection the query runs on
+	Pool Pool
+
+	// Tags are passed along in the LogDetail of the query, like to group queries in metrics
+	Tags []string
+}
+
+type namedQuery struct {
+	query  string
+	policy Policy
+}
+
+// RegisterQuery registers the query under the name, so it can be run with Run by its name,
+// and replaces the query and policy if the name was already registered
+func (db *Database) RegisterQuery(name, query string, policy Policy) *Database {
+	if db.namedQueries == nil {
+		db.namedQueries = new(sync.Map)
+	}
+	db.namedQueries.Store(name, &namedQuery{query: query, policy: policy})
+
+	return db
+}
+
+func (db *Database) namedQuery(name string) (*namedQuery, error) {
+	if db.namedQueries != nil {
+		if nq, ok := db.namedQueries.Load(name); ok {
+			return nq.(*namedQuery), nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w %q", ErrUnknownQuery, name)
+}
+
+// QueryPolicy returns the policy of the named query
+func (db *Database) QueryPolicy(name string) (Policy, error) {
+	nq, err := db.namedQuery(name)
+	if err != nil {
+		return Policy{}, err
+	}
+
+	return nq.policy, nil
+}
+
+// SetQueryPolicy changes the policy of the named query, which is safe to do while it's running
+func (db *Database) SetQueryPolicy(name string, policy Policy) error {
+	nq, err := db.namedQuery(name)
+	if err != nil {
+		return err
+	}
+
+	db.namedQueries.Store(name, &namedQuery{query: nq.query, policy: policy})
+	return nil
+}
+
+// SetQueryPolicies changes the policies of the named queries, like from a config file,
+// failing without changing any of them if one of the names isn't registered
+func (db *Database) SetQueryPolicies(policies map[string]Policy) error {
+	for name := range policies {
+		if _, err := db.namedQuery(name); err != nil {
+			return err
+		}
+	}
+
+	for name, policy := range policies {
+		if err := db.SetQueryPolicy(name, policy); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Run runs the named query with its policy, selecting into dest,
+// or executing it on the writes connection if dest is nil
+func (db *Database) Run(ctx context.Context, name string, dest any, params ...any) error {
+	nq, err := db.namedQuery(name)
+	if err != nil {
+		return err
+	}
+
+	ctx = context.WithValue(ctx, queryNameKey, namedQueryLabel{name: name, tags: nq.policy.Tags})
+
+	if dest == nil {
+		return db.ExecContext(ctx, nq.query, params...)
+	}
+
+	conn := db.Reads
+	if nq.policy.Pool == PoolWrites {
+		conn = db.Writes
+	}
+
+	return db.query(conn, ctx, dest, nq.query, nq.policy.TTL, params...)
+}
+
+var queryNameKey = key(8)
+
+type namedQueryLabel struct {
+	name string
+	tags []string
+}
+
+// withQueryName sets the name and tags of the named query being run, if any, on the log detail
+func withQueryName(ctx context.Context, detail LogDetail) LogDetail {
+	if l, ok := ctx.Value(queryNameKey).(namedQueryLabel); ok {
+		detail.QueryName = l.name
+		detail.Tags = l.tags
+	}
+
+	return detail
+}
@@ -0,0 +1,51 @@
+package mysql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDatabase_Run(t *testing.T) {
+	db := benchDatabase(t)
+
+	var details []LogDetail
+	db.Log = func(detail LogDetail) {
+		details = append(details, detail)
+	}
+
+	db.RegisterQuery("wideRows", "select*from`Wide`", Policy{Tags: []string{"reports"}})
+
+	var rows []benchWideRow
+	if err := db.Run(context.Background(), "wideRows", &rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1000 {
+		t.Errorf("Run() selected %d rows, want 1000", len(rows))
+	}
+	if len(details) != 1 || details[0].QueryName != "wideRows" || !reflect.DeepEqual(details[0].Tags, []string{"reports"}) {
+		t.Errorf("Run() logged %+v, want the query's name and tags", details)
+	}
+
+	if err := db.Run(context.Background(), "missing", &rows); !errors.Is(err, ErrUnknownQuery) {
+		t.Errorf("Run() of an unregistered query = %v, want ErrUnknownQuery", err)
+	}
+
+	var policies map[string]Policy
+	if err := json.Unmarshal([]byte(`{"wideRows":{"TTL":60000000000,"Pool":"writes"}}`), &policies); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetQueryPolicies(policies); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := db.QueryPolicy("wideRows"); p.TTL != time.Minute || p.Pool != PoolWrites {
+		t.Errorf("QueryPolicy() = %+v, want the policy from the config", p)
+	}
+
+	if err := db.SetQueryPolicies(map[string]Policy{"missing": {}}); !errors.Is(err, ErrUnknownQuery) {
+		t.Errorf("SetQueryPolicies() with an unregistered query = %v, want ErrUnknownQuery", err)
+	}
+}
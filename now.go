@@ -0,0 +1,35 @@
+package mysql
+
+import "time"
+
+// NowFunc returns the value of the built in `@@now` param, and can be
+// replaced in tests so queries using it are deterministic
+var NowFunc = time.Now
+
+// SetNowFunc sets the func used for the current time by the database instead of NowFunc,
+// which is the built in `@@now` param, the time of audit entries, Tx.Time,
+// and the times of versioned rows, so they can be frozen in tests
+func (db *Database) SetNowFunc(fn func() time.Time) *Database {
+	db.nowFunc = fn
+
+	return db
+}
+
+// now returns the current time of the database
+func (db *Database) now() time.Time {
+	if db.nowFunc != nil {
+		return db.nowFunc()
+	}
+
+	return NowFunc()
+}
+
+// nowParams puts the database's current time first in the params,
+// for the `@@now` param, if it has its own now func
+func (db *Database) nowParams(params []any) []any {
+	if db.nowFunc == nil {
+		return params
+	}
+
+	return append([]any{Params{"now": db.nowFunc()}}, params...)
+}
@@ -0,0 +1,30 @@
+package mysql
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetNowFunc(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	db := new(Database).SetNowFunc(func() time.Time {
+		return now
+	})
+
+	q, _, err := db.InterpolateParams("select@@now,@@ID", Params{"ID": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "selectconvert_tz('2020-01-02 03:04:05.000000','UTC',@@session.time_zone),1"; q != want {
+		t.Errorf("query = %q, want %q", q, want)
+	}
+
+	// params still override now
+	q, _, err = db.InterpolateParams("select@@now", Params{"now": 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "select5"; q != want {
+		t.Errorf("query = %q, want %q", q, want)
+	}
+}
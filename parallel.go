@@ -0,0 +1,212 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Partition splits a select into ranges of an integer key for SelectParallel
+type Partition struct {
+	column  string
+	n       int
+	ordered bool
+}
+
+// PartitionBy splits a select into n ranges of the integer column, which has to be one
+// of the columns selected by the query, ideally indexed in the table it comes from.
+// The ranges are of equal width between the column's min and max values,
+// so a column with gaps or skewed values can make some ranges much bigger than others.
+func PartitionBy(column string, n int) Partition {
+	return Partition{column: column, n: n}
+}
+
+// Ordered returns the rows ordered by the partition's column, instead of in whatever
+// order the ranges finish in. Later ranges are held in memory until the ranges before them are sent.
+func (p Partition) Ordered() Partition {
+	p.ordered = true
+	return p
+}
+
+type partitionRange struct {
+	lo, hi int64
+}
+
+// partitionRanges splits min to max, inclusive, into at most n ranges of equal width
+func partitionRanges(min, max int64, n int) []partitionRange {
+	if n < 1 {
+		n = 1
+	}
+
+	// the width is computed in uint64 so the full range of int64 doesn't overflow
+	width := uint64(max-min)/uint64(n) + 1
+
+	ranges := make([]partitionRange, 0, n)
+	for lo := min; ; {
+		hi := max
+		if uint64(max-lo) >= width {
+			hi = int64(uint64(lo) + width - 1)
+		}
+
+		ranges = append(ranges, partitionRange{lo: lo, hi: hi})
+		if hi == max {
+			break
+		}
+		lo = hi + 1
+	}
+
+	return ranges
+}
+
+// partitionQuery returns the query limited to the range of the partition's column
+func (p Partition) partitionQuery(q string, r partitionRange) string {
+	col := quoteIdentifier(p.column)
+
+	pq := "select*from(" + q + ")`cool_mysql_partition`where" + col + ">=" + strconv.FormatInt(r.lo, 10) +
+		" and" + col + "<=" + strconv.FormatInt(r.hi, 10)
+	if p.ordered {
+		pq += " order by" + col
+	}
+
+	return pq
+}
+
+// ranges probes the min and max of the partition's column and returns its ranges,
+// or nil if the query has no rows
+func (p Partition) ranges(ctx context.Context, db *Database, q string, params ...any) ([]partitionRange, error) {
+	if len(p.column) == 0 {
+		return nil, fmt.Errorf("cool-mysql: partition needs a column")
+	}
+
+	col := quoteIdentifier(p.column)
+
+	var bounds struct {
+		Min, Max *int64
+	}
+	err := db.SelectContext(ctx, &bounds, "select min("+col+")`Min`,max("+col+")`Max`from("+q+")`cool_mysql_partition`", 0, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe partition range: %w", err)
+	}
+	if bounds.Min == nil || bounds.Max == nil {
+		return nil, nil
+	}
+
+	return partitionRanges(*bounds.Min, *bounds.Max, p.n), nil
+}
+
+// SelectParallel splits the select into the partition's ranges, after probing the min and max
+// of its column, and runs them concurrently on the reads connection, returning all of the rows.
+// Rows are ordered by the partition's column if it's Ordered, and otherwise by range,
+// in whatever order each range returned them, unless they're merged by a Merge passed with the params.
+func SelectParallel[T any](ctx context.Context, db *Database, q string, partition Partition, params ...any) ([]T, error) {
+	merge, params, err := mergeFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+	var merged []T
+	order, unique, err := merge.columns(&merged, q)
+	if err != nil {
+		return nil, err
+	}
+
+	ranges, err := partition.ranges(ctx, db, q, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([][]T, len(ranges))
+
+	grp, ctx := errgroup.WithContext(ctx)
+	for i, r := range ranges {
+		i, r := i, r
+		grp.Go(func() error {
+			if err := db.SelectContext(ctx, &results[i], partition.partitionQuery(q, r), 0, params...); err != nil {
+				return fmt.Errorf("partition %d: %w", i, err)
+			}
+			return nil
+		})
+	}
+
+	if err := grp.Wait(); err != nil {
+		return nil, err
+	}
+
+	l := 0
+	for _, r := range results {
+		l += len(r)
+	}
+
+	merged = make([]T, 0, l)
+	for _, r := range results {
+		merged = append(merged, r...)
+	}
+
+	mergeRows(&merged, order, unique)
+
+	return merged, nil
+}
+
+// SelectParallelChan is like SelectParallel, but sends the rows to ch as they're selected,
+// or in the order of the partition's column if it's Ordered. The channel isn't closed.
+func SelectParallelChan[T any](ctx context.Context, db *Database, ch chan<- T, q string, partition Partition, params ...any) error {
+	ranges, err := partition.ranges(ctx, db, q, params...)
+	if err != nil {
+		return err
+	}
+
+	grp, ctx := errgroup.WithContext(ctx)
+
+	if !partition.ordered {
+		for i, r := range ranges {
+			i, r := i, r
+			grp.Go(func() error {
+				if err := db.SelectContext(ctx, ch, partition.partitionQuery(q, r), 0, params...); err != nil {
+					return fmt.Errorf("partition %d: %w", i, err)
+				}
+				return nil
+			})
+		}
+
+		return grp.Wait()
+	}
+
+	// every range is selected concurrently, but each one is
+	// only sent once all of the ranges before it have been
+	results := make([]chan []T, len(ranges))
+	for i, r := range ranges {
+		i, r := i, r
+		results[i] = make(chan []T, 1)
+		grp.Go(func() error {
+			var rows []T
+			if err := db.SelectContext(ctx, &rows, partition.partitionQuery(q, r), 0, params...); err != nil {
+				return fmt.Errorf("partition %d: %w", i, err)
+			}
+			results[i] <- rows
+			return nil
+		})
+	}
+
+	grp.Go(func() error {
+		for _, result := range results {
+			var rows []T
+			select {
+			case rows = <-result:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			for _, row := range rows {
+				select {
+				case ch <- row:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+		return nil
+	})
+
+	return grp.Wait()
+}
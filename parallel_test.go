@@ -0,0 +1,46 @@
+package mysql
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func Test_partitionRanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		min, max int64
+		n        int
+		want     []partitionRange
+	}{
+		{"even", 1, 10, 5, []partitionRange{{1, 2}, {3, 4}, {5, 6}, {7, 8}, {9, 10}}},
+		{"uneven", 1, 10, 3, []partitionRange{{1, 4}, {5, 8}, {9, 10}}},
+		{"more partitions than keys", 5, 7, 10, []partitionRange{{5, 5}, {6, 6}, {7, 7}}},
+		{"single key", 3, 3, 4, []partitionRange{{3, 3}}},
+		{"no partitions", 1, 10, 0, []partitionRange{{1, 10}}},
+		{"negative", -10, -1, 2, []partitionRange{{-10, -6}, {-5, -1}}},
+		{"full range", math.MinInt64, math.MaxInt64, 2, []partitionRange{{math.MinInt64, -1}, {0, math.MaxInt64}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := partitionRanges(tt.min, tt.max, tt.n); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("partitionRanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPartition_partitionQuery(t *testing.T) {
+	p := PartitionBy("ID", 4)
+	r := partitionRange{lo: 1, hi: 100}
+
+	want := "select*from(select*from`Users`)`cool_mysql_partition`where`ID`>=1 and`ID`<=100"
+	if got := p.partitionQuery("select*from`Users`", r); got != want {
+		t.Errorf("partitionQuery() = %q, want %q", got, want)
+	}
+
+	want += " order by`ID`"
+	if got := p.Ordered().partitionQuery("select*from`Users`", r); got != want {
+		t.Errorf("ordered partitionQuery() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,84 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrUnsupportedParam is matched by the errors of params, and values of inserted rows,
+// whose types can't be written in a query, see ParamError
+var ErrUnsupportedParam = errors.New("cool-mysql: unsupported param type")
+
+// ParamError is the error of a param, or a value of an inserted row, whose type can't be written in a query,
+// with where it came from, as far as it's known, and what would let it be written
+type ParamError struct {
+	// Param is the name of the param in the query, like "UserID" for `@@UserID`
+	Param string
+
+	// Field is the struct field the value came from, like "User.Balance"
+	Field string
+
+	// Column is the column the value was being inserted into
+	Column string
+
+	// Type is the type of the value, which can be an element of a slice param
+	Type reflect.Type
+}
+
+func (e *ParamError) Error() string {
+	var where []string
+	if len(e.Param) != 0 {
+		where = append(where, "param @@"+e.Param)
+	}
+	if len(e.Field) != 0 {
+		where = append(where, "field "+e.Field)
+	}
+	if len(e.Column) != 0 {
+		where = append(where, "column `"+e.Column+"`")
+	}
+
+	msg := fmt.Sprintf("%s %s", ErrUnsupportedParam, e.Type)
+	if len(where) != 0 {
+		msg += " of " + strings.Join(where, ", ")
+	}
+
+	return msg + ": " + e.suggestion()
+}
+
+func (e *ParamError) Is(target error) bool {
+	return target == ErrUnsupportedParam
+}
+
+// suggestion returns what would let the value be written
+func (e *ParamError) suggestion() string {
+	if e.Type == nil {
+		return "pass a value instead"
+	}
+
+	switch e.Type.Kind() {
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return "a " + e.Type.Kind().String() + " can't be written in a query, pass the value it holds instead"
+	}
+
+	return fmt.Sprintf("implement driver.Valuer on %s, or add a valuer func of it with Database.AddValuerFuncs", e.Type)
+}
+
+// withParamError fills in where the value of the error's ParamError came from, if it has one
+func withParamError(err error, fill func(e *ParamError)) error {
+	var paramErr *ParamError
+	if errors.As(err, &paramErr) {
+		fill(paramErr)
+	}
+
+	return err
+}
+
+// interpolateError returns the error of a query's params, with the query it was for
+func interpolateError(query string, err error) error {
+	return Error{
+		Err:           fmt.Errorf("failed to interpolate params: %w", err),
+		OriginalQuery: query,
+	}
+}
@@ -0,0 +1,65 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParamError(t *testing.T) {
+	type job struct {
+		ID   int
+		Done func()
+	}
+
+	db := benchDatabase(t)
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		run  func() error
+		want ParamError
+	}{
+		{
+			"param",
+			func() error {
+				return db.ExecContext(ctx, "update`jobs`set`Done`=1 where`ID`=@@ID", Params{"ID": make(chan int)})
+			},
+			ParamError{Param: "ID", Type: reflect.TypeOf(make(chan int))},
+		},
+		{
+			"struct param",
+			func() error {
+				var ids []int
+				return db.SelectContext(ctx, &ids, "select`ID`from`jobs`where`Done`=@@Done", 0, job{Done: func() {}})
+			},
+			ParamError{Param: "Done", Field: "job.Done", Type: reflect.TypeOf(func() {})},
+		},
+		{
+			"insert",
+			func() error {
+				return db.InsertContext(ctx, "jobs", job{ID: 1, Done: func() {}})
+			},
+			ParamError{Field: "job.Done", Column: "Done", Type: reflect.TypeOf(func() {})},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run()
+			if !errors.Is(err, ErrUnsupportedParam) {
+				t.Fatalf("error = %v, want ErrUnsupportedParam", err)
+			}
+
+			var paramErr *ParamError
+			if !errors.As(err, &paramErr) || !reflect.DeepEqual(*paramErr, tt.want) {
+				t.Errorf("ParamError = %+v, want %+v", paramErr, tt.want)
+			}
+		})
+	}
+
+	err := db.ExecContext(ctx, "update`jobs`set`Done`=1 where`ID`=@@ID", Params{"ID": make(chan int)})
+	var queryErr Error
+	if !errors.As(err, &queryErr) || queryErr.OriginalQuery != "update`jobs`set`Done`=1 where`ID`=@@ID" {
+		t.Errorf("error = %v, want an Error with the query", err)
+	}
+}
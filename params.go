@@ -1,7 +1,7 @@
 package mysql
 
 import (
-	"bytes"
+	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
@@ -15,6 +15,7 @@ import (
 	"cloud.google.com/go/civil"
 	"github.com/fatih/structtag"
 	"github.com/shopspring/decimal"
+	"google.golang.org/protobuf/proto"
 )
 
 // Params are a map of paramterer names to values
@@ -27,6 +28,17 @@ var stringsBuilderPool = sync.Pool{
 	},
 }
 
+// maxPooledQueryBufSize is the largest buffer kept for interpolating the next query,
+// so one huge query doesn't keep its buffer around forever
+const maxPooledQueryBufSize = 1 << 20
+
+var queryBufPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 1024)
+		return &b
+	},
+}
+
 var MaxTime = time.Unix((1<<31)-1, 999999999)
 
 var BuiltInParams = Params{
@@ -70,14 +82,14 @@ func mergeParams(caseSensitive bool, params []Params, paramMetas []map[string]pa
 // override the values of the previous. If there are 2 maps given,
 // both with the key "ID", the last one will be used
 func InterpolateParams(query string, tmplFuncs template.FuncMap, valuerFuncs map[reflect.Type]reflect.Value, params ...any) (replacedQuery string, normalizedParams Params, err error) {
-	return interpolateParams(query, tmplFuncs, valuerFuncs, params...)
+	return interpolateParams(query, tmplFuncs, newValuers(valuerFuncs), params...)
 }
 
-func interpolateParams(query string, tmplFuncs template.FuncMap, valuerFuncs map[reflect.Type]reflect.Value, params ...any) (replacedQuery string, mergedParams Params, err error) {
+func interpolateParams(query string, tmplFuncs template.FuncMap, valuerFuncs *valuers, params ...any) (replacedQuery string, mergedParams Params, err error) {
 	if strings.Contains(query, "{{") {
 		convertedParams := make([]Params, 0, len(params))
 		for _, p := range params {
-			cp, _ := convertToParams("param", p)
+			cp, _ := convertValuerParams("param", p, valuerFuncs)
 			convertedParams = append(convertedParams, cp)
 		}
 
@@ -111,12 +123,12 @@ func interpolateParams(query string, tmplFuncs template.FuncMap, valuerFuncs map
 	convertedParams := make([]Params, 0, len(params))
 	paramMetas := make([]map[string]paramMeta, 0, len(params))
 	for _, p := range params {
-		cp, pm := convertToParams(firstParamName, p)
+		cp, pm := convertValuerParams(firstParamName, p, valuerFuncs)
 		convertedParams = append(convertedParams, cp)
 		paramMetas = append(paramMetas, pm)
 	}
 
-	allParams := append(make([]Params, 0, len(params)+2), Params{"now": time.Now()}, BuiltInParams)
+	allParams := append(make([]Params, 0, len(params)+2), Params{"now": NowFunc()}, BuiltInParams)
 	allParams = append(allParams, convertedParams...)
 
 	var mergedParamMetas map[string]paramMeta
@@ -128,10 +140,12 @@ func interpolateParams(query string, tmplFuncs template.FuncMap, valuerFuncs map
 
 	usedParams := make(map[string]struct{})
 
-	s := stringsBuilderPool.Get().(*strings.Builder)
-	defer stringsBuilderPool.Put(s)
-	s.Reset()
+	bp := queryBufPool.Get().(*[]byte)
+	defer queryBufPool.Put(bp)
 
+	// the params are marshaled straight into the buffer, which keeps
+	// the capacity it grew to for the next query that uses it
+	b := (*bp)[:0]
 	for _, t := range queryTokens {
 		switch t.kind {
 		case queryTokenKindParam:
@@ -143,22 +157,28 @@ func interpolateParams(query string, tmplFuncs template.FuncMap, valuerFuncs map
 						opts |= marshalOptDefaultZero
 					}
 				}
-				b, err := marshal(v, opts, k, valuerFuncs)
+				b, err = appendMarshal(b, v, opts, k, valuerFuncs)
 				if err != nil {
-					return "", nil, err
+					return "", nil, withParamError(err, func(e *ParamError) {
+						e.Param = t.string[2:]
+						if len(e.Field) == 0 {
+							e.Field = mergedParamMetas[k].field
+						}
+					})
 				}
 
-				s.Write(b)
-
 				usedParams[k] = struct{}{}
 				break
 			}
 
-			s.WriteString(t.string)
+			b = append(b, t.string...)
 		default:
-			s.WriteString(t.string)
+			b = append(b, t.string...)
 		}
 	}
+	if cap(b) <= maxPooledQueryBufSize {
+		*bp = b
+	}
 
 	for k := range mergedParams {
 		if _, ok := usedParams[k]; !ok {
@@ -166,7 +186,7 @@ func interpolateParams(query string, tmplFuncs template.FuncMap, valuerFuncs map
 		}
 	}
 
-	return s.String(), mergedParams, nil
+	return string(b), mergedParams, nil
 }
 
 type queryToken struct {
@@ -312,7 +332,7 @@ func parseQuery(query string) []queryToken {
 }
 
 func Marshal(x any, valuerFuncs map[reflect.Type]reflect.Value) ([]byte, error) {
-	return marshal(x, 0, "", valuerFuncs)
+	return marshal(x, 0, "", newValuers(valuerFuncs))
 }
 
 type marshalOpt uint
@@ -327,91 +347,117 @@ const (
 // marshal returns the interpolated param, encoding values that could have escaping issues.
 // Strings and []byte are hex encoded so as to make extra sure nothing
 // bad is let through
-func marshal(x any, opts marshalOpt, fieldName string, valuerFuncs map[reflect.Type]reflect.Value) ([]byte, error) {
+func marshal(x any, opts marshalOpt, fieldName string, valuerFuncs *valuers) ([]byte, error) {
+	return appendMarshal(nil, x, opts, fieldName, valuerFuncs)
+}
+
+// appendMarshal appends the interpolated param to dst, like marshal,
+// so params can be written straight into the query being built
+func appendMarshal(dst []byte, x any, opts marshalOpt, fieldName string, valuerFuncs *valuers) ([]byte, error) {
 	if (opts&marshalOptDefaultZero) != 0 && isZero(x) {
 		if len(fieldName) != 0 {
-			return []byte("default(`" + fieldName + "`)"), nil
+			return append(append(append(dst, "default(`"...), fieldName...), "`)"...), nil
 		} else {
-			return []byte("default"), nil
+			return append(dst, "default"...), nil
 		}
 	}
 
 	switch v := x.(type) {
 	case bool:
 		if !v {
-			return []byte("0"), nil
+			return append(dst, "0"...), nil
 
 		}
-		return []byte("1"), nil
+		return append(dst, "1"...), nil
 	case string:
 		if len(v) == 0 {
-			return []byte("''"), nil
+			return append(dst, "''"...), nil
 		}
-		return []byte(fmt.Sprintf("_utf8mb4 0x%x collate utf8mb4_unicode_ci", v)), nil
+		return appendHexString(dst, v), nil
 	case []byte:
 		if v == nil {
-			return []byte("null"), nil
+			return append(dst, "null"...), nil
 		}
 		if len(v) == 0 {
-			return []byte("''"), nil
+			return append(dst, "''"...), nil
 		}
-		return []byte(fmt.Sprintf("0x%x", v)), nil
+		return appendHex(append(dst, "0x"...), v), nil
 	case int:
-		return []byte(strconv.FormatInt(int64(v), 10)), nil
+		return strconv.AppendInt(dst, int64(v), 10), nil
 	case int8:
-		return []byte(strconv.FormatInt(int64(v), 10)), nil
+		return strconv.AppendInt(dst, int64(v), 10), nil
 	case int16:
-		return []byte(strconv.FormatInt(int64(v), 10)), nil
+		return strconv.AppendInt(dst, int64(v), 10), nil
 	case int32:
-		return []byte(strconv.FormatInt(int64(v), 10)), nil
+		return strconv.AppendInt(dst, int64(v), 10), nil
 	case int64:
-		return []byte(strconv.FormatInt(v, 10)), nil
+		return strconv.AppendInt(dst, v, 10), nil
 	case uint:
-		return []byte(strconv.FormatUint(uint64(v), 10)), nil
+		return strconv.AppendUint(dst, uint64(v), 10), nil
 	case uint8:
-		return []byte(strconv.FormatUint(uint64(v), 10)), nil
+		return strconv.AppendUint(dst, uint64(v), 10), nil
 	case uint16:
-		return []byte(strconv.FormatUint(uint64(v), 10)), nil
+		return strconv.AppendUint(dst, uint64(v), 10), nil
 	case uint32:
-		return []byte(strconv.FormatUint(uint64(v), 10)), nil
+		return strconv.AppendUint(dst, uint64(v), 10), nil
 	case uint64:
-		return []byte(strconv.FormatUint(uint64(v), 10)), nil
+		return strconv.AppendUint(dst, uint64(v), 10), nil
 	case complex64:
-		return []byte(strconv.FormatComplex(complex128(v), 'E', -1, 64)), nil
+		return append(dst, strconv.FormatComplex(complex128(v), 'E', -1, 64)...), nil
 	case complex128:
-		return []byte(strconv.FormatComplex(complex128(v), 'E', -1, 64)), nil
+		return append(dst, strconv.FormatComplex(complex128(v), 'E', -1, 64)...), nil
 	case float32:
-		return []byte(strconv.FormatFloat(float64(v), 'E', -1, 64)), nil
+		return strconv.AppendFloat(dst, float64(v), 'E', -1, 64), nil
 	case float64:
-		return []byte(strconv.FormatFloat(float64(v), 'E', -1, 64)), nil
+		return strconv.AppendFloat(dst, float64(v), 'E', -1, 64), nil
 	case time.Time:
 		if v.IsZero() {
-			return []byte("null"), nil
+			return append(dst, "null"...), nil
 		}
-		return []byte(fmt.Sprintf("convert_tz('%s','UTC',@@session.time_zone)", v.UTC().Format("2006-01-02 15:04:05.000000"))), nil
+		dst = v.UTC().AppendFormat(append(dst, "convert_tz('"...), "2006-01-02 15:04:05.000000")
+		return append(dst, "','UTC',@@session.time_zone)"...), nil
+	case zonedTime:
+		if v.t.IsZero() {
+			return append(dst, "null"...), nil
+		}
+		dst = v.t.In(v.loc).AppendFormat(append(dst, '\''), "2006-01-02 15:04:05.000000")
+		return append(dst, '\''), nil
 	case civil.Date:
 		if v.IsZero() {
-			return []byte("null"), nil
+			return append(dst, "null"...), nil
 		}
-		return []byte(fmt.Sprintf("'%s'", v.String())), nil
+		return append(append(append(dst, '\''), v.String()...), '\''), nil
 	case decimal.Decimal:
-		return []byte(v.String()), nil
+		return append(dst, v.String()...), nil
 	case json.RawMessage:
 		if v == nil {
-			return []byte("null"), nil
+			return append(dst, "null"...), nil
 		}
 		if len(v) == 0 {
-			return []byte("''"), nil
+			return append(dst, "''"...), nil
 		}
-		return []byte(fmt.Sprintf("_utf8mb4 0x%x collate utf8mb4_unicode_ci", v)), nil
+		return appendHexString(dst, v), nil
 	case Raw:
-		return []byte(v), nil
+		return append(dst, v...), nil
+	case Collated:
+		return appendCollated(dst, v, opts, fieldName, valuerFuncs)
+	case Geometry:
+		if isNil(v) {
+			return append(dst, "null"...), nil
+		}
+		return append(dst, marshalGeometry(v)...), nil
+	case proto.Message:
+		b, err := marshalProto(v, false)
+		if err != nil {
+			return nil, err
+		}
+		return appendMarshal(dst, b, opts, fieldName, valuerFuncs)
 	}
 
 	v := reflect.ValueOf(x)
 	if v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
 		if v := v.Elem(); v.IsValid() {
-			return marshal(v.Interface(), opts, fieldName, valuerFuncs)
+			return appendMarshal(dst, v.Interface(), opts, fieldName, valuerFuncs)
 		}
 	}
 
@@ -421,7 +467,7 @@ func marshal(x any, opts marshalOpt, fieldName string, valuerFuncs map[reflect.T
 	v = reflectUnwrap(v)
 
 	if !v.IsValid() {
-		return []byte("null"), nil
+		return append(dst, "null"...), nil
 	}
 
 	// pv needs to always be a pointer to a value
@@ -433,23 +479,25 @@ func marshal(x any, opts marshalOpt, fieldName string, valuerFuncs map[reflect.T
 		pv.Elem().Set(v)
 	}
 
-	if valuerFuncs != nil {
-		fn, ok := valuerFuncs[pv.Type()]
+	if fn, in, ok := valuerFunc(valuerFuncs, pv.Type()); ok {
 		pv := pv
-		if !ok {
-			fn, ok = valuerFuncs[reflectUnwrapType(pv.Type())]
+		if in != pv.Type() {
 			pv = reflectUnwrap(pv)
-			if pv.Kind() == reflect.Ptr && pv.IsNil() && fn.Type().In(0).Kind() != reflect.Ptr {
-				return []byte("null"), nil
+			if pv.Kind() == reflect.Ptr && pv.IsNil() {
+				return append(dst, "null"...), nil
 			}
 		}
-		if ok {
-			returns := fn.Call([]reflect.Value{pv})
-			if err := returns[1].Interface(); err != nil {
-				return nil, fmt.Errorf("cool-mysql: failed to call valuer func: %w", err.(error))
-			}
-			return marshal(returns[0].Interface(), opts, fieldName, valuerFuncs)
+
+		returns := fn.Call([]reflect.Value{pv})
+		if err := returns[1].Interface(); err != nil {
+			return nil, fmt.Errorf("cool-mysql: failed to call valuer func: %w", err.(error))
 		}
+		return appendMarshal(dst, returns[0].Interface(), opts, fieldName, valuerFuncs)
+	}
+
+	// messages are usually pointers, which were unwrapped above
+	if m, ok := pv.Interface().(proto.Message); ok {
+		return appendMarshal(dst, m, opts, fieldName, valuerFuncs)
 	}
 
 	if v, ok := pv.Interface().(driver.Valuer); ok {
@@ -458,7 +506,7 @@ func marshal(x any, opts marshalOpt, fieldName string, valuerFuncs map[reflect.T
 			// so we need to check if the element type of the pointer has the method
 			// if it does have the method, then we can't call it, because we're nil
 			if _, ok := pv.Type().Elem().MethodByName("Value"); ok {
-				return []byte("null"), nil
+				return append(dst, "null"...), nil
 			}
 		}
 
@@ -466,13 +514,13 @@ func marshal(x any, opts marshalOpt, fieldName string, valuerFuncs map[reflect.T
 		if err != nil {
 			return nil, fmt.Errorf("cool-mysql: failed to call Value on driver.Valuer: %w", err)
 		}
-		return marshal(v, opts, fieldName, valuerFuncs)
+		return appendMarshal(dst, v, opts, fieldName, valuerFuncs)
 	}
 
 	if vs, ok := pv.Interface().(Valueser); ok {
 		if pv.IsNil() {
 			if _, ok := pv.Type().Elem().MethodByName("Value"); ok {
-				return []byte("null"), nil
+				return append(dst, "null"...), nil
 			}
 		}
 
@@ -480,37 +528,37 @@ func marshal(x any, opts marshalOpt, fieldName string, valuerFuncs map[reflect.T
 		if err != nil {
 			return nil, fmt.Errorf("cool-mysql: failed to call MySQLValues on mysql.MySQLValues: %w", err)
 		}
-		return marshal(vs, opts, fieldName, valuerFuncs)
+		return appendMarshal(dst, vs, opts, fieldName, valuerFuncs)
 	}
 
 	if isNil(x) {
-		return []byte("null"), nil
+		return append(dst, "null"...), nil
 	}
 
 	k := v.Kind()
 	switch k {
 	case reflect.Bool:
-		return marshal(v.Bool(), opts, fieldName, valuerFuncs)
+		return appendMarshal(dst, v.Bool(), opts, fieldName, valuerFuncs)
 	case reflect.String:
-		return marshal(v.String(), opts, fieldName, valuerFuncs)
+		return appendMarshal(dst, v.String(), opts, fieldName, valuerFuncs)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return marshal(v.Int(), opts, fieldName, valuerFuncs)
+		return appendMarshal(dst, v.Int(), opts, fieldName, valuerFuncs)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return marshal(v.Uint(), opts, fieldName, valuerFuncs)
+		return appendMarshal(dst, v.Uint(), opts, fieldName, valuerFuncs)
 	case reflect.Complex64, reflect.Complex128:
-		return marshal(v.Complex(), opts, fieldName, valuerFuncs)
+		return appendMarshal(dst, v.Complex(), opts, fieldName, valuerFuncs)
 	case reflect.Float32, reflect.Float64:
-		return marshal(v.Float(), opts, fieldName, valuerFuncs)
+		return appendMarshal(dst, v.Float(), opts, fieldName, valuerFuncs)
 	case reflect.Struct, reflect.Map:
 		j, err := json.Marshal(x)
 		if err != nil {
 			return nil, fmt.Errorf("cool-mysql: failed to marshal struct to json: %w", err)
 		}
 
-		return marshal(json.RawMessage(j), opts, fieldName, valuerFuncs)
+		return appendMarshal(dst, json.RawMessage(j), opts, fieldName, valuerFuncs)
 	case reflect.Slice:
 		if v.Type().Elem().Kind() == reflect.Uint8 {
-			return marshal(v.Bytes(), opts, fieldName, valuerFuncs)
+			return appendMarshal(dst, v.Bytes(), opts, fieldName, valuerFuncs)
 		}
 
 		if opts&marshalOptJSONSlice != 0 {
@@ -519,46 +567,97 @@ func marshal(x any, opts marshalOpt, fieldName string, valuerFuncs map[reflect.T
 				return nil, fmt.Errorf("cool-mysql: failed to marshal slice to json: %w", err)
 			}
 
-			return marshal(json.RawMessage(j), opts, fieldName, valuerFuncs)
+			return appendMarshal(dst, json.RawMessage(j), opts, fieldName, valuerFuncs)
 		}
 
-		buf := new(bytes.Buffer)
-
 		if opts&marshalOptWrapSliceWithParens != 0 {
-			buf.WriteByte('(')
+			dst = append(dst, '(')
 		}
 
 		refLen := v.Len()
 		if refLen == 0 {
-			buf.WriteString("null")
+			dst = append(dst, "null"...)
 		}
 		for i := 0; i < refLen; i++ {
 			if i != 0 {
-				buf.WriteByte(',')
+				dst = append(dst, ',')
 			}
 
-			b, err := marshal(v.Index(i).Interface(), opts|marshalOptWrapSliceWithParens, fieldName, valuerFuncs)
+			var err error
+			dst, err = appendMarshal(dst, v.Index(i).Interface(), opts|marshalOptWrapSliceWithParens, fieldName, valuerFuncs)
 			if err != nil {
 				return nil, err
 			}
-			buf.Write(b)
 		}
 
 		if opts&marshalOptWrapSliceWithParens != 0 {
-			buf.WriteByte(')')
+			dst = append(dst, ')')
 		}
 
-		return buf.Bytes(), nil
+		return dst, nil
+	}
+
+	return nil, &ParamError{Type: reflect.TypeOf(x)}
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendHex appends the lowercase hex of s to dst
+func appendHex[T ~string | ~[]byte](dst []byte, s T) []byte {
+	for i := 0; i < len(s); i++ {
+		dst = append(dst, hexDigits[s[i]>>4], hexDigits[s[i]&0x0f])
+	}
+	return dst
+}
+
+// appendHexString appends s as a hex encoded utf8mb4 string literal
+func appendHexString[T ~string | ~[]byte](dst []byte, s T) []byte {
+	dst = appendHex(append(dst, "_utf8mb4 0x"...), s)
+	return append(dst, " collate utf8mb4_unicode_ci"...)
+}
+
+// namedArgParams returns the params of the named arg, which is the first param if it has no name
+func namedArgParams(firstParamName string, na sql.NamedArg) Params {
+	if len(na.Name) == 0 {
+		return Params{firstParamName: na.Value}
 	}
 
-	return nil, fmt.Errorf("cool-mysql: not sure how to interpret %q of type %T", x, x)
+	return Params{na.Name: na.Value}
 }
 
 type paramMeta struct {
 	defaultZero bool
+
+	// field is the struct field of the param, like "User.ID"
+	field string
+}
+
+// convertValuerParams is convertToParams, except values with valuer funcs are always single params,
+// like driver.Valuers, instead of their fields or items, since the funcs are how they're meant to be written
+func convertValuerParams(firstParamName string, v any, valuerFuncs *valuers) (Params, map[string]paramMeta) {
+	switch v.(type) {
+	case Params, sql.NamedArg, *sql.NamedArg:
+	default:
+		if hasValuerFunc(valuerFuncs, reflect.TypeOf(v)) {
+			return Params{firstParamName: v}, nil
+		}
+	}
+
+	return convertToParams(firstParamName, v)
 }
 
 func convertToParams(firstParamName string, v any) (Params, map[string]paramMeta) {
+	// named args are params of their names, like `sql.Named("ID", 5)` is `@@ID`,
+	// so code written for database/sql can pass them as they are
+	switch na := v.(type) {
+	case sql.NamedArg:
+		return namedArgParams(firstParamName, na), nil
+	case *sql.NamedArg:
+		if na != nil {
+			return namedArgParams(firstParamName, *na), nil
+		}
+	}
+
 	r := reflect.ValueOf(v)
 
 	if !r.IsValid() {
@@ -595,12 +694,12 @@ func convertToParams(firstParamName string, v any) (Params, map[string]paramMeta
 
 			p[f.Name] = rv.FieldByIndex(i).Interface()
 
-			t, _ := structtag.Parse(string(f.Tag))
-			if t, _ := t.Get("mysql"); t != nil {
-				meta[f.Name] = paramMeta{
-					defaultZero: t.HasOption("defaultzero"),
-				}
+			pm := paramMeta{field: t.Name() + "." + f.Name}
+			tags, _ := structtag.Parse(string(f.Tag))
+			if tag, _ := tags.Get("mysql"); tag != nil {
+				pm.defaultZero = tag.HasOption("defaultzero")
 			}
+			meta[f.Name] = pm
 		}
 
 		return p, meta
@@ -625,7 +724,7 @@ func convertToParams(firstParamName string, v any) (Params, map[string]paramMeta
 }
 
 func isSingleParam(t reflect.Type) bool {
-	if t.Implements(valuerType) || t == timeType || t == civilDateType {
+	if t.Implements(valuerType) || t == timeType || t == civilDateType || t == collatedType {
 		return true
 	}
 
@@ -640,8 +739,20 @@ func isSingleParam(t reflect.Type) bool {
 // string replacer for double backticks and escaped backticks
 var backtickReplacer = strings.NewReplacer("``", "`", "\\`", "`")
 
-// removes surrounding backticks and unescapes interior ones
+// removes surrounding backticks and unescapes interior ones,
+// of each part of names qualified by their table or schema, like `db`.`table`
 func parseName(s string) string {
+	if len(s) > 2 && s[0] == '`' && strings.Contains(s, "`.") {
+		if parts, ok := tableNameParts(parseQuery(s)); ok && len(parts) > 1 {
+			return strings.Join(parts, ".")
+		}
+	}
+
+	return unquoteName(s)
+}
+
+// removes surrounding backticks and unescapes interior ones
+func unquoteName(s string) string {
 	if len(s) < 2 {
 		return s
 	}
@@ -653,7 +764,40 @@ func parseName(s string) string {
 	return backtickReplacer.Replace(s)
 }
 
-func execTemplate(q string, params Params, addlTmplFuncs template.FuncMap, valuerFuncs map[reflect.Type]reflect.Value) (string, error) {
+// quoteIdentifier wraps each dot separated part of the name in backticks,
+// escaping any interior ones
+func quoteIdentifier(name string) string {
+	parts := splitIdentifier(name)
+	for i, p := range parts {
+		parts[i] = "`" + strings.ReplaceAll(unquoteName(p), "`", "``") + "`"
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// splitIdentifier splits the name on the dots that aren't inside backticks,
+// so quoted parts like `a.b` stay whole
+func splitIdentifier(name string) []string {
+	var parts []string
+	var quoted bool
+	start := 0
+	for i := 0; i < len(name); i++ {
+		switch name[i] {
+		case '`':
+			// escaped backticks inside quotes flip this twice, leaving it quoted
+			quoted = !quoted
+		case '.':
+			if !quoted {
+				parts = append(parts, name[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(parts, name[start:])
+}
+
+func execTemplate(q string, params Params, addlTmplFuncs template.FuncMap, valuerFuncs *valuers) (string, error) {
 	if !strings.Contains(q, "{{") {
 		return q, nil
 	}
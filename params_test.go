@@ -1,10 +1,13 @@
 package mysql
 
 import (
+	"context"
+	"database/sql"
 	"database/sql/driver"
 	"encoding/hex"
 	"reflect"
 	"testing"
+	"text/template"
 	"time"
 
 	"cloud.google.com/go/civil"
@@ -116,6 +119,21 @@ func Test_convertToParams(t *testing.T) {
 			args: args{firstParamName: "foo", v: []any{1, 2, 3}},
 			want: Params{"foo": []any{1, 2, 3}},
 		},
+		{
+			name: "named arg",
+			args: args{firstParamName: "foo", v: sql.Named("id", 5)},
+			want: Params{"id": 5},
+		},
+		{
+			name: "named arg pointer",
+			args: args{firstParamName: "foo", v: &sql.NamedArg{Name: "id", Value: []int{1, 2}}},
+			want: Params{"id": []int{1, 2}},
+		},
+		{
+			name: "unnamed named arg",
+			args: args{firstParamName: "foo", v: sql.NamedArg{Value: "bar"}},
+			want: Params{"foo": "bar"},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -287,6 +305,16 @@ func Test_parseName(t *testing.T) {
 			args: args{s: "f"},
 			want: "f",
 		},
+		{
+			name: "qualified",
+			args: args{s: "`foo`.`bar``baz`"},
+			want: "foo.bar`baz",
+		},
+		{
+			name: "dot",
+			args: args{s: "`foo.bar`"},
+			want: "foo.bar",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -297,6 +325,27 @@ func Test_parseName(t *testing.T) {
 	}
 }
 
+func Test_quoteIdentifier(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "foo", want: "`foo`"},
+		{name: "foo.bar", want: "`foo`.`bar`"},
+		{name: "`foo`.`bar``baz`", want: "`foo`.`bar``baz`"},
+		{name: "`foo.bar`", want: "`foo.bar`"},
+		{name: "db.`foo.bar`", want: "`db`.`foo.bar`"},
+		{name: "`a``.b`.c", want: "`a``.b`.`c`"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quoteIdentifier(tt.name); got != tt.want {
+				t.Errorf("quoteIdentifier() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_marshal(t *testing.T) {
 	type args struct {
 		x           any
@@ -500,7 +549,7 @@ func Test_marshal(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := marshal(tt.args.x, tt.args.opt, tt.args.fieldName, tt.args.valuerFuncs)
+			got, err := marshal(tt.args.x, tt.args.opt, tt.args.fieldName, newValuers(tt.args.valuerFuncs))
 			if (err != nil) != tt.wantErr {
 				t.Errorf("marshal() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -609,3 +658,40 @@ func Test_execTemplate(t *testing.T) {
 		})
 	}
 }
+
+func TestDatabase_AddCtxTemplateFuncs(t *testing.T) {
+	type localeKey struct{}
+
+	db := benchDatabase(t)
+	db.AddTemplateFuncs(template.FuncMap{
+		"locale": func() string { return "en" },
+		"table":  func() string { return "`users`" },
+	})
+	db.AddCtxTemplateFuncs(func(ctx context.Context) template.FuncMap {
+		return template.FuncMap{
+			"locale": func() string {
+				if l, ok := ctx.Value(localeKey{}).(string); ok {
+					return l
+				}
+				return "en"
+			},
+		}
+	})
+
+	ctx := context.WithValue(context.Background(), localeKey{}, "de")
+	got, _, err := db.InterpolateParamsContext(ctx, "select*from{{table}}where`Locale`='{{locale}}'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "select*from`users`where`Locale`='de'"; got != want {
+		t.Errorf("InterpolateParamsContext() = %q, want %q", got, want)
+	}
+
+	got, _, err = db.InterpolateParams("select*from{{table}}where`Locale`='{{locale}}'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "select*from`users`where`Locale`='en'"; got != want {
+		t.Errorf("InterpolateParams() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,132 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrQueryShed is matched by the errors of low priority queries that were shed
+// because the connection pool stayed saturated, see LoadShedding
+var ErrQueryShed = errors.New("cool-mysql: query shed because the connection pool is saturated")
+
+// ShedError is the error of a query that was shed
+type ShedError struct {
+	Priority Priority
+
+	// InUse and MaxOpen are the connections of the pool when the query was shed
+	InUse   int
+	MaxOpen int
+}
+
+func (e ShedError) Error() string {
+	return fmt.Sprintf("%s: %d of %d connections in use", ErrQueryShed, e.InUse, e.MaxOpen)
+}
+
+func (e ShedError) Is(target error) bool {
+	return target == ErrQueryShed
+}
+
+// Priority is how important a context's queries are when the connection pool is saturated
+type Priority int
+
+const (
+	// PriorityLow queries, like background reports, wait for the pool to have room
+	// when it's saturated, and are shed if it doesn't in time
+	PriorityLow Priority = iota - 1
+
+	// PriorityNormal is the priority of queries whose context doesn't have one
+	PriorityNormal
+
+	// PriorityHigh queries, like checkouts, are never held back
+	PriorityHigh
+)
+
+var priorityKey = key(9)
+
+// WithPriority returns a new context.Context whose queries have the priority
+func WithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey, priority)
+}
+
+// priorityFromContext returns the priority of the context's queries
+func priorityFromContext(ctx context.Context) Priority {
+	priority, ok := ctx.Value(priorityKey).(Priority)
+	if !ok {
+		return PriorityNormal
+	}
+
+	return priority
+}
+
+// LoadShedding holds back low priority queries while the connection pool is saturated,
+// so they can't take the connections that more important queries sharing the database need.
+// It only works if the pool has a limit, set with SetMaxOpenConns.
+type LoadShedding struct {
+	// Saturation is the fraction of the pool's connections in use at which it's saturated,
+	// like 0.8, where 0 means queries are never held back
+	Saturation float64
+
+	// QueueTimeout is how long low priority queries wait for the pool to stop being
+	// saturated before they're shed, where 0 means they're shed right away
+	QueueTimeout time.Duration
+}
+
+// SetLoadShedding sets how low priority queries are held back while the pool is saturated
+func (db *Database) SetLoadShedding(shedding LoadShedding) *Database {
+	db.LoadShedding = shedding
+	return db
+}
+
+// loadSheddingPollInterval is how often queued queries check whether the pool has room
+const loadSheddingPollInterval = 10 * time.Millisecond
+
+// admit waits for the pool of the connection to have room for a low priority query,
+// returning a ShedError if it doesn't before the queue timeout.
+// Queries in transactions already have their connection, so they're always admitted.
+func (db *Database) admit(ctx context.Context, conn handlerWithContext) error {
+	priority := priorityFromContext(ctx)
+	if db.LoadShedding.Saturation <= 0 || priority >= PriorityNormal {
+		return nil
+	}
+
+	pool, ok := conn.(*sql.DB)
+	if !ok {
+		return nil
+	}
+
+	saturated := func() (sql.DBStats, bool) {
+		stats := pool.Stats()
+		return stats, stats.MaxOpenConnections > 0 &&
+			float64(stats.InUse) >= db.LoadShedding.Saturation*float64(stats.MaxOpenConnections)
+	}
+
+	stats, ok := saturated()
+	if !ok {
+		return nil
+	}
+
+	deadline := time.NewTimer(db.LoadShedding.QueueTimeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(loadSheddingPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline.C:
+			return ShedError{
+				Priority: priority,
+				InUse:    stats.InUse,
+				MaxOpen:  stats.MaxOpenConnections,
+			}
+		case <-ticker.C:
+			if stats, ok = saturated(); !ok {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDatabase_admit(t *testing.T) {
+	db := benchDatabase(t)
+	db.Writes.SetMaxOpenConns(1)
+	db.SetLoadShedding(LoadShedding{Saturation: 1, QueueTimeout: 20 * time.Millisecond})
+
+	ctx := context.Background()
+	low := WithPriority(ctx, PriorityLow)
+
+	held, err := db.Writes.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rows []benchWideRow
+	err = db.SelectContext(low, &rows, "select*from`Wide`", 0)
+	var shedErr ShedError
+	if !errors.Is(err, ErrQueryShed) || !errors.As(err, &shedErr) || shedErr.Priority != PriorityLow {
+		t.Fatalf("SelectContext() with a saturated pool = %v, want a ShedError", err)
+	}
+
+	if err := db.admit(ctx, db.Writes); err != nil {
+		t.Errorf("admit() of a normal priority query = %v, want nil", err)
+	}
+
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		held.Close()
+	}()
+	if err := db.SelectContext(low, &rows, "select*from`Wide`", 0); err != nil {
+		t.Errorf("SelectContext() after the pool had room = %v, want nil", err)
+	}
+}
@@ -0,0 +1,113 @@
+package mysql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Protobuf messages, like a *pb.User struct field, are stored in BLOB columns
+// marshaled with proto.Marshal, or in JSON columns marshaled with protojson
+// if the field is tagged like `mysql:"User,protojson"`. Either format is scanned back
+// into messages, and messages are kept in their binary format in cached results.
+
+var protoMessageType = reflect.TypeOf((*proto.Message)(nil)).Elem()
+
+// isProtoMessage returns true if t is a pointer to a protobuf message
+func isProtoMessage(t reflect.Type) bool {
+	return t.Kind() == reflect.Pointer && t.Implements(protoMessageType)
+}
+
+// marshalProto marshals the message as json with protojson or as binary
+func marshalProto(m proto.Message, asJSON bool) (any, error) {
+	if isNil(m) {
+		return nil, nil
+	}
+
+	if asJSON {
+		j, err := protojson.Marshal(m)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal protobuf message to json: %w", err)
+		}
+		return json.RawMessage(j), nil
+	}
+
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal protobuf message: %w", err)
+	}
+	return b, nil
+}
+
+// unmarshalProto unmarshals the column into dest, a pointer to a message,
+// where columns that are a json object were marshaled with protojson
+func unmarshalProto(b []byte, dest reflect.Value) error {
+	m := reflect.New(dest.Type().Elem())
+
+	var err error
+	if trimmed := bytes.TrimSpace(b); len(trimmed) != 0 && trimmed[0] == '{' && json.Valid(trimmed) {
+		err = protojson.Unmarshal(trimmed, m.Interface().(proto.Message))
+	} else {
+		err = proto.Unmarshal(b, m.Interface().(proto.Message))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal protobuf message: %w", err)
+	}
+
+	dest.Set(m)
+	return nil
+}
+
+var protoMsgpackTypes sync.Map
+
+// registerProtoMsgpack registers the protobuf messages in t with msgpack,
+// so cached results keep messages in their binary format instead of encoding
+// their generated structs, which have internal state msgpack can't handle
+func registerProtoMsgpack(t reflect.Type) {
+	if _, ok := protoMsgpackTypes.LoadOrStore(t, struct{}{}); ok {
+		return
+	}
+
+	if isProtoMessage(t) {
+		msgpack.Register(reflect.Zero(t).Interface(), func(e *msgpack.Encoder, v reflect.Value) error {
+			if v.IsNil() {
+				return e.EncodeNil()
+			}
+
+			b, err := proto.Marshal(v.Interface().(proto.Message))
+			if err != nil {
+				return err
+			}
+			return e.EncodeBytes(b)
+		}, func(d *msgpack.Decoder, v reflect.Value) error {
+			b, err := d.DecodeBytes()
+			if err != nil {
+				return err
+			}
+			if b == nil {
+				v.Set(reflect.Zero(v.Type()))
+				return nil
+			}
+
+			return unmarshalProto(b, v)
+		})
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+		registerProtoMsgpack(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.IsExported() {
+				registerProtoMsgpack(f.Type)
+			}
+		}
+	}
+}
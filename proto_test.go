@@ -0,0 +1,76 @@
+package mysql
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestMarshalProto(t *testing.T) {
+	m := wrapperspb.String("hi")
+
+	got, err := marshal(m, 0, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "0x0a026869"; string(got) != want {
+		t.Errorf("marshal() = %s, want %s", got, want)
+	}
+
+	got, err = marshal((*wrapperspb.StringValue)(nil), 0, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "null" {
+		t.Errorf("marshal(nil) = %s, want null", got)
+	}
+
+	j, err := marshalProto(m, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := json.RawMessage(`"hi"`); !reflect.DeepEqual(j, want) {
+		t.Errorf("marshalProto() = %s, want %s", j, want)
+	}
+}
+
+func TestUnmarshalProto(t *testing.T) {
+	want, _ := structpb.NewStruct(map[string]any{"a": "b"})
+	b, _ := proto.Marshal(want)
+
+	for _, src := range [][]byte{b, []byte(` {"a":"b"}`)} {
+		var m *structpb.Struct
+		if err := unmarshalProto(src, reflect.ValueOf(&m).Elem()); err != nil {
+			t.Fatalf("unmarshalProto(%q) error = %v", src, err)
+		}
+		if !proto.Equal(m, want) {
+			t.Errorf("unmarshalProto(%q) = %v, want %v", src, m, want)
+		}
+	}
+}
+
+func TestProtoMsgpack(t *testing.T) {
+	type row struct {
+		ID  int
+		Msg *wrapperspb.StringValue
+	}
+	registerProtoMsgpack(reflect.TypeOf([]row{}))
+
+	b, err := msgpack.Marshal([]row{{1, wrapperspb.String("hi")}, {2, nil}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rows []row
+	if err := msgpack.Unmarshal(b, &rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].Msg.GetValue() != "hi" || rows[1].Msg != nil {
+		t.Errorf("unmarshaled rows = %+v", rows)
+	}
+}
@@ -0,0 +1,120 @@
+package mysql
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RecentQuery is a query kept in the database's query log, see SetQueryLogSize
+type RecentQuery struct {
+	// Time is when the query finished
+	Time time.Time
+
+	LogDetail
+}
+
+// MarshalJSON writes the query with its duration in milliseconds and its error as a string,
+// leaving out its transaction
+func (q RecentQuery) MarshalJSON() ([]byte, error) {
+	var errString string
+	if q.Error != nil {
+		errString = q.Error.Error()
+	}
+
+	return json.Marshal(struct {
+		Time         time.Time `json:"time"`
+		Query        string    `json:"query"`
+		Params       Params    `json:"params,omitempty"`
+		DurationMS   float64   `json:"durationMs"`
+		CacheHit     bool      `json:"cacheHit"`
+		InTx         bool      `json:"inTx"`
+		RowsAffected int64     `json:"rowsAffected"`
+		Attempt      int       `json:"attempt"`
+		Error        string    `json:"error,omitempty"`
+		QueryName    string    `json:"queryName,omitempty"`
+		Tags         []string  `json:"tags,omitempty"`
+	}{
+		Time:         q.Time,
+		Query:        q.Query,
+		Params:       q.Params,
+		DurationMS:   float64(q.Duration) / float64(time.Millisecond),
+		CacheHit:     q.CacheHit,
+		InTx:         q.Tx != nil,
+		RowsAffected: q.RowsAffected,
+		Attempt:      q.Attempt,
+		Error:        errString,
+		QueryName:    q.QueryName,
+		Tags:         q.Tags,
+	})
+}
+
+// queryLog is a ring of the last queries logged
+type queryLog struct {
+	mx      sync.Mutex
+	queries []RecentQuery
+	next    int
+	full    bool
+}
+
+// SetQueryLogSize keeps the last n queries the database logs in memory, with their durations,
+// cache hits, and errors, so they can be read with RecentQueries, like from a debug endpoint
+// during an incident, see RecentQueriesHandler. Their params are kept too, so the log holds
+// whatever values the queries were sent. Zero stops keeping them.
+func (db *Database) SetQueryLogSize(n int) *Database {
+	if n <= 0 {
+		db.queryLog = nil
+		return db
+	}
+
+	db.queryLog = &queryLog{queries: make([]RecentQuery, n)}
+	return db
+}
+
+func (l *queryLog) add(detail LogDetail) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	l.queries[l.next] = RecentQuery{Time: time.Now(), LogDetail: detail}
+	l.next++
+	if l.next == len(l.queries) {
+		l.next = 0
+		l.full = true
+	}
+}
+
+// RecentQueries returns the last queries the database logged, oldest first,
+// or nothing if it doesn't keep them, see SetQueryLogSize
+func (db *Database) RecentQueries() []RecentQuery {
+	l := db.queryLog
+	if l == nil {
+		return nil
+	}
+
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	if !l.full {
+		return append([]RecentQuery(nil), l.queries[:l.next]...)
+	}
+
+	return append(append(make([]RecentQuery, 0, len(l.queries)), l.queries[l.next:]...), l.queries[:l.next]...)
+}
+
+// RecentQueriesHandler returns a handler that writes the database's recent queries as a JSON array,
+// oldest first, see RecentQueries. Since they have the queries' params, it should only be mounted
+// where only the people who could read the database can reach it, like behind a debug server's auth.
+func (db *Database) RecentQueriesHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries := db.RecentQueries()
+		if queries == nil {
+			queries = []RecentQuery{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(queries); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
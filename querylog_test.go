@@ -0,0 +1,50 @@
+package mysql
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestDatabase_RecentQueries(t *testing.T) {
+	db := benchDatabase(t)
+	if got := db.RecentQueries(); got != nil {
+		t.Errorf("RecentQueries() without a query log = %v, want nil", got)
+	}
+
+	db.SetQueryLogSize(3)
+	for i := 0; i < 5; i++ {
+		if err := db.Exec("delete from`users`where`ID`=" + strconv.Itoa(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var queries []string
+	for _, q := range db.RecentQueries() {
+		queries = append(queries, q.Query)
+	}
+	want := []string{
+		"delete from`users`where`ID`=2",
+		"delete from`users`where`ID`=3",
+		"delete from`users`where`ID`=4",
+	}
+	if !reflect.DeepEqual(queries, want) {
+		t.Errorf("RecentQueries() = %q, want %q", queries, want)
+	}
+
+	rec := httptest.NewRecorder()
+	db.RecentQueriesHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	var dumped []struct {
+		Query        string `json:"query"`
+		RowsAffected int64  `json:"rowsAffected"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &dumped); err != nil {
+		t.Fatal(err)
+	}
+	if len(dumped) != 3 || dumped[2].Query != want[2] || dumped[2].RowsAffected != 1 {
+		t.Errorf("RecentQueriesHandler() wrote %s", rec.Body.Bytes())
+	}
+}
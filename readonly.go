@@ -0,0 +1,66 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrReadOnly is matched by the errors of writes with a read-only view of a database, see ReadOnly
+var ErrReadOnly = errors.New("cool-mysql: database is read-only")
+
+// ReadOnlyError is the error of a write with a read-only view of a database
+type ReadOnlyError struct {
+	// Op is what was attempted, like "exec" or "insert"
+	Op string
+
+	// Query is the query that would have run, if there was one
+	Query string
+}
+
+func (e ReadOnlyError) Error() string {
+	if len(e.Query) == 0 {
+		return fmt.Sprintf("%s: can't %s", ErrReadOnly, e.Op)
+	}
+
+	return fmt.Sprintf("%s: can't %s %q", ErrReadOnly, e.Op, e.Query)
+}
+
+func (e ReadOnlyError) Is(target error) bool {
+	return target == ErrReadOnly
+}
+
+// ReadOnly returns a view of the database whose execs, inserts, upserts, and transactions on the writes
+// connection fail with a ReadOnlyError instead of running, for code that must never write, like reports.
+// Its selects, including those on the writes connection, and its transactions on the reads connection still work,
+// as long as every statement they run is a select, show, describe, or explain, including after a with clause.
+func (db *Database) ReadOnly() *Database {
+	return db.With(func(db *Database) {
+		db.readOnly = true
+	})
+}
+
+// IsReadOnly returns true if the database is a read-only view, see ReadOnly
+func (db *Database) IsReadOnly() bool {
+	return db.readOnly
+}
+
+// readOnlyVerbs are the first words of the statements that a read-only view's selects can run
+var readOnlyVerbs = map[string]struct{}{
+	"select": {}, "show": {}, "describe": {}, "desc": {}, "explain": {},
+}
+
+// checkReadOnly returns a ReadOnlyError if the database is a read-only view,
+// and one of the statements of the query, passed to a select, isn't a read
+func (db *Database) checkReadOnly(query string) error {
+	if !db.readOnly {
+		return nil
+	}
+
+	for _, stmt := range statements(query) {
+		if _, ok := readOnlyVerbs[statementVerb(stmt)]; !ok {
+			return ReadOnlyError{Op: "run", Query: query}
+		}
+	}
+
+	return nil
+}
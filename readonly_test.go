@@ -0,0 +1,81 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDatabase_ReadOnly(t *testing.T) {
+	db := benchDatabase(t)
+	ro := db.ReadOnly()
+	ctx := context.Background()
+
+	type row struct {
+		ID int
+	}
+
+	writes := []struct {
+		name string
+		fn   func() error
+	}{
+		{"exec", func() error { return ro.ExecContext(ctx, "delete from`users`") }},
+		{"insert", func() error { return ro.InsertContext(ctx, "users", row{ID: 1}) }},
+		{"upsert", func() error {
+			return ro.UpsertContext(ctx, "insert into`users`", []string{"ID"}, nil, "", nil, row{ID: 1})
+		}},
+		{"batch", func() error { return ro.Batch().Exec("delete from`users`").Run(ctx) }},
+		{"tx", func() error {
+			_, cancel, err := ro.BeginTxContext(ctx)
+			defer cancel()
+			return err
+		}},
+	}
+	for _, tt := range writes {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.fn()
+			if !errors.Is(err, ErrReadOnly) {
+				t.Errorf("%s with a read-only database = %v, want ErrReadOnly", tt.name, err)
+			}
+			var roErr ReadOnlyError
+			if !errors.As(err, &roErr) {
+				t.Errorf("%s with a read-only database = %T, want a ReadOnlyError", tt.name, err)
+			}
+		})
+	}
+
+	var rows []benchWideRow
+	if err := ro.SelectContext(ctx, &rows, "select*from`users`", 0); err != nil {
+		t.Errorf("SelectContext() with a read-only database failed: %v", err)
+	}
+
+	for _, q := range []string{
+		"delete from`users`",
+		"/* hi */ (update`users`set`Name`='x')",
+		"select*from`users`;drop table`users`",
+		"with`x`as(select 1)delete`users`from`users`join`x`",
+	} {
+		if err := ro.SelectContext(ctx, &rows, q, 0); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("SelectContext(%q) with a read-only database = %v, want ErrReadOnly", q, err)
+		}
+		if _, err := ro.ExistsContext(ctx, q, 0); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("ExistsContext(%q) with a read-only database = %v, want ErrReadOnly", q, err)
+		}
+	}
+	for _, q := range []string{
+		"/* hi */ (select*from`users`)",
+		"with`x`(`ID`)as(select 1),`y`as(select 2)select*from`users`",
+		"explain select*from`users`",
+	} {
+		if err := ro.SelectContext(ctx, &rows, q, 0); err != nil {
+			t.Errorf("SelectContext(%q) with a read-only database failed: %v", q, err)
+		}
+	}
+
+	if db.IsReadOnly() || !ro.IsReadOnly() {
+		t.Error("ReadOnly() should only change the view")
+	}
+	if err := db.ExecContext(ctx, "delete from`users`"); err != nil {
+		t.Errorf("ExecContext() with the database the read-only view came from failed: %v", err)
+	}
+}
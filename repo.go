@@ -0,0 +1,309 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Repository selects and writes rows of type T in one table, for the common
+// CRUD queries that would otherwise be written out by hand for every table
+type Repository[T any] struct {
+	db    *Database
+	Table string
+
+	key     []string
+	columns string
+	cache   RepoCache
+
+	// tenant is the tenant column of T, if it's a tenant scoped struct
+	tenant string
+}
+
+// RepoCache are how long each of a repository's selects are cached, where 0 means they aren't
+type RepoCache struct {
+	Get  time.Duration
+	List time.Duration
+}
+
+// Repo returns the repository of the table, whose rows are selected into T, usually a struct.
+// The table's key is the `ID` column, unless it's changed with SetKey. If T is a tenant scoped
+// struct, every query of the repository is limited to the rows of the context's tenant, see WithTenant.
+func Repo[T any](db *Database, table string) *Repository[T] {
+	r := &Repository[T]{
+		db:      db,
+		Table:   table,
+		key:     []string{"ID"},
+		columns: "*",
+	}
+
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() == reflect.Struct {
+		if columns, _, _, err := colNamesFromStruct(t); err == nil && len(columns) != 0 {
+			quoted := make([]string, len(columns))
+			for i, c := range columns {
+				quoted[i] = quoteIdentifier(c)
+			}
+			r.columns = strings.Join(quoted, ",")
+		}
+	}
+	if tf, err := tenantFieldFromStruct(t); err == nil && tf != nil {
+		r.tenant = tf.column
+	}
+
+	return r
+}
+
+// SetKey sets the columns that identify a row for Get, Update, and Delete,
+// in the order their values are passed to Get and Delete
+func (r *Repository[T]) SetKey(columns ...string) *Repository[T] {
+	r.key = columns
+	return r
+}
+
+// SetCache sets how long the repository's selects are cached
+func (r *Repository[T]) SetCache(cache RepoCache) *Repository[T] {
+	r.cache = cache
+	return r
+}
+
+// keyWhere returns the where clause that matches the key, and the context's tenant
+// if the repository is tenant scoped, with the key's values as params
+func (r *Repository[T]) keyWhere(ctx context.Context, key []any) (string, Params, error) {
+	if len(r.key) == 0 {
+		return "", nil, fmt.Errorf("cool-mysql: repository of %q has no key columns", r.Table)
+	}
+	if len(key) != len(r.key) {
+		return "", nil, fmt.Errorf("cool-mysql: repository of %q needs %d key values, got %d", r.Table, len(r.key), len(key))
+	}
+
+	where := new(strings.Builder)
+	params := make(Params, len(r.key))
+	for i, c := range r.key {
+		if i != 0 {
+			where.WriteString(" and")
+		}
+		where.WriteString(quoteIdentifier(c))
+		where.WriteString("=@@")
+		where.WriteString(c)
+		params[c] = key[i]
+	}
+
+	if len(r.tenant) != 0 {
+		if _, ok := TenantFromContext(ctx); !ok {
+			return "", nil, ErrNoTenant
+		}
+
+		where.WriteString(" and")
+		where.WriteString(quoteIdentifier(r.tenant))
+		where.WriteString("<=>@@Tenant")
+	}
+
+	return where.String(), params, nil
+}
+
+// Get selects the row with the key's values, returning sql.ErrNoRows if there isn't one
+func (r *Repository[T]) Get(ctx context.Context, key ...any) (T, error) {
+	var row T
+
+	where, params, err := r.keyWhere(ctx, key)
+	if err != nil {
+		return row, err
+	}
+
+	err = r.db.SelectContext(ctx, &row, "select"+r.columns+"from"+r.db.quoteTable(r.Table)+"where "+where+" limit 1", r.cache.Get, params)
+	return row, err
+}
+
+// List selects the rows whose columns equal the filter's values, or every row if the filter is empty
+func (r *Repository[T]) List(ctx context.Context, filter Params) ([]T, error) {
+	q := "select" + r.columns + "from" + r.db.quoteTable(r.Table)
+
+	if len(r.tenant) != 0 {
+		q += "where" + quoteIdentifier(r.tenant) + "<=>@@Tenant"
+		if len(filter) != 0 {
+			q += " and"
+		}
+	} else if len(filter) != 0 {
+		q += "where"
+	}
+
+	if len(filter) != 0 {
+		// sorted so the same filter always makes the same query, and the same cache key
+		columns := make([]string, 0, len(filter))
+		for c := range filter {
+			columns = append(columns, c)
+		}
+		sort.Strings(columns)
+
+		for i, c := range columns {
+			if i != 0 {
+				q += " and"
+			}
+			q += quoteIdentifier(c) + "<=>@@" + c
+		}
+	}
+
+	var rows []T
+	if err := r.db.SelectContext(ctx, &rows, q, r.cache.List, filter); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// Insert inserts the rows
+func (r *Repository[T]) Insert(ctx context.Context, rows ...T) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	return r.db.InsertContext(ctx, r.Table, rows)
+}
+
+// Update updates every column of the row with the same key, except the key's columns, the tenant's,
+// the fields tagged `generated` or `readonly`, and the zero fields tagged `insertDefault`
+func (r *Repository[T]) Update(ctx context.Context, row T) error {
+	if len(r.key) == 0 {
+		return fmt.Errorf("cool-mysql: repository of %q has no key columns", r.Table)
+	}
+
+	var colOpts map[string]insertColOpts
+	if t := reflectUnwrapType(reflect.TypeOf((*T)(nil)).Elem()); t.Kind() == reflect.Struct {
+		var err error
+		if _, colOpts, _, err = colNamesFromStruct(t); err != nil {
+			return err
+		}
+	}
+
+	after := row
+
+	// the key is read from the row even if it's readonly, so the row is converted like a read
+	return forEachRow(row, false, func(row map[string]any) error {
+		key := make([]any, len(r.key))
+		for i, c := range r.key {
+			v, ok := row[c]
+			if !ok {
+				return fmt.Errorf("cool-mysql: row is missing key column %q", c)
+			}
+			key[i] = v
+			delete(row, c)
+		}
+
+		for c, opts := range colOpts {
+			if !opts.writable() || opts.tenant {
+				delete(row, c)
+			}
+		}
+
+		if len(row) == 0 {
+			return nil
+		}
+
+		where, params, err := r.keyWhere(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		before, found, err := r.before(ctx, where, params)
+		if err != nil {
+			return err
+		}
+
+		columns := make([]string, 0, len(row))
+		for c := range row {
+			columns = append(columns, c)
+		}
+		sort.Strings(columns)
+
+		// the row's values are passed with a prefix so they can't collide with the key's
+		set := new(strings.Builder)
+		for i, c := range columns {
+			if i != 0 {
+				set.WriteByte(',')
+			}
+			set.WriteString(quoteIdentifier(c))
+			set.WriteString("=@@__Set")
+			set.WriteString(c)
+			params["__Set"+c] = row[c]
+		}
+
+		if err := r.db.ExecContext(ctx, "update"+r.db.quoteTable(r.Table)+"set"+set.String()+" where "+where, params); err != nil {
+			return err
+		}
+
+		if found {
+			r.db.reportChanges(nil, []ChangeEvent{{
+				Table:  r.Table,
+				Kind:   ChangeUpdate,
+				Keys:   r.keyParams(key),
+				Before: before,
+				After:  after,
+			}})
+		}
+
+		return nil
+	})
+}
+
+// Delete deletes the row with the key's values
+func (r *Repository[T]) Delete(ctx context.Context, key ...any) error {
+	where, params, err := r.keyWhere(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	before, found, err := r.before(ctx, where, params)
+	if err != nil {
+		return err
+	}
+
+	if err := r.db.ExecContext(ctx, "delete from"+r.db.quoteTable(r.Table)+"where "+where, params); err != nil {
+		return err
+	}
+
+	if found {
+		r.db.reportChanges(nil, []ChangeEvent{{
+			Table:  r.Table,
+			Kind:   ChangeDelete,
+			Keys:   r.keyParams(key),
+			Before: before,
+		}})
+	}
+
+	return nil
+}
+
+// before selects the row matching the key's where clause before it's updated or deleted,
+// for the Before of its change event, if the database handles changes. Outside of a
+// transaction, the row can still be changed by someone else between the select and the write.
+func (r *Repository[T]) before(ctx context.Context, where string, params Params) (row T, found bool, err error) {
+	if r.db.HandleChanges == nil {
+		return row, false, nil
+	}
+
+	err = r.db.SelectContext(ctx, &row, "select"+r.columns+"from"+r.db.quoteTable(r.Table)+"where "+where+" limit 1", 0, params)
+	if errors.Is(err, sql.ErrNoRows) {
+		return row, false, nil
+	}
+	if err != nil {
+		return row, false, fmt.Errorf("failed to select row before change: %w", err)
+	}
+
+	return row, true, nil
+}
+
+// keyParams returns the key's values by the names of their columns
+func (r *Repository[T]) keyParams(key []any) Params {
+	params := make(Params, len(r.key))
+	for i, c := range r.key {
+		params[c] = key[i]
+	}
+
+	return params
+}
@@ -0,0 +1,35 @@
+package mysql
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestRepository_keyWhere(t *testing.T) {
+	type user struct {
+		ID       int
+		TenantID int
+		Name     string `mysql:"name"`
+	}
+
+	r := Repo[user](nil, "users")
+	if want := "`ID`,`TenantID`,`name`"; r.columns != want {
+		t.Errorf("columns = %q, want %q", r.columns, want)
+	}
+
+	where, params, err := r.SetKey("TenantID", "ID").keyWhere(context.Background(), []any{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "`TenantID`=@@TenantID and`ID`=@@ID"; where != want {
+		t.Errorf("keyWhere() = %q, want %q", where, want)
+	}
+	if want := (Params{"TenantID": 1, "ID": 2}); !reflect.DeepEqual(params, want) {
+		t.Errorf("keyWhere() params = %v, want %v", params, want)
+	}
+
+	if _, _, err := r.keyWhere(context.Background(), []any{1}); err == nil {
+		t.Error("keyWhere() with too few values should fail")
+	}
+}
@@ -0,0 +1,159 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrResultTooLarge is matched by the error of a select that returned more rows or bytes
+// than its ResultLimit allows
+var ErrResultTooLarge = errors.New("cool-mysql: result is too large")
+
+// ResultLimit limits how big the result of a select can be, so a query that returns
+// far more rows than expected, like one missing its where clause, fails instead of running
+// the process out of memory. It's set for every select into a slice with Database.SetResultLimit,
+// or passed along with a query's params for just that query, whatever its dest, like
+// `db.SelectContext(ctx, &rows, query, 0, params, mysql.ResultLimit{MaxRows: 1000})`.
+type ResultLimit struct {
+	// MaxRows is the most rows the select can return, where 0 means no limit
+	MaxRows int
+
+	// MaxBytes is roughly the most memory the rows can take, counting the lengths
+	// of their strings, slices, and maps, where 0 means no limit
+	MaxBytes int
+}
+
+// SetResultLimit sets the limit of every select into a slice, since channels and funcs
+// don't hold on to every row. A limit passed with a query's params is used instead.
+func (db *Database) SetResultLimit(limit ResultLimit) *Database {
+	db.ResultLimit = limit
+	return db
+}
+
+func (l ResultLimit) isZero() bool {
+	return l.MaxRows <= 0 && l.MaxBytes <= 0
+}
+
+// resultLimitFromParams removes the result limit from the params
+func resultLimitFromParams(params []any) (*ResultLimit, []any, error) {
+	var limit *ResultLimit
+	var rest []any
+	for i, p := range params {
+		var l ResultLimit
+		switch v := p.(type) {
+		case ResultLimit:
+			l = v
+		case *ResultLimit:
+			if v == nil {
+				continue
+			}
+			l = *v
+		default:
+			if rest != nil {
+				rest = append(rest, p)
+			}
+			continue
+		}
+
+		if limit != nil {
+			return nil, nil, errors.New("cool-mysql: a query can only have one result limit")
+		}
+		limit = &l
+
+		if rest == nil {
+			rest = append(make([]any, 0, len(params)-1), params[:i]...)
+		}
+	}
+
+	if limit == nil {
+		return nil, params, nil
+	}
+
+	return limit, rest, nil
+}
+
+// resultCounter counts the rows of a result against its limit
+type resultCounter struct {
+	limit ResultLimit
+	rows  int
+	bytes int
+}
+
+func (c *resultCounter) add(el reflect.Value) error {
+	c.rows++
+	if c.limit.MaxRows > 0 && c.rows > c.limit.MaxRows {
+		return fmt.Errorf("%w: more than %d rows", ErrResultTooLarge, c.limit.MaxRows)
+	}
+
+	if c.limit.MaxBytes > 0 {
+		c.bytes += approxSize(el)
+		if c.bytes > c.limit.MaxBytes {
+			return fmt.Errorf("%w: more than %d bytes", ErrResultTooLarge, c.limit.MaxBytes)
+		}
+	}
+
+	return nil
+}
+
+// approxSize returns roughly how many bytes the value takes in memory.
+// Pointers in unexported fields aren't followed, since they're usually
+// shared, like the location of a time.Time.
+func approxSize(v reflect.Value) int {
+	if !v.IsValid() {
+		return 0
+	}
+
+	size := int(v.Type().Size())
+	switch v.Kind() {
+	case reflect.String:
+		size += v.Len()
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			size += approxSize(v.Elem())
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Array {
+			size = 0
+		}
+
+		if isFixedSize(v.Type().Elem()) {
+			size += v.Len() * int(v.Type().Elem().Size())
+			break
+		}
+		for i := 0; i < v.Len(); i++ {
+			size += approxSize(v.Index(i))
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			size += approxSize(iter.Key()) + approxSize(iter.Value())
+		}
+	case reflect.Struct:
+		size = 0
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				size += int(t.Field(i).Type.Size())
+				continue
+			}
+			size += approxSize(v.Field(i))
+		}
+	}
+
+	return size
+}
+
+// isFixedSize returns true if values of the type don't reference any other memory
+func isFixedSize(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	case reflect.Array:
+		return isFixedSize(t.Elem())
+	}
+
+	return false
+}
@@ -0,0 +1,73 @@
+package mysql
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_approxSize(t *testing.T) {
+	type row struct {
+		ID   int64
+		Name string
+		Tags []string
+		At   time.Time
+	}
+
+	tests := []struct {
+		name string
+		v    any
+		want int
+	}{
+		{"int", int64(1), 8},
+		{"string", "abc", 16 + 3},
+		{"bytes", []byte("abcd"), 24 + 4},
+		{"nil pointer", (*int)(nil), 8},
+		{"pointer", new(int64), 8 + 8},
+		{"struct", row{ID: 1, Name: "ab", Tags: []string{"c"}, At: time.Now()}, 8 + (16 + 2) + (24 + 16 + 1) + 24},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := approxSize(reflect.ValueOf(tt.v)); got != tt.want {
+				t.Errorf("approxSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResultLimit(t *testing.T) {
+	db := benchDatabase(t)
+	db.SetResultLimit(ResultLimit{MaxRows: 10})
+
+	var rows []benchWideRow
+	err := db.Select(&rows, "select*from`Wide`", 0)
+	if !errors.Is(err, ErrResultTooLarge) {
+		t.Errorf("slice select error = %v, want ErrResultTooLarge", err)
+	}
+
+	// the database's limit is only for slices
+	n := 0
+	err = db.Select(func(benchWideRow) { n++ }, "select*from`Wide`", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1000 {
+		t.Errorf("func select got %d rows, want 1000", n)
+	}
+
+	err = db.Select(func(benchWideRow) {}, "select*from`Wide`", 0, ResultLimit{MaxBytes: 1 << 10})
+	if !errors.Is(err, ErrResultTooLarge) {
+		t.Errorf("func select with limit error = %v, want ErrResultTooLarge", err)
+	}
+
+	// a query's limit replaces the database's
+	rows = nil
+	err = db.Select(&rows, "select*from`Wide`", 0, ResultLimit{MaxRows: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1000 {
+		t.Errorf("slice select got %d rows, want 1000", len(rows))
+	}
+}
@@ -0,0 +1,148 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ErrReturningUnsupported is returned when `returning` is used on a server without it
+var ErrReturningUnsupported = errors.New("cool-mysql: server doesn't support returning")
+
+// SetReturning makes inserts populate the given columns, like generated IDs,
+// back into the inserted structs with `returning`, which is only supported by MariaDB.
+// The structs must be pointers or in a slice so they can be set. Upserts only
+// populate the rows they insert, since updates can't return their rows.
+func (in *Inserter) SetReturning(columns ...string) *Inserter {
+	in.returning = columns
+
+	return in
+}
+
+// returningClause returns the `returning` clause for the columns of the struct
+// and the indexes of their fields
+func returningClause(columns []string, colOpts map[string]insertColOpts) (string, [][]int, error) {
+	s := new(strings.Builder)
+	s.WriteString("returning")
+
+	indexes := make([][]int, 0, len(columns))
+	for i, c := range columns {
+		opts, ok := colOpts[c]
+		if !ok {
+			for name, o := range colOpts {
+				if strings.EqualFold(name, c) {
+					opts, ok = o, true
+					break
+				}
+			}
+		}
+		if !ok {
+			return "", nil, fmt.Errorf("cool-mysql: returning column %q has no struct field", c)
+		}
+		indexes = append(indexes, opts.index)
+
+		if i != 0 {
+			s.WriteByte(',')
+		}
+		s.WriteString(quoteIdentifier(c))
+	}
+
+	return s.String(), indexes, nil
+}
+
+// queryReturning runs a write with a `returning` clause, scanning the returned rows into dest,
+// and audits it like any other write
+func (db *Database) queryReturning(conn handlerWithContext, ctx context.Context, tx *Tx, dest any, query string, params ...any) error {
+	start := time.Now()
+	startTime := db.now()
+	err := db.query(conn, ctx, dest, query, 0, params...)
+
+	if db.Audit != nil {
+		var txID uint64
+		if tx != nil {
+			txID = tx.ID
+		}
+
+		var rowsAffected int64
+		if v := reflect.Indirect(reflect.ValueOf(dest)); v.Kind() == reflect.Slice {
+			rowsAffected = int64(v.Len())
+		}
+
+		db.callAudit(ctx, AuditEntry{
+			Query:        redactQuery(query),
+			TxID:         txID,
+			RowsAffected: rowsAffected,
+			Time:         startTime,
+			Duration:     time.Since(start),
+			Error:        err,
+		})
+	}
+
+	return err
+}
+
+// DeleteReturning runs the delete query and selects the deleted rows into dest with `returning`,
+// which is only supported by MariaDB. The returned columns are the columns of dest's structs,
+// or every column for other types.
+func (db *Database) DeleteReturning(ctx context.Context, dest any, query string, params ...any) error {
+	return db.deleteReturning(db.Writes, ctx, nil, dest, query, params...)
+}
+
+// DeleteReturning runs the delete query and selects the deleted rows into dest with `returning`,
+// which is only supported by MariaDB. The returned columns are the columns of dest's structs,
+// or every column for other types.
+func (tx *Tx) DeleteReturning(ctx context.Context, dest any, query string, params ...any) error {
+	return tx.db.deleteReturning(tx.Tx, ctx, tx, dest, query, params...)
+}
+
+func (db *Database) deleteReturning(conn handlerWithContext, ctx context.Context, tx *Tx, dest any, query string, params ...any) error {
+	if !db.serverInfoContext(ctx).SupportsReturning() {
+		return ErrReturningUnsupported
+	}
+
+	destRef := reflect.ValueOf(dest)
+	if destRef.Kind() != reflect.Pointer && destRef.Kind() != reflect.Chan && destRef.Kind() != reflect.Func {
+		return ErrDestType
+	}
+
+	returning := "returning*"
+	t, _ := getElementTypeFromDest(destRef)
+	if rt := reflectUnwrapType(t); rt.Kind() == reflect.Struct && isMultiValueElement(rt) {
+		columns, colOpts, _, err := colNamesFromStruct(rt)
+		if err != nil {
+			return err
+		}
+
+		returning, _, err = returningClause(columns, colOpts)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := db.queryReturning(conn, ctx, tx, dest, strings.TrimRight(query, "; \t\r\n")+"\n"+returning, params...)
+	if err != nil || db.HandleChanges == nil {
+		return err
+	}
+
+	// the deleted rows are only known for dests that hold them, not channels or funcs
+	var events []ChangeEvent
+	tableNameParts := tableNamePartsAfter(parseQuery(query), "from")
+	for i, p := range tableNameParts {
+		tableNameParts[i] = parseName(p)
+	}
+	table := strings.Join(tableNameParts, ".")
+	switch v := reflect.Indirect(destRef); {
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8:
+		for i := 0; i < v.Len(); i++ {
+			events = append(events, ChangeEvent{Table: table, Kind: ChangeDelete, Before: v.Index(i).Interface()})
+		}
+	case destRef.Kind() == reflect.Pointer:
+		events = append(events, ChangeEvent{Table: table, Kind: ChangeDelete, Before: v.Interface()})
+	}
+	db.reportChanges(tx, events)
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/StirlingMarketingGroup/cool-mysql/internal/testdriver"
+)
+
+func Test_returningClause(t *testing.T) {
+	colOpts := map[string]insertColOpts{
+		"ID":      {index: []int{0}},
+		"Created": {index: []int{2}},
+	}
+
+	clause, indexes, err := returningClause([]string{"id", "Created"}, colOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "returning`id`,`Created`"; clause != want {
+		t.Errorf("returningClause() = %q, want %q", clause, want)
+	}
+	if want := [][]int{{0}, {2}}; !reflect.DeepEqual(indexes, want) {
+		t.Errorf("returningClause() indexes = %v, want %v", indexes, want)
+	}
+
+	if _, _, err := returningClause([]string{"Missing"}, colOpts); err == nil || !strings.Contains(err.Error(), `"Missing"`) {
+		t.Errorf("returningClause() of a column without a field error = %v, want one naming it", err)
+	}
+}
+
+func TestInserter_UpsertReturning(t *testing.T) {
+	// the rows don't exist yet, so they're all inserted, and get the IDs of their inserts
+	db := testDatabase(t, &testdriver.Driver{
+		QueryFunc: func(ctx context.Context, query string) (driver.Rows, error) {
+			if strings.Contains(query, "returning") {
+				return testdriver.NewRows([]string{"ID"}, []driver.Value{int64(7)}, []driver.Value{int64(8)}), nil
+			}
+			return testdriver.NewRows([]string{"0"}), nil
+		},
+	})
+	db.SetServerInfo(ParseServerVersion("10.11.2-MariaDB"))
+
+	var queries []string
+	db.Log = func(detail LogDetail) {
+		queries = append(queries, detail.Query)
+	}
+
+	type row struct {
+		ID   int `mysql:"ID,insertDefault"`
+		Name string
+	}
+	rows := []row{{Name: "Ann"}, {Name: "Bob"}}
+
+	err := db.I().SetReturning("id").UpsertContext(context.Background(), "insert into`users`", []string{"Name"}, nil, "", nil, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []row{{ID: 7, Name: "Ann"}, {ID: 8, Name: "Bob"}}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("UpsertContext() rows = %v, want %v", rows, want)
+	}
+	if last := queries[len(queries)-1]; !strings.HasSuffix(last, "returning`id`") {
+		t.Errorf("UpsertContext() inserted with %q, want it returning `id`", last)
+	}
+}
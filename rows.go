@@ -306,7 +306,7 @@ func convertAssignRows(dest, src any) error {
 		if src == nil {
 			return fmt.Errorf("converting NULL to %s is unsupported", dv.Kind())
 		}
-		s := asString(src)
+		s := asIntegerString(src)
 		i64, err := strconv.ParseInt(s, 10, dv.Type().Bits())
 		if err != nil {
 			err = strconvErr(err)
@@ -318,7 +318,7 @@ func convertAssignRows(dest, src any) error {
 		if src == nil {
 			return fmt.Errorf("converting NULL to %s is unsupported", dv.Kind())
 		}
-		s := asString(src)
+		s := asIntegerString(src)
 		u64, err := strconv.ParseUint(s, 10, dv.Type().Bits())
 		if err != nil {
 			err = strconvErr(err)
@@ -394,6 +394,20 @@ func asString(src any) string {
 	return fmt.Sprintf("%v", src)
 }
 
+// asIntegerString is like asString, but formats floats without an exponent,
+// so big whole numbers, like unsigned bigints decoded from json, can be parsed as integers
+func asIntegerString(src any) string {
+	rv := reflect.ValueOf(src)
+	switch rv.Kind() {
+	case reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 64)
+	case reflect.Float32:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 32)
+	}
+
+	return asString(src)
+}
+
 func asBytes(buf []byte, rv reflect.Value) (b []byte, ok bool) {
 	switch rv.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
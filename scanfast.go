@@ -0,0 +1,131 @@
+package mysql
+
+import (
+	"database/sql"
+	"reflect"
+	"strconv"
+	"time"
+	"unsafe"
+
+	"cloud.google.com/go/civil"
+)
+
+// fastScanner scans a column of one of the most common field types into a sql.Null* value
+// that's reused for every row, and assigns it to the field directly, instead of scanning
+// into a new pointer for every row so that NULLs can become zero values
+type fastScanner interface {
+	dest() any
+	assign(v reflect.Value)
+}
+
+var (
+	intType     = reflect.TypeOf(int(0))
+	int64Type   = reflect.TypeOf(int64(0))
+	float64Type = reflect.TypeOf(float64(0))
+	stringType  = reflect.TypeOf("")
+	boolType    = reflect.TypeOf(false)
+)
+
+// newFastScanner returns a fast scanner for fields of type t, or nil if t isn't
+// one of the supported types. Only the exact types are supported, since named types
+// can be enums or scanners, and smaller ints would need to be checked for overflow.
+func newFastScanner(t reflect.Type) fastScanner {
+	switch t {
+	case int64Type:
+		return new(fastInt)
+	case intType:
+		if strconv.IntSize == 64 {
+			return new(fastInt)
+		}
+	case float64Type:
+		return new(fastFloat)
+	case stringType:
+		return new(fastString)
+	case boolType:
+		return new(fastBool)
+	case timeType, civilDateType:
+		return new(fastTime)
+	}
+
+	return nil
+}
+
+type fastInt struct{ n sql.NullInt64 }
+
+func (s *fastInt) dest() any { return &s.n }
+
+func (s *fastInt) assign(v reflect.Value) {
+	if s.n.Valid {
+		v.SetInt(s.n.Int64)
+	} else {
+		v.SetInt(0)
+	}
+}
+
+type fastFloat struct{ n sql.NullFloat64 }
+
+func (s *fastFloat) dest() any { return &s.n }
+
+func (s *fastFloat) assign(v reflect.Value) {
+	if s.n.Valid {
+		v.SetFloat(s.n.Float64)
+	} else {
+		v.SetFloat(0)
+	}
+}
+
+type fastString struct{ s sql.NullString }
+
+func (s *fastString) dest() any { return &s.s }
+
+func (s *fastString) assign(v reflect.Value) {
+	if s.s.Valid {
+		v.SetString(s.s.String)
+	} else {
+		v.SetString("")
+	}
+}
+
+// rawString scans a string column without copying it, so the string shares
+// the driver's buffer and is only valid until the next row is scanned, see ZeroCopyStrings
+type rawString struct{ b sql.RawBytes }
+
+func (s *rawString) dest() any { return &s.b }
+
+func (s *rawString) assign(v reflect.Value) {
+	if len(s.b) == 0 {
+		v.SetString("")
+		return
+	}
+
+	v.SetString(*(*string)(unsafe.Pointer(&s.b)))
+}
+
+type fastBool struct{ b sql.NullBool }
+
+func (s *fastBool) dest() any { return &s.b }
+
+func (s *fastBool) assign(v reflect.Value) {
+	v.SetBool(s.b.Valid && s.b.Bool)
+}
+
+type fastTime struct{ t sql.NullTime }
+
+func (s *fastTime) dest() any { return &s.t }
+
+func (s *fastTime) assign(v reflect.Value) {
+	switch p := v.Addr().Interface().(type) {
+	case *time.Time:
+		if s.t.Valid {
+			*p = s.t.Time
+		} else {
+			*p = time.Time{}
+		}
+	case *civil.Date:
+		if s.t.Valid {
+			*p = civil.DateOf(s.t.Time)
+		} else {
+			*p = civil.Date{}
+		}
+	}
+}
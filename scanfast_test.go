@@ -0,0 +1,78 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+)
+
+func Test_fastScanner(t *testing.T) {
+	now := time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	var s struct {
+		Int    int
+		Int64  int64
+		Float  float64
+		String string
+		Bool   bool
+		Time   time.Time
+		Date   civil.Date
+	}
+
+	// the null cases come after a case that set the same field, to make sure nulls reset it
+	tests := []struct {
+		name  string
+		field string
+		src   any
+		want  any
+	}{
+		{"int", "Int", int64(5), 5},
+		{"int null", "Int", nil, 0},
+		{"int64 from bytes", "Int64", []byte("-7"), int64(-7)},
+		{"float", "Float", 1.5, 1.5},
+		{"float null", "Float", nil, float64(0)},
+		{"string", "String", []byte("abc"), "abc"},
+		{"string null", "String", nil, ""},
+		{"bool", "Bool", int64(1), true},
+		{"bool null", "Bool", nil, false},
+		{"time", "Time", now, now},
+		{"time null", "Time", nil, time.Time{}},
+		{"date", "Date", now, civil.Date{Year: 2024, Month: 3, Day: 4}},
+		{"date null", "Date", nil, civil.Date{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := reflect.ValueOf(&s).Elem().FieldByName(tt.field)
+
+			fieldType := field.Type()
+			if fieldType == civilDateType {
+				fieldType = timeType
+			}
+			fast := newFastScanner(fieldType)
+			if fast == nil {
+				t.Fatalf("no fast scanner for %s", fieldType)
+			}
+
+			if err := fast.dest().(interface{ Scan(any) error }).Scan(tt.src); err != nil {
+				t.Fatal(err)
+			}
+			fast.assign(field)
+
+			if got := field.Interface(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newFastScanner_unsupported(t *testing.T) {
+	type enum string
+
+	for _, v := range []any{int8(0), uint64(0), enum(""), new(int), []byte(nil)} {
+		if fast := newFastScanner(reflect.TypeOf(v)); fast != nil {
+			t.Errorf("got fast scanner for %T", v)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package mysql
+
+import (
+	"strings"
+)
+
+// OnSchema returns a view of the database whose table names are qualified by the schema,
+// like `analytics`.`events`, when they're passed to its helpers without a schema of their own,
+// like the table names of inserts, upserts, repositories, collections, and TableColumns.
+// The connections stay on their own schema, so queries written out in full aren't changed.
+func (db *Database) OnSchema(schema string) *Database {
+	return db.With(func(db *Database) {
+		db.schema = schema
+	})
+}
+
+// Schema returns the schema of the view's table names, see OnSchema,
+// or an empty string if they use the connection's schema
+func (db *Database) Schema() string {
+	return db.schema
+}
+
+// quoteTable returns the table name quoted, like `analytics`.`events`,
+// and qualified by the view's schema if it isn't already
+func (db *Database) quoteTable(table string) string {
+	parts, ok := tableNameParts(parseQuery(table))
+	if !ok {
+		return quoteIdentifier(table)
+	}
+
+	return db.quoteTableParts(parts)
+}
+
+// quoteTableParts returns the parts of the table name quoted and joined,
+// and qualified by the view's schema if there's only the table's name
+func (db *Database) quoteTableParts(parts []string) string {
+	if len(parts) == 1 && len(db.schema) != 0 {
+		parts = []string{db.schema, parts[0]}
+	}
+
+	quoted := make([]string, len(parts))
+	for i, p := range parts {
+		quoted[i] = "`" + strings.ReplaceAll(db.normalizeTableName(p), "`", "``") + "`"
+	}
+
+	return strings.Join(quoted, ".")
+}
+
+// tableNameParts returns the parts of the tokens, with their quotes removed, if they're only a table name,
+// like `analytics`.`events`, analytics.events, or events
+func tableNameParts(queryTokens []queryToken) ([]string, bool) {
+	// whitespace can be around the dots
+	named := queryTokens[:0:0]
+	for _, t := range queryTokens {
+		if t.kind != queryTokenKindMisc || strings.TrimSpace(t.string) != "" {
+			named = append(named, t)
+		}
+	}
+	queryTokens = named
+
+	if len(queryTokens) == 0 || len(queryTokens)%2 == 0 {
+		return nil, false
+	}
+
+	parts := make([]string, 0, len(queryTokens)/2+1)
+	for i, t := range queryTokens {
+		if i%2 == 1 {
+			if t.string != "." {
+				return nil, false
+			}
+			continue
+		}
+
+		if t.kind != queryTokenKindWord && (t.kind != queryTokenKindString || t.string[0] != '`') {
+			return nil, false
+		}
+		parts = append(parts, unquoteName(t.string))
+	}
+
+	return parts, true
+}
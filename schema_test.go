@@ -0,0 +1,60 @@
+package mysql
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestDatabase_OnSchema(t *testing.T) {
+	type event struct {
+		ID int
+	}
+
+	db := benchDatabase(t)
+	analytics := db.OnSchema("analytics")
+
+	var queries []string
+	analytics.Log = func(detail LogDetail) {
+		queries = append(queries, detail.Query)
+	}
+
+	ctx := context.Background()
+	for _, table := range []string{"events", "`events`", "archive.events", "`archive` . `events`"} {
+		if err := analytics.InsertContext(ctx, table, event{ID: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := Repo[event](analytics, "events").Get(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"insert into`analytics`.`events`(`ID`)values(1)",
+		"insert into`analytics`.`events`(`ID`)values(1)",
+		"insert into`archive`.`events`(`ID`)values(1)",
+		"insert into`archive`.`events`(`ID`)values(1)",
+		"select`ID`from`analytics`.`events`where `ID`=1 limit 1",
+	}
+	if !reflect.DeepEqual(queries, want) {
+		t.Errorf("OnSchema() ran %q, want %q", queries, want)
+	}
+	if len(db.Schema()) != 0 {
+		t.Error("OnSchema() should only change the view")
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"insert into`events`(`ID`)", []string{"`events`"}},
+		{"insert into events values(1)", []string{"events"}},
+		{"insert into `archive` . `events` (`ID`)", []string{"`archive`", "`events`"}},
+		{"insert into archive.events(ID)", []string{"archive", "events"}},
+	}
+	for _, tt := range tests {
+		if got := tableNamePartsFromQuery(parseQuery(tt.query)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tableNamePartsFromQuery(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
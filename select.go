@@ -1,37 +1,95 @@
 package mysql
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"cloud.google.com/go/civil"
 	"github.com/cenkalti/backoff/v4"
 	"github.com/fatih/structtag"
-	"github.com/go-redsync/redsync/v4"
 	"github.com/go-sql-driver/mysql"
-	"github.com/redis/go-redis/v9"
 	"github.com/vmihailenco/msgpack/v5"
 	"golang.org/x/crypto/sha3"
 )
 
 var ErrDestType = fmt.Errorf("cool-mysql: select destination must be a channel or a pointer to something")
 
-func (db *Database) query(conn handlerWithContext, ctx context.Context, dest any, query string, cacheDuration time.Duration, params ...any) (err error) {
+func (db *Database) query(conn handlerWithContext, ctx context.Context, dest any, query string, cacheDuration time.Duration, params ...any) error {
+	merge, params, err := mergeFromParams(params)
+	if err != nil {
+		return err
+	}
+	order, unique, err := merge.columns(dest, query)
+	if err != nil {
+		return err
+	}
+
+	if c, ok := inChunks(query, params, merge != nil && merge.QueryOrder, db.valuers()); ok {
+		err = db.queryInChunks(conn, ctx, c, dest, query, cacheDuration, params...)
+	} else if len(db.middleware) == 0 {
+		err = db.runQuery(conn, ctx, dest, query, cacheDuration, params...)
+	} else {
+		q := &Query{
+			Kind:   QueryKindSelect,
+			Query:  query,
+			Params: params,
+			Dest:   dest,
+			Cache:  cacheDuration,
+		}
+		err = db.withMiddleware(func(ctx context.Context, q *Query) error {
+			return db.runQuery(conn, ctx, q.Dest, q.Query, q.Cache, q.Params...)
+		})(ctx, q)
+	}
+	if err != nil {
+		return err
+	}
+
+	mergeRows(dest, order, unique)
+	return nil
+}
+
+// runQuery selects into dest, after the query went through the middleware
+func (db *Database) runQuery(conn handlerWithContext, ctx context.Context, dest any, query string, cacheDuration time.Duration, params ...any) (err error) {
+	ctx, cancelTotal := db.totalContext(ctx)
+	defer cancelTotal()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	replacedQuery, normalizedParams, err := db.InterpolateParams(query, params...)
+	params = tenantParams(ctx, params)
+
+	lock, params, err := lockingClauseFromParams(conn, params)
+	if err != nil {
+		return err
+	}
+
+	resultLimit, params, err := resultLimitFromParams(params)
+	if err != nil {
+		return err
+	}
+	if lock != nil {
+		// locked rows have to come from the database itself
+		cacheDuration = 0
+	}
+
+	replacedQuery, normalizedParams, err := db.interpolateParams(ctx, query, params...)
 	if err != nil {
-		return fmt.Errorf("failed to interpolate params: %w", err)
+		return interpolateError(query, err)
+	}
+	if lock != nil {
+		replacedQuery = appendLockingClause(replacedQuery, lock, db.serverInfoContext(ctx).Flavor)
 	}
 
 	if db.die {
@@ -41,6 +99,14 @@ func (db *Database) query(conn handlerWithContext, ctx context.Context, dest any
 		os.Exit(0)
 	}
 
+	if err := db.checkReadOnly(replacedQuery); err != nil {
+		return err
+	}
+
+	if err := db.checkStatementPolicy(replacedQuery); err != nil {
+		return err
+	}
+
 	defer func() {
 		if err != nil {
 			err = Error{
@@ -61,7 +127,45 @@ func (db *Database) query(conn handlerWithContext, ctx context.Context, dest any
 		indirectType = t.Elem()
 	}
 
+	tf, err := tenantFieldFromStruct(indirectType)
+	if err != nil {
+		return err
+	}
+	tenant, hasTenant := TenantFromContext(ctx)
+	if tf != nil && !hasTenant {
+		return ErrNoTenant
+	}
+	if err := checkTenantScope(ctx, query, tf != nil); err != nil {
+		return err
+	}
+
+	var maskedFields []maskedField
+	if db.MaskPolicy != nil && !db.MaskPolicy.unmasked(ctx) {
+		maskedFields, err = db.MaskPolicy.maskedFieldsFromStruct(indirectType)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the database's limit is only for slices, since other dests don't hold on to every row
+	var counter *resultCounter
+	if resultLimit != nil && !resultLimit.isZero() {
+		counter = &resultCounter{limit: *resultLimit}
+	} else if resultLimit == nil && !db.ResultLimit.isZero() && multiRow && destKind == reflect.Slice {
+		counter = &resultCounter{limit: db.ResultLimit}
+	}
+
 	sendElement := func(el reflect.Value) error {
+		if counter != nil {
+			if err := counter.add(el); err != nil {
+				return err
+			}
+		}
+
+		if len(maskedFields) != 0 {
+			el = maskRow(el, maskedFields)
+		}
+
 		if multiRow {
 			switch destKind {
 			case reflect.Chan:
@@ -93,78 +197,75 @@ func (db *Database) query(conn handlerWithContext, ctx context.Context, dest any
 	}
 
 	var cacheKey string
-	var cacheSlice reflect.Value
+
+	// decrypted values are never cached, since the cache would hold them in plaintext
+	if cacheDuration > 0 && typeHasEncryptedFields(t) {
+		cacheDuration = 0
+	}
 
 	if cacheDuration > 0 {
-		cacheSlice = reflect.New(reflect.SliceOf(t)).Elem()
+		registerProtoMsgpack(t)
 
 		key := new(strings.Builder)
-		key.WriteString("cool-mysql:")
+		// v2 is the streamed format, with one msgpack value per row instead of one array,
+		// and v3 encodes decimals and json numbers as msgpack extensions
+		key.WriteString("cool-mysql:v3:")
 		key.WriteString(t.String())
 		key.WriteByte(':')
-		key.WriteString(replacedQuery)
+		key.WriteString(db.cacheKeyQuery(replacedQuery))
 		key.WriteByte(':')
 		key.WriteString(strconv.FormatInt(int64(cacheDuration), 10))
+		if hasTenant {
+			// rows cached for one tenant can never be served to another
+			b, _ := marshal(tenant, 0, "", nil)
+			key.WriteString(":tenant:")
+			key.Write(b)
+		}
 
 		h := sha3.Sum224([]byte(key.String()))
 		cacheKey = hex.EncodeToString(h[:])
 
 		start := time.Now()
 
-	CHECK_CACHE:
-		b, err := db.redis.Get(ctx, cacheKey).Bytes()
-		if errors.Is(err, redis.Nil) {
-			// cache miss!
-
-			// grab a lock so we can update the cache
-			mutex := db.rs.NewMutex(cacheKey+":mutex", redsync.WithTries(1))
-
-			if err = mutex.Lock(); err != nil {
-				// if we couldn't get the lock, then just check the cache again
-				time.Sleep(RedisLockRetryDelay)
-				goto CHECK_CACHE
-			}
-
-			unlock := func() {
-				if mutex != nil && len(mutex.Value()) != 0 {
-					if _, err = mutex.Unlock(); err != nil {
-						db.Logger.Warn(fmt.Sprintf("failed to unlock redis mutex: %v", err))
-					}
-				}
-			}
-
+		// on a miss, the cache is filled under the key's lock, which is held until the rows are cached
+		b, hit, unlock, err := db.cacheGetOrLock(ctx, cacheKey)
+		if unlock != nil {
 			defer unlock()
-		} else if err != nil {
-			err = fmt.Errorf("failed to get data from redis: %w", err)
-			if db.HandleRedisError != nil {
-				err = db.HandleRedisError(err)
-			}
-			if err != nil {
+		}
+		if errors.Is(err, ErrCacheLockTimeout) {
+			return err
+		}
+		if err != nil {
+			if err := db.handleCacheGetError(ctx, err); err != nil {
 				return err
 			}
-		} else {
+		} else if hit {
 			tx, _ := conn.(*sql.Tx)
-			db.callLog(LogDetail{
+			db.callLog(withQueryName(ctx, LogDetail{
 				Query:    replacedQuery,
 				Params:   normalizedParams,
 				Duration: time.Since(start),
 				CacheHit: true,
 				Tx:       tx,
 				Attempt:  1,
-			})
-
-			err = msgpack.Unmarshal(b, cacheSlice.Addr().Interface())
-			if err != nil {
-				return fmt.Errorf("failed to unmarshal from cache: %w", err)
-			}
-
-			l := cacheSlice.Len()
-			if !multiRow && l == 0 {
-				return sql.ErrNoRows
-			}
+			}))
+
+			// rows are decoded one at a time as they're sent, so the whole
+			// result never has to be decoded into memory at once
+			dec := msgpack.NewDecoder(bytes.NewReader(b))
+			l := 0
+			for {
+				el := reflect.New(t).Elem()
+				err = dec.DecodeValue(el)
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					return fmt.Errorf("failed to unmarshal from cache: %w", err)
+				}
+				l++
 
-			for i := 0; i < l; i++ {
-				err = sendElement(cacheSlice.Index(i))
+				err = sendElement(el)
 				if err != nil {
 					return err
 				}
@@ -173,31 +274,49 @@ func (db *Database) query(conn handlerWithContext, ctx context.Context, dest any
 				}
 			}
 
+			if !multiRow && l == 0 {
+				return sql.ErrNoRows
+			}
+
 			return nil
 		}
 	}
 
+	if err := db.admit(ctx, conn); err != nil {
+		return err
+	}
+
 	var rows *sql.Rows
 	start := time.Now()
 
-	var b = backoff.NewExponentialBackOff()
-	b.MaxElapsedTime = MaxExecutionTime
+	b := db.retryBackOff(ctx)
 	var attempt int
+
+	// the rows are read with the context of the attempt that selected them
+	cancelAttempt := context.CancelFunc(func() {})
+	defer func() {
+		cancelAttempt()
+	}()
+
 	err = backoff.Retry(func() error {
 		attempt++
+		cancelAttempt()
+		var attemptCtx context.Context
+		attemptCtx, cancelAttempt = db.attemptContext(ctx)
+
 		var err error
-		rows, err = conn.QueryContext(ctx, replacedQuery)
+		rows, err = conn.QueryContext(attemptCtx, db.commented(attemptCtx, replacedQuery))
 		tx, _ := conn.(*sql.Tx)
-		db.callLog(LogDetail{
+		db.callLog(withQueryName(ctx, LogDetail{
 			Query:    replacedQuery,
 			Params:   normalizedParams,
 			Duration: time.Since(start),
 			Tx:       tx,
 			Attempt:  attempt,
 			Error:    err,
-		})
+		}))
 		if err != nil {
-			if checkRetryError(err) {
+			if checkRetryError(err) || retryTimedOutAttempt(conn, attemptCtx, ctx) {
 				return err
 			} else if errors.Is(err, mysql.ErrInvalidConn) {
 				return db.Test()
@@ -208,8 +327,10 @@ func (db *Database) query(conn handlerWithContext, ctx context.Context, dest any
 
 		return nil
 	}, backoff.WithContext(b, ctx))
+	err = b.err(err)
 	defer func() {
-		if rows != nil {
+		// the rows of a batch have its later result sets after these, so the batch closes them
+		if _, inBatch := conn.(batchResultSet); rows != nil && !inBatch {
 			rows.Close()
 		}
 	}()
@@ -217,24 +338,56 @@ func (db *Database) query(conn handlerWithContext, ctx context.Context, dest any
 		return err
 	}
 
+	db.analyzeFullScan(replacedQuery)
+
 	columns, err := rows.Columns()
 	if err != nil {
 		return err
 	}
 
-	if t != mapRowType {
+	if t != mapRowType || db.lowerMapRowKeys() {
 		// since the map keys are literally the column names, we don't need to compare
-		// without case sensitivity. But for structs, we do.
+		// without case sensitivity, unless they're normalized. But for structs, we do.
 		for i := range columns {
 			columns[i] = strings.ToLower(columns[i])
 		}
 	}
 
-	ptrs, jsonFields, fieldsMap, ptrDests, isStruct, err := setupElementPtrs(db, t, indirectType, columns)
+	// strings can only alias the driver's buffer if every row is done with before the next
+	// is scanned, which is only true of func dests, and only if the rows aren't cached
+	zeroCopy := db.ZeroCopyStrings && destKind == reflect.Func && len(cacheKey) == 0
+
+	// rows are encoded for the cache as they're scanned, instead of being
+	// kept in a slice and encoded all at once after the last one
+	var cacheBuf *bytes.Buffer
+	var cacheEnc *msgpack.Encoder
+	if len(cacheKey) != 0 {
+		cacheBuf = new(bytes.Buffer)
+		cacheEnc = msgpack.NewEncoder(cacheBuf)
+	}
+
+	if indirectType == mapRowType {
+		if err := duplicateColumnError(columns); err != nil {
+			return err
+		}
+	}
+
+	ptrs, jsonFields, fieldIndexes, ptrDests, isStruct, err := setupElementPtrs(db, t, indirectType, columns, zeroCopy)
 	if err != nil {
 		return err
 	}
 
+	var converters []columnConverter
+	if indirectType == mapRowType || indirectType == sliceRowType {
+		if converters, err = db.columnConverters(rows); err != nil {
+			return err
+		}
+	}
+
+	checkTenant := tf != nil && tf.selected(columns)
+	enumFields := db.enumFieldsFromStruct(indirectType)
+	elEnum := db.enumDef(indirectType)
+
 	i := 0
 	for rows.Next() {
 		el := reflect.New(t).Elem()
@@ -245,32 +398,51 @@ func (db *Database) query(conn handlerWithContext, ctx context.Context, dest any
 			el.Set(reflect.MakeSlice(reflect.SliceOf(t.Elem()), len(columns), len(columns)))
 		}
 
-		updateElementPtrs(el, &ptrs, jsonFields, columns, fieldsMap, ptrDests)
+		updateElementPtrs(el, &ptrs, jsonFields, columns, fieldIndexes, ptrDests)
 
 		err = rows.Scan(ptrs...)
 		if err != nil {
 			return err
 		}
 
+		for i, conv := range converters {
+			if conv == nil {
+				continue
+			}
+
+			p := ptrs[i].(*any)
+			if *p, err = conv(*p); err != nil {
+				return fmt.Errorf("failed to convert column %q: %w", columns[i], err)
+			}
+		}
+
 		for _, dest := range ptrDests {
-			v := dest.tempDest.Elem()
-
-			// special case: if we're scanning into a civil.Date, we need to convert the time.Time
-			// we need to convert the time.Time we got from the db to a civil.Date
-			if dest.finalDest.Type() == reflect.PointerTo(civilDateType) {
-				if !v.IsNil() {
-					d := civil.DateOf(v.Elem().Interface().(time.Time))
-					dest.finalDest.Elem().Set(reflect.ValueOf(d))
-				} else {
-					dest.finalDest.Elem().Set(reflect.Zero(civilDateType))
-				}
+			if dest.fast != nil {
+				dest.fast.assign(dest.finalDest.Elem())
 			} else {
-				if !v.IsNil() {
-					dest.finalDest.Elem().Set(v.Elem())
+				v := dest.tempDest.Elem()
+
+				// special case: if we're scanning into a civil.Date, we need to convert the time.Time
+				// we need to convert the time.Time we got from the db to a civil.Date
+				if dest.finalDest.Type().Elem() == civilDateType {
+					if !v.IsNil() {
+						d := civil.DateOf(v.Elem().Interface().(time.Time))
+						dest.finalDest.Elem().Set(reflect.ValueOf(d))
+					} else {
+						dest.finalDest.Elem().Set(reflect.Zero(civilDateType))
+					}
 				} else {
-					dest.finalDest.Elem().Set(reflect.Zero(dest.finalDest.Type().Elem()))
+					if !v.IsNil() {
+						dest.finalDest.Elem().Set(v.Elem())
+					} else {
+						dest.finalDest.Elem().Set(reflect.Zero(dest.finalDest.Type().Elem()))
+					}
 				}
 			}
+
+			if dest.zone != nil {
+				rezone(dest.finalDest.Elem(), dest.zone)
+			}
 		}
 
 		indirectEl := reflect.Indirect(el)
@@ -291,21 +463,65 @@ func (db *Database) query(conn handlerWithContext, ctx context.Context, dest any
 			}
 
 			if !isStruct {
-				err = json.Unmarshal(jsonField.j, el.Interface())
+				err = db.unmarshalJSON(jsonField.j, el.Interface())
 				if err != nil {
 					return fmt.Errorf("failed to unmarshal json into dest: %w", err)
 				}
+
+				if err = db.decryptNested(el); err != nil {
+					return fmt.Errorf("failed to decrypt dest: %w", err)
+				}
+			} else if jsonField.proto && !jsonField.encrypted {
+				f := indirectEl.FieldByIndex(jsonField.index)
+				err = unmarshalProto(jsonField.j, f)
+				if err != nil {
+					return fmt.Errorf("failed to unmarshal struct field %q: %w", indirectEl.Type().FieldByIndex(jsonField.index).Name, err)
+				}
+			} else if jsonField.encrypted {
+				f := indirectEl.FieldByIndex(jsonField.index)
+				err = db.decryptInto(f, jsonField.j)
+				if err != nil {
+					return fmt.Errorf("failed to decrypt into struct field %q: %w", indirectEl.Type().FieldByIndex(jsonField.index).Name, err)
+				}
 			} else {
 				f := indirectEl.FieldByIndex(jsonField.index)
-				err = json.Unmarshal(jsonField.j, f.Addr().Interface())
+				err = db.unmarshalJSON(jsonField.j, f.Addr().Interface())
 				if err != nil {
 					return fmt.Errorf("failed to unmarshal json into struct field %q: %w", el.Type().FieldByIndex(jsonField.index).Name, err)
 				}
+
+				if err = db.decryptNested(f); err != nil {
+					return fmt.Errorf("failed to decrypt struct field %q: %w", el.Type().FieldByIndex(jsonField.index).Name, err)
+				}
+			}
+		}
+
+		for _, ef := range enumFields {
+			if err = checkEnum(indirectEl.FieldByIndex(ef.index), ef.def); err != nil {
+				return err
+			}
+		}
+		if elEnum != nil {
+			if err = checkEnum(el, elEnum); err != nil {
+				return err
+			}
+		}
+
+		if checkTenant {
+			if err = tf.checkRow(el, tenant); err != nil {
+				return err
 			}
 		}
 
-		if len(cacheKey) != 0 {
-			cacheSlice = reflect.Append(cacheSlice, el)
+		if cacheEnc != nil {
+			if err = cacheEnc.EncodeValue(el); err != nil {
+				return fmt.Errorf("failed to marshal results for cache: %w", err)
+			}
+
+			if MaxCacheSize > 0 && cacheBuf.Len() > MaxCacheSize {
+				// too big to cache, so there's no point in encoding the rest
+				cacheBuf, cacheEnc = nil, nil
+			}
 		}
 
 		i++
@@ -325,21 +541,10 @@ func (db *Database) query(conn handlerWithContext, ctx context.Context, dest any
 		return sql.ErrNoRows
 	}
 
-	if len(cacheKey) != 0 {
-		b, err := msgpack.Marshal(cacheSlice.Interface())
-		if err != nil {
-			return fmt.Errorf("failed to marshal results for cache: %w", err)
-		}
-
-		err = db.redis.Set(ctx, cacheKey, b, cacheDuration).Err()
+	if cacheBuf != nil {
+		err = db.redis.Set(ctx, cacheKey, cacheBuf.Bytes(), db.cacheTTL(cacheDuration)).Err()
 		if err != nil {
-			err = fmt.Errorf("failed to set redis cache: %w", err)
-			if db.HandleRedisError != nil {
-				err = db.HandleRedisError(err)
-			}
-			if err != nil {
-				return err
-			}
+			return db.handleCacheSetError(ctx, err)
 		}
 	}
 
@@ -387,93 +592,248 @@ func isMultiValueElement(t reflect.Type) bool {
 	return false
 }
 
-type jsonField struct {
+// structFieldsMap returns the field indexes of the struct keyed by their
+// lowercased column names, taken from the `mysql` tag or the field name
+func structFieldsMap(t reflect.Type) (map[string][]int, error) {
+	fields, err := structColumnFields(t)
+	if err != nil {
+		return nil, err
+	}
+
+	fieldsMap := make(map[string][]int, len(fields))
+	for _, f := range fields {
+		fieldsMap[f.name] = f.index
+	}
+
+	return fieldsMap, nil
+}
+
+// structColumnField is a field of a struct that columns can be scanned into
+type structColumnField struct {
+	// name is the field's lowercased column name
+	name  string
 	index []int
-	j     []byte
+
+	// embedded fields are structs whose fields are columns too
+	embedded bool
+}
+
+// structColumnFields returns the fields of the struct that columns can be scanned into, in the struct's order
+func structColumnFields(t reflect.Type) ([]structColumnField, error) {
+	structFieldIndexes := StructFieldIndexes(t)
+
+	fields := make([]structColumnField, 0, len(structFieldIndexes))
+	for _, i := range structFieldIndexes {
+		f := t.FieldByIndex(i)
+
+		if !f.IsExported() {
+			continue
+		}
+
+		tags, err := structtag.Parse(string(f.Tag))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse struct tag %q: %w", f.Tag, err)
+		}
+
+		name := f.Name
+		mysqlTag, _ := tags.Get("mysql")
+		if mysqlTag != nil && mysqlTag.HasOption("load") {
+			continue
+		}
+		if mysqlTag != nil && len(mysqlTag.Name) != 0 && mysqlTag.Name != "-" {
+			name, err = decodeHex(mysqlTag.Name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode hex in struct tag name %q: %w", mysqlTag.Name, err)
+			}
+		}
+
+		fields = append(fields, structColumnField{
+			name:     strings.ToLower(name),
+			index:    i,
+			embedded: f.Anonymous && f.Type.Kind() == reflect.Struct,
+		})
+	}
+
+	return fields, nil
+}
+
+type jsonField struct {
+	index     []int
+	j         []byte
+	encrypted bool
+	proto     bool
 }
 
 type ptrDest struct {
 	finalDest reflect.Value
 	tempDest  reflect.Value
-}
 
-func setupElementPtrs(db *Database, t reflect.Type, indirectType reflect.Type, columns []string) (ptrs []any, jsonFields []jsonField, fieldsMap map[string][]int, ptrDests map[int]*ptrDest, isStruct bool, err error) {
-	switch {
-	case isMultiValueElement(indirectType) && indirectType.Kind() == reflect.Struct:
-		structFieldIndexes := StructFieldIndexes(indirectType)
+	// fast, if it isn't nil, is scanned into instead of the temp dest
+	fast fastScanner
 
-		fieldsMap = make(map[string][]int, len(structFieldIndexes))
-		for _, i := range structFieldIndexes {
-			f := indirectType.FieldByIndex(i)
+	// zone, if it isn't nil, is the time zone of the column's struct tag
+	zone *time.Location
+}
 
-			if !f.IsExported() {
-				continue
-			}
+func newPtrDest(tempDestType reflect.Type, zeroCopy bool) *ptrDest {
+	if zeroCopy && tempDestType.Elem() == stringType {
+		return &ptrDest{fast: new(rawString)}
+	}
+	if fast := newFastScanner(tempDestType.Elem()); fast != nil {
+		return &ptrDest{fast: fast}
+	}
 
-			tags, err := structtag.Parse(string(f.Tag))
-			if err != nil {
-				return nil, nil, nil, nil, false, fmt.Errorf("failed to parse struct tag %q: %w", f.Tag, err)
-			}
+	return &ptrDest{tempDest: reflect.New(tempDestType)}
+}
 
-			name := f.Name
-			mysqlTag, _ := tags.Get("mysql")
-			if mysqlTag != nil && len(mysqlTag.Name) != 0 && mysqlTag.Name != "-" {
-				name, err = decodeHex(mysqlTag.Name)
-				if err != nil {
-					return nil, nil, nil, nil, false, fmt.Errorf("failed to decode hex in struct tag name %q: %w", mysqlTag.Name, err)
-				}
-			}
+// scanDest returns what the column is scanned into
+func (d *ptrDest) scanDest() any {
+	if d.fast != nil {
+		return d.fast.dest()
+	}
+
+	return d.tempDest.Interface()
+}
 
-			fieldsMap[strings.ToLower(name)] = i
+func setupElementPtrs(db *Database, t reflect.Type, indirectType reflect.Type, columns []string, zeroCopy bool) (ptrs []any, jsonFields []jsonField, fieldIndexes [][]int, ptrDests map[int]*ptrDest, isStruct bool, err error) {
+	switch {
+	case isMultiValueElement(indirectType) && indirectType.Kind() == reflect.Struct:
+		plan, err := getScanPlan(indirectType, columns, db.PositionalColumns)
+		if err != nil {
+			return nil, nil, nil, nil, false, err
 		}
 
-		for i, c := range columns {
-			fieldIndex, ok := fieldsMap[c]
-			if !ok {
-				if !db.DisableUnusedColumnWarnings {
-					db.Logger.Warn(fmt.Sprintf("column %q from query doesn't belong to any struct fields", c))
-				}
-				continue
+		if !db.DisableUnusedColumnWarnings {
+			for _, c := range plan.unusedColumns {
+				db.Logger.Warn(fmt.Sprintf("column %q from query doesn't belong to any struct fields", c))
 			}
+		}
 
-			f := indirectType.FieldByIndex(fieldIndex)
-			if isMultiValueElement(f.Type) {
-				jsonFields = append(jsonFields, jsonField{
-					index: fieldIndex,
-				})
-			} else {
-				if ptrDests == nil {
-					ptrDests = make(map[int]*ptrDest)
-				}
+		// the json fields hold the scanned bytes, so each query needs its own
+		jsonFields = append(make([]jsonField, 0, len(plan.jsonFields)), plan.jsonFields...)
 
-				var tempDest reflect.Value
-				if f.Type == civilDateType {
-					tempDest = reflect.New(reflect.PointerTo(timeType))
-				} else {
-					tempDest = reflect.New(reflect.PointerTo(f.Type))
-				}
+		if len(plan.tempDestTypes) != 0 {
+			ptrDests = make(map[int]*ptrDest, len(plan.tempDestTypes))
+			for i, t := range plan.tempDestTypes {
+				ptrDests[i] = newPtrDest(t, zeroCopy)
+			}
+		}
 
-				ptrDests[i] = &ptrDest{
-					tempDest: tempDest,
-				}
+		for i, zone := range plan.zones {
+			if ptrDests[i].zone, err = db.timeZone(zone); err != nil {
+				return nil, nil, nil, nil, false, err
 			}
 		}
-		return make([]any, len(columns)), jsonFields, fieldsMap, ptrDests, true, nil
+
+		return make([]any, len(columns)), jsonFields, plan.fieldIndexes, ptrDests, true, nil
 	case isMultiValueElement(indirectType):
 		return make([]any, len(columns)), make([]jsonField, 1), nil, nil, false, nil
 	default:
-		var tempDest reflect.Value
+		tempDestType := reflect.PointerTo(t)
 		if t == civilDateType {
-			tempDest = reflect.New(reflect.PointerTo(timeType))
-		} else {
-			tempDest = reflect.New(reflect.PointerTo(t))
+			tempDestType = reflect.PointerTo(timeType)
 		}
 
-		return make([]any, len(columns)), nil, nil, map[int]*ptrDest{0: {tempDest: tempDest}}, false, nil
+		return make([]any, len(columns)), nil, nil, map[int]*ptrDest{0: newPtrDest(tempDestType, zeroCopy)}, false, nil
 	}
 }
 
-func updateElementPtrs(ref reflect.Value, ptrs *[]any, jsonFields []jsonField, columns []string, fieldsMap map[string][]int, ptrDests map[int]*ptrDest) {
+// scanPlan is everything about scanning rows into a struct that only depends
+// on the struct type and the columns, so it's compiled once for each query shape
+type scanPlan struct {
+	// fieldIndexes are the indexes of the fields of the columns at the same positions, or nil for unused columns
+	fieldIndexes  [][]int
+	jsonFields    []jsonField
+	tempDestTypes map[int]reflect.Type
+	zones         map[int]string
+	unusedColumns []string
+}
+
+type scanPlanKey struct {
+	t          reflect.Type
+	columns    string
+	positional bool
+}
+
+var scanPlans sync.Map
+
+func getScanPlan(t reflect.Type, columns []string, positional bool) (*scanPlan, error) {
+	key := scanPlanKey{t: t, columns: strings.Join(columns, "\x00"), positional: positional}
+	if plan, ok := scanPlans.Load(key); ok {
+		return plan.(*scanPlan), nil
+	}
+
+	plan, err := compileScanPlan(t, columns, positional)
+	if err != nil {
+		return nil, err
+	}
+
+	scanPlans.Store(key, plan)
+	return plan, nil
+}
+
+func compileScanPlan(t reflect.Type, columns []string, positional bool) (*scanPlan, error) {
+	fieldIndexes, fieldColumns, err := columnFieldIndexes(t, columns, positional)
+	if err != nil {
+		return nil, err
+	}
+
+	var encrypted map[string]struct{}
+	if typeHasEncryptedFields(t) {
+		encrypted, err = encryptedColumns(t)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	plan := &scanPlan{fieldIndexes: fieldIndexes}
+	for i, c := range columns {
+		fieldIndex := fieldIndexes[i]
+		if fieldIndex == nil {
+			plan.unusedColumns = append(plan.unusedColumns, c)
+			continue
+		}
+
+		f := t.FieldByIndex(fieldIndex)
+		_, isEncrypted := encrypted[fieldColumns[i]]
+		if isMultiValueElement(f.Type) || isEncrypted {
+			// encrypted columns are scanned as raw bytes, just like json,
+			// and decrypted into the field afterwards
+			plan.jsonFields = append(plan.jsonFields, jsonField{
+				index:     fieldIndex,
+				encrypted: isEncrypted,
+				proto:     isProtoMessage(f.Type),
+			})
+		} else {
+			if plan.tempDestTypes == nil {
+				plan.tempDestTypes = make(map[int]reflect.Type)
+			}
+
+			if f.Type == civilDateType {
+				plan.tempDestTypes[i] = reflect.PointerTo(timeType)
+			} else {
+				plan.tempDestTypes[i] = reflect.PointerTo(f.Type)
+			}
+
+			if reflectUnwrapType(f.Type) == timeType {
+				tags, _ := structtag.Parse(string(f.Tag))
+				if tags != nil {
+					tag, _ := tags.Get("mysql")
+					if zone, ok := tagTimeZone(tag); ok {
+						if plan.zones == nil {
+							plan.zones = make(map[int]string)
+						}
+						plan.zones[i] = zone
+					}
+				}
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+func updateElementPtrs(ref reflect.Value, ptrs *[]any, jsonFields []jsonField, columns []string, fieldIndexes [][]int, ptrDests map[int]*ptrDest) {
 	indirectType := ref.Type()
 	indirectRef := ref
 	if indirectType.Kind() == reflect.Ptr {
@@ -486,21 +846,20 @@ func updateElementPtrs(ref reflect.Value, ptrs *[]any, jsonFields []jsonField, c
 	switch {
 	case isMultiValueElement(indirectType) && indirectType.Kind() == reflect.Struct:
 		jsonIndex := 0
-		for i, c := range columns {
-			fieldIndex, ok := fieldsMap[c]
-			if !ok {
+		for i := range columns {
+			fieldIndex := fieldIndexes[i]
+			if fieldIndex == nil {
 				(*ptrs)[i] = x
 				continue
 			}
 
-			f := indirectType.FieldByIndex(fieldIndex)
-			if isMultiValueElement(f.Type) {
+			if ptrDest, ok := ptrDests[i]; ok {
+				(*ptrs)[i] = ptrDest.scanDest()
+				ptrDest.finalDest = indirectRef.FieldByIndex(fieldIndex).Addr()
+			} else {
 				jsonFields[jsonIndex].j = jsonFields[jsonIndex].j[:0]
 				(*ptrs)[i] = &jsonFields[jsonIndex].j
 				jsonIndex++
-			} else {
-				(*ptrs)[i] = ptrDests[i].tempDest.Interface()
-				ptrDests[i].finalDest = indirectRef.FieldByIndex(fieldIndex).Addr()
 			}
 		}
 	case indirectType == mapRowType:
@@ -536,7 +895,7 @@ func updateElementPtrs(ref reflect.Value, ptrs *[]any, jsonFields []jsonField, c
 		}
 	default:
 		// this is one element (row), like a time, number, or string
-		(*ptrs)[0] = ptrDests[0].tempDest.Interface()
+		(*ptrs)[0] = ptrDests[0].scanDest()
 		ptrDests[0].finalDest = ref.Addr()
 		for i := 1; i < len(columns); i++ {
 			(*ptrs)[i] = x
@@ -0,0 +1,100 @@
+package mysql
+
+import (
+	"database/sql"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/StirlingMarketingGroup/cool-mysql/internal/testdriver"
+	"go.uber.org/zap"
+)
+
+// benchDatabase returns a database whose every query returns rows of wide text columns
+func benchDatabase(b testing.TB) *Database {
+	b.Helper()
+
+	return testDatabase(b, testdriver.Wide(8, 1024, 1000))
+}
+
+// testDatabase returns a database whose reads and writes are both the driver, closed when the test ends
+func testDatabase(b testing.TB, d *testdriver.Driver) *Database {
+	b.Helper()
+
+	conn := sql.OpenDB(d)
+	b.Cleanup(func() {
+		conn.Close()
+	})
+
+	db := &Database{
+		Writes:        conn,
+		Reads:         conn,
+		testMx:        new(sync.Mutex),
+		Logger:        zap.NewNop(),
+		MaxInsertSize: new(synct[int]),
+	}
+	db.MaxInsertSize.Set(4 << 20)
+
+	return db
+}
+
+type benchWideRow struct {
+	Text0, Text1, Text2, Text3, Text4, Text5, Text6, Text7 string
+}
+
+func BenchmarkSelectWideText(b *testing.B) {
+	for _, zeroCopy := range []bool{false, true} {
+		b.Run("ZeroCopyStrings="+strconv.FormatBool(zeroCopy), func(b *testing.B) {
+			db := benchDatabase(b)
+			db.ZeroCopyStrings = zeroCopy
+
+			var n int
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				err := db.Select(func(r benchWideRow) {
+					n += len(r.Text0) + len(r.Text7)
+				}, "select*from`Wide`", 0)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkInsert(b *testing.B) {
+	db := benchDatabase(b)
+
+	type row struct {
+		ID      int
+		Name    string
+		Score   float64
+		Created time.Time
+	}
+	rows := make([]row, 10000)
+	for i := range rows {
+		rows[i] = row{ID: i, Name: "name " + strconv.Itoa(i), Score: float64(i) / 3, Created: time.Now()}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := db.Insert("Rows", rows); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkInterpolateParams(b *testing.B) {
+	params := Params{"ID": 5, "Name": "name", "Created": time.Now(), "IDs": []int{1, 2, 3, 4, 5}}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _, err := InterpolateParams("select*from`Rows`where`ID`=@@ID and`Name`=@@Name and`Created`<@@Created and`ID`in(@@IDs)", nil, nil, params)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
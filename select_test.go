@@ -571,3 +571,41 @@ func Test_isMultiValueElement(t *testing.T) {
 		})
 	}
 }
+
+func Test_getScanPlan(t *testing.T) {
+	type row struct {
+		ID      int
+		Name    string `mysql:"name"`
+		Created civil.Date
+		Tags    []string
+	}
+
+	columns := []string{"id", "name", "created", "tags", "extra"}
+	plan, err := getScanPlan(reflect.TypeOf(row{}), columns, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"extra"}; !reflect.DeepEqual(plan.unusedColumns, want) {
+		t.Errorf("unusedColumns = %v, want %v", plan.unusedColumns, want)
+	}
+	if len(plan.jsonFields) != 1 || !reflect.DeepEqual(plan.jsonFields[0].index, []int{3}) {
+		t.Errorf("jsonFields = %+v", plan.jsonFields)
+	}
+	wantTypes := map[int]reflect.Type{
+		0: reflect.TypeOf(new(int)),
+		1: reflect.TypeOf(new(string)),
+		2: reflect.TypeOf(new(time.Time)),
+	}
+	if !reflect.DeepEqual(plan.tempDestTypes, wantTypes) {
+		t.Errorf("tempDestTypes = %v, want %v", plan.tempDestTypes, wantTypes)
+	}
+
+	again, err := getScanPlan(reflect.TypeOf(row{}), append([]string(nil), columns...), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != plan {
+		t.Error("scan plan wasn't cached")
+	}
+}
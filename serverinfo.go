@@ -0,0 +1,218 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ServerFlavor is the kind of server a database is connected to
+type ServerFlavor int
+
+const (
+	FlavorMySQL ServerFlavor = iota
+	FlavorMariaDB
+	FlavorTiDB
+)
+
+func (f ServerFlavor) String() string {
+	switch f {
+	case FlavorMySQL:
+		return "MySQL"
+	case FlavorMariaDB:
+		return "MariaDB"
+	case FlavorTiDB:
+		return "TiDB"
+	default:
+		return "ServerFlavor(" + strconv.Itoa(int(f)) + ")"
+	}
+}
+
+// ServerInfo describes the server a database is connected to
+type ServerInfo struct {
+	// Version is the whole version string reported by the server
+	Version string
+
+	Major, Minor, Patch int
+
+	Flavor ServerFlavor
+
+	LowerCaseTableNames int
+	DefaultCollation    string
+}
+
+// AtLeast returns true if the server's version is at least the given version
+func (s ServerInfo) AtLeast(major, minor, patch int) bool {
+	if s.Major != major {
+		return s.Major > major
+	}
+	if s.Minor != minor {
+		return s.Minor > minor
+	}
+
+	return s.Patch >= patch
+}
+
+// SupportsRowAlias returns true if inserts can refer to their new rows by alias,
+// like `insert into t values(...)as new on duplicate key update a=new.a`,
+// instead of the deprecated `values()` function
+func (s ServerInfo) SupportsRowAlias() bool {
+	return s.Flavor == FlavorMySQL && s.AtLeast(8, 0, 19)
+}
+
+// SupportsReturning returns true if inserts and deletes can return their rows with `returning`
+func (s ServerInfo) SupportsReturning() bool {
+	return s.Flavor == FlavorMariaDB && s.AtLeast(10, 5, 0)
+}
+
+// SupportsSkipLocked returns true if locking reads can use `skip locked` and `nowait`
+func (s ServerInfo) SupportsSkipLocked() bool {
+	switch s.Flavor {
+	case FlavorMySQL:
+		return s.AtLeast(8, 0, 1)
+	case FlavorMariaDB:
+		return s.AtLeast(10, 6, 0)
+	default:
+		return false
+	}
+}
+
+// ParseServerVersion parses the flavor and version numbers out of the version string
+// reported by a server, like "8.0.33" or "10.11.2-MariaDB-log"
+func ParseServerVersion(version string) ServerInfo {
+	info := ServerInfo{Version: version}
+
+	v := version
+	switch {
+	case strings.Contains(v, "MariaDB"):
+		info.Flavor = FlavorMariaDB
+		// MariaDB used to prefix its version with a fake one for old replication clients
+		v = strings.TrimPrefix(v, "5.5.5-")
+	case strings.Contains(v, "TiDB"):
+		info.Flavor = FlavorTiDB
+	}
+
+	if i := strings.IndexFunc(v, func(r rune) bool { return r != '.' && (r < '0' || r > '9') }); i != -1 {
+		v = v[:i]
+	}
+
+	parts := strings.SplitN(v, ".", 3)
+	nums := []*int{&info.Major, &info.Minor, &info.Patch}
+	for i, p := range parts {
+		*nums[i], _ = strconv.Atoi(p)
+	}
+
+	return info
+}
+
+// ServerInfo returns the info of the writes server, detected the first time it's needed
+// after connecting or reconnecting, or set by SetServerInfo. If it can't be detected,
+// like through a proxy that doesn't allow it, it's empty, a warning is logged,
+// and it's detected again the next time it's needed.
+func (db *Database) ServerInfo() ServerInfo {
+	return db.serverInfoContext(context.Background())
+}
+
+// serverInfoContext is ServerInfo, detecting the server info with ctx if it's needed
+func (db *Database) serverInfoContext(ctx context.Context) ServerInfo {
+	if db.serverInfo == nil {
+		return ServerInfo{}
+	}
+
+	if db.detectServerInfo != nil {
+		db.detectServerInfo.do(ctx, func(ctx context.Context) error {
+			info, err := db.DetectServerInfo(ctx)
+			if err != nil {
+				db.Logger.Warn(err.Error())
+				return err
+			}
+			db.serverInfo.Set(info)
+			return nil
+		})
+	}
+
+	return db.serverInfo.Get()
+}
+
+// SetServerInfo overrides the detected server info, for proxies that report
+// a different version than the server behind them, or that don't allow it to
+// be detected at all, and keeps it from being detected again after reconnecting
+func (db *Database) SetServerInfo(info ServerInfo) *Database {
+	if db.serverInfo == nil {
+		db.serverInfo = new(synct[ServerInfo])
+	}
+	if db.detectServerInfo != nil {
+		db.detectServerInfo.stop()
+	}
+	db.serverInfo.Set(info)
+
+	return db
+}
+
+// DetectServerInfo queries the writes server for its info
+func (db *Database) DetectServerInfo(ctx context.Context) (ServerInfo, error) {
+	var row struct {
+		Version             string
+		LowerCaseTableNames int
+		DefaultCollation    string
+	}
+	err := db.query(db.Writes, ctx, &row, "select version()`Version`,"+
+		"@@lower_case_table_names`LowerCaseTableNames`,"+
+		"@@collation_server`DefaultCollation`", 0)
+	if err != nil {
+		return ServerInfo{}, fmt.Errorf("failed to detect server info: %w", err)
+	}
+
+	info := ParseServerVersion(row.Version)
+	info.LowerCaseTableNames = row.LowerCaseTableNames
+	info.DefaultCollation = row.DefaultCollation
+
+	return info, nil
+}
+
+// insertRowAlias is the alias of the new rows in inserts,
+// when the server supports row aliases
+const insertRowAlias = "__new"
+
+// valuesToRowAlias rewrites the `values(col)` references in an `on duplicate key update`
+// clause to refer to the insert's row alias instead, returning false if there are none
+func valuesToRowAlias(onDuplicateKeyUpdate string) (string, bool) {
+	tokens := parseQuery(onDuplicateKeyUpdate)
+
+	// the tokens of the clause, without whitespace
+	var significant []queryToken
+	for _, t := range tokens {
+		if t.kind == queryTokenKindMisc && strings.TrimSpace(t.string) == "" {
+			continue
+		}
+		significant = append(significant, t)
+	}
+
+	var b strings.Builder
+	last := 0
+	rewritten := false
+	for i := 0; i+3 < len(significant); i++ {
+		values, open, col, end := significant[i], significant[i+1], significant[i+2], significant[i+3]
+		if values.kind != queryTokenKindWord || !strings.EqualFold(values.string, "values") ||
+			open.string != "(" || end.string != ")" ||
+			(col.kind != queryTokenKindWord && !(col.kind == queryTokenKindString && col.string[0] == '`')) {
+			continue
+		}
+
+		b.WriteString(onDuplicateKeyUpdate[last:values.pos])
+		b.WriteString(quoteIdentifier(insertRowAlias))
+		b.WriteByte('.')
+		b.WriteString(quoteIdentifier(parseName(col.string)))
+		last = end.end + 1
+		rewritten = true
+		i += 3
+	}
+
+	if !rewritten {
+		return onDuplicateKeyUpdate, false
+	}
+
+	b.WriteString(onDuplicateKeyUpdate[last:])
+	return b.String(), true
+}
@@ -0,0 +1,241 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/StirlingMarketingGroup/cool-mysql/internal/testdriver"
+)
+
+func TestParseServerVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		flavor  ServerFlavor
+		major   int
+		minor   int
+		patch   int
+	}{
+		{"8.0.33", FlavorMySQL, 8, 0, 33},
+		{"5.7.42-log", FlavorMySQL, 5, 7, 42},
+		{"10.11.2-MariaDB-1:10.11.2+maria~ubu2204-log", FlavorMariaDB, 10, 11, 2},
+		{"5.5.5-10.4.30-MariaDB", FlavorMariaDB, 10, 4, 30},
+		{"8.0.11-TiDB-v7.5.0", FlavorTiDB, 8, 0, 11},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got := ParseServerVersion(tt.version)
+			if got.Flavor != tt.flavor || got.Major != tt.major || got.Minor != tt.minor || got.Patch != tt.patch {
+				t.Errorf("ParseServerVersion(%q) = %+v", tt.version, got)
+			}
+		})
+	}
+}
+
+func TestServerInfoFeatures(t *testing.T) {
+	mysql8 := ParseServerVersion("8.0.33")
+	mysql57 := ParseServerVersion("5.7.42")
+	mariadb := ParseServerVersion("10.11.2-MariaDB")
+
+	if !mysql8.SupportsRowAlias() || mysql57.SupportsRowAlias() || mariadb.SupportsRowAlias() {
+		t.Errorf("SupportsRowAlias() should only be true for MySQL 8.0.19+")
+	}
+	if mysql8.SupportsReturning() || !mariadb.SupportsReturning() {
+		t.Errorf("SupportsReturning() should only be true for MariaDB 10.5+")
+	}
+	if !mysql8.SupportsSkipLocked() || mysql57.SupportsSkipLocked() || !mariadb.SupportsSkipLocked() {
+		t.Errorf("SupportsSkipLocked() should be true for MySQL 8.0.1+ and MariaDB 10.6+")
+	}
+}
+
+func TestValuesToRowAlias(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+		ok   bool
+	}{
+		{
+			"values",
+			"on duplicate key update`Name`=values(`Name`),Count=Count+VALUES ( Count )",
+			"on duplicate key update`Name`=`__new`.`Name`,Count=Count+`__new`.`Count`",
+			true,
+		},
+		{
+			"no values",
+			"on duplicate key update`Name`='values(Name)'",
+			"on duplicate key update`Name`='values(Name)'",
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := valuesToRowAlias(tt.in)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("valuesToRowAlias() = %q, %v, want %q, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+type serverInfoTestKey struct{}
+
+func TestDatabase_ServerInfoDetection(t *testing.T) {
+	version := "8.0.36"
+	var failing bool
+	db := testDatabase(t, &testdriver.Driver{
+		QueryFunc: func(ctx context.Context, query string) (driver.Rows, error) {
+			if !strings.Contains(query, "version()") {
+				return testdriver.NewRows([]string{"1"}, []driver.Value{int64(1)}), nil
+			}
+			if ctx.Value(serverInfoTestKey{}) == nil {
+				t.Error("the server info wasn't detected with the caller's context")
+			}
+			if failing {
+				return nil, errors.New("access denied")
+			}
+			return testdriver.NewRows([]string{"Version", "LowerCaseTableNames", "DefaultCollation"},
+				[]driver.Value{[]byte(version), int64(1), []byte("utf8mb4_0900_ai_ci")}), nil
+		},
+		Tx: true,
+	})
+	db.serverInfo = new(synct[ServerInfo])
+	db.detectServerInfo = new(lazyOnce)
+	ctx := context.WithValue(context.Background(), serverInfoTestKey{}, true)
+
+	var queries int
+	db.Log = func(detail LogDetail) {
+		if strings.Contains(detail.Query, "version()") {
+			queries++
+		}
+	}
+
+	// selects that don't lock don't need the server info
+	var id int
+	if err := db.SelectContext(ctx, &id, "select 1", 0); err != nil {
+		t.Fatal(err)
+	}
+	if queries != 0 {
+		t.Fatalf("the server info was detected %d times before it was needed", queries)
+	}
+
+	for i := 0; i < 2; i++ {
+		info := db.serverInfoContext(ctx)
+		if info.Flavor != FlavorMySQL || info.Major != 8 || info.LowerCaseTableNames != 1 || info.DefaultCollation != "utf8mb4_0900_ai_ci" {
+			t.Errorf("serverInfoContext() = %+v, want the detected MySQL 8", info)
+		}
+	}
+	if queries != 1 {
+		t.Errorf("serverInfoContext() detected the server info %d times, want once", queries)
+	}
+
+	// after a failover, the next server is detected again by the first select that locks
+	version = "10.11.2-MariaDB"
+	db.resetDetection(new(Database))
+	tx, cancel, err := db.BeginTxContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+	if err := tx.SelectContext(ctx, &id, "select 1", 0, ForUpdate()); err != nil {
+		t.Fatal(err)
+	}
+	if info := db.serverInfoContext(ctx); info.Flavor != FlavorMariaDB || queries != 2 {
+		t.Errorf("serverInfoContext() after reconnecting = %+v, after %d queries, want the detected MariaDB", info, queries)
+	}
+
+	// detection that fails is tried again the next time it's needed
+	failing = true
+	db.resetDetection(new(Database))
+	db.serverInfoContext(ctx)
+	failing = false
+	if info := db.serverInfoContext(ctx); info.Flavor != FlavorMariaDB || queries != 4 {
+		t.Errorf("serverInfoContext() after failing = %+v, after %d queries, want the detected MariaDB after 4", info, queries)
+	}
+	db.serverInfoContext(ctx)
+	if queries != 4 {
+		t.Errorf("serverInfoContext() detected the server info %d times after it succeeded, want 4", queries)
+	}
+
+	// set server info is kept after reconnecting
+	db.SetServerInfo(ParseServerVersion("5.7.44"))
+	db.resetDetection(new(Database))
+	if info := db.serverInfoContext(ctx); info.Major != 5 || queries != 4 {
+		t.Errorf("serverInfoContext() after it was set = %+v, after %d queries, want the set MySQL 5.7", info, queries)
+	}
+}
+
+func TestLazyOnce_wait(t *testing.T) {
+	var o lazyOnce
+	started, release := make(chan struct{}), make(chan struct{})
+	var runs int32
+
+	go o.do(context.Background(), func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+
+		// calls from within the detection don't wait for it
+		o.do(ctx, func(context.Context) error {
+			t.Error("do() ran again from within its own run")
+			return nil
+		})
+
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+
+	waited := make(chan struct{})
+	go func() {
+		o.do(context.Background(), func(context.Context) error {
+			atomic.AddInt32(&runs, 1)
+			return nil
+		})
+		close(waited)
+	}()
+
+	select {
+	case <-waited:
+		t.Fatal("do() returned before the running detection finished")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(release)
+	<-waited
+	if runs := atomic.LoadInt32(&runs); runs != 1 {
+		t.Errorf("do() ran %d times, want once", runs)
+	}
+}
+
+func TestDatabase_maxInsertSize(t *testing.T) {
+	db := testDatabase(t, &testdriver.Driver{
+		QueryFunc: func(ctx context.Context, query string) (driver.Rows, error) {
+			if !strings.Contains(query, "max_allowed_packet") {
+				t.Errorf("maxInsertSize() ran %q", query)
+			}
+			return testdriver.NewRows([]string{"@@max_allowed_packet"}, []driver.Value{int64(1 << 20)}), nil
+		},
+	})
+	db.WritesDSN = "/?maxAllowedPacket=4194304"
+	db.refreshLimits = new(lazyOnce)
+
+	var queries int
+	db.Log = func(detail LogDetail) {
+		queries++
+	}
+
+	if got := db.maxInsertSize(context.Background()); got != 1<<20 || queries != 1 {
+		t.Errorf("maxInsertSize() = %d, after %d queries, want the server's %d", got, queries, 1<<20)
+	}
+
+	// a size that was changed since connecting isn't replaced
+	db.MaxInsertSize.Set(1000)
+	db.resetDetection(new(Database))
+	if got := db.maxInsertSize(context.Background()); got != 1000 || queries != 1 {
+		t.Errorf("maxInsertSize() = %d, after %d queries, want the changed 1000", got, queries)
+	}
+}
@@ -0,0 +1,185 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ShadowQueueSize is the number of writes that can wait to be mirrored to a shadow,
+// after which writes aren't mirrored, and are reported as divergent, instead of slowing down the primary
+var ShadowQueueSize = 10000
+
+// Divergence is a write whose result on the shadow was different than on the primary
+type Divergence struct {
+	// Query is the write, with its params interpolated
+	Query string
+
+	PrimaryRowsAffected int64
+	ShadowRowsAffected  int64
+
+	PrimaryErr error
+	ShadowErr  error
+}
+
+// DivergenceFunc is called with every write whose results diverged
+type DivergenceFunc func(d Divergence)
+
+type shadowWrite struct {
+	query        string
+	rowsAffected int64
+	err          error
+}
+
+type shadow struct {
+	execer       Execer
+	compare      bool
+	onDivergence DivergenceFunc
+
+	queue chan shadowWrite
+	wg    sync.WaitGroup
+
+	// mu keeps writes from being queued after the queue is closed
+	mu     sync.RWMutex
+	closed bool
+}
+
+type txShadowWrites struct {
+	sync.Mutex
+	writes []shadowWrite
+}
+
+// SetShadow mirrors every write made by the database, asynchronously and in order,
+// to the shadow, like another Database for a new cluster or schema, or a LocalWriter.
+// Writes made in a transaction are mirrored once it's committed.
+// Writes are mirrored whether they failed on the primary or not, and onDivergence,
+// if it isn't nil, is called with the writes where one of them failed, or where the
+// rows affected are different. Rows affected aren't compared for a LocalWriter.
+// Setting a nil shadow stops mirroring, waiting for the queued writes first.
+func (db *Database) SetShadow(execer Execer, onDivergence DivergenceFunc) *Database {
+	if db.shadow != nil {
+		db.shadow.close()
+		db.shadow = nil
+	}
+
+	if execer == nil {
+		return db
+	}
+
+	_, isLocal := execer.(*LocalWriter)
+	s := &shadow{
+		execer:       execer,
+		compare:      !isLocal,
+		onDivergence: onDivergence,
+		queue:        make(chan shadowWrite, ShadowQueueSize),
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		for w := range s.queue {
+			s.mirror(w)
+		}
+	}()
+
+	db.shadow = s
+	return db
+}
+
+// FlushShadow waits for the writes queued for the shadow to be mirrored,
+// and stops mirroring. It should be called before the process exits.
+func (db *Database) FlushShadow() {
+	db.SetShadow(nil, nil)
+}
+
+func (s *shadow) close() {
+	s.mu.Lock()
+	s.closed = true
+	close(s.queue)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *shadow) mirror(w shadowWrite) {
+	res, err := s.execer.ExecContextResult(context.Background(), w.query)
+
+	var rowsAffected int64
+	if res != nil {
+		rowsAffected, _ = res.RowsAffected()
+	}
+
+	if s.onDivergence == nil {
+		return
+	}
+
+	if (w.err == nil) != (err == nil) || (s.compare && err == nil && w.rowsAffected != rowsAffected) {
+		s.onDivergence(Divergence{
+			Query:               w.query,
+			PrimaryRowsAffected: w.rowsAffected,
+			ShadowRowsAffected:  rowsAffected,
+			PrimaryErr:          w.err,
+			ShadowErr:           err,
+		})
+	}
+}
+
+// enqueue queues the write to be mirrored, reporting it as divergent if the queue is full
+func (s *shadow) enqueue(w shadowWrite) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return
+	}
+
+	select {
+	case s.queue <- w:
+	default:
+		if s.onDivergence != nil {
+			s.onDivergence(Divergence{
+				Query:               w.query,
+				PrimaryRowsAffected: w.rowsAffected,
+				PrimaryErr:          w.err,
+				ShadowErr:           fmt.Errorf("cool-mysql: shadow queue is full, write wasn't mirrored"),
+			})
+		}
+	}
+}
+
+// shadowWrite mirrors the write, or queues it in the transaction to be mirrored once it's committed
+func (db *Database) shadowWrite(tx *Tx, query string, rowsAffected int64, err error) {
+	s := db.shadow
+	if s == nil {
+		return
+	}
+
+	w := shadowWrite{query: query, rowsAffected: rowsAffected, err: err}
+	if tx != nil {
+		tx.shadowWrites.Lock()
+		defer tx.shadowWrites.Unlock()
+
+		tx.shadowWrites.writes = append(tx.shadowWrites.writes, w)
+		return
+	}
+
+	s.enqueue(w)
+}
+
+// flushShadow mirrors the writes queued in the transaction
+func (tx *Tx) flushShadow() {
+	tx.shadowWrites.Lock()
+	writes := tx.shadowWrites.writes
+	tx.shadowWrites.writes = nil
+	tx.shadowWrites.Unlock()
+
+	s := tx.db.shadow
+	if s == nil {
+		return
+	}
+
+	for _, w := range writes {
+		s.enqueue(w)
+	}
+}
@@ -0,0 +1,70 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type testExecer struct {
+	mu      sync.Mutex
+	queries []string
+}
+
+func (e *testExecer) ExecContextResult(ctx context.Context, query string, params ...any) (sql.Result, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.queries = append(e.queries, query)
+	if query == "fail" {
+		return nil, errors.New("failed")
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (e *testExecer) ExecContext(ctx context.Context, query string, params ...any) error {
+	_, err := e.ExecContextResult(ctx, query, params...)
+	return err
+}
+
+func (e *testExecer) ExecResult(query string, params ...any) (sql.Result, error) {
+	return e.ExecContextResult(context.Background(), query, params...)
+}
+
+func (e *testExecer) Exec(query string, params ...any) error {
+	return e.ExecContext(context.Background(), query, params...)
+}
+
+func TestShadow(t *testing.T) {
+	execer := new(testExecer)
+
+	var divergences []Divergence
+	db := new(Database).SetShadow(execer, func(d Divergence) {
+		divergences = append(divergences, d)
+	})
+
+	db.shadowWrite(nil, "a", 1, nil)
+	db.shadowWrite(nil, "b", 2, nil)
+	db.shadowWrite(nil, "fail", 1, nil)
+
+	tx := &Tx{db: db, shadowWrites: new(txShadowWrites)}
+	db.shadowWrite(tx, "c", 1, nil)
+	tx.flushShadow()
+
+	db.shadowWrite(&Tx{db: db, shadowWrites: new(txShadowWrites)}, "rolled back", 1, nil)
+
+	db.FlushShadow()
+	db.shadowWrite(nil, "after flush", 1, nil)
+
+	if want := []string{"a", "b", "fail", "c"}; !reflect.DeepEqual(execer.queries, want) {
+		t.Errorf("mirrored %q, want %q", execer.queries, want)
+	}
+
+	if len(divergences) != 2 || divergences[0].Query != "b" || divergences[1].Query != "fail" || divergences[1].ShadowErr == nil {
+		t.Errorf("divergences = %+v", divergences)
+	}
+}
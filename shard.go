@@ -0,0 +1,211 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// ErrNoShardKey is returned when a sharded operation's context has no shard key
+var ErrNoShardKey = errors.New("cool-mysql: no shard key in context")
+
+// ShardFunc returns the index of the shard that holds the given key
+type ShardFunc func(shardKey any) int
+
+// ShardedDatabase routes operations to one of many databases
+// by the shard key in the context
+type ShardedDatabase struct {
+	Shards []*Database
+	Route  ShardFunc
+}
+
+// NewShardedDatabase creates a new ShardedDatabase from its shards,
+// routing each shard key to a shard with route
+func NewShardedDatabase(route ShardFunc, shards ...*Database) *ShardedDatabase {
+	return &ShardedDatabase{
+		Shards: shards,
+		Route:  route,
+	}
+}
+
+var shardKeyKey = key(5)
+
+// WithShardKey returns a new context.Context with the given shard key
+func WithShardKey(ctx context.Context, shardKey any) context.Context {
+	return context.WithValue(ctx, shardKeyKey, shardKey)
+}
+
+// ShardKeyFromContext returns the shard key from a context.Context,
+// or false if none is present.
+func ShardKeyFromContext(ctx context.Context) (shardKey any, ok bool) {
+	shardKey = ctx.Value(shardKeyKey)
+	return shardKey, shardKey != nil
+}
+
+// Shard returns the database for the shard key in the context
+func (s *ShardedDatabase) Shard(ctx context.Context) (*Database, error) {
+	shardKey, ok := ShardKeyFromContext(ctx)
+	if !ok {
+		return nil, ErrNoShardKey
+	}
+
+	return s.ShardFor(shardKey)
+}
+
+// ShardFor returns the database for the given shard key
+func (s *ShardedDatabase) ShardFor(shardKey any) (*Database, error) {
+	i := s.Route(shardKey)
+	if i < 0 || i >= len(s.Shards) {
+		return nil, fmt.Errorf("cool-mysql: shard key %v routed to shard %d, but there are only %d shards", shardKey, i, len(s.Shards))
+	}
+
+	return s.Shards[i], nil
+}
+
+func (s *ShardedDatabase) SelectContext(ctx context.Context, dest any, q string, cache time.Duration, params ...any) error {
+	db, err := s.Shard(ctx)
+	if err != nil {
+		return err
+	}
+
+	return db.SelectContext(ctx, dest, q, cache, params...)
+}
+
+func (s *ShardedDatabase) SelectWritesContext(ctx context.Context, dest any, q string, cache time.Duration, params ...any) error {
+	db, err := s.Shard(ctx)
+	if err != nil {
+		return err
+	}
+
+	return db.SelectWritesContext(ctx, dest, q, cache, params...)
+}
+
+// ExistsContext efficiently checks if there are any rows in the given query on the context's shard
+func (s *ShardedDatabase) ExistsContext(ctx context.Context, query string, cache time.Duration, params ...any) (bool, error) {
+	db, err := s.Shard(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return db.ExistsContext(ctx, query, cache, params...)
+}
+
+// CountContext efficiently checks the number of rows a query returns on the context's shard
+func (s *ShardedDatabase) CountContext(ctx context.Context, query string, cache time.Duration, params ...any) (int, error) {
+	db, err := s.Shard(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return db.CountContext(ctx, query, cache, params...)
+}
+
+// ExecContextResult executes a query on the context's shard and nothing more
+func (s *ShardedDatabase) ExecContextResult(ctx context.Context, query string, params ...any) (sql.Result, error) {
+	db, err := s.Shard(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.ExecContextResult(ctx, query, params...)
+}
+
+// ExecContext executes a query on the context's shard and nothing more
+func (s *ShardedDatabase) ExecContext(ctx context.Context, query string, params ...any) error {
+	_, err := s.ExecContextResult(ctx, query, params...)
+	return err
+}
+
+func (s *ShardedDatabase) InsertContext(ctx context.Context, insert string, source any) error {
+	db, err := s.Shard(ctx)
+	if err != nil {
+		return err
+	}
+
+	return db.InsertContext(ctx, insert, source)
+}
+
+func (s *ShardedDatabase) UpsertContext(ctx context.Context, insert string, uniqueColumns, updateColumns []string, where string, whereParams Params, source any) error {
+	db, err := s.Shard(ctx)
+	if err != nil {
+		return err
+	}
+
+	return db.UpsertContext(ctx, insert, uniqueColumns, updateColumns, where, whereParams, source)
+}
+
+// BeginTxContext begins and returns a new transaction on the context's shard
+func (s *ShardedDatabase) BeginTxContext(ctx context.Context) (tx *Tx, cancel func() error, err error) {
+	db, err := s.Shard(ctx)
+	if err != nil {
+		return nil, func() error { return nil }, err
+	}
+
+	return db.BeginTxContext(ctx)
+}
+
+// ExecAllShards executes the query on every shard concurrently
+func (s *ShardedDatabase) ExecAllShards(ctx context.Context, query string, params ...any) error {
+	grp, ctx := errgroup.WithContext(ctx)
+	for i, db := range s.Shards {
+		i, db := i, db
+		grp.Go(func() error {
+			if err := db.ExecContext(ctx, query, params...); err != nil {
+				return fmt.Errorf("shard %d: %w", i, err)
+			}
+			return nil
+		})
+	}
+
+	return grp.Wait()
+}
+
+// SelectAllShards runs the query on every shard concurrently and returns all of the rows,
+// ordered by shard and then in the order each shard returned them, unless they're merged
+// by a Merge passed with the params, like to sort them or drop rows found on more than one shard
+func SelectAllShards[T any](ctx context.Context, s *ShardedDatabase, q string, cache time.Duration, params ...any) ([]T, error) {
+	merge, params, err := mergeFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+	var merged []T
+	order, unique, err := merge.columns(&merged, q)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([][]T, len(s.Shards))
+
+	grp, ctx := errgroup.WithContext(ctx)
+	for i, db := range s.Shards {
+		i, db := i, db
+		grp.Go(func() error {
+			if err := db.SelectContext(ctx, &results[i], q, cache, params...); err != nil {
+				return fmt.Errorf("shard %d: %w", i, err)
+			}
+			return nil
+		})
+	}
+
+	if err := grp.Wait(); err != nil {
+		return nil, err
+	}
+
+	l := 0
+	for _, r := range results {
+		l += len(r)
+	}
+
+	merged = make([]T, 0, l)
+	for _, r := range results {
+		merged = append(merged, r...)
+	}
+
+	mergeRows(&merged, order, unique)
+
+	return merged, nil
+}
@@ -0,0 +1,83 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestShardedDatabase_Shard(t *testing.T) {
+	shards := []*Database{new(Database), new(Database)}
+	s := NewShardedDatabase(func(shardKey any) int {
+		return shardKey.(int) % 3
+	}, shards...)
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		want    *Database
+		wantErr error
+	}{
+		{"first", WithShardKey(context.Background(), 3), shards[0], nil},
+		{"second", WithShardKey(context.Background(), 4), shards[1], nil},
+		{"out of range", WithShardKey(context.Background(), 5), nil, nil},
+		{"negative", WithShardKey(context.Background(), -1), nil, nil},
+		{"no key", context.Background(), nil, ErrNoShardKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Shard(tt.ctx)
+			if tt.want == nil {
+				if err == nil {
+					t.Fatalf("Shard() = %p, want an error", got)
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("Shard() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Shard() = %p, want %p", got, tt.want)
+			}
+
+			key, _ := ShardKeyFromContext(tt.ctx)
+			if got, err := s.ShardFor(key); err != nil || got != tt.want {
+				t.Errorf("ShardFor() = %p, %v, want %p", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestShardedDatabase_ExecContext(t *testing.T) {
+	var queries [2][]string
+	shards := make([]*Database, 2)
+	for i := range shards {
+		i := i
+		shards[i] = benchDatabase(t)
+		shards[i].Log = func(detail LogDetail) {
+			queries[i] = append(queries[i], detail.Query)
+		}
+	}
+	s := NewShardedDatabase(func(shardKey any) int { return shardKey.(int) }, shards...)
+
+	if err := s.ExecContext(WithShardKey(context.Background(), 1), "delete from`users`"); err != nil {
+		t.Fatal(err)
+	}
+	if len(queries[0]) != 0 || len(queries[1]) != 1 {
+		t.Errorf("ExecContext() ran %q on the shards, want it only on the second", queries)
+	}
+
+	if err := s.ExecContext(context.Background(), "delete from`users`"); !errors.Is(err, ErrNoShardKey) {
+		t.Errorf("ExecContext() without a shard key error = %v, want ErrNoShardKey", err)
+	}
+
+	if err := s.ExecAllShards(context.Background(), "delete from`users`"); err != nil {
+		t.Fatal(err)
+	}
+	if len(queries[0]) != 1 || len(queries[1]) != 2 {
+		t.Errorf("ExecAllShards() ran %q on the shards, want it on both", queries)
+	}
+}
@@ -0,0 +1,122 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SnapshotTx is a read-only transaction on a connection checked out of the reads pool, started
+// with a consistent snapshot, so every select in it sees the database as it was at the same moment,
+// like mysqldump's --single-transaction, however long the selects take and whatever's written meanwhile.
+// It's for jobs like exports that read many large queries that have to agree with each other.
+//
+// Its selects are never cached, since a cached result could be from before or after the snapshot,
+// so their cache durations are ignored. It has to be closed to end the transaction
+// and return the connection to the pool.
+type SnapshotTx struct {
+	db *Database
+
+	conn *Conn
+}
+
+// SnapshotTx begins a read-only transaction with a consistent snapshot on the reads connection, see SnapshotTx
+func (db *Database) SnapshotTx(ctx context.Context) (*SnapshotTx, error) {
+	c, err := db.Reads.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	// the snapshot is only consistent in repeatable read, and other isolation levels
+	// would quietly ignore it, so it's set for just this transaction
+	for _, q := range []string{
+		"set transaction isolation level repeatable read",
+		"start transaction with consistent snapshot,read only",
+	} {
+		start := time.Now()
+		_, err := c.ExecContext(ctx, q)
+		db.callLog(LogDetail{
+			Query:    q,
+			Duration: time.Since(start),
+			Attempt:  1,
+			Error:    err,
+		})
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("failed to start snapshot transaction: %w", err)
+		}
+	}
+
+	return &SnapshotTx{db: db, conn: &Conn{db: db, Conn: c}}, nil
+}
+
+// Close ends the transaction and returns the connection to the pool. If the transaction
+// can't be ended, the connection is closed instead, which ends it with the rest of the session.
+func (tx *SnapshotTx) Close() error {
+	start := time.Now()
+	_, err := tx.conn.Conn.ExecContext(context.Background(), "commit")
+	tx.db.callLog(LogDetail{
+		Query:    "commit",
+		Duration: time.Since(start),
+		Attempt:  1,
+		Error:    err,
+	})
+	if err != nil {
+		// a connection is discarded instead of returned to the pool if it's bad
+		tx.conn.Conn.Raw(func(any) error {
+			return driver.ErrBadConn
+		})
+		tx.conn.Close()
+		return fmt.Errorf("failed to end snapshot transaction: %w", err)
+	}
+
+	return tx.conn.Close()
+}
+
+func (tx *SnapshotTx) Select(dest any, q string, cache time.Duration, params ...any) error {
+	return tx.conn.Select(dest, q, 0, params...)
+}
+
+func (tx *SnapshotTx) SelectRows(q string, cache time.Duration, params ...any) (Rows, error) {
+	return tx.conn.SelectRows(q, 0, params...)
+}
+
+func (tx *SnapshotTx) SelectContext(ctx context.Context, dest any, q string, cache time.Duration, params ...any) error {
+	return tx.conn.SelectContext(ctx, dest, q, 0, params...)
+}
+
+func (tx *SnapshotTx) SelectJSON(dest any, query string, cache time.Duration, params ...any) error {
+	return tx.SelectJSONContext(context.Background(), dest, query, cache, params...)
+}
+
+func (tx *SnapshotTx) SelectJSONContext(ctx context.Context, dest any, query string, cache time.Duration, params ...any) error {
+	var j []byte
+	err := tx.SelectContext(ctx, &j, query, cache, params...)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(j, dest)
+}
+
+// Exists efficiently checks if there are any rows in the given query in the snapshot
+func (tx *SnapshotTx) Exists(query string, cache time.Duration, params ...any) (bool, error) {
+	return tx.conn.Exists(query, 0, params...)
+}
+
+// ExistsContext efficiently checks if there are any rows in the given query in the snapshot
+func (tx *SnapshotTx) ExistsContext(ctx context.Context, query string, cache time.Duration, params ...any) (bool, error) {
+	return tx.conn.ExistsContext(ctx, query, 0, params...)
+}
+
+// Count efficiently checks the number of rows a query returns in the snapshot
+func (tx *SnapshotTx) Count(query string, cache time.Duration, params ...any) (int, error) {
+	return tx.conn.Count(query, 0, params...)
+}
+
+// CountContext efficiently checks the number of rows a query returns in the snapshot
+func (tx *SnapshotTx) CountContext(ctx context.Context, query string, cache time.Duration, params ...any) (int, error) {
+	return tx.conn.CountContext(ctx, query, 0, params...)
+}
@@ -0,0 +1,55 @@
+package mysql
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDatabase_SnapshotTx(t *testing.T) {
+	db := benchDatabase(t)
+
+	var queries []string
+	db.Log = func(detail LogDetail) {
+		queries = append(queries, detail.Query)
+	}
+
+	// with one connection, anything that isn't run in the snapshot's
+	// connection would wait for it until the context times out
+	db.Reads.SetMaxOpenConns(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.SnapshotTx(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rows []benchWideRow
+	if err := tx.SelectContext(ctx, &rows, "select*from`users`", time.Minute); err != nil || len(rows) != 1000 {
+		t.Errorf("SelectContext() = %d rows, %v, want 1000 rows", len(rows), err)
+	}
+	if n, err := tx.CountContext(ctx, "select*from`users`", 0); err != nil || n != 1000 {
+		t.Errorf("CountContext() = %d, %v, want 1000", n, err)
+	}
+
+	if err := tx.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SelectContext(ctx, &rows, "select 1", 0); err != nil {
+		t.Errorf("SelectContext() after the snapshot was closed failed: %v", err)
+	}
+
+	want := []string{
+		"set transaction isolation level repeatable read",
+		"start transaction with consistent snapshot,read only",
+		"select*from`users`",
+		"select*from`users`",
+		"commit",
+		"select 1",
+	}
+	if !reflect.DeepEqual(queries, want) {
+		t.Errorf("SnapshotTx() ran %q, want %q", queries, want)
+	}
+}
@@ -0,0 +1,225 @@
+package mysql
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Geometry is a value of a spatial column. Geometries are marshaled as
+// `ST_GeomFromText`, and scanned from MySQL's internal format or WKB.
+type Geometry interface {
+	// WKT returns the well-known text of the geometry, like `POINT(1 2)`
+	WKT() string
+}
+
+// Point is a POINT
+type Point struct {
+	X, Y float64
+}
+
+// LineString is a LINESTRING
+type LineString []Point
+
+// Polygon is a POLYGON made of rings, where the first ring is the exterior
+// and the rest are holes
+type Polygon []LineString
+
+// GeometryWithSRID is a geometry in a spatial reference system,
+// marshaled with its SRID
+type GeometryWithSRID struct {
+	Geometry
+	SRID uint32
+}
+
+// WithSRID returns the geometry in the given spatial reference system, like 4326
+func WithSRID(g Geometry, srid uint32) GeometryWithSRID {
+	return GeometryWithSRID{Geometry: g, SRID: srid}
+}
+
+func (p Point) WKT() string {
+	return "POINT(" + p.coords() + ")"
+}
+
+func (p Point) coords() string {
+	return strconv.FormatFloat(p.X, 'g', -1, 64) + " " + strconv.FormatFloat(p.Y, 'g', -1, 64)
+}
+
+func (l LineString) WKT() string {
+	return "LINESTRING" + l.coords()
+}
+
+func (l LineString) coords() string {
+	s := new(strings.Builder)
+	s.WriteByte('(')
+	for i, p := range l {
+		if i != 0 {
+			s.WriteByte(',')
+		}
+		s.WriteString(p.coords())
+	}
+	s.WriteByte(')')
+
+	return s.String()
+}
+
+func (p Polygon) WKT() string {
+	s := new(strings.Builder)
+	s.WriteString("POLYGON(")
+	for i, r := range p {
+		if i != 0 {
+			s.WriteByte(',')
+		}
+		s.WriteString(r.coords())
+	}
+	s.WriteByte(')')
+
+	return s.String()
+}
+
+// marshalGeometry returns the geometry as `ST_GeomFromText`
+func marshalGeometry(g Geometry) []byte {
+	if g, ok := g.(GeometryWithSRID); ok {
+		if g.Geometry == nil || isNil(g.Geometry) {
+			return []byte("null")
+		}
+		return []byte("st_geomfromtext('" + g.WKT() + "'," + strconv.FormatUint(uint64(g.SRID), 10) + ")")
+	}
+
+	return []byte("st_geomfromtext('" + g.WKT() + "')")
+}
+
+const (
+	wkbPoint      = 1
+	wkbLineString = 2
+	wkbPolygon    = 3
+)
+
+var errInvalidWKB = errors.New("cool-mysql: invalid wkb")
+
+// Scan scans a point from MySQL's internal geometry format or WKB
+func (p *Point) Scan(src any) error {
+	return scanGeometry(src, wkbPoint, func(r *wkbReader) {
+		*p = r.point()
+	})
+}
+
+// Scan scans a line string from MySQL's internal geometry format or WKB
+func (l *LineString) Scan(src any) error {
+	return scanGeometry(src, wkbLineString, func(r *wkbReader) {
+		*l = r.lineString()
+	})
+}
+
+// Scan scans a polygon from MySQL's internal geometry format or WKB
+func (p *Polygon) Scan(src any) error {
+	return scanGeometry(src, wkbPolygon, func(r *wkbReader) {
+		n := r.uint32()
+		if r.err != nil || int(n) > len(r.b) {
+			r.err = errInvalidWKB
+			return
+		}
+
+		poly := make(Polygon, n)
+		for i := range poly {
+			poly[i] = r.lineString()
+		}
+		*p = poly
+	})
+}
+
+func scanGeometry(src any, wantType uint32, read func(r *wkbReader)) error {
+	var b []byte
+	switch src := src.(type) {
+	case []byte:
+		b = src
+	case string:
+		b = []byte(src)
+	default:
+		return fmt.Errorf("cool-mysql: can't scan %T into a geometry", src)
+	}
+
+	// MySQL's internal format is the SRID followed by the WKB
+	if len(b) >= 9 && (b[4] == 0 || b[4] == 1) {
+		if err := readWKB(b[4:], wantType, read); err == nil {
+			return nil
+		}
+	}
+
+	return readWKB(b, wantType, read)
+}
+
+func readWKB(b []byte, wantType uint32, read func(r *wkbReader)) error {
+	if len(b) < 5 || b[0] > 1 {
+		return errInvalidWKB
+	}
+
+	r := &wkbReader{b: b[1:], order: binary.LittleEndian}
+	if b[0] == 0 {
+		r.order = binary.BigEndian
+	}
+
+	if t := r.uint32(); t != wantType {
+		return fmt.Errorf("cool-mysql: expected wkb geometry type %d, got %d", wantType, t)
+	}
+
+	read(r)
+	if r.err != nil {
+		return r.err
+	}
+	if len(r.b) != 0 {
+		return errInvalidWKB
+	}
+
+	return nil
+}
+
+type wkbReader struct {
+	b     []byte
+	order binary.ByteOrder
+	err   error
+}
+
+func (r *wkbReader) uint32() uint32 {
+	if len(r.b) < 4 {
+		r.err = errInvalidWKB
+		return 0
+	}
+
+	v := r.order.Uint32(r.b)
+	r.b = r.b[4:]
+	return v
+}
+
+func (r *wkbReader) float64() float64 {
+	if len(r.b) < 8 {
+		r.err = errInvalidWKB
+		return 0
+	}
+
+	v := math.Float64frombits(r.order.Uint64(r.b))
+	r.b = r.b[8:]
+	return v
+}
+
+func (r *wkbReader) point() Point {
+	return Point{X: r.float64(), Y: r.float64()}
+}
+
+func (r *wkbReader) lineString() LineString {
+	n := r.uint32()
+	if r.err != nil || int(n) > len(r.b)/16 {
+		r.err = errInvalidWKB
+		return nil
+	}
+
+	l := make(LineString, n)
+	for i := range l {
+		l[i] = r.point()
+	}
+
+	return l
+}
@@ -0,0 +1,62 @@
+package mysql
+
+import (
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func TestGeometryMarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		g    any
+		want string
+	}{
+		{"point", Point{1, 2.5}, "st_geomfromtext('POINT(1 2.5)')"},
+		{"line string", LineString{{0, 0}, {1, 1}}, "st_geomfromtext('LINESTRING(0 0,1 1)')"},
+		{"polygon", Polygon{{{0, 0}, {0, 1}, {1, 1}, {0, 0}}}, "st_geomfromtext('POLYGON((0 0,0 1,1 1,0 0))')"},
+		{"srid", WithSRID(Point{-95.99, 36.15}, 4326), "st_geomfromtext('POINT(-95.99 36.15)',4326)"},
+		{"nil pointer", (*Point)(nil), "null"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := marshal(tt.g, 0, "", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("marshal() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeometryScan(t *testing.T) {
+	// POINT(1 2) in MySQL's internal format, with SRID 0
+	internal, _ := hex.DecodeString("00000000" + "0101000000000000000000f03f0000000000000040")
+
+	var p Point
+	if err := p.Scan(internal); err != nil {
+		t.Fatal(err)
+	}
+	if p != (Point{1, 2}) {
+		t.Errorf("Point.Scan() = %v, want POINT(1 2)", p)
+	}
+
+	// LINESTRING(1 2,3 4) as big endian WKB
+	wkb, _ := hex.DecodeString("000000000200000002" +
+		"3ff0000000000000" + "4000000000000000" +
+		"4008000000000000" + "4010000000000000")
+
+	var l LineString
+	if err := l.Scan(wkb); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(l, LineString{{1, 2}, {3, 4}}) {
+		t.Errorf("LineString.Scan() = %v, want LINESTRING(1 2,3 4)", l)
+	}
+
+	if err := p.Scan(wkb); err == nil {
+		t.Errorf("Point.Scan() of a line string should fail")
+	}
+}
@@ -11,15 +11,9 @@ type handlerWithContext interface {
 	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
 }
 
-type Handler interface {
-	Insert(insert string, source any) error
-	InsertContext(ctx context.Context, insert string, source any) error
-
-	ExecContextResult(ctx context.Context, query string, params ...any) (sql.Result, error)
-	ExecContext(ctx context.Context, query string, params ...any) error
-	ExecResult(query string, params ...any) (sql.Result, error)
-	Exec(query string, params ...any) error
-
+// Querier selects rows, and is satisfied by both *Database and *Tx,
+// so the same code can run inside or outside of a transaction
+type Querier interface {
 	Select(dest any, q string, cache time.Duration, params ...any) error
 	SelectRows(q string, cache time.Duration, params ...any) (Rows, error)
 	SelectContext(ctx context.Context, dest any, q string, cache time.Duration, params ...any) error
@@ -28,9 +22,36 @@ type Handler interface {
 
 	Exists(query string, cache time.Duration, params ...any) (bool, error)
 	ExistsContext(ctx context.Context, query string, cache time.Duration, params ...any) (bool, error)
-	Upsert(insert string, uniqueColumns, updateColumns []string, where string, source any) error
-	UpsertContext(ctx context.Context, insert string, uniqueColumns, updateColumns []string, where string, source any) error
+
+	Count(query string, cache time.Duration, params ...any) (int, error)
+	CountContext(ctx context.Context, query string, cache time.Duration, params ...any) (int, error)
+}
+
+// Execer executes queries, and is satisfied by both *Database and *Tx
+type Execer interface {
+	ExecContextResult(ctx context.Context, query string, params ...any) (sql.Result, error)
+	ExecContext(ctx context.Context, query string, params ...any) error
+	ExecResult(query string, params ...any) (sql.Result, error)
+	Exec(query string, params ...any) error
+}
+
+// InsertUpserter inserts and upserts rows, and is satisfied by both *Database and *Tx.
+// It's named so it doesn't collide with the Inserter returned by I()
+type InsertUpserter interface {
+	Insert(insert string, source any) error
+	InsertContext(ctx context.Context, insert string, source any) error
+	Upsert(insert string, uniqueColumns, updateColumns []string, where string, whereParams Params, source any) error
+	UpsertContext(ctx context.Context, insert string, uniqueColumns, updateColumns []string, where string, whereParams Params, source any) error
+}
+
+// Handler is everything both *Database and *Tx can do, for wrapping
+// the library with mocks or decorators
+type Handler interface {
+	Querier
+	Execer
+	InsertUpserter
 }
 
 var _ Handler = &Database{}
 var _ Handler = &Tx{}
+var _ Handler = &Conn{}
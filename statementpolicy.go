@@ -0,0 +1,379 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrStatementNotAllowed is matched by the errors of statements rejected by the database's StatementPolicy
+var ErrStatementNotAllowed = errors.New("cool-mysql: statement not allowed by policy")
+
+// StatementPolicyError is the error of a statement rejected by the database's StatementPolicy
+type StatementPolicyError struct {
+	// Query is the statement, with its params interpolated
+	Query string
+
+	// Fingerprint is the fingerprint of the statement the policy checked, see Fingerprint
+	Fingerprint string
+
+	// Reason is why the query was rejected, like "not in the allowlist"
+	Reason string
+}
+
+func (e StatementPolicyError) Error() string {
+	return fmt.Sprintf("%s: %s: %q", ErrStatementNotAllowed, e.Reason, e.Fingerprint)
+}
+
+func (e StatementPolicyError) Is(target error) bool {
+	return target == ErrStatementNotAllowed
+}
+
+// DenyDestructive matches the fingerprints of statements that drop or empty tables and databases,
+// for a StatementPolicy's Deny, which checks each statement of a query without its comments
+var DenyDestructive = []*regexp.Regexp{
+	regexp.MustCompile(`^(drop|truncate)\b`),
+}
+
+// StatementPolicy limits the statements a database can run to the ones a service is expected to,
+// by their fingerprints, so a bug or an injection can't run anything else. See Database.SetStatementPolicy.
+type StatementPolicy struct {
+	// Allow is the statements that can run, as their fingerprints or as they're written
+	// in the code, with their params, since they're fingerprinted either way.
+	// If it's empty, every statement that isn't denied can run.
+	Allow []string
+
+	// Deny matches the fingerprints of the statements that can't run, even if they're allowed,
+	// like DenyDestructive. Each statement of a query is matched on its own, without
+	// its comments or the parens it starts with.
+	Deny []*regexp.Regexp
+
+	// ReportOnly runs the statements the policy would reject, only reporting them,
+	// like to find the statements to allow before enforcing it
+	ReportOnly bool
+
+	// Violation is called with each rejected statement, like to count them in metrics.
+	// By default, they're logged as warnings.
+	Violation func(StatementPolicyError)
+
+	allowOnce sync.Once
+	allowed   map[string]struct{}
+}
+
+// Check returns a StatementPolicyError if the policy rejects the query
+func (p *StatementPolicy) Check(query string) error {
+	p.allowOnce.Do(func() {
+		if len(p.Allow) == 0 {
+			return
+		}
+
+		p.allowed = make(map[string]struct{}, len(p.Allow))
+		for _, q := range p.Allow {
+			p.allowed[Fingerprint(q)] = struct{}{}
+		}
+	})
+
+	// every statement is checked on its own, without its comments, so a denied statement
+	// can't hide behind a comment, a paren, or another statement before it
+	if len(p.Deny) != 0 {
+		for _, stmt := range statements(query) {
+			fingerprint := Fingerprint(stmt)
+			for _, re := range p.Deny {
+				if re.MatchString(fingerprint) {
+					return StatementPolicyError{Query: query, Fingerprint: fingerprint, Reason: "denied by " + re.String()}
+				}
+			}
+		}
+	}
+
+	fingerprint := Fingerprint(query)
+
+	if p.allowed != nil {
+		if _, ok := p.allowed[fingerprint]; !ok {
+			return StatementPolicyError{Query: query, Fingerprint: fingerprint, Reason: "not in the allowlist"}
+		}
+	}
+
+	return nil
+}
+
+// SetStatementPolicy sets the policy every select and exec of the database is checked against,
+// including inserts and upserts, or stops checking if it's nil
+func (db *Database) SetStatementPolicy(p *StatementPolicy) *Database {
+	db.statementPolicy = p
+	return db
+}
+
+// checkStatementPolicy returns the error of the query if the database's policy rejects it,
+// reporting the rejection either way
+func (db *Database) checkStatementPolicy(replacedQuery string) error {
+	p := db.statementPolicy
+	if p == nil {
+		return nil
+	}
+
+	err := p.Check(replacedQuery)
+	if err == nil {
+		return nil
+	}
+
+	var policyErr StatementPolicyError
+	if errors.As(err, &policyErr) {
+		if p.Violation != nil {
+			p.Violation(policyErr)
+		} else {
+			db.Logger.Warn(fmt.Sprintf("statement %s: %s", policyErr.Reason, policyErr.Query))
+		}
+	}
+
+	if p.ReportOnly {
+		return nil
+	}
+
+	return err
+}
+
+// Fingerprint returns the normalized form of the query that's the same for every run of it,
+// whatever its values, so it can identify the statement, like in a StatementPolicy.
+// Literals and params become "?", lists of them, like in "in(...)" or the rows of an insert,
+// become one, keywords are lowercased, and whitespace is removed where it's not needed.
+// Identifiers quoted with backticks are left as they are.
+func Fingerprint(query string) string {
+	queryTokens := parseQuery(query)
+
+	var sb strings.Builder
+	sb.Grow(len(query))
+
+	// wordLike is true if the last part written needs a space before a word,
+	// and operand is true if it's something a sign would be subtracted from, instead of negating a number
+	wordLike, operand := false, false
+	write := func(s string, word bool) {
+		if word && wordLike {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(s)
+		wordLike, operand = word, word || s == ")" || s[0] == '`'
+	}
+
+	// literal is true if the last part written is a placeholder, and collation is true
+	// if the next word is the name of a collation of the literal
+	literal, collation := false, false
+	for i, t := range queryTokens {
+		if t.kind == queryTokenKindMisc && strings.TrimSpace(t.string) == "" {
+			continue
+		}
+
+		isLiteral := false
+		switch t.kind {
+		case queryTokenKindParam:
+			isLiteral = true
+		case queryTokenKindString:
+			isLiteral = t.string[0] != '`'
+		case queryTokenKindWord:
+			isLiteral = '0' <= t.string[0] && t.string[0] <= '9' || strings.EqualFold(t.string, "null")
+		case queryTokenKindMisc:
+			isLiteral = t.string == "?"
+		}
+
+		if isLiteral {
+			// numbers like `1.5E+00` are split into multiple tokens, so they're one placeholder
+			if !literal {
+				write("?", true)
+				literal = true
+			}
+			continue
+		}
+
+		switch t.kind {
+		case queryTokenKindWord:
+			switch w := strings.ToLower(t.string); {
+			case collation:
+				collation = false
+				continue
+			case literal && w == "collate":
+				// the collation of a string param, like `_utf8mb4 0x61 collate utf8mb4_unicode_ci`,
+				// which isn't there for empty strings, so it's left out with its name
+				collation = true
+				continue
+			case w[0] == '_' && nextIsLiteral(queryTokens[i+1:]):
+				// the charset introducer of a string, like `_utf8mb4 0x61`
+				continue
+			default:
+				write(w, true)
+			}
+		case queryTokenKindVar:
+			write(strings.ToLower(t.string), true)
+		case queryTokenKindMisc:
+			switch t.string {
+			case ".", "+", "-":
+				if literal || !operand && nextIsLiteral(queryTokens[i+1:]) {
+					// part of a number, like its sign or decimal point
+					continue
+				}
+			}
+			write(t.string, false)
+		default:
+			write(t.string, false)
+		}
+		literal = false
+	}
+
+	fingerprint := sb.String()
+	for {
+		collapsed := fingerprint
+		for _, r := range fingerprintLists {
+			collapsed = strings.ReplaceAll(collapsed, r[0], r[1])
+		}
+		if collapsed == fingerprint {
+			return fingerprint
+		}
+		fingerprint = collapsed
+	}
+}
+
+// fingerprintLists are the replacements that collapse the lists of a fingerprint into one of their items,
+// and the values that are marshaled as funcs into a placeholder, like times
+var fingerprintLists = [][2]string{
+	{"?,?", "?"},
+	{"(?),(?)", "(?)"},
+	{"convert_tz(?)", "?"},
+}
+
+// nextIsLiteral returns true if the next token that isn't whitespace is a literal
+func nextIsLiteral(queryTokens []queryToken) bool {
+	for _, t := range queryTokens {
+		switch {
+		case t.kind == queryTokenKindMisc && strings.TrimSpace(t.string) == "":
+			continue
+		case t.kind == queryTokenKindString:
+			return t.string[0] != '`'
+		case t.kind == queryTokenKindWord:
+			return '0' <= t.string[0] && t.string[0] <= '9'
+		case t.kind == queryTokenKindParam:
+			return true
+		}
+
+		return false
+	}
+
+	return false
+}
+
+// statements returns the statements of the query, split on the semicolons between them, without
+// their comments or the parens they start with, so they can be checked by their first words.
+// The contents of the comments MySQL runs, like `/*!50000 drop table t*/`, are kept.
+func statements(query string) []string {
+	var stmts []string
+	var sb strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(strings.TrimLeft(sb.String(), "( \t\r\n")); len(s) != 0 {
+			stmts = append(stmts, s)
+		}
+		sb.Reset()
+	}
+
+	// executable is true inside a comment MySQL runs, whose end is dropped like its start
+	executable := false
+	l := len(query)
+	for i := 0; i < l; i++ {
+		b := query[i]
+		switch {
+		case b == '\'' || b == '"' || b == '`':
+			// strings and quoted identifiers are copied whole, with their escapes
+			j := i + 1
+			for ; j < l; j++ {
+				if query[j] == '\\' && b != '`' {
+					j++
+				} else if query[j] == b {
+					if j+1 < l && query[j+1] == b {
+						j++
+						continue
+					}
+					break
+				}
+			}
+			if j >= l {
+				j = l - 1
+			}
+			sb.WriteString(query[i : j+1])
+			i = j
+		case b == '/' && i+1 < l && query[i+1] == '*':
+			if i+2 < l && (query[i+2] == '!' || query[i+2] == '+') {
+				// the version of an executable comment, like the 50000 of `/*!50000`, isn't part of the statement
+				i += 2
+				for i+1 < l && '0' <= query[i+1] && query[i+1] <= '9' {
+					i++
+				}
+				executable = true
+				sb.WriteByte(' ')
+				continue
+			}
+
+			end := strings.Index(query[i+2:], "*/")
+			if end == -1 {
+				i = l
+			} else {
+				i += 2 + end + 1
+			}
+			sb.WriteByte(' ')
+		case b == '*' && executable && i+1 < l && query[i+1] == '/':
+			executable = false
+			i++
+			sb.WriteByte(' ')
+		case b == '#' || b == '-' && i+2 < l && query[i+1] == '-' && (query[i+2] == ' ' || query[i+2] == '\t' || query[i+2] == '\n' || query[i+2] == '\r') ||
+			b == '-' && i+2 == l && query[i+1] == '-':
+			end := strings.IndexByte(query[i:], '\n')
+			if end == -1 {
+				i = l
+			} else {
+				i += end
+			}
+			sb.WriteByte(' ')
+		case b == ';':
+			flush()
+		default:
+			sb.WriteByte(b)
+		}
+	}
+	flush()
+
+	return stmts
+}
+
+// statementVerb returns the lowercased first word of the statement, like "select" or "delete",
+// after the common table expressions of its with clause, if it has one
+func statementVerb(stmt string) string {
+	depth := 0
+	with := false
+	afterParen := false
+	for _, t := range parseQuery(stmt) {
+		switch {
+		case t.kind == queryTokenKindMisc && strings.TrimSpace(t.string) == "":
+			continue
+		case t.kind == queryTokenKindParen:
+			if t.string == "(" {
+				depth++
+			} else {
+				depth--
+			}
+			afterParen = depth == 0
+			continue
+		case t.kind == queryTokenKindWord && depth == 0:
+			w := strings.ToLower(t.string)
+			if !with {
+				if w != "with" {
+					return w
+				}
+				with = true
+			} else if afterParen && w != "as" {
+				// the first word after the parens of an expression that isn't another expression's as
+				return w
+			}
+		}
+		afterParen = false
+	}
+
+	return ""
+}
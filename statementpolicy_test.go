@@ -0,0 +1,144 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"params", "SELECT `ID`, `Name` FROM `users` WHERE `ID` = @@ID AND `Name` = @@Name", "select`ID`,`Name`from`users`where`ID`=? and`Name`=?"},
+		{"numbers", "select*from`t`where`A`=-1.5E+00 and`B`>2 limit 10", "select*from`t`where`A`=? and`B`>? limit ?"},
+		{"strings", "select*from`t`where`A`=_utf8mb4 0x61 collate utf8mb4_unicode_ci and`B`=''and`C`is null", "select*from`t`where`A`=? and`B`=? and`C`is ?"},
+		{"lists", "select*from`t`where`ID`in(1,2,3)", "select*from`t`where`ID`in(?)"},
+		{"rows", "insert into`t`(`A`,`B`)values(1,'a'),(2,'b'),(3,'c')", "insert into`t`(`A`,`B`)values(?)"},
+		{"times", "update`t`set`At`=convert_tz('2020-01-01 00:00:00.000000','UTC',@@session.time_zone)", "update`t`set`At`=?"},
+		{"subtraction", "update`t`set`N`=`N`-1", "update`t`set`N`=`N`-?"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Fingerprint(tt.query); got != tt.want {
+				t.Errorf("Fingerprint() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// a query fingerprints the same as it's written and as it's run
+	query := "select*from`t`where`ID`in(@@IDs)and`Name`=@@Name and`At`>@@At"
+	replaced, _, err := InterpolateParams(query, nil, nil, Params{"IDs": []int{1, 2}, "Name": "a", "At": time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Fingerprint(query) != Fingerprint(replaced) {
+		t.Errorf("Fingerprint(%q) = %q, want %q", replaced, Fingerprint(replaced), Fingerprint(query))
+	}
+}
+
+func TestDatabase_SetStatementPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	type user struct {
+		ID   int
+		Name string
+	}
+
+	var violations []StatementPolicyError
+	db := benchDatabase(t).SetStatementPolicy(&StatementPolicy{
+		Allow: []string{
+			"select`Text0`from`users`where`ID`=@@ID",
+			"insert into`users`(`ID`,`Name`)values(@@ID,@@Name)",
+			"truncate`users`",
+		},
+		Deny:      DenyDestructive,
+		Violation: func(e StatementPolicyError) { violations = append(violations, e) },
+	})
+
+	var rows []string
+	if err := db.SelectContext(ctx, &rows, "select`Text0`from`users`where`ID`=@@ID", 0, 5); err != nil {
+		t.Errorf("SelectContext() with an allowed select failed: %v", err)
+	}
+	if err := db.InsertContext(ctx, "users", []user{{1, "a"}, {2, "b"}}); err != nil {
+		t.Errorf("InsertContext() with an allowed insert failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		fn   func() error
+	}{
+		{"not allowed", func() error { return db.SelectContext(ctx, &rows, "select`Text1`from`users`", 0) }},
+		{"denied", func() error { return db.ExecContext(ctx, "truncate`users`") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.fn(); !errors.Is(err, ErrStatementNotAllowed) {
+				t.Errorf("error = %v, want ErrStatementNotAllowed", err)
+			}
+		})
+	}
+	if len(violations) != len(tests) {
+		t.Errorf("got %d violations, want %d", len(violations), len(tests))
+	}
+
+	db.SetStatementPolicy(&StatementPolicy{Allow: []string{"select 1"}, ReportOnly: true, Violation: func(StatementPolicyError) {}})
+	if err := db.ExecContext(ctx, "truncate`users`"); err != nil {
+		t.Errorf("ExecContext() with a report only policy failed: %v", err)
+	}
+}
+
+func Test_statements(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"select 1", []string{"select 1"}},
+		{"/* hi */ drop table users", []string{"drop table users"}},
+		{"-- x\ndrop table users", []string{"drop table users"}},
+		{"# x\ndrop table users", []string{"drop table users"}},
+		{"((drop table x))", []string{"drop table x))"}},
+		{"select 1; drop table users;", []string{"select 1", "drop table users"}},
+		{"select';--',`a;b`/*;*/from t", []string{"select';--',`a;b` from t"}},
+		{"select 1 /*!50000 ;drop table users*/", []string{"select 1", "drop table users"}},
+		{"select 'it''s; fine', \"a\\\";b\"", []string{"select 'it''s; fine', \"a\\\";b\""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := statements(tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("statements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatementPolicy_CheckDenyDestructive(t *testing.T) {
+	p := &StatementPolicy{Deny: DenyDestructive}
+
+	for _, query := range []string{
+		"drop table users",
+		"/* hi */ drop table users",
+		"-- x\ndrop table users",
+		"(drop table x)",
+		"select 1; drop table users",
+		"select 1 /*!50000 ;truncate users*/",
+	} {
+		if err := p.Check(query); !errors.Is(err, ErrStatementNotAllowed) {
+			t.Errorf("Check(%q) = %v, want ErrStatementNotAllowed", query, err)
+		}
+	}
+
+	for _, query := range []string{
+		"select 1",
+		"select'; drop table users'",
+		"select`drop`from t",
+	} {
+		if err := p.Check(query); err != nil {
+			t.Errorf("Check(%q) = %v, want nil", query, err)
+		}
+	}
+}
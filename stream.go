@@ -0,0 +1,312 @@
+package mysql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+)
+
+// BinlogPosition is a position in the binary log that a stream can resume from
+type BinlogPosition struct {
+	File string
+	Pos  uint32
+
+	// GTID is the executed GTID set, if the server uses GTIDs
+	GTID string
+}
+
+// BinlogRowsEvent is a single rows event read from the binary log
+type BinlogRowsEvent struct {
+	Schema  string
+	Table   string
+	Kind    ChangeKind
+	Columns []string
+
+	// Rows are the changed rows, with values in the same order as Columns.
+	// For updates, the rows come in before and after pairs.
+	Rows [][]any
+
+	Position BinlogPosition
+}
+
+// BinlogReader reads rows events from the binary log, such as an adapter around a replication client.
+// cool-mysql doesn't connect as a replica itself, and doesn't ship an adapter, so it doesn't depend on a
+// replication client. With github.com/go-mysql-org/go-mysql, an adapter reads the events of a BinlogSyncer's
+// streamer, keeping the file of the last rotate event and the GTID set of the last GTID event,
+// and returns each of its *replication.RowsEvent with the column names of the table from
+// information_schema, see Database.TableColumns, since the events only have them with
+// binlog_row_metadata=FULL, the Kind of the event's type, and the Pos of its header.
+type BinlogReader interface {
+	ReadRowsEvent(ctx context.Context) (BinlogRowsEvent, error)
+	Close() error
+}
+
+// StreamEvent is a decoded row change
+type StreamEvent[T any] struct {
+	Schema string
+	Table  string
+	Kind   ChangeKind
+
+	// Before is nil for inserts, After is nil for deletes
+	Before *T
+	After  *T
+
+	Position BinlogPosition
+}
+
+// CheckpointFunc is called with the position of the last event once
+// the consumer has finished with it, so it can be saved and the stream resumed later
+type CheckpointFunc func(ctx context.Context, pos BinlogPosition) error
+
+// Stream decodes rows events from a binlog reader into T,
+// using the same struct tags used for selects
+type Stream[T any] struct {
+	reader BinlogReader
+	tables map[string]struct{}
+
+	fieldsMap map[string][]int
+
+	Checkpoint CheckpointFunc
+
+	pending []StreamEvent[T]
+	last    *BinlogPosition
+}
+
+// NewStream returns a new stream of the given tables' changes from reader.
+// Tables can be given as `table` or `schema.table`, and if none are given,
+// every table's rows events are decoded into T.
+func NewStream[T any](reader BinlogReader, tables ...string) (*Stream[T], error) {
+	s := &Stream[T]{
+		reader: reader,
+	}
+
+	if len(tables) != 0 {
+		s.tables = make(map[string]struct{}, len(tables))
+		for _, t := range tables {
+			s.tables[strings.ToLower(t)] = struct{}{}
+		}
+	}
+
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() == reflect.Struct && isMultiValueElement(t) {
+		var err error
+		s.fieldsMap, err = structFieldsMap(t)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// SetCheckpoint sets the func called with the position of every consumed event
+func (s *Stream[T]) SetCheckpoint(fn CheckpointFunc) *Stream[T] {
+	s.Checkpoint = fn
+
+	return s
+}
+
+// Next returns the next decoded row change. Calling Next again marks
+// the previous event as consumed, checkpointing its position.
+func (s *Stream[T]) Next(ctx context.Context) (StreamEvent[T], error) {
+	if err := s.checkpoint(ctx); err != nil {
+		return StreamEvent[T]{}, err
+	}
+
+	for len(s.pending) == 0 {
+		ev, err := s.reader.ReadRowsEvent(ctx)
+		if err != nil {
+			return StreamEvent[T]{}, err
+		}
+
+		if !s.wantsTable(ev.Schema, ev.Table) {
+			continue
+		}
+
+		s.pending, err = s.decodeEvent(ev)
+		if err != nil {
+			return StreamEvent[T]{}, fmt.Errorf("failed to decode rows event for %s.%s: %w", ev.Schema, ev.Table, err)
+		}
+	}
+
+	ev := s.pending[0]
+	s.pending = s.pending[1:]
+	s.last = &ev.Position
+
+	return ev, nil
+}
+
+// Run sends every decoded row change to ch until ctx is done or
+// the reader fails. An event is checkpointed after it is received from ch.
+func (s *Stream[T]) Run(ctx context.Context, ch chan<- StreamEvent[T]) error {
+	defer s.reader.Close()
+
+	for {
+		ev, err := s.Next(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case ch <- ev:
+		}
+	}
+}
+
+// Close closes the underlying reader, checkpointing the last event
+func (s *Stream[T]) Close(ctx context.Context) error {
+	if err := s.checkpoint(ctx); err != nil {
+		return err
+	}
+
+	return s.reader.Close()
+}
+
+func (s *Stream[T]) checkpoint(ctx context.Context) error {
+	// only checkpoint once every row from an event has been consumed,
+	// since resuming from the event's position replays all of its rows
+	if s.last == nil || len(s.pending) != 0 || s.Checkpoint == nil {
+		return nil
+	}
+
+	pos := *s.last
+	s.last = nil
+
+	if err := s.Checkpoint(ctx, pos); err != nil {
+		return fmt.Errorf("failed to checkpoint binlog position: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Stream[T]) wantsTable(schema, table string) bool {
+	if s.tables == nil {
+		return true
+	}
+
+	if _, ok := s.tables[strings.ToLower(table)]; ok {
+		return true
+	}
+
+	_, ok := s.tables[strings.ToLower(schema+"."+table)]
+	return ok
+}
+
+func (s *Stream[T]) decodeEvent(ev BinlogRowsEvent) ([]StreamEvent[T], error) {
+	columns := make([]string, len(ev.Columns))
+	for i, c := range ev.Columns {
+		columns[i] = strings.ToLower(c)
+	}
+
+	step := 1
+	if ev.Kind == ChangeUpdate {
+		step = 2
+		if len(ev.Rows)%2 != 0 {
+			return nil, fmt.Errorf("update event has an odd number of rows (%d)", len(ev.Rows))
+		}
+	}
+
+	events := make([]StreamEvent[T], 0, len(ev.Rows)/step)
+	for i := 0; i < len(ev.Rows); i += step {
+		se := StreamEvent[T]{
+			Schema:   ev.Schema,
+			Table:    ev.Table,
+			Kind:     ev.Kind,
+			Position: ev.Position,
+		}
+
+		row, err := s.decodeRow(columns, ev.Rows[i])
+		if err != nil {
+			return nil, err
+		}
+
+		switch ev.Kind {
+		case ChangeDelete:
+			se.Before = row
+		case ChangeUpdate:
+			se.Before = row
+			se.After, err = s.decodeRow(columns, ev.Rows[i+1])
+			if err != nil {
+				return nil, err
+			}
+		default:
+			se.After = row
+		}
+
+		events = append(events, se)
+	}
+
+	return events, nil
+}
+
+func (s *Stream[T]) decodeRow(columns []string, values []any) (*T, error) {
+	dest := new(T)
+	ref := reflect.ValueOf(dest).Elem()
+
+	if s.fieldsMap == nil {
+		if len(values) == 0 {
+			return dest, nil
+		}
+		return dest, decodeValue(ref, values[0])
+	}
+
+	for i, c := range columns {
+		if i >= len(values) {
+			break
+		}
+
+		fieldIndex, ok := s.fieldsMap[c]
+		if !ok {
+			continue
+		}
+
+		f := ref.FieldByIndex(fieldIndex)
+		if err := decodeValue(f, values[i]); err != nil {
+			return nil, fmt.Errorf("failed to decode column %q: %w", c, err)
+		}
+	}
+
+	return dest, nil
+}
+
+// decodeValue sets dest from a raw value the same way a scanned column would be
+func decodeValue(dest reflect.Value, v any) error {
+	if v == nil {
+		dest.Set(reflect.Zero(dest.Type()))
+		return nil
+	}
+
+	if isMultiValueElement(dest.Type()) {
+		var j []byte
+		switch v := v.(type) {
+		case []byte:
+			j = v
+		case string:
+			j = []byte(v)
+		default:
+			return fmt.Errorf("unsupported json value type %T", v)
+		}
+
+		return json.Unmarshal(j, dest.Addr().Interface())
+	}
+
+	if dest.Type() == civilDateType {
+		if t, ok := v.(time.Time); ok {
+			dest.Set(reflect.ValueOf(civil.DateOf(t)))
+			return nil
+		}
+	}
+
+	return convertAssignRows(dest.Addr().Interface(), v)
+}
@@ -0,0 +1,96 @@
+package mysql
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"testing"
+)
+
+type testBinlogReader struct {
+	events []BinlogRowsEvent
+}
+
+func (r *testBinlogReader) ReadRowsEvent(ctx context.Context) (BinlogRowsEvent, error) {
+	if len(r.events) == 0 {
+		return BinlogRowsEvent{}, io.EOF
+	}
+
+	ev := r.events[0]
+	r.events = r.events[1:]
+	return ev, nil
+}
+
+func (r *testBinlogReader) Close() error {
+	return nil
+}
+
+func TestStream(t *testing.T) {
+	type user struct {
+		ID    int
+		Name  string `mysql:"UserName"`
+		Tags  []string
+		Email *string
+	}
+
+	reader := &testBinlogReader{events: []BinlogRowsEvent{
+		{
+			Schema: "test", Table: "other", Kind: ChangeInsert,
+			Columns: []string{"ID"},
+			Rows:    [][]any{{int64(9)}},
+		},
+		{
+			Schema: "test", Table: "users", Kind: ChangeInsert,
+			Columns:  []string{"ID", "UserName", "Tags", "Email"},
+			Rows:     [][]any{{int64(1), []byte("Alice"), []byte(`["a","b"]`), nil}},
+			Position: BinlogPosition{File: "binlog.000001", Pos: 100},
+		},
+		{
+			Schema: "test", Table: "users", Kind: ChangeUpdate,
+			Columns: []string{"id", "username", "tags", "email"},
+			Rows: [][]any{
+				{int64(1), "Alice", nil, nil},
+				{int64(1), "Alicia", nil, "alicia@example.com"},
+			},
+			Position: BinlogPosition{File: "binlog.000001", Pos: 200},
+		},
+	}}
+
+	var checkpoints []BinlogPosition
+	s, err := NewStream[user](reader, "test.users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetCheckpoint(func(ctx context.Context, pos BinlogPosition) error {
+		checkpoints = append(checkpoints, pos)
+		return nil
+	})
+
+	ctx := context.Background()
+
+	ev, err := s.Next(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := user{ID: 1, Name: "Alice", Tags: []string{"a", "b"}}
+	if ev.Kind != ChangeInsert || ev.Before != nil || !reflect.DeepEqual(*ev.After, want) {
+		t.Errorf("Next() = %+v, want insert of %+v", ev, want)
+	}
+
+	ev, err = s.Next(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Kind != ChangeUpdate || ev.Before.Name != "Alice" || ev.After.Name != "Alicia" || *ev.After.Email != "alicia@example.com" {
+		t.Errorf("Next() = %+v, want update from Alice to Alicia", ev)
+	}
+
+	if _, err = s.Next(ctx); err != io.EOF {
+		t.Errorf("Next() error = %v, want %v", err, io.EOF)
+	}
+
+	wantCheckpoints := []BinlogPosition{{File: "binlog.000001", Pos: 100}, {File: "binlog.000001", Pos: 200}}
+	if !reflect.DeepEqual(checkpoints, wantCheckpoints) {
+		t.Errorf("checkpoints = %v, want %v", checkpoints, wantCheckpoints)
+	}
+}
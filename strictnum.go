@@ -0,0 +1,198 @@
+package mysql
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/shopspring/decimal"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// DecimalMode is how DECIMAL columns are scanned into MapRows and SliceRows,
+// and how numbers in json are unmarshaled into interfaces
+type DecimalMode int
+
+const (
+	// DecimalBytes leaves decimals as the bytes the driver returns,
+	// and numbers in json as float64
+	DecimalBytes DecimalMode = iota
+
+	// DecimalAsDecimal scans decimals as decimal.Decimal,
+	// and keeps numbers in json as json.Number
+	DecimalAsDecimal
+
+	// DecimalAsJSONNumber scans decimals as json.Number, so they're
+	// marshaled back to json as the same numbers, and keeps numbers in json as json.Number
+	DecimalAsJSONNumber
+)
+
+// SetDecimalMode sets how decimals are scanned into MapRows and SliceRows, see DecimalMode
+func (db *Database) SetDecimalMode(mode DecimalMode) *Database {
+	db.DecimalMode = mode
+	return db
+}
+
+// SetStrictUint64 sets whether unsigned bigints keep their full precision everywhere they're selected,
+// see StrictUint64
+func (db *Database) SetStrictUint64(strict bool) *Database {
+	db.StrictUint64 = strict
+	return db
+}
+
+// columnConverter converts the value of a column scanned into a MapRow or SliceRow
+type columnConverter func(v any) (any, error)
+
+// columnConverters returns the converters of the columns of dynamic rows, by their index,
+// or nil if none of the columns need converting
+func (db *Database) columnConverters(rows *sql.Rows) ([]columnConverter, error) {
+	if !db.StrictUint64 && db.DecimalMode == DecimalBytes {
+		return nil, nil
+	}
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get column types: %w", err)
+	}
+
+	var converters []columnConverter
+	for i, t := range types {
+		var conv columnConverter
+		switch t.DatabaseTypeName() {
+		case "UNSIGNED BIGINT":
+			if db.StrictUint64 {
+				conv = convertUint64
+			}
+		case "DECIMAL":
+			switch db.DecimalMode {
+			case DecimalAsDecimal:
+				conv = convertDecimal
+			case DecimalAsJSONNumber:
+				conv = convertJSONNumber
+			}
+		}
+
+		if conv != nil {
+			if converters == nil {
+				converters = make([]columnConverter, len(types))
+			}
+			converters[i] = conv
+		}
+	}
+
+	return converters, nil
+}
+
+// convertUint64 converts an unsigned bigint from the driver to a uint64,
+// since the driver returns them as bytes, or as strings when they don't fit in an int64
+func convertUint64(v any) (any, error) {
+	var s string
+	switch v := v.(type) {
+	case nil, uint64:
+		return v, nil
+	case int64:
+		return uint64(v), nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return v, nil
+	}
+
+	u, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse unsigned bigint %q: %w", s, err)
+	}
+
+	return u, nil
+}
+
+// convertDecimal converts a decimal from the driver to a decimal.Decimal
+func convertDecimal(v any) (any, error) {
+	switch v := v.(type) {
+	case []byte:
+		d, err := decimal.NewFromString(string(v))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse decimal %q: %w", v, err)
+		}
+		return d, nil
+	case string:
+		d, err := decimal.NewFromString(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse decimal %q: %w", v, err)
+		}
+		return d, nil
+	}
+
+	return v, nil
+}
+
+// convertJSONNumber converts a decimal from the driver to a json.Number
+func convertJSONNumber(v any) (any, error) {
+	switch v := v.(type) {
+	case []byte:
+		return json.Number(v), nil
+	case string:
+		return json.Number(v), nil
+	}
+
+	return v, nil
+}
+
+// decimals and json numbers are encoded as msgpack extensions, so they're decoded
+// from the cache as the same types in MapRows and SliceRows, instead of as bytes and strings
+const (
+	msgpackDecimalExtID    = 67
+	msgpackJSONNumberExtID = 68
+)
+
+func init() {
+	msgpack.RegisterExtEncoder(msgpackDecimalExtID, decimal.Decimal{}, func(e *msgpack.Encoder, v reflect.Value) ([]byte, error) {
+		return v.Interface().(decimal.Decimal).MarshalBinary()
+	})
+	msgpack.RegisterExtDecoder(msgpackDecimalExtID, decimal.Decimal{}, func(d *msgpack.Decoder, v reflect.Value, extLen int) error {
+		b := make([]byte, extLen)
+		if err := d.ReadFull(b); err != nil {
+			return err
+		}
+		return v.Addr().Interface().(*decimal.Decimal).UnmarshalBinary(b)
+	})
+
+	msgpack.RegisterExtEncoder(msgpackJSONNumberExtID, json.Number(""), func(e *msgpack.Encoder, v reflect.Value) ([]byte, error) {
+		return []byte(v.String()), nil
+	})
+	msgpack.RegisterExtDecoder(msgpackJSONNumberExtID, json.Number(""), func(d *msgpack.Decoder, v reflect.Value, extLen int) error {
+		b := make([]byte, extLen)
+		if err := d.ReadFull(b); err != nil {
+			return err
+		}
+		v.SetString(string(b))
+		return nil
+	})
+}
+
+// unmarshalJSON unmarshals the json into v, keeping numbers as json.Number instead of
+// float64 in interfaces if the database is strict about their precision or has a decimal mode
+func (db *Database) unmarshalJSON(data []byte, v any) error {
+	if !db.StrictUint64 && db.DecimalMode == DecimalBytes {
+		return json.Unmarshal(data, v)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+
+	// json.Unmarshal fails on anything after the value, so this does too
+	if dec.More() {
+		return errors.New("cool-mysql: unexpected data after json value")
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package mysql
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func Test_convertUint64(t *testing.T) {
+	tests := []struct {
+		name    string
+		v       any
+		want    any
+		wantErr bool
+	}{
+		{"null", nil, nil, false},
+		{"bytes", []byte("18446744073709551615"), uint64(18446744073709551615), false},
+		{"string", "9007199254740993", uint64(9007199254740993), false},
+		{"int64", int64(42), uint64(42), false},
+		{"invalid", []byte("-1"), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertUint64(tt.v)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("convertUint64() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("convertUint64() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDatabase_unmarshalJSON(t *testing.T) {
+	db := new(Database).SetStrictUint64(true)
+
+	var v map[string]any
+	if err := db.unmarshalJSON([]byte(`{"ID":18446744073709551615}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if got := v["ID"]; got != json.Number("18446744073709551615") {
+		t.Errorf("unmarshalJSON() ID = %#v, want the exact json.Number", got)
+	}
+	if got := Uint64(v["ID"]); got != 18446744073709551615 {
+		t.Errorf("Uint64() = %d, want 18446744073709551615", got)
+	}
+
+	if err := db.unmarshalJSON([]byte(`{} {}`), &v); err == nil {
+		t.Error("unmarshalJSON() with trailing data should fail")
+	}
+
+	if got := Uint64(1e19); got != 10000000000000000000 {
+		t.Errorf("Uint64() of a float64 = %d, want 10000000000000000000", got)
+	}
+}
+
+func Test_decimalMsgpack(t *testing.T) {
+	row := MapRow{
+		"Amount": decimal.RequireFromString("12.50"),
+		"Total":  json.Number("1234567890.123456789"),
+	}
+
+	b, err := msgpack.Marshal(row)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got MapRow
+	if err := msgpack.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if d, ok := got["Amount"].(decimal.Decimal); !ok || d.String() != "12.5" {
+		t.Errorf("decoded Amount = %#v, want a decimal.Decimal", got["Amount"])
+	}
+	if n, ok := got["Total"].(json.Number); !ok || n != "1234567890.123456789" {
+		t.Errorf("decoded Total = %#v, want a json.Number", got["Total"])
+	}
+
+	if got, _ := convertJSONNumber([]byte("0.10")); got != json.Number("0.10") {
+		t.Errorf("convertJSONNumber() = %#v, want json.Number(\"0.10\")", got)
+	}
+}
@@ -1,6 +1,9 @@
 package mysql
 
-import "sync"
+import (
+	"context"
+	"sync"
+)
 
 type synct[T any] struct {
 	mx sync.RWMutex
@@ -20,3 +23,58 @@ func (s *synct[T]) Set(v T) {
 
 	s.v = v
 }
+
+// lazyOnce runs a detection of the server the first time it's needed instead of when connecting,
+// and again after it's reset, like when the database reconnects
+type lazyOnce struct {
+	// run is held while the detection runs, so other callers wait for it
+	run     sync.Mutex
+	mx      sync.Mutex
+	done    bool
+	stopped bool
+}
+
+// do runs fn with ctx if it hasn't succeeded since the last reset, waiting for it if it's
+// already running. Calls with the ctx that fn was given return right away, so fn's own
+// queries can need what it detects without running it again.
+func (o *lazyOnce) do(ctx context.Context, fn func(ctx context.Context) error) {
+	if ctx.Value(o) != nil || o.finished() {
+		return
+	}
+
+	o.run.Lock()
+	defer o.run.Unlock()
+
+	// it may have finished while this waited
+	if o.finished() {
+		return
+	}
+
+	if fn(context.WithValue(ctx, o, true)) == nil {
+		o.mx.Lock()
+		o.done = true
+		o.mx.Unlock()
+	}
+}
+
+// finished returns true if do shouldn't run again
+func (o *lazyOnce) finished() bool {
+	o.mx.Lock()
+	defer o.mx.Unlock()
+
+	return o.done || o.stopped
+}
+
+// reset makes the next do run again
+func (o *lazyOnce) reset() {
+	o.mx.Lock()
+	o.done = false
+	o.mx.Unlock()
+}
+
+// stop keeps do from ever running again, even after a reset
+func (o *lazyOnce) stop() {
+	o.mx.Lock()
+	o.stopped = true
+	o.mx.Unlock()
+}
@@ -2,8 +2,30 @@ package mysql
 
 import (
 	"fmt"
+	"strings"
+
+	"github.com/fatih/structtag"
 )
 
+// tagOption returns the value of the tag's `name=value` option, or an empty value
+// for just `name`, and whether the tag has the option at all
+func tagOption(tag *structtag.Tag, name string) (string, bool) {
+	if tag == nil {
+		return "", false
+	}
+
+	for _, o := range tag.Options {
+		if o == name {
+			return "", true
+		}
+		if strings.HasPrefix(o, name) && len(o) > len(name) && o[len(name)] == '=' {
+			return o[len(name)+1:], true
+		}
+	}
+
+	return "", false
+}
+
 func decodeHex(s string) (string, error) {
 	var result []byte
 	for i := 0; i < len(s); i++ {
@@ -0,0 +1,100 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// WithTempTable creates a temporary table on a connection checked out of the writes pool,
+// and calls fn with the connection, since it's the only one that can see the table,
+// so fn can insert rows into it and join it in its selects.
+// The ddl is the create table statement, like "create temporary table`ids`(`ID`int primary key)",
+// which is made temporary if it isn't, so it can't be left behind.
+//
+// The table is dropped when fn returns, even if it fails or panics, and the connection
+// is returned to the pool. If the table can't be dropped, the connection is closed instead,
+// which drops it with the rest of the session.
+func (db *Database) WithTempTable(ctx context.Context, ddl string, fn func(conn *Conn) error) (err error) {
+	ddl, table, err := tempTableDDL(ddl)
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		dropErr := conn.ExecContext(ctx, "drop temporary table if exists "+table)
+		if dropErr != nil {
+			// a connection is discarded instead of returned to the pool if it's bad
+			conn.Conn.Raw(func(any) error {
+				return driver.ErrBadConn
+			})
+			if err == nil {
+				err = fmt.Errorf("failed to drop temporary table %s: %w", table, dropErr)
+			}
+		}
+
+		conn.Close()
+	}()
+
+	if err := conn.ExecContext(ctx, ddl); err != nil {
+		return fmt.Errorf("failed to create temporary table %s: %w", table, err)
+	}
+
+	return fn(conn)
+}
+
+// tempTableDDL returns the create table statement, made temporary, and the name of its table, as it's written
+func tempTableDDL(ddl string) (string, string, error) {
+	var queryTokens []queryToken
+	for _, t := range parseQuery(ddl) {
+		if t.kind != queryTokenKindMisc || strings.TrimSpace(t.string) != "" {
+			queryTokens = append(queryTokens, t)
+		}
+	}
+
+	is := func(i int, word string) bool {
+		return i < len(queryTokens) && queryTokens[i].kind == queryTokenKindWord && strings.EqualFold(queryTokens[i].string, word)
+	}
+
+	// the words before the name are "create [temporary] table [if not exists]"
+	i := 1
+	temporary := is(i, "temporary")
+	if temporary {
+		i++
+	}
+	if !is(0, "create") || !is(i, "table") {
+		return "", "", errors.New("cool-mysql: temporary table ddl isn't a create table statement")
+	}
+	i++
+	if is(i, "if") && is(i+1, "not") && is(i+2, "exists") {
+		i += 3
+	}
+
+	// the name can be qualified by its schema, like `db`.`table`
+	var table string
+	for ; i < len(queryTokens); i += 2 {
+		if t := queryTokens[i]; t.kind == queryTokenKindWord || t.kind == queryTokenKindString {
+			table += t.string
+		}
+		if i+1 >= len(queryTokens) || queryTokens[i+1].string != "." {
+			break
+		}
+		table += "."
+	}
+	if len(table) == 0 {
+		return "", "", ErrNoTableName
+	}
+
+	if !temporary {
+		create := queryTokens[0]
+		ddl = ddl[:create.end+1] + " temporary" + ddl[create.end+1:]
+	}
+
+	return ddl, table, nil
+}
@@ -0,0 +1,62 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDatabase_WithTempTable(t *testing.T) {
+	tests := []struct {
+		name      string
+		ddl       string
+		wantDDL   string
+		wantTable string
+		wantErr   bool
+	}{
+		{"temporary", "create temporary table`ids`(`ID`int)", "create temporary table`ids`(`ID`int)", "`ids`", false},
+		{"made temporary", "CREATE TABLE IF NOT EXISTS `etl`.`ids` LIKE `ids`", "CREATE temporary TABLE IF NOT EXISTS `etl`.`ids` LIKE `ids`", "`etl`.`ids`", false},
+		{"unquoted", "create table ids (ID int)", "create temporary table ids (ID int)", "ids", false},
+		{"not create table", "select*from`ids`", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ddl, table, err := tempTableDDL(tt.ddl)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("tempTableDDL() error = %v, wantErr %t", err, tt.wantErr)
+			}
+			if ddl != tt.wantDDL || table != tt.wantTable {
+				t.Errorf("tempTableDDL() = %q, %q, want %q, %q", ddl, table, tt.wantDDL, tt.wantTable)
+			}
+		})
+	}
+
+	db := benchDatabase(t)
+	db.Writes.SetMaxOpenConns(1)
+
+	var queries []string
+	db.Log = func(detail LogDetail) {
+		queries = append(queries, detail.Query)
+	}
+
+	errFn := errors.New("fn failed")
+	err := db.WithTempTable(context.Background(), "create temporary table`ids`(`ID`int)", func(conn *Conn) error {
+		if err := conn.Insert("ids", []struct{ ID int }{{1}}); err != nil {
+			return err
+		}
+		return errFn
+	})
+	if !errors.Is(err, errFn) {
+		t.Errorf("WithTempTable() = %v, want the error of fn", err)
+	}
+
+	want := []string{
+		"create temporary table`ids`(`ID`int)",
+		"insert into`ids`(`ID`)values(1)",
+		"drop temporary table if exists `ids`",
+	}
+	if !reflect.DeepEqual(queries, want) {
+		t.Errorf("WithTempTable() ran %q, want %q", queries, want)
+	}
+}
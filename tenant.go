@@ -0,0 +1,176 @@
+package mysql
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/fatih/structtag"
+)
+
+// ErrNoTenant is returned when a query uses a struct with a tenant field,
+// but there's no tenant in the context
+var ErrNoTenant = errors.New("cool-mysql: no tenant in context for tenant scoped struct")
+
+// ErrTenantMismatch is returned when a row belongs to a different tenant than the one in the context
+var ErrTenantMismatch = errors.New("cool-mysql: row belongs to a different tenant")
+
+// ErrTenantUnscoped is returned when a query in a tenant scoped context has to use
+// the `@@Tenant` param, but doesn't, see WithTenant
+var ErrTenantUnscoped = errors.New("cool-mysql: query in a tenant scoped context doesn't use @@Tenant")
+
+var tenantKey = key(4)
+
+// WithTenant returns a new context.Context scoped to the given tenant.
+// Structs with a field tagged like `mysql:"CompanyID,tenant"` can only be selected or
+// inserted with a tenant scoped context, where inserts populate the tenant column and
+// selected rows are checked against the tenant. The tenant is also available
+// to every query as the `@@Tenant` param.
+//
+// Since the tenant can't be added to the where clauses of hand written queries, they're refused
+// with ErrTenantUnscoped unless they use `@@Tenant`, if they're updates, deletes, or selects of
+// tenant scoped structs. Queries of tables that aren't scoped to tenants can opt out with AllowAllTenants.
+// Repositories of tenant scoped structs and upserts of them add the tenant to their where clauses themselves.
+func WithTenant(ctx context.Context, tenant any) context.Context {
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+var allTenantsKey = key(13)
+
+// AllowAllTenants returns a new context.Context whose queries don't have to
+// use `@@Tenant` in a tenant scoped context, like ones of tables shared by every tenant
+func AllowAllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allTenantsKey, true)
+}
+
+// allTenantsAllowed returns true if the context's queries don't have to use `@@Tenant`
+func allTenantsAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(allTenantsKey).(bool)
+	return allowed
+}
+
+// checkTenantScope returns ErrTenantUnscoped if the context has a tenant and the query has to use
+// `@@Tenant`, because it's an update or delete, or selects rows of a tenant scoped struct, but doesn't
+func checkTenantScope(ctx context.Context, query string, scopedDest bool) error {
+	if _, ok := TenantFromContext(ctx); !ok || allTenantsAllowed(ctx) {
+		return nil
+	}
+
+	if !scopedDest {
+		if verb, _ := updateOrDeleteWhere(query); len(verb) == 0 {
+			return nil
+		}
+	}
+
+	for _, t := range parseQuery(query) {
+		if t.kind == queryTokenKindParam && strings.EqualFold(t.string[2:], "Tenant") {
+			return nil
+		}
+	}
+
+	return ErrTenantUnscoped
+}
+
+// TenantFromContext returns the tenant from a context.Context,
+// or false if none is present.
+func TenantFromContext(ctx context.Context) (tenant any, ok bool) {
+	tenant = ctx.Value(tenantKey)
+	return tenant, tenant != nil
+}
+
+type tenantField struct {
+	index  []int
+	column string
+}
+
+// tenantFieldFromStruct returns the field tagged as the tenant column of the struct, if any
+func tenantFieldFromStruct(t reflect.Type) (*tenantField, error) {
+	t = reflectUnwrapType(t)
+	if t.Kind() != reflect.Struct || !isMultiValueElement(t) {
+		return nil, nil
+	}
+
+	for _, i := range StructFieldIndexes(t) {
+		f := t.FieldByIndex(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		tags, err := structtag.Parse(string(f.Tag))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse struct tag %q: %w", f.Tag, err)
+		}
+
+		mysqlTag, _ := tags.Get("mysql")
+		if mysqlTag == nil || !mysqlTag.HasOption("tenant") {
+			continue
+		}
+
+		column := f.Name
+		if len(mysqlTag.Name) != 0 && mysqlTag.Name != "-" {
+			column, err = decodeHex(mysqlTag.Name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode hex in struct tag name %q: %w", mysqlTag.Name, err)
+			}
+		}
+
+		return &tenantField{index: i, column: column}, nil
+	}
+
+	return nil, nil
+}
+
+// tenantParams adds the tenant from the context to the params, so that
+// every query can use `@@Tenant`. It's added last so it can't be overridden.
+func tenantParams(ctx context.Context, params []any) []any {
+	tenant, ok := TenantFromContext(ctx)
+	if !ok {
+		return params
+	}
+
+	return append(append(make([]any, 0, len(params)+1), params...), Params{"Tenant": tenant})
+}
+
+// sameTenant compares tenants by their marshaled values, so that
+// different numeric types with the same value are equal
+func sameTenant(a, b any) bool {
+	ab, err := marshal(a, 0, "", nil)
+	if err != nil {
+		return false
+	}
+
+	bb, err := marshal(b, 0, "", nil)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(ab, bb)
+}
+
+// selected returns true if the tenant column is one of the columns
+func (f *tenantField) selected(columns []string) bool {
+	for _, c := range columns {
+		if strings.EqualFold(c, f.column) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkRow returns an error if the row doesn't belong to the tenant
+func (f *tenantField) checkRow(row reflect.Value, tenant any) error {
+	row = reflect.Indirect(row)
+	if !row.IsValid() {
+		return nil
+	}
+
+	if v := row.FieldByIndex(f.index).Interface(); !sameTenant(v, tenant) {
+		return fmt.Errorf("%w: got %v, want %v", ErrTenantMismatch, v, tenant)
+	}
+
+	return nil
+}
@@ -0,0 +1,208 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/StirlingMarketingGroup/cool-mysql/internal/testdriver"
+	stdMysql "github.com/go-sql-driver/mysql"
+)
+
+type tenantUser struct {
+	ID        int
+	CompanyID int `mysql:"CompanyID,tenant"`
+	Name      string
+}
+
+func Test_tenantFieldFromStruct(t *testing.T) {
+	tf, err := tenantFieldFromStruct(reflect.TypeOf(tenantUser{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tf == nil || tf.column != "CompanyID" || !reflect.DeepEqual(tf.index, []int{1}) {
+		t.Errorf("tenantFieldFromStruct() = %+v, want the CompanyID field", tf)
+	}
+
+	if tf, err := tenantFieldFromStruct(reflect.TypeOf(changeUser{})); err != nil || tf != nil {
+		t.Errorf("tenantFieldFromStruct() of a struct without a tenant = %+v, %v", tf, err)
+	}
+}
+
+func Test_checkTenantScope(t *testing.T) {
+	tenant := WithTenant(context.Background(), 7)
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		query      string
+		scopedDest bool
+		want       error
+	}{
+		{"no tenant", context.Background(), "delete from`users`", false, nil},
+		{"select", tenant, "select*from`users`", false, nil},
+		{"scoped select", tenant, "select*from`users`", true, ErrTenantUnscoped},
+		{"scoped select with tenant", tenant, "select*from`users`where`CompanyID`=@@tenant", true, nil},
+		{"update", tenant, "update`users`set`Name`='a'where`ID`=1", false, ErrTenantUnscoped},
+		{"update with tenant", tenant, "update`users`set`Name`='a'where`CompanyID`=@@Tenant", false, nil},
+		{"delete", tenant, "delete from`users`where`ID`=@@ID", false, ErrTenantUnscoped},
+		{"tenant in a string", tenant, "delete from`users`where`Name`='@@Tenant'", false, ErrTenantUnscoped},
+		{"insert", tenant, "insert into`users`(`ID`)values(1)", false, nil},
+		{"all tenants", AllowAllTenants(tenant), "delete from`settings`", false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkTenantScope(tt.ctx, tt.query, tt.scopedDest); err != tt.want {
+				t.Errorf("checkTenantScope() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDatabase_tenantScope(t *testing.T) {
+	var rows [][]driver.Value
+	db := testDatabase(t, &testdriver.Driver{
+		QueryFunc: func(ctx context.Context, query string) (driver.Rows, error) {
+			return testdriver.NewRows([]string{"ID", "CompanyID", "Name"}, rows...), nil
+		},
+	})
+
+	var queries []string
+	db.Log = func(detail LogDetail) {
+		queries = append(queries, detail.Query)
+	}
+
+	ctx := WithTenant(context.Background(), 7)
+	rows = [][]driver.Value{{int64(1), int64(7), []byte("Ann")}}
+
+	var users []tenantUser
+	if err := db.SelectContext(context.Background(), &users, "select*from`users`where`CompanyID`=@@Tenant", 0); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("SelectContext() without a tenant error = %v, want ErrNoTenant", err)
+	}
+	if err := db.SelectContext(ctx, &users, "select*from`users`", 0); !errors.Is(err, ErrTenantUnscoped) {
+		t.Errorf("SelectContext() without @@Tenant error = %v, want ErrTenantUnscoped", err)
+	}
+	if err := db.SelectContext(ctx, &users, "select*from`users`where`CompanyID`=@@Tenant", 0); err != nil {
+		t.Fatal(err)
+	}
+	if want := []tenantUser{{1, 7, "Ann"}}; !reflect.DeepEqual(users, want) {
+		t.Errorf("SelectContext() = %v, want %v", users, want)
+	}
+
+	rows = [][]driver.Value{{int64(2), int64(8), []byte("Bob")}}
+	if err := db.SelectContext(ctx, &users, "select*from`users`where`CompanyID`=@@Tenant", 0); !errors.Is(err, ErrTenantMismatch) {
+		t.Errorf("SelectContext() of another tenant's row error = %v, want ErrTenantMismatch", err)
+	}
+
+	queries = nil
+	if err := db.ExecContext(ctx, "delete from`users`where`ID`=1"); !errors.Is(err, ErrTenantUnscoped) {
+		t.Errorf("ExecContext() without @@Tenant error = %v, want ErrTenantUnscoped", err)
+	}
+	if err := db.ExecContext(ctx, "delete from`users`where`ID`=1 and`CompanyID`=@@Tenant"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.ExecContext(AllowAllTenants(ctx), "delete from`settings`where`ID`=1"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"delete from`users`where`ID`=1 and`CompanyID`=7", "delete from`settings`where`ID`=1"}; !reflect.DeepEqual(queries, want) {
+		t.Errorf("ExecContext() ran %q, want %q", queries, want)
+	}
+}
+
+func TestRepository_tenant(t *testing.T) {
+	db := testDatabase(t, &testdriver.Driver{
+		QueryFunc: func(ctx context.Context, query string) (driver.Rows, error) {
+			return testdriver.NewRows([]string{"ID", "CompanyID", "Name"}, []driver.Value{int64(1), int64(7), []byte("Ann")}), nil
+		},
+	})
+
+	var queries []string
+	db.Log = func(detail LogDetail) {
+		queries = append(queries, detail.Query)
+	}
+
+	users := Repo[tenantUser](db, "users")
+	ctx := WithTenant(context.Background(), 7)
+
+	if _, err := users.Get(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.List(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.List(ctx, Params{"Name": "Ann"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.Update(ctx, tenantUser{ID: 1, Name: "Amy"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.Delete(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"select`ID`,`CompanyID`,`Name`from`users`where `ID`=1 and`CompanyID`<=>7 limit 1",
+		"select`ID`,`CompanyID`,`Name`from`users`where`CompanyID`<=>7",
+		"select`ID`,`CompanyID`,`Name`from`users`where`CompanyID`<=>7 and`Name`<=>_utf8mb4 0x416e6e collate utf8mb4_unicode_ci",
+		// the tenant column is never updated
+		"update`users`set`Name`=_utf8mb4 0x416d79 collate utf8mb4_unicode_ci where `ID`=1 and`CompanyID`<=>7",
+		"delete from`users`where `ID`=1 and`CompanyID`<=>7",
+	}
+	if !reflect.DeepEqual(queries, want) {
+		t.Errorf("Repository ran %q, want %q", queries, want)
+	}
+
+	if _, err := users.Get(context.Background(), 1); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("Get() without a tenant error = %v, want ErrNoTenant", err)
+	}
+	if err := users.Delete(context.Background(), 1); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("Delete() without a tenant error = %v, want ErrNoTenant", err)
+	}
+}
+
+func TestTx_tenantDeadlockReplay(t *testing.T) {
+	var execs []string
+	var deadlocked bool
+	db := testDatabase(t, &testdriver.Driver{
+		ExecFunc: func(ctx context.Context, query string) (driver.Result, error) {
+			execs = append(execs, query)
+			if strings.HasPrefix(query, "update`b`") && !deadlocked {
+				deadlocked = true
+				return nil, &stdMysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+			}
+			return driver.RowsAffected(1), nil
+		},
+		Tx: true,
+	})
+
+	ctx := WithTenant(context.Background(), 7)
+	tx, cancel, err := db.BeginTxContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+
+	if err := tx.ExecContext(ctx, "update`a`set`N`=1 where`CompanyID`=@@Tenant"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.ExecContext(ctx, "update`b`set`N`=1 where`CompanyID`=@@Tenant"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the whole transaction was rolled back by the deadlock, so it's all run again
+	want := []string{
+		"update`a`set`N`=1 where`CompanyID`=7",
+		"update`b`set`N`=1 where`CompanyID`=7",
+		"update`a`set`N`=1 where`CompanyID`=7",
+		"update`b`set`N`=1 where`CompanyID`=7",
+	}
+	if !reflect.DeepEqual(execs, want) {
+		t.Errorf("the driver ran %q, want %q", execs, want)
+	}
+}
@@ -0,0 +1,136 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// ErrRetryDeadline is matched by the errors of queries that stopped being retried before MaxExecutionTime,
+// because their next attempt couldn't have finished before their context's deadline
+var ErrRetryDeadline = errors.New("cool-mysql: no time left before the context's deadline to retry")
+
+// RetryDeadlineError is the error of a query that stopped being retried early, see ErrRetryDeadline.
+// It wraps the error of the query's last attempt.
+type RetryDeadlineError struct {
+	Err error
+
+	// Remaining is how long there was until the context's deadline when the query stopped
+	Remaining time.Duration
+}
+
+func (e RetryDeadlineError) Error() string {
+	return fmt.Sprintf("%s, with %s left: %s", ErrRetryDeadline, e.Remaining, e.Err)
+}
+
+func (e RetryDeadlineError) Unwrap() error {
+	return e.Err
+}
+
+func (e RetryDeadlineError) Is(target error) bool {
+	return target == ErrRetryDeadline
+}
+
+// Timeouts limit how long queries can run, separately from MaxExecutionTime,
+// which only limits how long failed queries keep being retried
+type Timeouts struct {
+	// Attempt cancels each attempt of a query after this long, and retries it if MaxExecutionTime
+	// hasn't passed, so one hung attempt can't use up the whole retry budget. Selects are limited
+	// while their rows are read too, but aren't retried once rows were read. Attempts in a
+	// transaction aren't retried, since canceling them breaks the transaction's connection.
+	// 0 means no limit.
+	Attempt time.Duration
+
+	// Total cancels a query after this long, across all of its attempts and the waits between them,
+	// where MaxExecutionTime only stops new attempts from being started. 0 means no limit.
+	Total time.Duration
+}
+
+// SetTimeouts sets the timeouts of every query
+func (db *Database) SetTimeouts(timeouts Timeouts) *Database {
+	db.Timeouts = timeouts
+	return db
+}
+
+// totalContext returns the context of a whole query, limited by the total timeout
+func (db *Database) totalContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if db.Timeouts.Total <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, db.Timeouts.Total)
+}
+
+// attemptContext returns the context of one attempt of a query, limited by the attempt timeout
+func (db *Database) attemptContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if db.Timeouts.Attempt <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, db.Timeouts.Attempt)
+}
+
+// retryTimedOutAttempt returns true if the attempt was canceled by its own timeout,
+// and not by the query's context, so it can be tried again
+func retryTimedOutAttempt(conn handlerWithContext, attemptCtx, ctx context.Context) bool {
+	if _, ok := conn.(*sql.Tx); ok {
+		return false
+	}
+
+	return attemptCtx.Err() != nil && ctx.Err() == nil
+}
+
+// deadlineBackOff stops retrying a query once its next attempt couldn't finish before its context's deadline,
+// assuming it takes as long as its last one, instead of waiting for an attempt the caller would cancel anyway.
+// Each attempt is already limited by the deadline, since its context comes from the query's.
+type deadlineBackOff struct {
+	backoff.BackOff
+
+	ctx          context.Context
+	attemptStart time.Time
+
+	stopped   bool
+	remaining time.Duration
+}
+
+// retryBackOff returns the backoff of a query's retries, which stop after MaxExecutionTime,
+// or earlier if the context's deadline is closer, see deadlineBackOff
+func (db *Database) retryBackOff(ctx context.Context) *deadlineBackOff {
+	b := backoff.NewExponentialBackOff()
+	b.MaxElapsedTime = db.maxExecutionTime()
+
+	return &deadlineBackOff{BackOff: b, ctx: ctx, attemptStart: time.Now()}
+}
+
+func (b *deadlineBackOff) Reset() {
+	b.BackOff.Reset()
+	b.attemptStart = time.Now()
+	b.stopped = false
+}
+
+func (b *deadlineBackOff) NextBackOff() time.Duration {
+	next := b.BackOff.NextBackOff()
+	now := time.Now()
+
+	deadline, ok := b.ctx.Deadline()
+	if next != backoff.Stop && ok && now.Add(next+now.Sub(b.attemptStart)).After(deadline) {
+		b.stopped, b.remaining = true, deadline.Sub(now)
+		return backoff.Stop
+	}
+
+	b.attemptStart = now.Add(next)
+	return next
+}
+
+// err returns the error of the query's retries, as a RetryDeadlineError if they were stopped by the deadline
+func (b *deadlineBackOff) err(err error) error {
+	if err == nil || !b.stopped || b.ctx.Err() != nil {
+		return err
+	}
+
+	return RetryDeadlineError{Err: err, Remaining: b.remaining}
+}
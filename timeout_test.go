@@ -0,0 +1,112 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/StirlingMarketingGroup/cool-mysql/internal/testdriver"
+	"github.com/cenkalti/backoff/v4"
+)
+
+// hangingDriver returns a driver whose first attempt of every query hangs until it's canceled,
+// and whose attempts are counted in attempts
+func hangingDriver(attempts *int32) *testdriver.Driver {
+	hang := func(ctx context.Context) error {
+		if atomic.AddInt32(attempts, 1)%2 == 1 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}
+
+	d := &testdriver.Driver{Columns: []string{"ID"}, Row: []driver.Value{int64(1)}, Rows: 1}
+	d.ExecFunc = func(ctx context.Context, query string) (driver.Result, error) {
+		if err := hang(ctx); err != nil {
+			return nil, err
+		}
+		return driver.RowsAffected(1), nil
+	}
+	d.QueryFunc = func(ctx context.Context, query string) (driver.Rows, error) {
+		if err := hang(ctx); err != nil {
+			return nil, err
+		}
+		return testdriver.NewRows(d.Columns, d.Row), nil
+	}
+
+	return d
+}
+
+func TestTimeouts(t *testing.T) {
+	var attempts int32
+	db := testDatabase(t, hangingDriver(&attempts))
+
+	db.SetTimeouts(Timeouts{Attempt: 50 * time.Millisecond})
+
+	var id int
+	if err := db.Select(&id, "select`ID`from`Rows`", 0); err != nil {
+		t.Fatalf("timed out select wasn't retried: %v", err)
+	}
+	if id != 1 {
+		t.Errorf("got %d, want 1", id)
+	}
+
+	if err := db.Exec("update`Rows`set`ID`=2"); err != nil {
+		t.Fatalf("timed out exec wasn't retried: %v", err)
+	}
+
+	db.SetNoRetryAfterSend(true)
+	if err := db.Exec("update`Rows`set`ID`=2"); !errors.Is(err, ErrAmbiguousWrite) {
+		t.Errorf("timed out exec error = %v, want ErrAmbiguousWrite", err)
+	}
+	db.SetNoRetryAfterSend(false)
+
+	atomic.StoreInt32(&attempts, 0)
+	db.SetTimeouts(Timeouts{Total: 50 * time.Millisecond})
+	if err := db.Exec("update`Rows`set`ID`=2"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("exec past total timeout error = %v, want context.DeadlineExceeded", err)
+	}
+
+	atomic.StoreInt32(&attempts, 0)
+	if _, err := db.Exists("select`ID`from`Rows`", 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("exists past total timeout error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestDatabase_retryBackOff(t *testing.T) {
+	db := benchDatabase(t)
+	errAttempt := errors.New("attempt failed")
+
+	b := db.retryBackOff(context.Background())
+	b.Reset()
+	if next := b.NextBackOff(); next == backoff.Stop {
+		t.Error("NextBackOff() without a deadline stopped")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	b = db.retryBackOff(ctx)
+	b.Reset()
+	if next := b.NextBackOff(); next == backoff.Stop {
+		t.Error("NextBackOff() with plenty of time left stopped")
+	}
+	if err := b.err(errAttempt); err != errAttempt {
+		t.Errorf("err() = %v, want the attempt's error", err)
+	}
+
+	// the last attempt took almost as long as there's time left
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	b = db.retryBackOff(ctx)
+	b.Reset()
+	b.attemptStart = time.Now().Add(-90 * time.Millisecond)
+	if next := b.NextBackOff(); next != backoff.Stop {
+		t.Errorf("NextBackOff() = %s, want it to stop before the deadline", next)
+	}
+	if err := b.err(errAttempt); !errors.Is(err, ErrRetryDeadline) || !errors.Is(err, errAttempt) {
+		t.Errorf("err() = %v, want ErrRetryDeadline wrapping the attempt's error", err)
+	}
+}
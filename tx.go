@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,29 +18,46 @@ type Tx struct {
 	Tx   *sql.Tx
 	Time time.Time
 
+	// ID uniquely identifies the transaction for the life of the process
+	ID uint64
+
 	updates *struct {
 		sync.RWMutex
 		queries []string
 	}
 
+	changes *txChanges
+
+	shadowWrites *txShadowWrites
+
 	PostCommitHooks []func() error
 }
 
 type txCancelFunc func() error
 
+var lastTxID uint64
+
 func (db *Database) beginTx(conn *sql.DB, ctx context.Context) (*Tx, txCancelFunc, error) {
+	if db.readOnly && conn == db.Writes {
+		return nil, func() error { return nil }, ReadOnlyError{Op: "begin a transaction on the writes connection"}
+	}
+
 	start := time.Now()
 
 	t, err := conn.BeginTx(ctx, nil)
 	tx := &Tx{
 		db:   db,
 		Tx:   t,
-		Time: time.Now(),
+		Time: db.now(),
+		ID:   atomic.AddUint64(&lastTxID, 1),
 
 		updates: &struct {
 			sync.RWMutex
 			queries []string
 		}{queries: make([]string, 0)},
+
+		changes:      new(txChanges),
+		shadowWrites: new(txShadowWrites),
 	}
 
 	db.callLog(LogDetail{
@@ -89,6 +107,9 @@ func (tx *Tx) Commit() error {
 	})
 
 	if err == nil {
+		tx.flushChanges()
+		tx.flushShadow()
+
 		for _, hook := range tx.PostCommitHooks {
 			if err := hook(); err != nil {
 				return fmt.Errorf("post commit hook failed: %w", err)
@@ -211,10 +232,10 @@ func (tx *Tx) ExistsContext(ctx context.Context, query string, cache time.Durati
 	return tx.db.exists(tx.Tx, ctx, query, cache, params...)
 }
 
-func (tx *Tx) Upsert(insert string, uniqueColumns, updateColumns []string, where string, source any) error {
-	return tx.I().Upsert(insert, uniqueColumns, updateColumns, where, source)
+func (tx *Tx) Upsert(insert string, uniqueColumns, updateColumns []string, where string, whereParams Params, source any) error {
+	return tx.I().Upsert(insert, uniqueColumns, updateColumns, where, whereParams, source)
 }
 
-func (tx *Tx) UpsertContext(ctx context.Context, insert string, uniqueColumns, updateColumns []string, where string, source any) error {
-	return tx.I().UpsertContext(ctx, insert, uniqueColumns, updateColumns, where, source)
+func (tx *Tx) UpsertContext(ctx context.Context, insert string, uniqueColumns, updateColumns []string, where string, whereParams Params, source any) error {
+	return tx.I().UpsertContext(ctx, insert, uniqueColumns, updateColumns, where, whereParams, source)
 }
@@ -0,0 +1,155 @@
+package mysql
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/fatih/structtag"
+)
+
+// SetLocalTimeZone sets the zone of the columns tagged `tz` without a zone name, see LocalTimeZone
+func (db *Database) SetLocalTimeZone(loc *time.Location) *Database {
+	db.LocalTimeZone = loc
+	return db
+}
+
+// tagTimeZone returns the zone name of the tag's `tz` or `tz=Zone` option, and whether it has one.
+// The name is empty for `tz` alone, meaning the database's LocalTimeZone.
+func tagTimeZone(tag *structtag.Tag) (string, bool) {
+	return tagOption(tag, "tz")
+}
+
+var timeZones sync.Map
+
+// timeZone returns the location of the zone name, or the database's local time zone if it's empty
+func (db *Database) timeZone(name string) (*time.Location, error) {
+	if len(name) == 0 {
+		if db.LocalTimeZone == nil {
+			return nil, fmt.Errorf("cool-mysql: column is tagged `tz` without a zone, but the database has no LocalTimeZone")
+		}
+		return db.LocalTimeZone, nil
+	}
+
+	if loc, ok := timeZones.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load time zone %q: %w", name, err)
+	}
+	timeZones.Store(name, loc)
+
+	return loc, nil
+}
+
+// zonedTime is a time marshaled as the wall clock of its zone, instead
+// of being converted from UTC to the session's time zone by the server
+type zonedTime struct {
+	t   time.Time
+	loc *time.Location
+}
+
+// rezone sets the time in v, a time.Time or *time.Time, to the same wall clock in the location,
+// since the driver parses datetimes in the connection's location
+func rezone(v reflect.Value, loc *time.Location) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	t, ok := v.Interface().(time.Time)
+	if !ok || t.IsZero() {
+		return
+	}
+
+	y, mo, d := t.Date()
+	h, mi, s := t.Clock()
+	v.Set(reflect.ValueOf(time.Date(y, mo, d, h, mi, s, t.Nanosecond(), loc)))
+}
+
+// timeZoneField is a time field of a struct tagged with a time zone
+type timeZoneField struct {
+	index []int
+	name  string
+	zone  string
+}
+
+var timeZoneFieldsCache sync.Map
+
+// timeZoneFields returns the time fields of the struct that are tagged with a time zone
+func timeZoneFields(t reflect.Type) []timeZoneField {
+	if fields, ok := timeZoneFieldsCache.Load(t); ok {
+		return fields.([]timeZoneField)
+	}
+
+	var fields []timeZoneField
+	for _, i := range StructFieldIndexes(t) {
+		f := t.FieldByIndex(i)
+		if !f.IsExported() || reflectUnwrapType(f.Type) != timeType {
+			continue
+		}
+
+		tags, _ := structtag.Parse(string(f.Tag))
+		if tags == nil {
+			continue
+		}
+		tag, _ := tags.Get("mysql")
+		if zone, ok := tagTimeZone(tag); ok {
+			fields = append(fields, timeZoneField{index: i, name: f.Name, zone: zone})
+		}
+	}
+
+	timeZoneFieldsCache.Store(t, fields)
+	return fields
+}
+
+// zonedParams puts the time fields tagged with time zones of the struct params right after
+// their structs, as params of the same names, so they're marshaled in their zones
+func (db *Database) zonedParams(params []any) ([]any, error) {
+	var zoned []any
+	for i, p := range params {
+		v := reflectUnwrap(reflect.ValueOf(p))
+		var fields []timeZoneField
+		if v.Kind() == reflect.Struct {
+			fields = timeZoneFields(v.Type())
+		}
+		if len(fields) == 0 {
+			if zoned != nil {
+				zoned = append(zoned, p)
+			}
+			continue
+		}
+
+		if zoned == nil {
+			zoned = append(make([]any, 0, len(params)+1), params[:i]...)
+		}
+		zoned = append(zoned, p)
+
+		overrides := make(Params, len(fields))
+		for _, f := range fields {
+			fv := reflectUnwrap(v.FieldByIndex(f.index))
+			t, ok := fv.Interface().(time.Time)
+			if !ok {
+				continue
+			}
+
+			loc, err := db.timeZone(f.zone)
+			if err != nil {
+				return nil, err
+			}
+			overrides[f.name] = zonedTime{t: t, loc: loc}
+		}
+		zoned = append(zoned, overrides)
+	}
+
+	if zoned == nil {
+		return params, nil
+	}
+
+	return zoned, nil
+}
@@ -0,0 +1,56 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDatabase_zonedParams(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+
+	type row struct {
+		CreatedAt time.Time  `mysql:"created_at,tz=America/New_York"`
+		LocalAt   *time.Time `mysql:"local_at,tz"`
+		UpdatedAt time.Time  `mysql:"updated_at"`
+	}
+
+	at := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	r := row{CreatedAt: at, LocalAt: &at, UpdatedAt: at}
+
+	db := benchDatabase(t)
+	if _, _, err := db.InterpolateParams("@@CreatedAt", r); err == nil {
+		t.Error("InterpolateParams() of a `tz` column without a LocalTimeZone should fail")
+	}
+
+	db.SetLocalTimeZone(time.FixedZone("UTC+2", 2*60*60))
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"@@CreatedAt", "'2024-01-02 10:04:05.000000'"},
+		{"@@LocalAt", "'2024-01-02 17:04:05.000000'"},
+		{"@@UpdatedAt", "convert_tz('2024-01-02 15:04:05.000000','UTC',@@session.time_zone)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, _, err := db.InterpolateParams(tt.query, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("InterpolateParams() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	scanned := time.Date(2024, 1, 2, 10, 4, 5, 0, time.UTC)
+	rezone(reflect.ValueOf(&scanned).Elem(), ny)
+	if !scanned.Equal(at) {
+		t.Errorf("rezone() = %v, want %v", scanned, at)
+	}
+}
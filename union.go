@@ -0,0 +1,176 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// unionIndexColumn is the column of the index of the param set of each row's branch
+const unionIndexColumn = "cool_mysql_union_index"
+
+// SelectUnion selects the query once for each of the param sets, as the branches of `union all` queries
+// of at most UnionChunkSize branches each, so many lookups of the same shape, like one per key with its own
+// filters, take one round trip per chunk instead of one per lookup. The rows of the set at each index
+// are returned at the same index, and the params are used by every branch, under each set's.
+//
+// Each branch is a derived table, so its query can have its own order by and limit, which a plain
+// `union all` of the queries couldn't. The rows can be structs, MapRow, SliceRow, or single column values.
+func SelectUnion[T any, P any](ctx context.Context, db *Database, q string, sets []P, cache time.Duration, params ...any) ([][]T, error) {
+	results := make([][]T, len(sets))
+	if len(sets) == 0 {
+		return results, nil
+	}
+
+	size := UnionChunkSize
+	if size < 1 {
+		size = 1
+	}
+
+	scan, err := unionScanner[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	for start := 0; start < len(sets); start += size {
+		end := start + size
+		if end > len(sets) {
+			end = len(sets)
+		}
+
+		union, err := db.unionQuery(ctx, q, sets[start:end], start, params)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := scan(ctx, db, union, cache, results); err != nil {
+			return nil, fmt.Errorf("failed to select union: %w", err)
+		}
+	}
+
+	return results, nil
+}
+
+// unionQuery returns the `union all` of the query's branches, one for each param set, whose indexes start at offset
+func (db *Database) unionQuery(ctx context.Context, q string, sets any, offset int, params []any) (string, error) {
+	rv := reflect.ValueOf(sets)
+
+	s := new(strings.Builder)
+	for i := 0; i < rv.Len(); i++ {
+		// each set comes last, so its params are used over the shared ones with the same names
+		branch, _, err := db.interpolateParams(ctx, q, tenantParams(ctx, append(params[:len(params):len(params)], rv.Index(i).Interface()))...)
+		if err != nil {
+			return "", fmt.Errorf("failed to interpolate params of union branch %d: %w", offset+i, err)
+		}
+
+		if i != 0 {
+			s.WriteString("union all")
+		}
+		s.WriteString("(select ")
+		s.WriteString(strconv.Itoa(offset + i))
+		s.WriteString(quoteIdentifier(unionIndexColumn))
+		s.WriteString(",`cool_mysql_union`.*from(")
+		s.WriteString(strings.TrimRight(strings.TrimSpace(branch), ";"))
+		s.WriteString(")`cool_mysql_union`)")
+	}
+
+	return s.String(), nil
+}
+
+// unionScan selects the rows of a union into the results at the indexes of their branches
+type unionScan[T any] func(ctx context.Context, db *Database, union string, cache time.Duration, results [][]T) error
+
+// unionScanner returns how the union's rows of the type are scanned, where structs are scanned
+// with the index column into a struct that embeds them, so their tags work like they do in any select
+func unionScanner[T any]() (unionScan[T], error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	switch {
+	case t == mapRowType:
+		return func(ctx context.Context, db *Database, union string, cache time.Duration, results [][]T) error {
+			var rows []MapRow
+			if err := db.query(db.Reads, ctx, &rows, union, cache); err != nil {
+				return err
+			}
+
+			for _, r := range rows {
+				i := Int(r[unionIndexColumn])
+				delete(r, unionIndexColumn)
+				results[i] = append(results[i], any(r).(T))
+			}
+
+			return nil
+		}, nil
+	case t.Kind() == reflect.Struct && isMultiValueElement(t):
+		rt, err := unionRowType(t)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(ctx context.Context, db *Database, union string, cache time.Duration, results [][]T) error {
+			// the index column is the first column, but its field comes after the embedded struct, so the columns are bound by name
+			if db.PositionalColumns {
+				db = db.Clone()
+				db.PositionalColumns = false
+			}
+
+			rows := reflect.New(reflect.SliceOf(rt))
+			if err := db.query(db.Reads, ctx, rows.Interface(), union, cache); err != nil {
+				return err
+			}
+
+			rows = rows.Elem()
+			for j := 0; j < rows.Len(); j++ {
+				r := rows.Index(j)
+				i := int(r.Field(1).Int())
+				results[i] = append(results[i], r.Field(0).Interface().(T))
+			}
+
+			return nil
+		}, nil
+	default:
+		return func(ctx context.Context, db *Database, union string, cache time.Duration, results [][]T) error {
+			var rows []SliceRow
+			if err := db.query(db.Reads, ctx, &rows, union, cache); err != nil {
+				return err
+			}
+
+			for _, r := range rows {
+				i := Int(r[0])
+
+				var v T
+				switch d := any(&v).(type) {
+				case *SliceRow:
+					*d = r[1:]
+				default:
+					if len(r) != 2 {
+						return fmt.Errorf("cool-mysql: union branches select %d columns, but %s is a single column", len(r)-1, t)
+					}
+					if err := convertAssignRows(d, r[1]); err != nil {
+						return fmt.Errorf("failed to convert union column: %w", err)
+					}
+				}
+				results[i] = append(results[i], v)
+			}
+
+			return nil
+		}, nil
+	}
+}
+
+// unionRowType returns a struct that embeds the struct type, with the index column after it
+func unionRowType(t reflect.Type) (rt reflect.Type, err error) {
+	// embedded types with methods can only be embedded in some ways, which StructOf panics about
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("cool-mysql: can't select union rows of %s: %v", t, r)
+		}
+	}()
+
+	return reflect.StructOf([]reflect.StructField{
+		{Name: "CoolMySQLUnionRow", Type: t, Anonymous: true},
+		{Name: "CoolMySQLUnionIndex", Type: reflect.TypeOf(0), Tag: `mysql:"` + unionIndexColumn + `"`},
+	}), nil
+}
@@ -0,0 +1,72 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+
+	"github.com/StirlingMarketingGroup/cool-mysql/internal/testdriver"
+	"go.uber.org/zap"
+)
+
+func TestSelectUnion(t *testing.T) {
+	defer func(size int) { UnionChunkSize = size }(UnionChunkSize)
+	UnionChunkSize = 2
+
+	// every row of the driver is from the branch of the second set, whichever chunk selects it
+	conn := sql.OpenDB(&testdriver.Driver{
+		Columns: []string{unionIndexColumn, "Name"},
+		Row:     []driver.Value{int64(1), []byte("Ann")},
+		Rows:    2,
+	})
+	defer conn.Close()
+
+	db := &Database{Writes: conn, Reads: conn, Logger: zap.NewNop()}
+
+	var queries []string
+	db.Log = func(detail LogDetail) {
+		queries = append(queries, detail.Query)
+	}
+
+	ctx := context.Background()
+	q := "select`Name`from`users`where`ID`=@@ID and`Active`=@@Active limit 1"
+	sets := []Params{{"ID": 1}, {"ID": 2}, {"ID": 3}}
+
+	type user struct {
+		Name string
+	}
+	users, err := SelectUnion[user](ctx, db, q, sets, 0, Params{"Active": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]user{nil, {{"Ann"}, {"Ann"}, {"Ann"}, {"Ann"}}, nil}; !reflect.DeepEqual(users, want) {
+		t.Errorf("SelectUnion() = %+v, want %+v", users, want)
+	}
+
+	want := []string{
+		"(select 0`cool_mysql_union_index`,`cool_mysql_union`.*from(select`Name`from`users`where`ID`=1 and`Active`=1 limit 1)`cool_mysql_union`)" +
+			"union all(select 1`cool_mysql_union_index`,`cool_mysql_union`.*from(select`Name`from`users`where`ID`=2 and`Active`=1 limit 1)`cool_mysql_union`)",
+		"(select 2`cool_mysql_union_index`,`cool_mysql_union`.*from(select`Name`from`users`where`ID`=3 and`Active`=1 limit 1)`cool_mysql_union`)",
+	}
+	if !reflect.DeepEqual(queries, want) {
+		t.Errorf("SelectUnion() ran %q, want %q", queries, want)
+	}
+
+	names, err := SelectUnion[string](ctx, db, q, sets[:2], 0, Params{"Active": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]string{nil, {"Ann", "Ann"}}; !reflect.DeepEqual(names, want) {
+		t.Errorf("SelectUnion() = %q, want %q", names, want)
+	}
+
+	rows, err := SelectUnion[MapRow](ctx, db, q, sets[:2], 0, Params{"Active": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows[1]) != 2 || String(rows[1][0]["Name"]) != "Ann" || len(rows[1][0]) != 1 {
+		t.Errorf("SelectUnion() = %v, want the rows without their index", rows)
+	}
+}
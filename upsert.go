@@ -11,19 +11,29 @@ import (
 	"golang.org/x/sync/errgroup"
 )
 
-func (in *Inserter) Upsert(query string, uniqueColumns, updateColumns []string, where string, source any) error {
-	return in.upsert(context.Background(), query, uniqueColumns, updateColumns, where, source)
+// Upsert updates the rows of source that already exist, matched by their unique columns
+// and the where clause, and inserts the rest. The where clause can use the `@@` params of
+// each row's fields, and of whereParams, like user supplied values that can't safely be
+// written into the where clause itself. Row fields are used over where params with the same name.
+func (in *Inserter) Upsert(query string, uniqueColumns, updateColumns []string, where string, whereParams Params, source any) error {
+	return in.upsert(context.Background(), query, uniqueColumns, updateColumns, where, whereParams, source)
 }
 
-func (in *Inserter) UpsertContext(ctx context.Context, query string, uniqueColumns, updateColumns []string, where string, source any) error {
-	return in.upsert(ctx, query, uniqueColumns, updateColumns, where, source)
+// UpsertContext is like Upsert, with a context, and like InsertContext,
+// is part of the context's transaction, if it has one of the inserter's database
+func (in *Inserter) UpsertContext(ctx context.Context, query string, uniqueColumns, updateColumns []string, where string, whereParams Params, source any) error {
+	return in.contextTx(ctx).upsert(ctx, query, uniqueColumns, updateColumns, where, whereParams, source)
 }
 
-func (in *Inserter) upsert(ctx context.Context, query string, uniqueColumns, updateColumns []string, where string, source any) error {
+func (in *Inserter) upsert(ctx context.Context, query string, uniqueColumns, updateColumns []string, where string, whereParams Params, source any) error {
+	if in.db.readOnly {
+		return ReadOnlyError{Op: "upsert", Query: query}
+	}
+
 	modifiedQuery := query
 	queryTokens := parseQuery(query)
-	if len(queryTokens) == 1 {
-		modifiedQuery = "insert into`" + parseName(query) + "`"
+	if parts, ok := tableNameParts(queryTokens); ok {
+		modifiedQuery = "insert into" + in.db.quoteTableParts(parts)
 		queryTokens = parseQuery(modifiedQuery)
 	}
 
@@ -32,6 +42,13 @@ func (in *Inserter) upsert(ctx context.Context, query string, uniqueColumns, upd
 	if err != nil {
 		return Wrap(err, query, modifiedQuery, source)
 	}
+	changeTableName, _ := tableNameFromQuery(queryTokens)
+
+	if in.validateUpsert {
+		if err := in.db.ValidateUpsert(ctx, tableName, uniqueColumns, updateColumns); err != nil {
+			return Wrap(err, query, modifiedQuery, source)
+		}
+	}
 
 	sv := reflectUnwrap(reflect.ValueOf(source))
 	st := sv.Type()
@@ -83,6 +100,7 @@ func (in *Inserter) upsert(ctx context.Context, query string, uniqueColumns, upd
 		return nil
 	}
 
+	var colOpts map[string]insertColOpts
 	var colFieldMap map[string]string
 	if len(columnNames) == 0 {
 		if typeHasColNames(rt) {
@@ -90,7 +108,7 @@ func (in *Inserter) upsert(ctx context.Context, query string, uniqueColumns, upd
 			case reflect.Map:
 				columnNames = colNamesFromMap(currentRow)
 			case reflect.Struct:
-				columnNames, _, colFieldMap, err = colNamesFromStruct(rt)
+				columnNames, colOpts, colFieldMap, err = colNamesFromStruct(rt)
 				if err != nil {
 					return Wrap(err, query, modifiedQuery, source)
 				}
@@ -108,17 +126,42 @@ func (in *Inserter) upsert(ctx context.Context, query string, uniqueColumns, upd
 				colFieldMap[c] = strconv.Itoa(i)
 			}
 		case reflect.Struct:
-			_, _, colFieldMap, err = colNamesFromStruct(rt)
+			_, colOpts, colFieldMap, err = colNamesFromStruct(rt)
 			if err != nil {
 				return Wrap(err, query, modifiedQuery, source)
 			}
 		}
 	}
 
+	// generated columns can't be updated any more than they can be inserted
+	generated, err := in.generatedColumns(ctx, queryTokens)
+	if err != nil {
+		return Wrap(err, query, modifiedQuery, source)
+	}
+	updateColumns = writableColumns(updateColumns, colOpts, generated)
+
 	if len(columnNames) == 0 {
 		return Wrap(ErrNoColumnNames, query, modifiedQuery, source)
 	}
 
+	var tenantColumn string
+	if rt.Kind() == reflect.Struct {
+		tf, err := tenantFieldFromStruct(rt)
+		if err != nil {
+			return Wrap(err, query, modifiedQuery, source)
+		}
+		if tf != nil {
+			if _, ok := TenantFromContext(ctx); !ok {
+				return Wrap(ErrNoTenant, query, modifiedQuery, source)
+			}
+			tenantColumn = tf.column
+		}
+	}
+	if len(tenantColumn) == 0 {
+		// rows that aren't tenant scoped are matched by their unique columns alone
+		ctx = AllowAllTenants(ctx)
+	}
+
 	s := new(strings.Builder)
 	if len(updateColumns) != 0 {
 		s.WriteString("update ")
@@ -134,7 +177,9 @@ func (in *Inserter) upsert(ctx context.Context, query string, uniqueColumns, upd
 			s.WriteString(c)
 			s.WriteString("`=@@")
 
-			if colFieldMap != nil {
+			if strings.EqualFold(c, tenantColumn) {
+				s.WriteString("Tenant")
+			} else if colFieldMap != nil {
 				s.WriteString(colFieldMap[c])
 			} else {
 				s.WriteString(c)
@@ -144,11 +189,23 @@ func (in *Inserter) upsert(ctx context.Context, query string, uniqueColumns, upd
 		s.WriteString("select 0 from ")
 		s.WriteString(tableName)
 	}
+	whereStart := s.Len()
 
-	if len(uniqueColumns) != 0 || len(where) != 0 {
+	if len(uniqueColumns) != 0 || len(where) != 0 || len(tenantColumn) != 0 {
 		s.WriteString(" where")
 	}
 
+	if len(tenantColumn) != 0 {
+		// existing rows are only ever matched within the tenant
+		s.WriteByte('`')
+		s.WriteString(tenantColumn)
+		s.WriteString("`<=>@@Tenant")
+
+		if len(uniqueColumns) != 0 || len(where) != 0 {
+			s.WriteString(" and")
+		}
+	}
+
 	if len(uniqueColumns) != 0 {
 		for i, c := range uniqueColumns {
 			if i != 0 {
@@ -159,7 +216,9 @@ func (in *Inserter) upsert(ctx context.Context, query string, uniqueColumns, upd
 			s.WriteString(c)
 			s.WriteString("`<=>@@")
 
-			if colFieldMap != nil {
+			if strings.EqualFold(c, tenantColumn) {
+				s.WriteString("Tenant")
+			} else if colFieldMap != nil {
 				s.WriteString(colFieldMap[c])
 			} else {
 				s.WriteString(c)
@@ -179,9 +238,35 @@ func (in *Inserter) upsert(ctx context.Context, query string, uniqueColumns, upd
 
 	q := s.String()
 
-	ch := reflect.MakeChan(reflect.ChanOf(reflect.BothDir, rt), 0)
+	// without clientFoundRows, updates that match rows without changing them
+	// don't affect any, so whether they matched has to be checked separately
+	var existsQuery string
+	if len(updateColumns) != 0 && !in.db.clientFoundRows() {
+		existsQuery = "select 0 from " + tableName + q[whereStart:]
+	}
+
+	// columns with valuers are written by them in the update and its where too, like they are in the insert,
+	// so rows are matched by the values they were written with
+	valuers := in.db.columnValuersOf(changeTableName, columnNames)
+	if len(tenantColumn) != 0 {
+		delete(valuers, tenantColumn)
+	}
+
+	// rows are sent by pointer when returning, so the
+	// inserted rows get the returned values too
+	sendPtrs := len(in.returning) != 0 && currentRow.CanAddr()
+
+	chType := rt
+	if sendPtrs {
+		chType = reflect.PtrTo(rt)
+	}
+	ch := reflect.MakeChan(reflect.ChanOf(reflect.BothDir, chType), 0)
 	grp := new(errgroup.Group)
 
+	// the updated rows are reported once the inserts are done, so HandleChanges
+	// isn't called by both of the upsert's goroutines at the same time
+	var updated []ChangeEvent
+
 	var sliceToMap func(slice reflect.Value) map[string]any
 	switch rt.Kind() {
 	case reflect.Array, reflect.Slice:
@@ -205,17 +290,61 @@ func (in *Inserter) upsert(ctx context.Context, query string, uniqueColumns, upd
 				r = sliceToMap(currentRow)
 			}
 
+			// the row comes last so its fields are used over where params with the same names
+			params := []any{r}
+			if len(whereParams) != 0 {
+				params = []any{whereParams, r}
+			}
+
+			if len(valuers) != 0 {
+				rowParams, _ := convertToParams("", r)
+				overrides := make(Params, len(valuers))
+				for c, fn := range valuers {
+					name := c
+					if colFieldMap != nil {
+						name = colFieldMap[c]
+					}
+
+					b, err := appendColumnValue(nil, fn, c, rowParams[name])
+					if err != nil {
+						return Wrap(err, query, q, r)
+					}
+					overrides[name] = Raw(b)
+				}
+				params = append(params, overrides)
+			}
+
 			if len(updateColumns) != 0 {
-				res, err := in.db.exec(in.conn, ctx, in.tx, true, q, r)
+				res, err := in.db.exec(in.conn, ctx, in.tx, true, q, params...)
 				if err != nil {
 					return Wrap(fmt.Errorf("failed to update: %w", err), query, q, r)
 				}
 
 				if m, _ := res.RowsAffected(); m != 0 {
+					if in.db.HandleChanges != nil {
+						updated = append(updated, ChangeEvent{
+							Table: changeTableName,
+							Kind:  ChangeUpdate,
+							Keys:  upsertKeys(r, uniqueColumns, colFieldMap),
+							After: currentRow.Interface(),
+						})
+					}
+
 					goto NEXT
 				}
+
+				if len(existsQuery) != 0 {
+					ok, err := in.db.exists(in.conn, ctx, existsQuery, 0, params...)
+					if err != nil {
+						return Wrap(fmt.Errorf("failed to check if exists: %w", err), query, existsQuery, r)
+					}
+
+					if ok {
+						goto NEXT
+					}
+				}
 			} else {
-				ok, err := in.db.exists(in.conn, ctx, q, 0, r)
+				ok, err := in.db.exists(in.conn, ctx, q, 0, params...)
 				if err != nil {
 					return Wrap(fmt.Errorf("failed to check if exists: %w", err), query, q, r)
 				}
@@ -225,7 +354,11 @@ func (in *Inserter) upsert(ctx context.Context, query string, uniqueColumns, upd
 				}
 			}
 
-			ch.Send(currentRow)
+			if sendPtrs {
+				ch.Send(currentRow.Addr())
+			} else {
+				ch.Send(currentRow)
+			}
 
 		NEXT:
 			if !next() {
@@ -240,39 +373,91 @@ func (in *Inserter) upsert(ctx context.Context, query string, uniqueColumns, upd
 		return in.insert(ctx, query, ch.Interface())
 	})
 
-	return grp.Wait()
+	err = grp.Wait()
+	in.db.reportChanges(in.tx, updated)
+
+	return err
+}
+
+// upsertKeys returns the values of the unique columns from the given row
+func upsertKeys(row any, uniqueColumns []string, colFieldMap map[string]string) Params {
+	if len(uniqueColumns) == 0 {
+		return nil
+	}
+
+	rowParams, _ := convertToParams("", row)
+
+	keys := make(Params, len(uniqueColumns))
+	for _, c := range uniqueColumns {
+		name := c
+		if colFieldMap != nil {
+			name = colFieldMap[c]
+		}
+		keys[c] = rowParams[name]
+	}
+
+	return keys
 }
 
 var ErrNoTableName = errors.New("no table name found")
 
 func rawTableNameFromQuery(queryTokens []queryToken) (string, error) {
-	var tableNameParts []string
+	tableNameParts := tableNamePartsFromQuery(queryTokens)
+	if len(tableNameParts) == 0 {
+		return "", ErrNoTableName
+	}
 
-	for i, t := range queryTokens {
-		if t.kind == queryTokenKindWord && strings.EqualFold(t.string, "into") {
-			if i+1 >= len(queryTokens) {
-				break
-			}
+	return strings.Join(tableNameParts, "."), nil
+}
+
+// tableNameFromQuery returns the table name from an insert query
+// with the backticks removed
+func tableNameFromQuery(queryTokens []queryToken) (string, error) {
+	tableNameParts := tableNamePartsFromQuery(queryTokens)
+	if len(tableNameParts) == 0 {
+		return "", ErrNoTableName
+	}
 
+	for i, p := range tableNameParts {
+		tableNameParts[i] = parseName(p)
+	}
+
+	return strings.Join(tableNameParts, "."), nil
+}
+
+// tableNamePartsFromQuery returns the parts of the table name after "into", as they're written,
+// like ["`db`", "`table`"] for "insert into`db`.`table`(...)"
+func tableNamePartsFromQuery(queryTokens []queryToken) (tableNameParts []string) {
+	return tableNamePartsAfter(queryTokens, "into")
+}
+
+// tableNamePartsAfter returns the parts of the table name after the first keyword,
+// like "into" of inserts or "from" of deletes, as they're written
+func tableNamePartsAfter(queryTokens []queryToken, keyword string) (tableNameParts []string) {
+	for i, t := range queryTokens {
+		if t.kind == queryTokenKindWord && strings.EqualFold(t.string, keyword) {
+			// the parts are separated by dots, which whitespace can be around,
+			// and the name ends at anything else, like the columns or "values"
+			dot := true
 			for _, t := range queryTokens[i+1:] {
-				if t.kind == queryTokenKindMisc {
+				switch {
+				case t.kind == queryTokenKindMisc && strings.TrimSpace(t.string) == "":
 					continue
-				}
-
-				if t.kind == queryTokenKindWord || t.kind == queryTokenKindString {
+				case t.kind == queryTokenKindMisc && t.string == "." && !dot:
+					dot = true
+					continue
+				case dot && (t.kind == queryTokenKindWord || t.kind == queryTokenKindString):
 					tableNameParts = append(tableNameParts, t.string)
-				} else {
-					break
+					dot = false
+					continue
 				}
+
+				break
 			}
 
 			break
 		}
 	}
 
-	if len(tableNameParts) == 0 {
-		return "", ErrNoTableName
-	}
-
-	return strings.Join(tableNameParts, "."), nil
+	return
 }
@@ -0,0 +1,90 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/StirlingMarketingGroup/cool-mysql/internal/testdriver"
+)
+
+func TestInserter_UpsertWhereParams(t *testing.T) {
+	db := benchDatabase(t)
+
+	var queries []string
+	db.Log = func(detail LogDetail) {
+		queries = append(queries, detail.Query)
+	}
+
+	type row struct {
+		ID    int
+		Count int
+	}
+
+	err := db.UpsertContext(context.Background(), "insert into`Counts`", []string{"ID"}, []string{"Count"},
+		"`Status`=@@Status and`Count`<@@Count", Params{"Status": 5, "Count": 100}, []row{{ID: 1, Count: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the row's Count is used over the where param with the same name
+	want := "update `Counts` set`Count`=2 where`ID`<=>1 and(`Status`=5 and`Count`<2)"
+	if len(queries) != 1 || !strings.EqualFold(queries[0], want) {
+		t.Errorf("UpsertContext() ran %q, want %q", queries, want)
+	}
+}
+
+func TestInserter_UpsertClientFoundRows(t *testing.T) {
+	// writes never change any rows, like updates that set rows to the values they already have
+	db := testDatabase(t, &testdriver.Driver{
+		Columns:  []string{"0"},
+		Row:      []driver.Value{int64(0)},
+		Rows:     1,
+		ExecFunc: func(ctx context.Context, query string) (driver.Result, error) { return driver.RowsAffected(0), nil },
+	})
+
+	var queries []string
+	db.Log = func(detail LogDetail) {
+		queries = append(queries, detail.Query)
+	}
+
+	type row struct {
+		ID    int
+		Count int
+	}
+
+	tests := []struct {
+		name string
+		dsn  string
+		want []string
+	}{
+		{
+			// the row matched, it just didn't change
+			name: "affected rows",
+			dsn:  "user@tcp(localhost)/db",
+			want: []string{"update `Counts` set`Count`=2 where`ID`<=>1", "select 0 from `Counts` where`ID`<=>1"},
+		},
+		{
+			// the row would have been found, so it doesn't exist
+			name: "found rows",
+			dsn:  "user@tcp(localhost)/db?clientFoundRows=true",
+			want: []string{"update `Counts` set`Count`=2 where`ID`<=>1", "insert into`Counts`(`ID`,`Count`)values(1,2)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db.WritesDSN = tt.dsn
+			queries = nil
+
+			err := db.Upsert("insert into`Counts`", []string{"ID"}, []string{"Count"}, "", nil, row{ID: 1, Count: 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(queries, tt.want) {
+				t.Errorf("Upsert() ran %q, want %q", queries, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// ModelDiffKind is the kind of difference between a struct and a table
+type ModelDiffKind int
+
+const (
+	// ModelDiffMissingColumn is a struct field without a column in the table
+	ModelDiffMissingColumn ModelDiffKind = iota + 1
+	// ModelDiffUnmappedColumn is a table column without a field in the struct
+	ModelDiffUnmappedColumn
+	// ModelDiffType is a field whose type can't hold the column's values
+	ModelDiffType
+	// ModelDiffNullability is a field that can't hold null for a nullable column,
+	// or a nullable field for a not null column
+	ModelDiffNullability
+	// ModelDiffLength is a field that can't hold the column's full range or length
+	ModelDiffLength
+)
+
+func (k ModelDiffKind) String() string {
+	switch k {
+	case ModelDiffMissingColumn:
+		return "missing column"
+	case ModelDiffUnmappedColumn:
+		return "unmapped column"
+	case ModelDiffType:
+		return "type"
+	case ModelDiffNullability:
+		return "nullability"
+	case ModelDiffLength:
+		return "length"
+	default:
+		return "unknown"
+	}
+}
+
+// ModelDiff is a single difference between a struct and a table
+type ModelDiff struct {
+	Kind    ModelDiffKind
+	Column  string
+	Field   string
+	Message string
+}
+
+func (d ModelDiff) String() string {
+	return fmt.Sprintf("%s: column %q, field %q: %s", d.Kind, d.Column, d.Field, d.Message)
+}
+
+// ModelDiffs are all the differences between a struct and a table
+type ModelDiffs []ModelDiff
+
+func (d ModelDiffs) Error() string {
+	s := new(strings.Builder)
+	s.WriteString("cool-mysql: model doesn't match table:")
+	for _, diff := range d {
+		s.WriteString("\n\t")
+		s.WriteString(diff.String())
+	}
+
+	return s.String()
+}
+
+// ValidateModel compares the struct T against the live columns of the table,
+// checking column names, types, nullability, and lengths.
+// The returned diffs are empty if the model matches the table.
+func ValidateModel[T any](ctx context.Context, db *Database, table string) (ModelDiffs, error) {
+	columns, err := db.TableColumns(ctx, table, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return validateModel(reflect.TypeOf((*T)(nil)).Elem(), columns)
+}
+
+func validateModel(t reflect.Type, columns []TableColumn) (ModelDiffs, error) {
+	t = reflectUnwrapType(t)
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cool-mysql: model must be a struct, got %s", t)
+	}
+
+	fieldColumns, colOpts, colFieldMap, err := colNamesFromStruct(t)
+	if err != nil {
+		return nil, err
+	}
+
+	tableColumns := make(map[string]TableColumn, len(columns))
+	for _, c := range columns {
+		tableColumns[strings.ToLower(c.Name)] = c
+	}
+
+	var diffs ModelDiffs
+	mapped := make(map[string]struct{}, len(fieldColumns))
+	for _, col := range fieldColumns {
+		f := t.FieldByIndex(colOpts[col].index)
+		fieldName := colFieldMap[col]
+
+		c, ok := tableColumns[strings.ToLower(col)]
+		if !ok {
+			// embedded structs are flattened, so they aren't columns themselves
+			if f.Anonymous && reflectUnwrapType(f.Type).Kind() == reflect.Struct {
+				continue
+			}
+
+			diffs = append(diffs, ModelDiff{
+				Kind:    ModelDiffMissingColumn,
+				Column:  col,
+				Field:   fieldName,
+				Message: "table has no such column",
+			})
+			continue
+		}
+		mapped[strings.ToLower(col)] = struct{}{}
+
+		for _, diff := range validateModelField(f.Type, c) {
+			diff.Column = c.Name
+			diff.Field = fieldName
+			diffs = append(diffs, diff)
+		}
+	}
+
+	for _, c := range columns {
+		if _, ok := mapped[strings.ToLower(c.Name)]; !ok {
+			diffs = append(diffs, ModelDiff{
+				Kind:    ModelDiffUnmappedColumn,
+				Column:  c.Name,
+				Message: "struct has no field for column",
+			})
+		}
+	}
+
+	return diffs, nil
+}
+
+var decimalType = reflect.TypeOf((*decimal.Decimal)(nil)).Elem()
+
+var intBits = map[string]int{
+	"tinyint":   8,
+	"smallint":  16,
+	"mediumint": 24,
+	"int":       32,
+	"integer":   32,
+	"bigint":    64,
+}
+
+func validateModelField(ft reflect.Type, c TableColumn) (diffs []ModelDiff) {
+	nullable := ft.Kind() == reflect.Pointer || ft.Kind() == reflect.Interface
+	t := reflectUnwrapType(ft)
+	dataType := strings.ToLower(c.DataType)
+
+	// types that handle their own conversion can't be checked any further
+	if t.Kind() != reflect.Interface && reflect.PointerTo(t).Implements(scannerType) && t != civilDateType && t != decimalType {
+		return nil
+	}
+
+	if c.Nullable && !nullable && (t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Uint8) {
+		diffs = append(diffs, ModelDiff{
+			Kind:    ModelDiffNullability,
+			Message: "column is nullable but field is not a pointer, so null will be scanned as the zero value",
+		})
+	} else if !c.Nullable && nullable && ft.Kind() == reflect.Pointer {
+		diffs = append(diffs, ModelDiff{
+			Kind:    ModelDiffNullability,
+			Message: "column is not null but field is a pointer",
+		})
+	}
+
+	typeDiff := func() {
+		diffs = append(diffs, ModelDiff{
+			Kind:    ModelDiffType,
+			Message: fmt.Sprintf("field of type %s can't hold column of type %s", ft, c.ColumnType),
+		})
+	}
+
+	switch {
+	case t.Kind() == reflect.Interface:
+		// anything goes
+	case t == timeType:
+		switch dataType {
+		case "date", "datetime", "timestamp":
+		default:
+			typeDiff()
+		}
+	case t == civilDateType:
+		if dataType != "date" {
+			typeDiff()
+		}
+	case t == decimalType:
+		switch dataType {
+		case "decimal", "float", "double", "tinyint", "smallint", "mediumint", "int", "integer", "bigint":
+		default:
+			typeDiff()
+		}
+	case t.Kind() == reflect.Bool:
+		if dataType != "tinyint" && dataType != "bit" {
+			typeDiff()
+		}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		bits, ok := intBits[dataType]
+		if !ok {
+			if dataType != "year" && dataType != "bit" {
+				typeDiff()
+			}
+			break
+		}
+
+		unsignedField := t.Kind() >= reflect.Uint
+		switch {
+		case !c.Unsigned() && unsignedField:
+			diffs = append(diffs, ModelDiff{
+				Kind:    ModelDiffLength,
+				Message: fmt.Sprintf("field of type %s can't hold negative values of column type %s", ft, c.ColumnType),
+			})
+		case c.Unsigned() && !unsignedField && t.Bits() <= bits,
+			t.Bits() < bits:
+			diffs = append(diffs, ModelDiff{
+				Kind:    ModelDiffLength,
+				Message: fmt.Sprintf("field of type %s can't hold the full range of column type %s", ft, c.ColumnType),
+			})
+		}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		switch dataType {
+		case "float", "double", "decimal", "tinyint", "smallint", "mediumint", "int", "integer", "bigint":
+		default:
+			typeDiff()
+		}
+	case t.Kind() == reflect.String:
+		if isBinaryDataType(dataType) || dataType == "bit" {
+			break
+		}
+		if !isStringDataType(dataType) && !isNumericDataType(dataType) && !isTemporalDataType(dataType) {
+			typeDiff()
+		}
+	case (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() == reflect.Uint8:
+		if !isBinaryDataType(dataType) && !isStringDataType(dataType) && dataType != "bit" {
+			typeDiff()
+			break
+		}
+
+		if t.Kind() == reflect.Array && c.CharacterMaxLength != nil && int64(t.Len()) < *c.CharacterMaxLength {
+			diffs = append(diffs, ModelDiff{
+				Kind:    ModelDiffLength,
+				Message: fmt.Sprintf("field of type %s is shorter than column type %s", ft, c.ColumnType),
+			})
+		}
+	case isMultiColumn(t):
+		// structs, maps, and slices are (un)marshaled as json
+		if dataType != "json" && !isStringDataType(dataType) && !isBinaryDataType(dataType) {
+			typeDiff()
+		}
+	}
+
+	return diffs
+}
+
+func isStringDataType(dataType string) bool {
+	switch dataType {
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext", "enum", "set", "json":
+		return true
+	default:
+		return false
+	}
+}
+
+func isBinaryDataType(dataType string) bool {
+	switch dataType {
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob":
+		return true
+	default:
+		return false
+	}
+}
+
+func isNumericDataType(dataType string) bool {
+	switch dataType {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint", "decimal", "float", "double", "year":
+		return true
+	default:
+		return false
+	}
+}
+
+func isTemporalDataType(dataType string) bool {
+	switch dataType {
+	case "date", "datetime", "timestamp", "time", "year":
+		return true
+	default:
+		return false
+	}
+}
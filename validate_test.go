@@ -0,0 +1,55 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_validateModel(t *testing.T) {
+	type model struct {
+		ID        uint64
+		Name      string
+		Nickname  string
+		Age       int8
+		CreatedAt time.Time
+		DeletedAt *time.Time
+		Extra     string `mysql:"-"`
+		Gone      int
+	}
+
+	columns := []TableColumn{
+		{Name: "ID", DataType: "bigint", ColumnType: "bigint unsigned"},
+		{Name: "Name", DataType: "varchar", ColumnType: "varchar(255)"},
+		{Name: "Nickname", DataType: "varchar", ColumnType: "varchar(255)", Nullable: true},
+		{Name: "Age", DataType: "int", ColumnType: "int"},
+		{Name: "CreatedAt", DataType: "int", ColumnType: "int"},
+		{Name: "DeletedAt", DataType: "datetime", ColumnType: "datetime", Nullable: true},
+		{Name: "UpdatedAt", DataType: "datetime", ColumnType: "datetime"},
+	}
+
+	diffs, err := validateModel(reflect.TypeOf(model{}), columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type diffKey struct {
+		kind   ModelDiffKind
+		column string
+	}
+	got := make([]diffKey, len(diffs))
+	for i, d := range diffs {
+		got[i] = diffKey{d.Kind, d.Column}
+	}
+
+	want := []diffKey{
+		{ModelDiffNullability, "Nickname"},
+		{ModelDiffLength, "Age"},
+		{ModelDiffType, "CreatedAt"},
+		{ModelDiffMissingColumn, "Gone"},
+		{ModelDiffUnmappedColumn, "UpdatedAt"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("validateModel() = %v, want %v", got, want)
+	}
+}
@@ -3,6 +3,8 @@ package mysql
 import (
 	"database/sql/driver"
 	"reflect"
+	"strings"
+	"sync"
 )
 
 type Valueser interface {
@@ -34,3 +36,131 @@ func isValuerFunc(rt reflect.Type) bool {
 
 	return true
 }
+
+// genericTypeName returns the name of the generic type of the type with its package, like
+// `example.com/set.Set` for set.Set[int], or empty if it isn't an instantiation of a generic type
+func genericTypeName(t reflect.Type) string {
+	i := strings.IndexByte(t.Name(), '[')
+	if i == -1 {
+		return ""
+	}
+
+	return t.PkgPath() + "." + t.Name()[:i]
+}
+
+// valuers are the valuer funcs of a database, see Database.AddValuerFuncs
+type valuers struct {
+	funcs map[reflect.Type]reflect.Value
+
+	// generics are the funcs of generic types in the order they were added, see Database.AddGenericValuerFunc
+	generics []genericValuerFunc
+
+	// cache is the func found for each type, if it's kept
+	cache *valuerCache
+}
+
+// newValuers returns the valuers of the funcs, which are looked for every time instead of cached
+func newValuers(funcs map[reflect.Type]reflect.Value) *valuers {
+	if len(funcs) == 0 {
+		return nil
+	}
+
+	return &valuers{funcs: funcs}
+}
+
+// genericValuerFunc is a func of every instantiation of a generic type
+type genericValuerFunc struct {
+	// name is the name of the generic type, see genericTypeName
+	name string
+	fn   reflect.Value
+}
+
+// valuerCache is the valuer func found for each type, so it's only looked for once,
+// instead of for every value, until more funcs are added
+type valuerCache struct {
+	resolved sync.Map
+}
+
+type resolvedValuerFunc struct {
+	fn reflect.Value
+	in reflect.Type
+	ok bool
+}
+
+// valuerFunc returns the valuer func of the pointer type, and the type it's called with, which is either
+// the pointer type or the type it points to. The funcs of the type itself come first, then the func
+// of its generic type, then the funcs of the interfaces it implements, where the interface with
+// the most methods wins, then the one whose name sorts first, so the same func is always picked.
+// Nil pointers are only passed to funcs called with the pointer type, like funcs of the pointer type
+// itself, or of interfaces only its pointers implement, and are null for the others.
+func valuerFunc(valuerFuncs *valuers, pt reflect.Type) (fn reflect.Value, in reflect.Type, ok bool) {
+	if valuerFuncs == nil {
+		return reflect.Value{}, nil, false
+	}
+
+	c := valuerFuncs.cache
+	if c != nil {
+		if r, ok := c.resolved.Load(pt); ok {
+			r := r.(resolvedValuerFunc)
+			return r.fn, r.in, r.ok
+		}
+	}
+
+	fn, in, ok = resolveValuerFunc(valuerFuncs, pt)
+	if c != nil {
+		c.resolved.Store(pt, resolvedValuerFunc{fn: fn, in: in, ok: ok})
+	}
+
+	return fn, in, ok
+}
+
+// resolveValuerFunc looks for the valuer func of the pointer type, see valuerFunc
+func resolveValuerFunc(valuerFuncs *valuers, pt reflect.Type) (fn reflect.Value, in reflect.Type, ok bool) {
+	t := reflectUnwrapType(pt)
+	if fn, ok := valuerFuncs.funcs[pt]; ok {
+		return fn, pt, true
+	}
+	if fn, ok := valuerFuncs.funcs[t]; ok {
+		return fn, t, true
+	}
+
+	if generic := genericTypeName(t); len(generic) != 0 {
+		for _, g := range valuerFuncs.generics {
+			if g.name == generic {
+				return g.fn, t, true
+			}
+		}
+	}
+
+	var iface reflect.Type
+	for k := range valuerFuncs.funcs {
+		if k.Kind() == reflect.Interface && (t.Implements(k) || pt.Implements(k)) {
+			if iface == nil || k.NumMethod() > iface.NumMethod() ||
+				k.NumMethod() == iface.NumMethod() && k.String() < iface.String() {
+				iface = k
+			}
+		}
+	}
+	if iface == nil {
+		return reflect.Value{}, nil, false
+	}
+
+	// values are preferred over their pointers, since they can be called with the value of a nil pointer's type
+	in = t
+	if !t.Implements(iface) {
+		in = pt
+	}
+
+	return valuerFuncs.funcs[iface], in, true
+}
+
+// hasValuerFunc returns true if values of the type, or its pointers, are marshaled by a valuer func,
+// so they're single values, like driver.Valuers, instead of being split into fields or list items
+func hasValuerFunc(valuerFuncs *valuers, t reflect.Type) bool {
+	if valuerFuncs == nil || t == nil {
+		return false
+	}
+
+	_, _, ok := valuerFunc(valuerFuncs, reflect.PointerTo(reflectUnwrapType(t)))
+	return ok
+}
@@ -0,0 +1,144 @@
+package mysql
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+type testSet[T comparable] map[T]struct{}
+
+func (s testSet[T]) String() string {
+	items := make([]string, 0, len(s))
+	for k := range s {
+		items = append(items, fmt.Sprint(k))
+	}
+	sort.Strings(items)
+
+	return strings.Join(items, ",")
+}
+
+type testLabeled struct {
+	Label string
+}
+
+func (l *testLabeled) String() string {
+	return l.Label
+}
+
+func (l *testLabeled) GoString() string {
+	return "labeled " + l.Label
+}
+
+func TestDatabase_AddGenericValuerFunc(t *testing.T) {
+	db := &Database{}
+	db.AddValuerFuncs(
+		func(v fmt.Stringer) (driver.Value, error) { return "stringer " + v.String(), nil },
+		func(v fmt.GoStringer) (driver.Value, error) { return v.GoString(), nil },
+		func(v testSet[bool]) (driver.Value, error) { return len(v), nil },
+	)
+	db.AddGenericValuerFunc(testSet[int]{}, func(v any) (driver.Value, error) {
+		return v.(fmt.Stringer).String(), nil
+	})
+
+	tests := []struct {
+		name string
+		x    any
+		want any
+	}{
+		{"generic", testSet[string]{"b": {}, "a": {}}, "a,b"},
+		{"other instantiation", testSet[int]{2: {}, 1: {}}, "1,2"},
+		{"pointer to generic", &testSet[string]{"a": {}}, "a"},
+		{"nil pointer to generic", (*testSet[string])(nil), nil},
+		{"instantiation func first", testSet[bool]{true: {}}, 1},
+		{"interfaces by name", testLabeled{"a"}, "labeled a"},
+		{"pointer interface", &testLabeled{"b"}, "labeled b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := marshal(tt.x, 0, "", db.valuers())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want, _ := marshal(tt.want, 0, "", nil)
+			if string(got) != string(want) {
+				t.Errorf("marshal() = %s, want %s", got, want)
+			}
+		})
+	}
+
+	// adding a func for the same generic type replaces the earlier one, whichever instantiation it's added with
+	db.AddGenericValuerFunc(testSet[string]{}, func(v any) (driver.Value, error) { return "replaced", nil })
+	got, err := marshal(testSet[int]{1: {}}, 0, "", db.valuers())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := marshal("replaced", 0, "", nil); string(got) != string(want) {
+		t.Errorf("marshal() after replacing the generic func = %s, want %s", got, want)
+	}
+	if len(db.valuerFuncs) != 3 || len(db.genericValuerFuncs) != 1 {
+		t.Errorf("the database has %d valuer funcs and %d generic ones, want 3 and 1", len(db.valuerFuncs), len(db.genericValuerFuncs))
+	}
+
+	query, _, err := db.InterpolateParams("select @@Labeled", testLabeled{"c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "labeled c"; !strings.Contains(query, fmt.Sprintf("%x", want)) {
+		t.Errorf("InterpolateParams() = %s, want the struct marshaled by its valuer func", query)
+	}
+}
+
+type testValued struct {
+	N int
+}
+
+func TestDatabase_AddValuerFuncsNil(t *testing.T) {
+	db := &Database{}
+	db.AddValuerFuncs(
+		func(v *testValued) (driver.Value, error) {
+			if v == nil {
+				return "nil valued", nil
+			}
+			return v.N, nil
+		},
+		func(v fmt.GoStringer) (driver.Value, error) { return fmt.Sprintf("%T", v), nil },
+		func(v testSet[int]) (driver.Value, error) { return len(v), nil },
+	)
+
+	tests := []struct {
+		name string
+		x    any
+		want any
+	}{
+		{"pointer func gets nil", (*testValued)(nil), "nil valued"},
+		{"pointer interface gets nil", (*testLabeled)(nil), "*mysql.testLabeled"},
+		{"value func is null", (*testSet[int])(nil), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := marshal(tt.x, 0, "", db.valuers())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want, _ := marshal(tt.want, 0, "", nil)
+			if string(got) != string(want) {
+				t.Errorf("marshal() = %s, want %s", got, want)
+			}
+		})
+	}
+
+	// the interface's func was cached for the type, but funcs added since come first
+	db.AddValuerFuncs(func(v *testLabeled) (driver.Value, error) { return "added", nil })
+	got, err := marshal(&testLabeled{"a"}, 0, "", db.valuers())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := marshal("added", 0, "", nil); string(got) != string(want) {
+		t.Errorf("marshal() after adding a func = %s, want %s", got, want)
+	}
+}
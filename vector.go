@@ -0,0 +1,104 @@
+package mysql
+
+import (
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Vector is a value of a MySQL 9 `VECTOR` column,
+// stored as little endian 32 bit floats
+type Vector []float32
+
+// VectorParam returns the floats as a Vector, to be used as a query param
+// like `select*from Docs order by distance(Embedding,@@Query,'COSINE')limit 10`
+func VectorParam(v []float32) Vector {
+	return Vector(v)
+}
+
+// Value marshals the vector to its binary form
+func (v Vector) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	b := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(f))
+	}
+
+	return b, nil
+}
+
+// Scan unmarshals the vector from its binary form,
+// or from its string form like `[1.00000e+00,2.00000e+00]`
+func (v *Vector) Scan(src any) error {
+	var b []byte
+	switch src := src.(type) {
+	case nil:
+		*v = nil
+		return nil
+	case []byte:
+		b = src
+	case string:
+		b = []byte(src)
+	default:
+		return fmt.Errorf("cool-mysql: can't scan %T into a vector", src)
+	}
+
+	if l := len(b); l >= 2 && b[0] == '[' && b[l-1] == ']' {
+		var f []float32
+		if err := json.Unmarshal(b, &f); err == nil {
+			*v = f
+			return nil
+		}
+	}
+
+	if len(b)%4 != 0 {
+		return fmt.Errorf("cool-mysql: vector has %d bytes, which isn't a multiple of 4", len(b))
+	}
+
+	out := make(Vector, len(b)/4)
+	for i := range out {
+		out[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	*v = out
+
+	return nil
+}
+
+// String returns the vector in the form used by `STRING_TO_VECTOR`
+func (v Vector) String() string {
+	s := new(strings.Builder)
+	s.WriteByte('[')
+	for i, f := range v {
+		if i != 0 {
+			s.WriteByte(',')
+		}
+		fmt.Fprint(s, f)
+	}
+	s.WriteByte(']')
+
+	return s.String()
+}
+
+// VectorMetric is a way of measuring the distance between vectors
+type VectorMetric string
+
+const (
+	VectorCosine    VectorMetric = "COSINE"
+	VectorDot       VectorMetric = "DOT"
+	VectorEuclidean VectorMetric = "EUCLIDEAN"
+)
+
+// VectorDistance returns the distance between the vector column and v,
+// to be used in a select or order by, like
+// `"select*from Docs order by"+mysql.VectorDistance("Embedding", q, mysql.VectorCosine)+"limit 10"`
+func VectorDistance(column string, v Vector, metric VectorMetric) Raw {
+	b, _ := marshal(v, 0, "", nil)
+
+	return Raw("distance(" + quoteIdentifier(column) + "," + string(b) + ",'" + string(metric) + "')")
+}
@@ -0,0 +1,39 @@
+package mysql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVector(t *testing.T) {
+	v := Vector{1, -2.5, 0.125}
+
+	b, err := v.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var scanned Vector
+	if err := scanned.Scan(b); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(scanned, v) {
+		t.Errorf("Scan(Value()) = %v, want %v", scanned, v)
+	}
+
+	var fromString Vector
+	if err := fromString.Scan("[1.00000e+00,-2.50000e+00,1.25000e-01]"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromString, v) {
+		t.Errorf("Scan(string) = %v, want %v", fromString, v)
+	}
+
+	if err := new(Vector).Scan([]byte{1, 2, 3}); err == nil {
+		t.Errorf("Scan() of 3 bytes should fail")
+	}
+
+	if got, want := string(VectorDistance("Embedding", Vector{1}, VectorCosine)), "distance(`Embedding`,0x0000803f,'COSINE')"; got != want {
+		t.Errorf("VectorDistance() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,313 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TemporalColumns are the names of the columns that track
+// the versions of rows in a versioned table. Empty names are
+// the defaults, `__Version`, `__Latest`, `__ValidFrom`, and `__ValidUntil`.
+type TemporalColumns struct {
+	Version    string
+	Latest     string
+	ValidFrom  string
+	ValidUntil string
+}
+
+// withDefaults returns the columns with the defaults for the ones that are empty
+func (c TemporalColumns) withDefaults() TemporalColumns {
+	if len(c.Version) == 0 {
+		c.Version = "__Version"
+	}
+	if len(c.Latest) == 0 {
+		c.Latest = "__Latest"
+	}
+	if len(c.ValidFrom) == 0 {
+		c.ValidFrom = "__ValidFrom"
+	}
+	if len(c.ValidUntil) == 0 {
+		c.ValidUntil = "__ValidUntil"
+	}
+
+	return c
+}
+
+// versionedBatchSize is the most rows InsertVersioned versions with each select, update, and insert
+const versionedBatchSize = 1000
+
+// InsertVersioned inserts each row of source as the new latest version of the row with the
+// same key columns, closing the previous version at the same instant, in one transaction
+func (db *Database) InsertVersioned(ctx context.Context, table string, cols TemporalColumns, keyColumns []string, source any) error {
+	tx, cancel, err := db.BeginTxContext(ctx)
+	defer cancel()
+	if err != nil {
+		return fmt.Errorf("failed to begin tx: %w", err)
+	}
+
+	if err := tx.InsertVersioned(ctx, table, cols, keyColumns, source); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// InsertVersioned inserts each row of source as the new latest version of the row with the
+// same key columns, closing the previous version at the same instant.
+// Rows are versioned in batches, each with one select of the previous versions,
+// one update closing them, and one insert of the new versions.
+func (tx *Tx) InsertVersioned(ctx context.Context, table string, cols TemporalColumns, keyColumns []string, source any) error {
+	if len(keyColumns) == 0 {
+		return fmt.Errorf("cool-mysql: versioned insert into %q needs key columns", table)
+	}
+
+	cols = cols.withDefaults()
+	quotedTable := tx.db.quoteTable(table)
+
+	batch := make([]map[string]any, 0, versionedBatchSize)
+	err := forEachRow(source, true, func(row map[string]any) error {
+		for _, c := range keyColumns {
+			if _, ok := row[c]; !ok {
+				return fmt.Errorf("cool-mysql: versioned row is missing key column %q", c)
+			}
+		}
+
+		batch = append(batch, row)
+		if len(batch) < versionedBatchSize {
+			return nil
+		}
+
+		err := tx.insertVersionedBatch(ctx, table, quotedTable, cols, keyColumns, batch)
+		batch = batch[:0]
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	return tx.insertVersionedBatch(ctx, table, quotedTable, cols, keyColumns, batch)
+}
+
+// insertVersionedBatch closes the latest versions of the batch's rows and inserts the rows
+// as the new ones. Rows with the same keys in one batch are versioned in their order,
+// each closing the one before it.
+func (tx *Tx) insertVersionedBatch(ctx context.Context, table, quotedTable string, cols TemporalColumns, keyColumns []string, batch []map[string]any) error {
+	now := tx.db.now()
+
+	// the batch's distinct keys, selected as a derived table
+	// so the previous versions can be matched back to them
+	params := Params{"__Now": now}
+	keys := new(strings.Builder)
+	keyIndexes := make(map[string]int, len(batch))
+	rowKeys := make([]int, len(batch))
+	for i, row := range batch {
+		id := new(strings.Builder)
+		for _, c := range keyColumns {
+			b, err := marshal(row[c], 0, "", tx.db.valuers())
+			if err != nil {
+				return fmt.Errorf("failed to marshal key column %q: %w", c, err)
+			}
+			id.Write(b)
+			id.WriteByte(',')
+		}
+
+		k, ok := keyIndexes[id.String()]
+		if !ok {
+			k = len(keyIndexes)
+			keyIndexes[id.String()] = k
+
+			if k != 0 {
+				keys.WriteString(" union all ")
+			}
+			keys.WriteString("select ")
+			keys.WriteString(strconv.Itoa(k))
+			if k == 0 {
+				keys.WriteString("`__i`")
+			}
+			for j, c := range keyColumns {
+				name := "__Key" + strconv.Itoa(k) + "_" + strconv.Itoa(j)
+				params[name] = row[c]
+				keys.WriteString(",@@")
+				keys.WriteString(name)
+				if k == 0 {
+					keys.WriteString(quoteIdentifier(c))
+				}
+			}
+		}
+		rowKeys[i] = k
+	}
+
+	on := new(strings.Builder)
+	for i, c := range keyColumns {
+		if i != 0 {
+			on.WriteString("and")
+		}
+		on.WriteString("`__t`.")
+		on.WriteString(quoteIdentifier(c))
+		on.WriteString("<=>`__keys`.")
+		on.WriteString(quoteIdentifier(c))
+	}
+	join := "(" + keys.String() + ")`__keys`join" + quotedTable + "`__t`on" + on.String()
+
+	var previous []struct {
+		I       int   `mysql:"__i"`
+		Version int64 `mysql:"__Version"`
+	}
+	if err := tx.SelectContext(ctx, &previous, "select`__keys`.`__i`,max(`__t`."+quoteIdentifier(cols.Version)+
+		")`__Version`from"+join+"group by`__keys`.`__i`", 0, params, ForUpdate()); err != nil {
+		return fmt.Errorf("failed to get previous versions: %w", err)
+	}
+
+	// the previous versions are the ones of the rows' keys, which have the tenant if the table is scoped to one
+	if err := tx.ExecContext(AllowAllTenants(ctx), "update"+join+"set`__t`."+quoteIdentifier(cols.Latest)+"=0,`__t`."+
+		quoteIdentifier(cols.ValidUntil)+"=@@__Now where`__t`."+quoteIdentifier(cols.Latest)+"=1", params); err != nil {
+		return fmt.Errorf("failed to close previous versions: %w", err)
+	}
+
+	versions := make([]int64, len(keyIndexes))
+	for _, p := range previous {
+		versions[p.I] = p.Version
+	}
+
+	latest := make([]map[string]any, len(keyIndexes))
+	for i, row := range batch {
+		k := rowKeys[i]
+		if prev := latest[k]; prev != nil {
+			prev[cols.Latest] = false
+			prev[cols.ValidUntil] = now
+		}
+		latest[k] = row
+
+		versions[k]++
+		row[cols.Version] = versions[k]
+		row[cols.Latest] = true
+		row[cols.ValidFrom] = now
+		row[cols.ValidUntil] = MaxTime
+	}
+
+	return tx.InsertContext(ctx, table, batch)
+}
+
+// forEachRow calls fn with each struct or map row of source as a map of column names to values.
+// Zero struct fields tagged `insertDefault` are left out so they get their defaults,
+// and so are fields tagged `generated` or `readonly` if the rows are being written.
+func forEachRow(source any, writes bool, fn func(row map[string]any) error) error {
+	sv := reflectUnwrap(reflect.ValueOf(source))
+	if !sv.IsValid() {
+		return nil
+	}
+
+	toMap := func(row reflect.Value) (map[string]any, error) {
+		row = reflectUnwrap(row)
+		switch row.Kind() {
+		case reflect.Struct:
+			columns, colOpts, _, err := colNamesFromStruct(row.Type())
+			if err != nil {
+				return nil, err
+			}
+
+			m := make(map[string]any, len(columns))
+			for _, c := range columns {
+				f := row.FieldByIndex(colOpts[c].index)
+				if writes && !colOpts[c].writable() || colOpts[c].insertDefault && isZero(f.Interface()) {
+					continue
+				}
+				m[c] = f.Interface()
+			}
+
+			return m, nil
+		case reflect.Map:
+			m := make(map[string]any, row.Len())
+			for _, k := range row.MapKeys() {
+				m[fmt.Sprint(k.Interface())] = row.MapIndex(k).Interface()
+			}
+
+			return m, nil
+		default:
+			return nil, fmt.Errorf("cool-mysql: rows must be structs or maps, got %s", row.Type())
+		}
+	}
+
+	if !isMultiRow(sv.Type()) {
+		m, err := toMap(sv)
+		if err != nil {
+			return err
+		}
+		return fn(m)
+	}
+
+	switch sv.Kind() {
+	case reflect.Chan:
+		for {
+			row, ok := sv.Recv()
+			if !ok {
+				return nil
+			}
+
+			m, err := toMap(row)
+			if err != nil {
+				return err
+			}
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+	default:
+		for i := 0; i < sv.Len(); i++ {
+			m, err := toMap(sv.Index(i))
+			if err != nil {
+				return err
+			}
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// AsOf returns the predicate that matches the versions of rows in a versioned table
+// with the default temporal columns that were valid at the given time, to be used in a where clause
+func AsOf(t time.Time) Raw {
+	return TemporalColumns{}.AsOf(t)
+}
+
+// AsOf returns the predicate that matches the versions of rows in a versioned table
+// with these temporal columns that were valid at the given time, to be used in a where clause
+func (c TemporalColumns) AsOf(t time.Time) Raw {
+	cols := c.withDefaults()
+	b, _ := marshal(t, 0, "", nil)
+
+	return Raw("(" + quoteIdentifier(cols.ValidFrom) + "<=" + string(b) + " and" +
+		quoteIdentifier(cols.ValidUntil) + ">" + string(b) + ")")
+}
+
+// SelectAsOf selects the versions of rows from a versioned table that were valid at the given time.
+// The where clause is optional and can use params like any other query.
+func (db *Database) SelectAsOf(ctx context.Context, dest any, table string, cols TemporalColumns, asOf time.Time, where string, cache time.Duration, params ...any) error {
+	return db.query(db.Reads, ctx, dest, selectAsOfQuery(db.quoteTable(table), cols, asOf, where), cache, params...)
+}
+
+// SelectAsOf selects the versions of rows from a versioned table that were valid at the given time.
+// The where clause is optional and can use params like any other query.
+func (tx *Tx) SelectAsOf(ctx context.Context, dest any, table string, cols TemporalColumns, asOf time.Time, where string, cache time.Duration, params ...any) error {
+	return tx.db.query(tx.Tx, ctx, dest, selectAsOfQuery(tx.db.quoteTable(table), cols, asOf, where), cache, params...)
+}
+
+func selectAsOfQuery(quotedTable string, cols TemporalColumns, asOf time.Time, where string) string {
+	q := "select*from" + quotedTable + "where" + string(cols.AsOf(asOf))
+	if len(strings.TrimSpace(where)) != 0 {
+		q += "and(" + where + ")"
+	}
+
+	return q
+}
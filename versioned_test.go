@@ -0,0 +1,99 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StirlingMarketingGroup/cool-mysql/internal/testdriver"
+)
+
+func TestTemporalColumns_AsOf(t *testing.T) {
+	asOf := time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC)
+	b, _ := marshal(asOf, 0, "", nil)
+
+	if got, want := AsOf(asOf), Raw("(`__ValidFrom`<="+string(b)+" and`__ValidUntil`>"+string(b)+")"); got != want {
+		t.Errorf("AsOf() = %s, want %s", got, want)
+	}
+
+	cols := TemporalColumns{ValidFrom: "From", ValidUntil: "Until"}
+	if got, want := cols.AsOf(asOf), Raw("(`From`<="+string(b)+" and`Until`>"+string(b)+")"); got != want {
+		t.Errorf("TemporalColumns.AsOf() = %s, want %s", got, want)
+	}
+}
+
+func TestDatabase_SelectAsOf(t *testing.T) {
+	db := testDatabase(t, &testdriver.Driver{})
+
+	var queries []string
+	db.Log = func(detail LogDetail) {
+		queries = append(queries, detail.Query)
+	}
+
+	asOf := time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC)
+	var rows []MapRow
+	if err := db.SelectAsOf(context.Background(), &rows, "posts", TemporalColumns{ValidFrom: "From"}, asOf, "`ID`=@@ID", 0, Params{"ID": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ := marshal(asOf, 0, "", nil)
+	want := "select*from`posts`where(`From`<=" + string(b) + " and`__ValidUntil`>" + string(b) + ")and(`ID`=1)"
+	if len(queries) != 1 || queries[0] != want {
+		t.Errorf("SelectAsOf() ran %q, want %q", queries, want)
+	}
+}
+
+func TestDatabase_InsertVersioned(t *testing.T) {
+	db := testDatabase(t, &testdriver.Driver{
+		QueryFunc: func(ctx context.Context, query string) (driver.Rows, error) {
+			// the first key already has 3 versions
+			return testdriver.NewRows([]string{"__i", "__Version"}, []driver.Value{int64(0), int64(3)}), nil
+		},
+		Tx: true,
+	})
+	now := time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC)
+	db.SetNowFunc(func() time.Time { return now })
+
+	var queries []string
+	db.Log = func(detail LogDetail) {
+		queries = append(queries, detail.Query)
+	}
+
+	type post struct {
+		ID    int
+		Title string
+	}
+
+	err := db.InsertVersioned(context.Background(), "posts", TemporalColumns{Version: "Version"}, []string{"ID"}, []post{
+		{1, "a"},
+		{2, "b"},
+		{1, "c"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, _ := marshal(now, 0, "", nil)
+	maxTime, _ := marshal(MaxTime, 0, "", nil)
+	keys := "(select 0`__i`,1`ID` union all select 1,2)`__keys`join`posts``__t`on`__t`.`ID`<=>`__keys`.`ID`"
+	want := []string{
+		"select`__keys`.`__i`,max(`__t`.`Version`)`__Version`from" + keys + "group by`__keys`.`__i`\nfor update",
+		"update" + keys + "set`__t`.`__Latest`=0,`__t`.`__ValidUntil`=" + string(n) + " where`__t`.`__Latest`=1",
+		// the repeated key's first version is closed by its second
+		"insert into`posts`(`ID`,`Title`,`Version`,`__Latest`,`__ValidFrom`,`__ValidUntil`)values" +
+			"(1,_utf8mb4 0x61 collate utf8mb4_unicode_ci,4,0," + string(n) + "," + string(n) + ")," +
+			"(2,_utf8mb4 0x62 collate utf8mb4_unicode_ci,1,1," + string(n) + "," + string(maxTime) + ")," +
+			"(1,_utf8mb4 0x63 collate utf8mb4_unicode_ci,5,1," + string(n) + "," + string(maxTime) + ")",
+	}
+	if !reflect.DeepEqual(queries[1:len(queries)-2], want) {
+		t.Errorf("InsertVersioned() ran %q, want %q", queries, want)
+	}
+
+	err = db.InsertVersioned(context.Background(), "posts", TemporalColumns{}, []string{"Slug"}, []post{{1, "a"}})
+	if err == nil || !strings.Contains(err.Error(), `"Slug"`) {
+		t.Errorf("InsertVersioned() of rows without their key error = %v, want one naming it", err)
+	}
+}
@@ -0,0 +1,89 @@
+package mysql
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Option changes a default of a view of a database, see With
+type Option func(db *Database)
+
+// With returns a view of the database with different defaults, sharing its connection pools,
+// cache, and config, like for one service or endpoint. Changing the view's defaults,
+// or calling With on it again, doesn't change the database it came from.
+func (db *Database) With(opts ...Option) *Database {
+	view := db.Clone()
+	for _, opt := range opts {
+		opt(view)
+	}
+
+	return view
+}
+
+// WithLoggerFields adds the fields to everything the view logs
+func WithLoggerFields(fields ...zap.Field) Option {
+	return func(db *Database) {
+		if db.Logger != nil {
+			db.Logger = db.Logger.With(fields...)
+		}
+	}
+}
+
+// WithCacheTTLMultiplier scales how long the view's selects and exists checks are cached,
+// on top of the CacheTTLMultiplier of the database's config
+func WithCacheTTLMultiplier(multiplier float64) Option {
+	return func(db *Database) {
+		if multiplier > 0 {
+			db.cacheTTLMultiplier = multiplier
+		}
+	}
+}
+
+// WithComment adds the comment to the end of every query the view sends,
+// so they can be told apart in the process list and slow log
+func WithComment(comment string) Option {
+	return func(db *Database) {
+		db.comment = comment
+	}
+}
+
+// WithDefaultParams adds the params to every query of the view, where params
+// with the same names that are passed to a query are used instead.
+// Inserts use them in their queries, like their on duplicate key updates,
+// but never in the values of their rows, which are only the rows' own.
+func WithDefaultParams(params Params) Option {
+	return func(db *Database) {
+		merged := make(Params, len(db.defaultParams)+len(params))
+		for k, v := range db.defaultParams {
+			merged[k] = v
+		}
+		for k, v := range params {
+			merged[k] = v
+		}
+		db.defaultParams = merged
+	}
+}
+
+// withDefaultParams puts the default params of the view first in the params,
+// so the params passed to the query override them
+func (db *Database) withDefaultParams(params []any) []any {
+	if len(db.defaultParams) == 0 {
+		return params
+	}
+
+	return append([]any{db.defaultParams}, params...)
+}
+
+// commented returns the query with the view's comment at the end,
+// and then the comment of the application name and the context's labels, see WithLabels
+func (db *Database) commented(ctx context.Context, query string) string {
+	if len(db.comment) != 0 {
+		// the comment can't be allowed to end itself early and inject sql after it,
+		// and the space keeps comments starting with ! or + from being run as `/*!` or `/*+` ones
+		query += "/* " + strings.ReplaceAll(db.comment, "*/", "* /") + "*/"
+	}
+
+	return query + db.labelsComment(ctx)
+}
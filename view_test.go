@@ -0,0 +1,47 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDatabase_With(t *testing.T) {
+	db := new(Database)
+
+	view := db.With(
+		WithComment("checkout */ drop table`users`"),
+		WithDefaultParams(Params{"Site": 1, "Limit": 10}),
+		WithCacheTTLMultiplier(2),
+	)
+
+	if got, want := view.commented(context.Background(), "select 1"), "select 1/* checkout * / drop table`users`*/"; got != want {
+		t.Errorf("commented() = %q, want %q", got, want)
+	}
+	for _, comment := range []string{"!50000 drop table`users`", "+ MAX_EXECUTION_TIME(1)"} {
+		if got, want := db.With(WithComment(comment)).commented(context.Background(), "select 1"), "select 1/* "+comment+"*/"; got != want {
+			t.Errorf("commented() = %q, want %q", got, want)
+		}
+	}
+	if got, want := db.commented(WithLabels(context.Background(), map[string]string{" x": "y"}), "select 1"), "select 1/*%20x='y'*/"; got != want {
+		t.Errorf("commented() with labels = %q, want %q", got, want)
+	}
+	if got := db.commented(context.Background(), "select 1"); got != "select 1" {
+		t.Errorf("commented() of the original = %q, want it unchanged", got)
+	}
+
+	q, _, err := view.InterpolateParams("select @@Site,@@Limit", Params{"Limit": 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "select 1,20"; q != want {
+		t.Errorf("InterpolateParams() = %q, want %q", q, want)
+	}
+
+	if got := view.With(WithCacheTTLMultiplier(3)).cacheTTL(time.Second); got != 3*time.Second {
+		t.Errorf("cacheTTL() of a view of a view = %s, want 3s", got)
+	}
+	if got := view.cacheTTL(time.Second); got != 2*time.Second {
+		t.Errorf("cacheTTL() = %s, want 2s", got)
+	}
+}
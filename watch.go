@@ -0,0 +1,81 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TableVersion is the state of a watched table
+type TableVersion struct {
+	Version *string
+	Count   int64
+}
+
+// Watch polls the table every interval, sending on the returned channel whenever
+// the max value of versionColumn or the table's row count changes.
+// Only changes to those two are seen, so updates that don't raise the version, like ones
+// that don't set versionColumn or set it lower, and deletes and inserts between polls
+// that leave the count as it was without raising the version, aren't sent.
+// The first poll is what later ones are compared to, so it's never sent itself.
+// When redis is enabled, the poll results are cached for the interval so that
+// many watchers of the same table only cost one query per interval.
+// The channel is closed once ctx is done.
+func (db *Database) Watch(ctx context.Context, table, versionColumn string, interval time.Duration) <-chan TableVersion {
+	ch := make(chan TableVersion)
+
+	q := "select max(" + quoteIdentifier(versionColumn) + ")`Version`,count(*)`Count`from" + db.quoteTable(table)
+
+	var cache time.Duration
+	if db.redis != nil {
+		cache = interval
+	}
+
+	go func() {
+		defer close(ch)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var last *TableVersion
+		for {
+			var v TableVersion
+			err := db.query(db.Reads, ctx, &v, q, cache)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				db.Logger.Warn(fmt.Sprintf("failed to poll watched table %q: %v", table, err))
+			} else {
+				if last != nil && !last.equal(v) {
+					select {
+					case <-ctx.Done():
+						return
+					case ch <- v:
+					}
+				}
+				last = &v
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return ch
+}
+
+func (v TableVersion) equal(v2 TableVersion) bool {
+	if v.Count != v2.Count {
+		return false
+	}
+
+	if v.Version == nil || v2.Version == nil {
+		return v.Version == v2.Version
+	}
+
+	return *v.Version == *v2.Version
+}
@@ -0,0 +1,84 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/StirlingMarketingGroup/cool-mysql/internal/testdriver"
+)
+
+func TestTableVersion_equal(t *testing.T) {
+	a, b := "a", "b"
+	tests := []struct {
+		name string
+		v    TableVersion
+		v2   TableVersion
+		want bool
+	}{
+		{"same", TableVersion{&a, 1}, TableVersion{&a, 1}, true},
+		{"empty", TableVersion{nil, 0}, TableVersion{nil, 0}, true},
+		{"version", TableVersion{&a, 1}, TableVersion{&b, 1}, false},
+		{"count", TableVersion{&a, 1}, TableVersion{&a, 2}, false},
+		{"first row", TableVersion{nil, 0}, TableVersion{&a, 1}, false},
+		{"last row deleted", TableVersion{&a, 0}, TableVersion{nil, 0}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.v.equal(tt.v2); got != tt.want {
+				t.Errorf("equal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDatabase_Watch(t *testing.T) {
+	// each poll gets the next version and count, and the last one from then on
+	polls := [][]driver.Value{
+		{[]byte("1"), int64(2)},
+		{[]byte("1"), int64(2)},
+		{[]byte("2"), int64(2)},
+		// a delete and an insert that don't raise the version aren't seen
+		{[]byte("2"), int64(2)},
+		{[]byte("2"), int64(3)},
+	}
+	var poll int32
+	db := testDatabase(t, &testdriver.Driver{
+		QueryFunc: func(ctx context.Context, query string) (driver.Rows, error) {
+			i := int(atomic.AddInt32(&poll, 1)) - 1
+			if i >= len(polls) {
+				i = len(polls) - 1
+			}
+			return testdriver.NewRows([]string{"Version", "Count"}, polls[i]), nil
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := db.Watch(ctx, "users", "Updated", time.Millisecond)
+
+	two := "2"
+	for _, want := range []TableVersion{{Version: &two, Count: 2}, {Version: &two, Count: 3}} {
+		select {
+		case v := <-ch:
+			if !v.equal(want) {
+				t.Errorf("Watch() sent %v %d, want %v %d", *v.Version, v.Count, *want.Version, want.Count)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Watch() didn't send a change")
+		}
+	}
+
+	cancel()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Error("Watch() sent a change that didn't happen")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch() didn't close its channel")
+	}
+}